@@ -64,6 +64,7 @@ type RequestForm struct {
 	RelayState   string `json:"relayState"`
 	SamlRequest  string `json:"samlRequest"`
 	SamlResponse string `json:"samlResponse"`
+	SamlQuery    string `json:"samlQuery"`
 }
 
 type Response struct {
@@ -73,6 +74,7 @@ type Response struct {
 	Name   string      `json:"name"`
 	Data   interface{} `json:"data"`
 	Data2  interface{} `json:"data2"`
+	Cursor string      `json:"cursor,omitempty"`
 }
 
 type Captcha struct {
@@ -236,6 +238,16 @@ func (c *ApiController) Logout() {
 	application := c.GetSessionApplication()
 	c.SetSessionUsername("")
 	c.SetSessionData(nil)
+	c.SetSessionAuthContext(nil)
+
+	if user != "" {
+		util.SafeGoroutine(func() { object.SendCasLogoutRequests(user) })
+
+		organization, _ := util.GetOwnerAndNameFromIdNoCheck(user)
+		event := object.NewEvent(object.EventUserLogout, organization, user, nil, nil)
+		event.Actor = user
+		c.publishEvent(event)
+	}
 
 	if application == nil || application.Name == "app-built-in" || application.HomepageUrl == "" {
 		c.ResponseOk(user)
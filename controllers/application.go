@@ -35,10 +35,6 @@ func (c *ApiController) GetApplications() {
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	organization := c.Input().Get("organization")
 
 	if limit == "" || page == "" {
@@ -53,9 +49,15 @@ func (c *ApiController) GetApplications() {
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetApplicationCount(owner, field, value)))
-		applications := object.GetMaskedApplications(object.GetPaginationApplications(owner, paginator.Offset(), limit, field, value, sortField, sortOrder), userId)
-		c.ResponseOk(applications, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetApplicationCount(owner, query)))
+		applications := object.GetMaskedApplications(object.GetPaginationApplications(owner, paginator.Offset(), limit, query), userId)
+		c.ResponsePage(applications, paginator, query)
 	}
 }
 
@@ -111,7 +113,13 @@ func (c *ApiController) UpdateApplication() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateApplication(id, &application))
+	oldApplication := object.GetApplication(id)
+	affected := object.UpdateApplication(id, &application)
+	if affected {
+		c.addAuditLog(object.AuditOperationUpdate, "application", id, oldApplication, object.GetApplication(application.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -129,7 +137,12 @@ func (c *ApiController) AddApplication() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.AddApplication(&application))
+	affected := object.AddApplication(&application)
+	if affected {
+		c.addAuditLog(object.AuditOperationAdd, "application", application.GetId(), nil, object.GetApplication(application.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -147,6 +160,12 @@ func (c *ApiController) DeleteApplication() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.DeleteApplication(&application))
+	oldApplication := object.GetApplication(application.GetId())
+	affected := object.DeleteApplication(&application)
+	if affected {
+		c.addAuditLog(object.AuditOperationDelete, "application", application.GetId(), oldApplication, nil)
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
@@ -0,0 +1,65 @@
+// Copyright 2021 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"strings"
+
+	"github.com/astaxie/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetAuditLogs
+// @Title GetAuditLogs
+// @Tag Audit Log API
+// @Description get the audit logs of the changes made through the API, with the field-level diffs
+// @Param   owner     query    string  false        "The owner of the changed objects, empty for all"
+// @Param   pageSize     query    string  true        "The size of each page"
+// @Param   p     query    string  true        "The number of the page"
+// @Success 200 {array} object.AuditLog The Response object
+// @router /get-audit-logs [get]
+func (c *ApiController) GetAuditLogs() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetAuditLogs(owner)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetAuditLogCount(owner, query)))
+		auditLogs := object.GetPaginationAuditLogs(owner, paginator.Offset(), limit, query)
+		c.ResponsePage(auditLogs, paginator, query)
+	}
+}
+
+// addAuditLog records the change of an object made by the signed-in user of the request
+func (c *ApiController) addAuditLog(operation string, objectType string, objectId string, before interface{}, after interface{}) {
+	auditLog := &object.AuditLog{
+		Actor:      c.GetSessionUsername(),
+		ClientIp:   strings.Replace(util.GetIPFromRequest(c.Ctx.Request), ": ", "", -1),
+		Operation:  operation,
+		ObjectType: objectType,
+		ObjectId:   objectId,
+	}
+	object.AddAuditLog(auditLog, before, after)
+}
@@ -47,11 +47,64 @@ func tokenToResponse(token *object.Token) *Response {
 
 }
 
+// getMaxAge parses the OIDC "max_age" parameter, -1 means that it is not requested
+func getMaxAge(maxAge string) int {
+	if maxAge == "" || maxAge == "null" {
+		return -1
+	}
+
+	res, err := strconv.Atoi(maxAge)
+	if err != nil || res < 0 {
+		return -1
+	}
+	return res
+}
+
+// isFreshLogin returns whether the user has just presented credentials, instead of reusing the signed-in session
+func isFreshLogin(form *RequestForm) bool {
+	return form.Username != "" || form.Provider != ""
+}
+
+// getLoginAuthContext returns how and when the user authenticated, a fresh login of the same user
+// is merged with the methods already used in the session so that a second factor steps up the session.
+func (c *ApiController) getLoginAuthContext(userId string, form *RequestForm) *object.AuthContext {
+	previous := c.GetSessionAuthContext()
+	if c.GetSessionUsername() != userId {
+		previous = nil
+	}
+
+	var authContext *object.AuthContext
+	if form.Provider != "" {
+		authContext = object.NewAuthContext(object.AuthMethodFederated)
+	} else if form.Username != "" && form.Password != "" {
+		authContext = object.NewAuthContext(object.AuthMethodPassword)
+	} else if form.Username != "" {
+		authContext = object.NewAuthContext(object.AuthMethodOtp)
+	} else {
+		return previous
+	}
+
+	authContext.Merge(previous)
+	return authContext
+}
+
 // HandleLoggedIn ...
 func (c *ApiController) HandleLoggedIn(application *object.Application, user *object.User, form *RequestForm) (resp *Response) {
 	userId := user.GetId()
+
+	prompt := c.Input().Get("prompt")
+	if form.Type != ResponseTypeLogin && util.InSlice(strings.Fields(prompt), "login") && !isFreshLogin(form) {
+		c.ResponseError("The application requires you to sign in again (prompt=login)")
+		return
+	}
+
+	authContext := c.getLoginAuthContext(userId, form)
+	acrValues := c.Input().Get("acr_values")
+	maxAge := getMaxAge(c.Input().Get("max_age"))
+
 	if form.Type == ResponseTypeLogin {
 		c.SetSessionUsername(userId)
+		c.SetSessionAuthContext(authContext)
 		util.LogInfo(c.Ctx, "API: [%s] signed in", userId)
 		resp = &Response{Status: "ok", Msg: "", Data: userId}
 	} else if form.Type == ResponseTypeCode {
@@ -68,24 +121,34 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 			c.ResponseError("Challenge method should be S256")
 			return
 		}
-		code := object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, c.Ctx.Request.Host)
+		code := object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, c.Ctx.Request.Host, acrValues, maxAge, authContext)
 		resp = codeToResponse(code)
 
 		if application.EnableSigninSession || application.HasPromptPage() {
 			// The prompt page needs the user to be signed in
 			c.SetSessionUsername(userId)
+			c.SetSessionAuthContext(authContext)
 		}
 	} else if form.Type == ResponseTypeToken || form.Type == ResponseTypeIdToken { //implicit flow
 		if !object.IsGrantTypeValid(form.Type, application.GrantTypes) {
 			resp = &Response{Status: "error", Msg: fmt.Sprintf("error: grant_type: %s is not supported in this application", form.Type), Data: ""}
 		} else {
+			acr, msg := object.CheckAuthContext(application, authContext, acrValues, maxAge)
+			if msg != "" {
+				c.ResponseError(msg)
+				return
+			}
+			if authContext != nil {
+				authContext.Acr = acr
+			}
+
 			scope := c.Input().Get("scope")
-			token, _ := object.GetTokenByUser(application, user, scope, c.Ctx.Request.Host)
+			token, _ := object.GetTokenByUser(application, user, scope, c.Ctx.Request.Host, authContext)
 			resp = tokenToResponse(token)
 		}
 
 	} else if form.Type == ResponseTypeSaml { // saml flow
-		res, redirectUrl, err := object.GetSamlResponse(application, user, form.SamlRequest, c.Ctx.Request.Host)
+		res, redirectUrl, err := object.GetSamlResponse(application, user, form.SamlRequest, form.SamlQuery, c.Ctx.Request.Host)
 		if err != nil {
 			c.ResponseError(err.Error(), nil)
 			return
@@ -106,12 +169,23 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 		if application.EnableSigninSession || application.HasPromptPage() {
 			// The prompt page needs the user to be signed in
 			c.SetSessionUsername(userId)
+			c.SetSessionAuthContext(authContext)
 		}
 
 	} else {
 		resp = wrapErrorResponse(fmt.Errorf("Unknown response type: %s", form.Type))
 	}
 
+	if resp.Status == "ok" {
+		event := object.NewEvent(object.EventUserLoginSucceeded, user.Owner, userId, nil, nil)
+		event.Actor = userId
+		event.Data["application"] = application.Name
+		event.Data["responseType"] = form.Type
+		c.publishEvent(event)
+
+		c.checkUserDevice(application, user)
+	}
+
 	// if user did not check auto signin
 	if resp.Status == "ok" && !form.AutoSignin {
 		timestamp := time.Now().Unix()
@@ -124,6 +198,53 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 	return resp
 }
 
+func getLoginMethod(form *RequestForm) string {
+	if form.Username != "" {
+		if form.Password == "" {
+			return object.LoginMethodCode
+		}
+		return object.LoginMethodPassword
+	} else if form.Provider != "" {
+		// linking a provider to the signed-in user is not a sign-in
+		if form.Method != "signup" {
+			return ""
+		}
+		return object.LoginMethodProvider
+	}
+	return object.LoginMethodSession
+}
+
+// addLoginAttempt records the result of Login() from the response it has served
+func (c *ApiController) addLoginAttempt(attempt *object.LoginAttempt) {
+	if attempt.Method == "" {
+		return
+	}
+
+	var resp *Response
+	switch data := c.Data["json"].(type) {
+	case Response:
+		resp = &data
+	case *Response:
+		resp = data
+	}
+	if resp == nil {
+		return
+	}
+
+	attempt.IsSuccess = resp.Status == "ok"
+	if !attempt.IsSuccess {
+		attempt.Reason = resp.Msg
+	}
+	util.SafeGoroutine(func() { object.AddLoginAttempt(attempt) })
+}
+
+func (c *ApiController) publishLoginFailedEvent(form *RequestForm, reason string) {
+	event := object.NewEvent(object.EventUserLoginFailed, form.Organization, fmt.Sprintf("%s/%s", form.Organization, form.Username), nil, nil)
+	event.Data["application"] = form.Application
+	event.Data["reason"] = reason
+	c.publishEvent(event)
+}
+
 // GetApplicationLogin ...
 // @Title GetApplicationLogin
 // @Tag Login API
@@ -184,6 +305,14 @@ func (c *ApiController) Login() {
 		return
 	}
 
+	attempt := object.NewLoginAttempt(c.Ctx)
+	attempt.Owner = form.Organization
+	attempt.Application = form.Application
+	attempt.User = form.Username
+	attempt.Method = getLoginMethod(&form)
+	attempt.Provider = form.Provider
+	defer c.addLoginAttempt(attempt)
+
 	if form.Username != "" {
 		if form.Type == ResponseTypeLogin {
 			if c.GetSessionUsername() != "" {
@@ -225,6 +354,7 @@ func (c *ApiController) Login() {
 			}
 			if len(checkResult) != 0 {
 				responseText := fmt.Sprintf("%s%s", verificationCodeType, checkResult)
+				c.publishLoginFailedEvent(&form, responseText)
 				c.ResponseError(responseText)
 				return
 			}
@@ -247,6 +377,7 @@ func (c *ApiController) Login() {
 		}
 
 		if msg != "" {
+			c.publishLoginFailedEvent(&form, msg)
 			resp = &Response{Status: "error", Msg: msg}
 		} else {
 			application := object.GetApplication(fmt.Sprintf("admin/%s", form.Application))
@@ -256,6 +387,7 @@ func (c *ApiController) Login() {
 			}
 
 			resp = c.HandleLoggedIn(application, user, &form)
+			attempt.User = user.Name
 
 			record := object.NewRecord(c.Ctx)
 			record.Organization = application.Organization
@@ -271,6 +403,8 @@ func (c *ApiController) Login() {
 
 		organization := object.GetOrganization(fmt.Sprintf("%s/%s", "admin", application.Organization))
 		provider := object.GetProvider(fmt.Sprintf("admin/%s", form.Provider))
+		attempt.Owner = application.Organization
+		attempt.ProviderType = provider.Type
 		providerItem := application.GetProviderItem(provider.Name)
 		if !providerItem.IsProviderVisible() {
 			c.ResponseError(fmt.Sprintf("The provider: %s is not enabled for the application", provider.Name))
@@ -343,6 +477,7 @@ func (c *ApiController) Login() {
 				}
 
 				resp = c.HandleLoggedIn(application, user, &form)
+				attempt.User = user.Name
 
 				record := object.NewRecord(c.Ctx)
 				record.Organization = application.Organization
@@ -405,6 +540,7 @@ func (c *ApiController) Login() {
 				object.LinkUserAccount(user, provider.Type, userInfo.Id)
 
 				resp = c.HandleLoggedIn(application, user, &form)
+				attempt.User = user.Name
 
 				record := object.NewRecord(c.Ctx)
 				record.Organization = application.Organization
@@ -456,6 +592,8 @@ func (c *ApiController) Login() {
 
 			user := c.getCurrentUser()
 			resp = c.HandleLoggedIn(application, user, &form)
+			attempt.Owner = user.Owner
+			attempt.User = user.Name
 
 			record := object.NewRecord(c.Ctx)
 			record.Organization = application.Organization
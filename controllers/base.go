@@ -134,6 +134,32 @@ func (c *ApiController) SetSessionData(s *SessionData) {
 	c.SetSession("SessionData", util.StructToJson(s))
 }
 
+// GetSessionAuthContext ...
+func (c *ApiController) GetSessionAuthContext() *object.AuthContext {
+	session := c.GetSession("authContext")
+	if session == nil {
+		return nil
+	}
+
+	authContext := &object.AuthContext{}
+	err := util.JsonToStruct(session.(string), authContext)
+	if err != nil {
+		panic(err)
+	}
+
+	return authContext
+}
+
+// SetSessionAuthContext ...
+func (c *ApiController) SetSessionAuthContext(authContext *object.AuthContext) {
+	if authContext == nil {
+		c.DelSession("authContext")
+		return
+	}
+
+	c.SetSession("authContext", util.StructToJson(authContext))
+}
+
 func wrapActionResponse(affected bool) *Response {
 	if affected {
 		return &Response{Status: "ok", Msg: "", Data: "Affected"}
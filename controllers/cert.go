@@ -33,18 +33,20 @@ func (c *ApiController) GetCerts() {
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetMaskedCerts(object.GetCerts(owner))
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetCertCount(owner, field, value)))
-		certs := object.GetMaskedCerts(object.GetPaginationCerts(owner, paginator.Offset(), limit, field, value, sortField, sortOrder))
-		c.ResponseOk(certs, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetCertCount(owner, query)))
+		certs := object.GetMaskedCerts(object.GetPaginationCerts(owner, paginator.Offset(), limit, query))
+		c.ResponsePage(certs, paginator, query)
 	}
 }
 
@@ -77,7 +79,13 @@ func (c *ApiController) UpdateCert() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateCert(id, &cert))
+	oldCert := object.GetCert(id)
+	affected := object.UpdateCert(id, &cert)
+	if affected {
+		c.addAuditLog(object.AuditOperationUpdate, "cert", id, oldCert, object.GetCert(cert.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -94,7 +102,12 @@ func (c *ApiController) AddCert() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.AddCert(&cert))
+	affected := object.AddCert(&cert)
+	if affected {
+		c.addAuditLog(object.AuditOperationAdd, "cert", cert.GetId(), nil, object.GetCert(cert.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -111,6 +124,12 @@ func (c *ApiController) DeleteCert() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.DeleteCert(&cert))
+	oldCert := object.GetCert(cert.GetId())
+	affected := object.DeleteCert(&cert)
+	if affected {
+		c.addAuditLog(object.AuditOperationDelete, "cert", cert.GetId(), oldCert, nil)
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
@@ -0,0 +1,92 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+func (c *ApiController) addImpersonationRecord(action string, target string, impersonator string) {
+	record := object.NewRecord(c.Ctx)
+	record.Action = action
+	record.Organization, record.User = util.GetOwnerAndNameFromId(target)
+	record.Impersonator = impersonator
+	util.SafeGoroutine(func() { object.AddRecord(record) })
+}
+
+// StartImpersonation
+// @Title StartImpersonation
+// @Tag Login API
+// @Description sign in as another user, the tokens issued in the session carry an "act" claim of the real admin
+// @Param   id     query    string  true        "The id of the user to impersonate"
+// @Success 200 {object} controllers.Response The Response object
+// @router /start-impersonation [post]
+func (c *ApiController) StartImpersonation() {
+	adminId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+
+	id := c.Input().Get("id")
+	target := object.GetUser(id)
+	if target == nil {
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", id))
+		return
+	}
+
+	authContext, msg := object.StartImpersonation(object.GetUser(adminId), target, c.GetSessionAuthContext())
+	if msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	c.SetSessionUsername(target.GetId())
+	c.SetSessionAuthContext(authContext)
+	util.LogInfo(c.Ctx, "API: [%s] started impersonating [%s]", adminId, target.GetId())
+	c.addImpersonationRecord("start-impersonation", target.GetId(), adminId)
+
+	c.ResponseOk(target.GetId())
+}
+
+// StopImpersonation
+// @Title StopImpersonation
+// @Tag Login API
+// @Description stop the impersonation and sign in as the real admin again
+// @Success 200 {object} controllers.Response The Response object
+// @router /stop-impersonation [post]
+func (c *ApiController) StopImpersonation() {
+	userId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+
+	authContext := c.GetSessionAuthContext()
+	if authContext == nil || authContext.Impersonator == "" {
+		c.ResponseError("You are not impersonating any user")
+		return
+	}
+
+	adminId := authContext.Impersonator
+	authContext.Impersonator = ""
+	c.SetSessionUsername(adminId)
+	c.SetSessionAuthContext(authContext)
+	util.LogInfo(c.Ctx, "API: [%s] stopped impersonating [%s]", adminId, userId)
+	c.addImpersonationRecord("stop-impersonation", userId, adminId)
+
+	c.ResponseOk(adminId)
+}
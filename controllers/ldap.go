@@ -48,7 +48,7 @@ type LdapSyncResp struct {
 // @Title GetLdapser
 // @router /get-ldap-user [post]
 func (c *ApiController) GetLdapUser() {
-	ldapServer := LdapServer{}
+	ldapServer := object.Ldap{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &ldapServer)
 	if err != nil || util.IsStrsEmpty(ldapServer.Host, ldapServer.Admin, ldapServer.Passwd, ldapServer.BaseDn) {
 		c.ResponseError("Missing parameter")
@@ -57,7 +57,7 @@ func (c *ApiController) GetLdapUser() {
 
 	var resp LdapResp
 
-	conn, err := object.GetLdapConn(ldapServer.Host, ldapServer.Port, ldapServer.Admin, ldapServer.Passwd)
+	conn, err := object.GetLdapConn(&ldapServer)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -76,7 +76,7 @@ func (c *ApiController) GetLdapUser() {
 	//	})
 	//}
 
-	users, err := conn.GetLdapUsers(ldapServer.BaseDn)
+	users, err := conn.GetLdapUsers(&ldapServer)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -93,6 +93,8 @@ func (c *ApiController) GetLdapUser() {
 			Email:   util.GetMaxLenStr(user.Mail, user.Email, user.EmailAddress),
 			Phone:   util.GetMaxLenStr(user.TelephoneNumber, user.Mobile, user.MobileTelephoneNumber),
 			Address: util.GetMaxLenStr(user.RegisteredAddress, user.PostalAddress),
+
+			Attributes: user.Fields,
 		})
 	}
 
@@ -216,6 +218,12 @@ func (c *ApiController) SyncLdapUsers() {
 	object.UpdateLdapSyncTime(ldapId)
 
 	exist, failed := object.SyncLdapUsers(owner, users, ldapId)
+	err = object.SyncLdapGroups(ldapId)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
 	c.Data["json"] = &Response{Status: "ok", Data: &LdapSyncResp{
 		Exist:  *exist,
 		Failed: *failed,
@@ -0,0 +1,88 @@
+// Copyright 2021 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"github.com/astaxie/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetLoginAttempts
+// @Title GetLoginAttempts
+// @Tag Login Analytics API
+// @Description get the sign-in attempts with their application, provider, result and failure reason
+// @Param   owner     query    string  false        "The organization of the users, empty for all"
+// @Param   pageSize     query    string  true        "The size of each page"
+// @Param   p     query    string  true        "The number of the page"
+// @Success 200 {array} object.LoginAttempt The Response object
+// @router /get-login-attempts [get]
+func (c *ApiController) GetLoginAttempts() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	if limit == "" || page == "" {
+		c.ResponseError("The login attempts can only be listed by page, please specify pageSize and p")
+		return
+	}
+
+	query, err := c.getQuery()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	pageSize := util.ParseInt(limit)
+	paginator := pagination.SetPaginator(c.Ctx, pageSize, int64(object.GetLoginAttemptCount(owner, query)))
+	attempts := object.GetPaginationLoginAttempts(owner, paginator.Offset(), pageSize, query)
+	c.ResponsePage(attempts, paginator, query)
+}
+
+// GetLoginStats
+// @Title GetLoginStats
+// @Tag Login Analytics API
+// @Description get the sign-ins and the failure rate per application, the top failing IPs, the provider usage and the signups, in time buckets
+// @Param   organization     query    string  false        "The organization, empty for all"
+// @Param   application     query    string  false        "The application, empty for all"
+// @Param   startTime     query    string  false        "The start of the created time in RFC 3339 format, inclusive"
+// @Param   endTime     query    string  false        "The end of the created time in RFC 3339 format, exclusive"
+// @Param   bucket     query    string  false        "The time bucket: hour, day (default) or month"
+// @Param   limit     query    string  false        "The size of the top failing IPs, 10 by default"
+// @Success 200 {object} object.LoginStats The Response object
+// @router /get-login-stats [get]
+func (c *ApiController) GetLoginStats() {
+	filter := &object.LoginStatsFilter{
+		Organization: c.Input().Get("organization"),
+		Application:  c.Input().Get("application"),
+		StartTime:    c.Input().Get("startTime"),
+		EndTime:      c.Input().Get("endTime"),
+		Bucket:       c.Input().Get("bucket"),
+		Limit:        10,
+	}
+	if filter.Bucket == "" {
+		filter.Bucket = "day"
+	}
+	if limit := c.Input().Get("limit"); limit != "" {
+		filter.Limit = util.ParseInt(limit)
+	}
+
+	err := object.CheckLoginStatsFilter(filter)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(object.GetLoginStats(filter))
+}
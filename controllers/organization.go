@@ -33,18 +33,20 @@ func (c *ApiController) GetOrganizations() {
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetMaskedOrganizations(object.GetOrganizations(owner))
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetOrganizationCount(owner, field, value)))
-		organizations := object.GetMaskedOrganizations(object.GetPaginationOrganizations(owner, paginator.Offset(), limit, field, value, sortField, sortOrder))
-		c.ResponseOk(organizations, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetOrganizationCount(owner, query)))
+		organizations := object.GetMaskedOrganizations(object.GetPaginationOrganizations(owner, paginator.Offset(), limit, query))
+		c.ResponsePage(organizations, paginator, query)
 	}
 }
 
@@ -79,7 +81,13 @@ func (c *ApiController) UpdateOrganization() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateOrganization(id, &organization))
+	oldOrganization := object.GetOrganization(id)
+	affected := object.UpdateOrganization(id, &organization)
+	if affected {
+		c.addAuditLog(object.AuditOperationUpdate, "organization", id, oldOrganization, object.GetOrganization(util.GetId(organization.Name)))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -97,7 +105,12 @@ func (c *ApiController) AddOrganization() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.AddOrganization(&organization))
+	affected := object.AddOrganization(&organization)
+	if affected {
+		c.addAuditLog(object.AuditOperationAdd, "organization", util.GetId(organization.Name), nil, object.GetOrganization(util.GetId(organization.Name)))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -115,6 +128,12 @@ func (c *ApiController) DeleteOrganization() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.DeleteOrganization(&organization))
+	oldOrganization := object.GetOrganization(util.GetId(organization.Name))
+	affected := object.DeleteOrganization(&organization)
+	if affected {
+		c.addAuditLog(object.AuditOperationDelete, "organization", util.GetId(organization.Name), oldOrganization, nil)
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
@@ -33,18 +33,20 @@ func (c *ApiController) GetPermissions() {
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetPermissions(owner)
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetPermissionCount(owner, field, value)))
-		permissions := object.GetPaginationPermissions(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
-		c.ResponseOk(permissions, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetPermissionCount(owner, query)))
+		permissions := object.GetPaginationPermissions(owner, paginator.Offset(), limit, query)
+		c.ResponsePage(permissions, paginator, query)
 	}
 }
 
@@ -77,7 +79,13 @@ func (c *ApiController) UpdatePermission() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdatePermission(id, &permission))
+	oldPermission := object.GetPermission(id)
+	affected := object.UpdatePermission(id, &permission)
+	if affected {
+		c.addAuditLog(object.AuditOperationUpdate, "permission", id, oldPermission, object.GetPermission(permission.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -94,7 +102,12 @@ func (c *ApiController) AddPermission() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.AddPermission(&permission))
+	affected := object.AddPermission(&permission)
+	if affected {
+		c.addAuditLog(object.AuditOperationAdd, "permission", permission.GetId(), nil, object.GetPermission(permission.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -111,6 +124,12 @@ func (c *ApiController) DeletePermission() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.DeletePermission(&permission))
+	oldPermission := object.GetPermission(permission.GetId())
+	affected := object.DeletePermission(&permission)
+	if affected {
+		c.addAuditLog(object.AuditOperationDelete, "permission", permission.GetId(), oldPermission, nil)
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
@@ -32,18 +32,20 @@ func (c *ApiController) GetProviders() {
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetMaskedProviders(object.GetProviders(owner))
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetProviderCount(owner, field, value)))
-		providers := object.GetMaskedProviders(object.GetPaginationProviders(owner, paginator.Offset(), limit, field, value, sortField, sortOrder))
-		c.ResponseOk(providers, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetProviderCount(owner, query)))
+		providers := object.GetMaskedProviders(object.GetPaginationProviders(owner, paginator.Offset(), limit, query))
+		c.ResponsePage(providers, paginator, query)
 	}
 }
 
@@ -76,7 +78,13 @@ func (c *ApiController) UpdateProvider() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateProvider(id, &provider))
+	oldProvider := object.GetProvider(id)
+	affected := object.UpdateProvider(id, &provider)
+	if affected {
+		c.addAuditLog(object.AuditOperationUpdate, "provider", id, oldProvider, object.GetProvider(provider.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -93,7 +101,12 @@ func (c *ApiController) AddProvider() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.AddProvider(&provider))
+	affected := object.AddProvider(&provider)
+	if affected {
+		c.addAuditLog(object.AuditOperationAdd, "provider", provider.GetId(), nil, object.GetProvider(provider.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -110,6 +123,12 @@ func (c *ApiController) DeleteProvider() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.DeleteProvider(&provider))
+	oldProvider := object.GetProvider(provider.GetId())
+	affected := object.DeleteProvider(&provider)
+	if affected {
+		c.addAuditLog(object.AuditOperationDelete, "provider", provider.GetId(), oldProvider, nil)
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
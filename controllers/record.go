@@ -15,7 +15,12 @@
 package controllers
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/astaxie/beego/logs"
 	"github.com/astaxie/beego/utils/pagination"
+	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
 )
@@ -26,23 +31,29 @@ import (
 // @Description get all records
 // @Param   pageSize     query    string  true        "The size of each page"
 // @Param   p     query    string  true        "The number of the page"
+// @Param   filter     query    string  false        "Repeatable condition like \"clientIp:eq:10.0.0.1\", the operators are eq, ne, gt, gte, lt, lte and prefix"
+// @Param   startTime     query    string  false        "The start of the created time in RFC 3339 format, inclusive"
+// @Param   endTime     query    string  false        "The end of the created time in RFC 3339 format, exclusive"
+// @Param   cursor     query    string  false        "The cursor returned with the previous page, to get the next page"
 // @Success 200 {object} object.Record The Response object
 // @router /get-records [get]
 func (c *ApiController) GetRecords() {
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetRecords()
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetRecordCount(field, value)))
-		records := object.GetPaginationRecords(paginator.Offset(), limit, field, value, sortField, sortOrder)
-		c.ResponseOk(records, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetRecordCount(query)))
+		records := object.GetPaginationRecords(paginator.Offset(), limit, query)
+		c.ResponsePage(records, paginator, query)
 	}
 }
 
@@ -65,3 +76,123 @@ func (c *ApiController) GetRecordsByFilter() {
 	c.Data["json"] = object.GetRecordsByField(record)
 	c.ServeJSON()
 }
+
+// VerifyRecords
+// @Title VerifyRecords
+// @Tag Record API
+// @Description walk the hash chain of the records of an organization and report the first broken link
+// @Param   organization     query    string  true        "The organization of the records"
+// @Success 200 {object} object.RecordChainResult The Response object
+// @router /verify-records [get]
+func (c *ApiController) VerifyRecords() {
+	organization := c.Input().Get("organization")
+
+	c.ResponseOk(object.VerifyRecordChain(organization))
+}
+
+// AddRecordCheckpoint
+// @Title AddRecordCheckpoint
+// @Tag Record API
+// @Description sign the head of the record chain of an organization with the private key of a cert
+// @Param   organization     query    string  true        "The organization of the records"
+// @Param   cert     query    string  false        "The id of the cert, recordCheckpointCert in app.conf by default"
+// @Success 200 {object} object.RecordCheckpoint The Response object
+// @router /add-record-checkpoint [post]
+func (c *ApiController) AddRecordCheckpoint() {
+	organization := c.Input().Get("organization")
+	certId := c.Input().Get("cert")
+	if certId == "" {
+		certId = conf.GetConfigString("recordCheckpointCert")
+	}
+
+	checkpoint, err := object.AddRecordCheckpoint(organization, certId)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(checkpoint)
+}
+
+// ExportRecords
+// @Title ExportRecords
+// @Tag Record API
+// @Description stream the records as CSV or JSONL
+// @Param   format     query    string  true        "csv or jsonl"
+// @Param   organization     query    string  false        "The organization of the records"
+// @Param   startTime     query    string  false        "The inclusive start of the time range, in RFC3339"
+// @Param   endTime     query    string  false        "The exclusive end of the time range, in RFC3339"
+// @Param   fields     query    string  false        "The comma-separated fields to export, all of them by default"
+// @Success 200 {string} string The records
+// @router /export-records [get]
+func (c *ApiController) ExportRecords() {
+	format := c.Input().Get("format")
+	fields := c.Input().Get("fields")
+
+	filter := &object.RecordExportFilter{
+		Organization: c.Input().Get("organization"),
+		StartTime:    c.Input().Get("startTime"),
+		EndTime:      c.Input().Get("endTime"),
+		Field:        c.Input().Get("field"),
+		Value:        c.Input().Get("value"),
+	}
+	if fields != "" {
+		filter.Fields = strings.Split(fields, ",")
+	}
+
+	err := object.CheckRecordExportFilter(format, filter)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == object.RecordExportFormatJsonl {
+		contentType = "application/x-ndjson"
+	}
+	c.Ctx.Output.Header("Content-Type", contentType)
+	c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=records.%s", format))
+
+	err = object.ExportRecords(c.Ctx.ResponseWriter, format, filter)
+	if err != nil {
+		logs.Error("failed to export the records: %s", err.Error())
+	}
+}
+
+// GetRecordArchives
+// @Title GetRecordArchives
+// @Tag Record API
+// @Description get the archives of the records removed by the retention policy of an organization
+// @Param   organization     query    string  true        "The organization of the records"
+// @Success 200 {array} object.RecordArchive The Response object
+// @router /get-record-archives [get]
+func (c *ApiController) GetRecordArchives() {
+	organization := c.Input().Get("organization")
+
+	c.ResponseOk(object.GetRecordArchives(organization))
+}
+
+// ArchiveRecords
+// @Title ArchiveRecords
+// @Tag Record API
+// @Description archive the expired records of an organization now instead of waiting for the daily run
+// @Param   organization     query    string  true        "The organization of the records"
+// @Success 200 {object} object.RecordArchive The Response object
+// @router /archive-records [post]
+func (c *ApiController) ArchiveRecords() {
+	id := util.GetId(c.Input().Get("organization"))
+
+	organization := object.GetOrganization(id)
+	if organization == nil {
+		c.ResponseError(fmt.Sprintf("The organization: %s doesn't exist", id))
+		return
+	}
+
+	archive, err := object.ArchiveRecords(organization)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(archive)
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/astaxie/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetRecordSinks
+// @Title GetRecordSinks
+// @Tag Record Sink API
+// @Description get record sinks
+// @Param   owner     query    string  true        "The owner of record sinks"
+// @Success 200 {array} object.RecordSink The Response object
+// @router /get-record-sinks [get]
+func (c *ApiController) GetRecordSinks() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetRecordSinks(owner)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetRecordSinkCount(owner, query)))
+		recordSinks := object.GetPaginationRecordSinks(owner, paginator.Offset(), limit, query)
+		c.ResponsePage(recordSinks, paginator, query)
+	}
+}
+
+// @Title GetRecordSink
+// @Tag Record Sink API
+// @Description get record sink
+// @Param   id    query    string  true        "The id of the record sink"
+// @Success 200 {object} object.RecordSink The Response object
+// @router /get-record-sink [get]
+func (c *ApiController) GetRecordSink() {
+	id := c.Input().Get("id")
+
+	c.Data["json"] = object.GetRecordSink(id)
+	c.ServeJSON()
+}
+
+// @Title UpdateRecordSink
+// @Tag Record Sink API
+// @Description update record sink
+// @Param   id    query    string  true        "The id of the record sink"
+// @Param   body    body   object.RecordSink  true        "The details of the record sink"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-record-sink [post]
+func (c *ApiController) UpdateRecordSink() {
+	id := c.Input().Get("id")
+
+	var recordSink object.RecordSink
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recordSink)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateRecordSink(id, &recordSink))
+	c.ServeJSON()
+}
+
+// @Title AddRecordSink
+// @Tag Record Sink API
+// @Description add record sink
+// @Param   body    body   object.RecordSink  true        "The details of the record sink"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-record-sink [post]
+func (c *ApiController) AddRecordSink() {
+	var recordSink object.RecordSink
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recordSink)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddRecordSink(&recordSink))
+	c.ServeJSON()
+}
+
+// @Title DeleteRecordSink
+// @Tag Record Sink API
+// @Description delete record sink
+// @Param   body    body   object.RecordSink  true        "The details of the record sink"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-record-sink [post]
+func (c *ApiController) DeleteRecordSink() {
+	var recordSink object.RecordSink
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recordSink)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteRecordSink(&recordSink))
+	c.ServeJSON()
+}
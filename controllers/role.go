@@ -33,18 +33,20 @@ func (c *ApiController) GetRoles() {
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetRoles(owner)
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetRoleCount(owner, field, value)))
-		roles := object.GetPaginationRoles(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
-		c.ResponseOk(roles, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetRoleCount(owner, query)))
+		roles := object.GetPaginationRoles(owner, paginator.Offset(), limit, query)
+		c.ResponsePage(roles, paginator, query)
 	}
 }
 
@@ -77,7 +79,13 @@ func (c *ApiController) UpdateRole() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateRole(id, &role))
+	oldRole := object.GetRole(id)
+	affected := object.UpdateRole(id, &role)
+	if affected {
+		c.addAuditLog(object.AuditOperationUpdate, "role", id, oldRole, object.GetRole(role.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -94,7 +102,12 @@ func (c *ApiController) AddRole() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.AddRole(&role))
+	affected := object.AddRole(&role)
+	if affected {
+		c.addAuditLog(object.AuditOperationAdd, "role", role.GetId(), nil, object.GetRole(role.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -111,6 +124,12 @@ func (c *ApiController) DeleteRole() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.DeleteRole(&role))
+	oldRole := object.GetRole(role.GetId())
+	affected := object.DeleteRole(&role)
+	if affected {
+		c.addAuditLog(object.AuditOperationDelete, "role", role.GetId(), oldRole, nil)
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
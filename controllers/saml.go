@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
 )
 
 func (c *ApiController) GetSamlMeta() {
@@ -32,3 +33,92 @@ func (c *ApiController) GetSamlMeta() {
 	c.Data["xml"] = metadata
 	c.ServeXML()
 }
+
+// HandleSamlLogout
+// @Title HandleSamlLogout
+// @Tag Login API
+// @Description the SAML single logout service, it accepts LogoutRequests with the HTTP-Redirect and HTTP-POST bindings and ends the Casdoor session of the user, the logout is not propagated to the other SPs
+// @Param   owner          path    string  true        "The owner of the application"
+// @Param   application    path    string  true        "The name of the application"
+// @router /saml/slo/:owner/:application [get,post]
+func (c *ApiController) HandleSamlLogout() {
+	owner := c.Ctx.Input.Param(":owner")
+	applicationName := c.Ctx.Input.Param(":application")
+	application := object.GetApplication(fmt.Sprintf("%s/%s", owner, applicationName))
+	if application == nil {
+		c.ResponseError(fmt.Sprintf("err: application %s/%s not found", owner, applicationName))
+		return
+	}
+
+	binding := object.SamlBindingRedirect
+	if c.Ctx.Request.Method == "POST" {
+		binding = object.SamlBindingPost
+	}
+
+	samlRequest := c.Input().Get("SAMLRequest")
+	if samlRequest == "" {
+		c.ResponseError("err: SAMLRequest is missing")
+		return
+	}
+
+	user, res, responseBinding, err := object.HandleSamlLogoutRequest(application, samlRequest, c.Ctx.Request.URL.RawQuery, c.Input().Get("RelayState"), binding, c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if user != nil && c.GetSessionUsername() == user.GetId() {
+		util.LogInfo(c.Ctx, "API: [%s] logged out by SAML single logout of application: [%s]", user.GetId(), application.Name)
+		c.SetSessionUsername("")
+		c.SetSessionData(nil)
+		c.SetSessionAuthContext(nil)
+	}
+
+	if responseBinding == object.SamlBindingPost {
+		c.Ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+		c.Ctx.WriteString(res)
+		return
+	}
+	c.Redirect(res, 302)
+}
+
+// GetSamlIdpInitiatedLogin
+// @Title GetSamlIdpInitiatedLogin
+// @Tag Login API
+// @Description sign in to the SP of the application without an AuthnRequest, the SAML response is posted to its ACS URL
+// @Param   application    query    string  true        "The id of the application"
+// @Param   entityId       query    string  false       "The entity ID of the registered SP, the first SP of the application by default"
+// @Param   RelayState     query    string  false       "The RelayState passed to the SP"
+// @router /saml/idp-initiated [get]
+func (c *ApiController) GetSamlIdpInitiatedLogin() {
+	userId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+
+	paramApp := c.Input().Get("application")
+	application := object.GetApplication(paramApp)
+	if application == nil {
+		c.ResponseError(fmt.Sprintf("err: application %s not found", paramApp))
+		return
+	}
+
+	user := object.GetUser(userId)
+	if user == nil {
+		c.ResponseError(fmt.Sprintf("The user: %s doesn't exist", userId))
+		return
+	}
+	if user.Owner != application.Organization && user.Owner != "built-in" {
+		c.ResponseError(fmt.Sprintf("err: the user: %s doesn't belong to the organization of application: %s", userId, application.Name))
+		return
+	}
+
+	res, acsUrl, err := object.GetSamlIdpInitiatedResponse(application, user, c.Input().Get("entityId"), c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+	c.Ctx.WriteString(object.GetSamlPostForm(acsUrl, "SAMLResponse", res, c.Input().Get("RelayState")))
+}
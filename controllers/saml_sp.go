@@ -0,0 +1,173 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/astaxie/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetSamlSps
+// @Title GetSamlSps
+// @Tag SAML SP API
+// @Description get SAML service providers
+// @Param   owner     query    string  true        "The owner of SAML SPs"
+// @Success 200 {array} object.SamlSp The Response object
+// @router /get-saml-sps [get]
+func (c *ApiController) GetSamlSps() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetSamlSps(owner)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetSamlSpCount(owner, query)))
+		samlSps := object.GetPaginationSamlSps(owner, paginator.Offset(), limit, query)
+		c.ResponsePage(samlSps, paginator, query)
+	}
+}
+
+// @Title GetSamlSp
+// @Tag SAML SP API
+// @Description get SAML service provider
+// @Param   id    query    string  true        "The id of the SAML SP"
+// @Success 200 {object} object.SamlSp The Response object
+// @router /get-saml-sp [get]
+func (c *ApiController) GetSamlSp() {
+	id := c.Input().Get("id")
+
+	c.Data["json"] = object.GetSamlSp(id)
+	c.ServeJSON()
+}
+
+// @Title UpdateSamlSp
+// @Tag SAML SP API
+// @Description update SAML service provider
+// @Param   id    query    string  true        "The id of the SAML SP"
+// @Param   body    body   object.SamlSp  true        "The details of the SAML SP"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-saml-sp [post]
+func (c *ApiController) UpdateSamlSp() {
+	id := c.Input().Get("id")
+
+	var samlSp object.SamlSp
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &samlSp)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateSamlSp(id, &samlSp))
+	c.ServeJSON()
+}
+
+// @Title AddSamlSp
+// @Tag SAML SP API
+// @Description add SAML service provider
+// @Param   body    body   object.SamlSp  true        "The details of the SAML SP"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-saml-sp [post]
+func (c *ApiController) AddSamlSp() {
+	var samlSp object.SamlSp
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &samlSp)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddSamlSp(&samlSp))
+	c.ServeJSON()
+}
+
+// @Title DeleteSamlSp
+// @Tag SAML SP API
+// @Description delete SAML service provider
+// @Param   body    body   object.SamlSp  true        "The details of the SAML SP"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-saml-sp [post]
+func (c *ApiController) DeleteSamlSp() {
+	var samlSp object.SamlSp
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &samlSp)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteSamlSp(&samlSp))
+	c.ServeJSON()
+}
+
+// ImportSamlSpMetadata
+// @Title ImportSamlSpMetadata
+// @Tag SAML SP API
+// @Description import the SP metadata XML from the metadata URL or an uploaded file into the SAML SP
+// @Param   id      query    string  true        "The id of the SAML SP"
+// @Param   url     query    string  false       "The metadata URL, the uploaded file is used when empty"
+// @Param   file    formData file    false       "The metadata XML file"
+// @Success 200 {object} controllers.Response The Response object
+// @router /import-saml-sp-metadata [post]
+func (c *ApiController) ImportSamlSpMetadata() {
+	id := c.Input().Get("id")
+	metadataUrl := c.Input().Get("url")
+
+	samlSp := object.GetSamlSp(id)
+	if samlSp == nil {
+		c.ResponseError(fmt.Sprintf("The SAML SP: %s doesn't exist", id))
+		return
+	}
+
+	var metadata []byte
+	var err error
+	if metadataUrl != "" {
+		metadata, err = object.FetchSamlSpMetadata(metadataUrl)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+		samlSp.MetadataUrl = metadataUrl
+	} else {
+		file, _, err := c.GetFile("file")
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+		defer file.Close()
+
+		metadata, err = ioutil.ReadAll(file)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+	}
+
+	err = object.ImportSamlSpMetadata(samlSp, metadata)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	object.UpdateSamlSp(id, samlSp)
+	c.ResponseOk(samlSp)
+}
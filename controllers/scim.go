@@ -0,0 +1,376 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// getScimServer authenticates the SCIM client of the organization in the path, it responds 401 if it fails
+func (c *RootController) getScimServer() *object.ScimServer {
+	organization := c.Ctx.Input.Param(":organization")
+	clientId, clientSecret, _ := c.Ctx.Request.BasicAuth()
+	accessToken := ""
+	if authorization := c.Ctx.Request.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		accessToken = strings.TrimPrefix(authorization, "Bearer ")
+	}
+
+	server := object.GetScimServer(organization, c.Ctx.Request.Host, clientId, clientSecret, accessToken)
+	if server == nil {
+		c.Ctx.Output.Header("WWW-Authenticate", "Bearer realm=\"SCIM\"")
+		c.responseScimError(&object.ScimError{
+			Schemas: []string{object.ScimErrorSchema},
+			Status:  "401",
+			Detail:  "invalid client credentials or access token for the organization: " + organization,
+		})
+		return nil
+	}
+
+	server.ClientIp = strings.Replace(util.GetIPFromRequest(c.Ctx.Request), ": ", "", -1)
+	return server
+}
+
+func (c *RootController) responseScim(status int, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Ctx.Output.Header("Content-Type", "application/scim+json; charset=utf-8")
+	c.Ctx.Output.SetStatus(status)
+	err = c.Ctx.Output.Body(body)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *RootController) responseScimError(scimErr *object.ScimError) {
+	c.responseScim(scimErr.GetStatus(), scimErr)
+}
+
+// responseScimResource sends a resource with its ETag, or 304 if the client already has this version
+func (c *RootController) responseScimResource(status int, data interface{}, meta *object.ScimMeta) {
+	c.Ctx.Output.Header("ETag", meta.Version)
+	if status == http.StatusCreated {
+		c.Ctx.Output.Header("Location", meta.Location)
+	}
+	if status == http.StatusOK && c.Ctx.Request.Method == http.MethodGet && c.Ctx.Input.Header("If-None-Match") == meta.Version {
+		c.Ctx.Output.SetStatus(http.StatusNotModified)
+		return
+	}
+	c.responseScim(status, data)
+}
+
+func (c *RootController) responseScimList(list *object.ScimListResponse, scimErr *object.ScimError) {
+	if scimErr != nil {
+		c.responseScimError(scimErr)
+		return
+	}
+	c.responseScim(http.StatusOK, list)
+}
+
+func (c *RootController) getScimPageParams() (string, int, int) {
+	startIndex, err := c.GetInt("startIndex", 1)
+	if err != nil {
+		startIndex = 1
+	}
+	count, err := c.GetInt("count", object.ScimDefaultCount)
+	if err != nil {
+		count = object.ScimDefaultCount
+	}
+	return c.Input().Get("filter"), startIndex, count
+}
+
+// GetScimUsers
+// @Title GetScimUsers
+// @Tag SCIM API
+// @Description list the users of the organization, with an optional SCIM filter
+// @Param   organization     path    string  true        "The organization"
+// @Param   filter     query    string  false        "The SCIM filter, like: userName eq \"alice\""
+// @Param   startIndex     query    int  false        "The 1-based index of the first user"
+// @Param   count     query    int  false        "The page size"
+// @Success 200 {object} object.ScimListResponse The Response object
+// @router /scim/:organization/v2/Users [get]
+func (c *RootController) GetScimUsers() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	c.responseScimList(server.GetUsers(c.getScimPageParams()))
+}
+
+// GetScimUser
+// @Title GetScimUser
+// @Tag SCIM API
+// @Description get a user of the organization
+// @Param   organization     path    string  true        "The organization"
+// @Param   id     path    string  true        "The ID of the user"
+// @Success 200 {object} object.ScimUser The Response object
+// @router /scim/:organization/v2/Users/:id [get]
+func (c *RootController) GetScimUser() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	scimUser, scimErr := server.GetUser(c.Ctx.Input.Param(":id"))
+	if scimErr != nil {
+		c.responseScimError(scimErr)
+		return
+	}
+	c.responseScimResource(http.StatusOK, scimUser, scimUser.Meta)
+}
+
+// AddScimUser
+// @Title AddScimUser
+// @Tag SCIM API
+// @Description create a user in the organization
+// @Param   organization     path    string  true        "The organization"
+// @Param   body    body   object.ScimUser  true        "The user"
+// @Success 201 {object} object.ScimUser The Response object
+// @router /scim/:organization/v2/Users [post]
+func (c *RootController) AddScimUser() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	scimUser, scimErr := server.AddUser(c.Ctx.Input.RequestBody)
+	if scimErr != nil {
+		c.responseScimError(scimErr)
+		return
+	}
+	c.responseScimResource(http.StatusCreated, scimUser, scimUser.Meta)
+}
+
+// UpdateScimUser
+// @Title UpdateScimUser
+// @Tag SCIM API
+// @Description replace a user of the organization, or change it with PATCH operations
+// @Param   organization     path    string  true        "The organization"
+// @Param   id     path    string  true        "The ID of the user"
+// @Param   body    body   object.ScimUser  true        "The user, or the PATCH operations"
+// @Success 200 {object} object.ScimUser The Response object
+// @router /scim/:organization/v2/Users/:id [put,patch]
+func (c *RootController) UpdateScimUser() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	id := c.Ctx.Input.Param(":id")
+	ifMatch := c.Ctx.Input.Header("If-Match")
+	var scimUser *object.ScimUser
+	var scimErr *object.ScimError
+	if c.Ctx.Request.Method == http.MethodPatch {
+		scimUser, scimErr = server.PatchUser(id, c.Ctx.Input.RequestBody, ifMatch)
+	} else {
+		scimUser, scimErr = server.ReplaceUser(id, c.Ctx.Input.RequestBody, ifMatch)
+	}
+	if scimErr != nil {
+		c.responseScimError(scimErr)
+		return
+	}
+	c.responseScimResource(http.StatusOK, scimUser, scimUser.Meta)
+}
+
+// DeleteScimUser
+// @Title DeleteScimUser
+// @Tag SCIM API
+// @Description delete a user of the organization
+// @Param   organization     path    string  true        "The organization"
+// @Param   id     path    string  true        "The ID of the user"
+// @Success 204 The user is deleted
+// @router /scim/:organization/v2/Users/:id [delete]
+func (c *RootController) DeleteScimUser() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	scimErr := server.DeleteUser(c.Ctx.Input.Param(":id"), c.Ctx.Input.Header("If-Match"))
+	if scimErr != nil {
+		c.responseScimError(scimErr)
+		return
+	}
+	c.Ctx.Output.SetStatus(http.StatusNoContent)
+}
+
+// GetScimGroups
+// @Title GetScimGroups
+// @Tag SCIM API
+// @Description list the roles of the organization as SCIM groups, with an optional SCIM filter
+// @Param   organization     path    string  true        "The organization"
+// @Param   filter     query    string  false        "The SCIM filter, like: displayName eq \"admins\""
+// @Param   startIndex     query    int  false        "The 1-based index of the first group"
+// @Param   count     query    int  false        "The page size"
+// @Success 200 {object} object.ScimListResponse The Response object
+// @router /scim/:organization/v2/Groups [get]
+func (c *RootController) GetScimGroups() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	c.responseScimList(server.GetGroups(c.getScimPageParams()))
+}
+
+// GetScimGroup
+// @Title GetScimGroup
+// @Tag SCIM API
+// @Description get a role of the organization as a SCIM group
+// @Param   organization     path    string  true        "The organization"
+// @Param   id     path    string  true        "The name of the role"
+// @Success 200 {object} object.ScimGroup The Response object
+// @router /scim/:organization/v2/Groups/:id [get]
+func (c *RootController) GetScimGroup() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	scimGroup, scimErr := server.GetGroup(c.Ctx.Input.Param(":id"))
+	if scimErr != nil {
+		c.responseScimError(scimErr)
+		return
+	}
+	c.responseScimResource(http.StatusOK, scimGroup, scimGroup.Meta)
+}
+
+// AddScimGroup
+// @Title AddScimGroup
+// @Tag SCIM API
+// @Description create a role in the organization from a SCIM group
+// @Param   organization     path    string  true        "The organization"
+// @Param   body    body   object.ScimGroup  true        "The group"
+// @Success 201 {object} object.ScimGroup The Response object
+// @router /scim/:organization/v2/Groups [post]
+func (c *RootController) AddScimGroup() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	scimGroup, scimErr := server.AddGroup(c.Ctx.Input.RequestBody)
+	if scimErr != nil {
+		c.responseScimError(scimErr)
+		return
+	}
+	c.responseScimResource(http.StatusCreated, scimGroup, scimGroup.Meta)
+}
+
+// UpdateScimGroup
+// @Title UpdateScimGroup
+// @Tag SCIM API
+// @Description replace a SCIM group, or change it with PATCH operations
+// @Param   organization     path    string  true        "The organization"
+// @Param   id     path    string  true        "The name of the role"
+// @Param   body    body   object.ScimGroup  true        "The group, or the PATCH operations"
+// @Success 200 {object} object.ScimGroup The Response object
+// @router /scim/:organization/v2/Groups/:id [put,patch]
+func (c *RootController) UpdateScimGroup() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	id := c.Ctx.Input.Param(":id")
+	ifMatch := c.Ctx.Input.Header("If-Match")
+	var scimGroup *object.ScimGroup
+	var scimErr *object.ScimError
+	if c.Ctx.Request.Method == http.MethodPatch {
+		scimGroup, scimErr = server.PatchGroup(id, c.Ctx.Input.RequestBody, ifMatch)
+	} else {
+		scimGroup, scimErr = server.ReplaceGroup(id, c.Ctx.Input.RequestBody, ifMatch)
+	}
+	if scimErr != nil {
+		c.responseScimError(scimErr)
+		return
+	}
+	c.responseScimResource(http.StatusOK, scimGroup, scimGroup.Meta)
+}
+
+// DeleteScimGroup
+// @Title DeleteScimGroup
+// @Tag SCIM API
+// @Description delete a role of the organization
+// @Param   organization     path    string  true        "The organization"
+// @Param   id     path    string  true        "The name of the role"
+// @Success 204 The group is deleted
+// @router /scim/:organization/v2/Groups/:id [delete]
+func (c *RootController) DeleteScimGroup() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	scimErr := server.DeleteGroup(c.Ctx.Input.Param(":id"), c.Ctx.Input.Header("If-Match"))
+	if scimErr != nil {
+		c.responseScimError(scimErr)
+		return
+	}
+	c.Ctx.Output.SetStatus(http.StatusNoContent)
+}
+
+// GetScimServiceProviderConfig
+// @Title GetScimServiceProviderConfig
+// @Tag SCIM API
+// @Description get the SCIM features supported by the server
+// @Param   organization     path    string  true        "The organization"
+// @router /scim/:organization/v2/ServiceProviderConfig [get]
+func (c *RootController) GetScimServiceProviderConfig() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	c.responseScim(http.StatusOK, server.GetServiceProviderConfig())
+}
+
+// GetScimSchemas
+// @Title GetScimSchemas
+// @Tag SCIM API
+// @Description get the schemas of the SCIM resources
+// @Param   organization     path    string  true        "The organization"
+// @router /scim/:organization/v2/Schemas [get]
+func (c *RootController) GetScimSchemas() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	c.responseScim(http.StatusOK, server.GetSchemas())
+}
+
+// GetScimResourceTypes
+// @Title GetScimResourceTypes
+// @Tag SCIM API
+// @Description get the types of the SCIM resources
+// @Param   organization     path    string  true        "The organization"
+// @router /scim/:organization/v2/ResourceTypes [get]
+func (c *RootController) GetScimResourceTypes() {
+	server := c.getScimServer()
+	if server == nil {
+		return
+	}
+
+	c.responseScim(http.StatusOK, server.GetResourceTypes())
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/astaxie/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetProvisioningStatuses
+// @Title GetProvisioningStatuses
+// @Tag Application API
+// @Description get the SCIM provisioning states of the users of an application
+// @Param   id     query    string  true        "The id of the application"
+// @Success 200 {array} object.ProvisioningStatus The Response object
+// @router /get-provisioning-statuses [get]
+func (c *ApiController) GetProvisioningStatuses() {
+	id := c.Input().Get("id")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+
+	_, name := util.GetOwnerAndNameFromId(id)
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetProvisioningStatuses(name)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetProvisioningStatusCount(name, query)))
+		statuses := object.GetPaginationProvisioningStatuses(name, paginator.Offset(), limit, query)
+		c.ResponsePage(statuses, paginator, query)
+	}
+}
+
+// ReconcileScimProvisioning
+// @Title ReconcileScimProvisioning
+// @Tag Application API
+// @Description push all the users of the organization to the SCIM endpoint of an application
+// @Param   id     query    string  true        "The id of the application"
+// @Success 200 {object} object.ScimReconcileResult The Response object
+// @router /reconcile-scim-provisioning [post]
+func (c *ApiController) ReconcileScimProvisioning() {
+	id := c.Input().Get("id")
+
+	application := object.GetApplication(id)
+	if application == nil {
+		c.ResponseError(fmt.Sprintf("The application: %s doesn't exist", id))
+		return
+	}
+	if !application.EnableScimProvisioning || application.ScimBaseUrl == "" {
+		c.ResponseError(fmt.Sprintf("The SCIM provisioning of the application: %s is disabled", id))
+		return
+	}
+
+	c.ResponseOk(object.ReconcileScimProvisioning(application))
+}
@@ -16,6 +16,8 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
+
 	"github.com/astaxie/beego/utils/pagination"
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
@@ -32,18 +34,20 @@ func (c *ApiController) GetSyncers() {
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetSyncers(owner)
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetSyncerCount(owner, field, value)))
-		syncers := object.GetPaginationSyncers(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
-		c.ResponseOk(syncers, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetSyncerCount(owner, query)))
+		syncers := object.GetPaginationSyncers(owner, paginator.Offset(), limit, query)
+		c.ResponsePage(syncers, paginator, query)
 	}
 }
 
@@ -118,13 +122,126 @@ func (c *ApiController) DeleteSyncer() {
 // @Tag Syncer API
 // @Description run syncer
 // @Param   body    body   object.Syncer  true        "The details of the syncer"
-// @Success 200 {object} controllers.Response The Response object
+// @Success 200 {object} object.SyncerRun The Response object
 // @router /run-syncer [get]
 func (c *ApiController) RunSyncer() {
 	id := c.Input().Get("id")
 	syncer := object.GetSyncer(id)
+	if syncer == nil {
+		c.ResponseError(fmt.Sprintf("The syncer: %s doesn't exist", id))
+		return
+	}
+
+	c.ResponseOk(object.RunSyncer(syncer))
+}
+
+// @Title DryRunSyncer
+// @Tag Syncer API
+// @Description compute the creates, updates, pushes and deletions of a syncer without applying them
+// @Param   id    query    string  true        "The id of the syncer"
+// @Success 200 {object} object.SyncerPlan The Response object
+// @router /dry-run-syncer [get]
+func (c *ApiController) DryRunSyncer() {
+	id := c.Input().Get("id")
+	syncer := object.GetSyncer(id)
+	if syncer == nil {
+		c.ResponseError(fmt.Sprintf("The syncer: %s doesn't exist", id))
+		return
+	}
+
+	plan, err := object.DryRunSyncer(syncer)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(plan)
+}
+
+// @Title GetSyncerRuns
+// @Tag Syncer API
+// @Description get the run history of a syncer
+// @Param   id    query    string  true        "The id of the syncer"
+// @Success 200 {array} object.SyncerRun The Response object
+// @router /get-syncer-runs [get]
+func (c *ApiController) GetSyncerRuns() {
+	id := c.Input().Get("id")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+
+	syncer := object.GetSyncer(id)
+	if syncer == nil {
+		c.ResponseError(fmt.Sprintf("The syncer: %s doesn't exist", id))
+		return
+	}
+
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetSyncerRuns(syncer)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetSyncerRunCount(syncer, query)))
+		runs := object.GetPaginationSyncerRuns(syncer, paginator.Offset(), limit, query)
+		c.ResponsePage(runs, paginator, query)
+	}
+}
+
+// @Title GetSyncerDecisions
+// @Tag Syncer API
+// @Description get the conflicts and the deletions recorded by a syncer
+// @Param   id    query    string  true        "The id of the syncer"
+// @Success 200 {array} object.SyncerDecision The Response object
+// @router /get-syncer-decisions [get]
+func (c *ApiController) GetSyncerDecisions() {
+	id := c.Input().Get("id")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+
+	syncer := object.GetSyncer(id)
+	if syncer == nil {
+		c.ResponseError(fmt.Sprintf("The syncer: %s doesn't exist", id))
+		return
+	}
+
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetSyncerDecisions(syncer)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetSyncerDecisionCount(syncer, query)))
+		decisions := object.GetPaginationSyncerDecisions(syncer, paginator.Offset(), limit, query)
+		c.ResponsePage(decisions, paginator, query)
+	}
+}
 
-	object.RunSyncer(syncer)
+// @Title ResolveSyncerDecision
+// @Tag Syncer API
+// @Description resolve a conflict left for review by pulling the user from the source or pushing it to the source
+// @Param   id    query    string  true        "The id of the syncer decision"
+// @Param   action    query    string  true        "Pull or Push"
+// @Success 200 {object} controllers.Response The Response object
+// @router /resolve-syncer-decision [post]
+func (c *ApiController) ResolveSyncerDecision() {
+	id := util.ParseInt(c.Input().Get("id"))
+	action := c.Input().Get("action")
+
+	err := object.ResolveSyncerDecision(id, action, c.GetSessionUsername())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
 
 	c.ResponseOk()
 }
@@ -17,6 +17,7 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/astaxie/beego/utils/pagination"
 	"github.com/casdoor/casdoor/object"
@@ -36,18 +37,20 @@ func (c *ApiController) GetTokens() {
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetTokens(owner)
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetTokenCount(owner, field, value)))
-		tokens := object.GetPaginationTokens(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
-		c.ResponseOk(tokens, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetTokenCount(owner, query)))
+		tokens := object.GetPaginationTokens(owner, paginator.Offset(), limit, query)
+		c.ResponsePage(tokens, paginator, query)
 	}
 }
 
@@ -152,7 +155,19 @@ func (c *ApiController) GetOAuthCode() {
 	}
 	host := c.Ctx.Request.Host
 
-	c.Data["json"] = object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, host)
+	acrValues := c.Input().Get("acr_values")
+	maxAge := getMaxAge(c.Input().Get("max_age"))
+	if util.InSlice(strings.Fields(c.Input().Get("prompt")), "login") {
+		// the user is not signing in through this API, so prompt=login only accepts an authentication from this very moment
+		maxAge = 0
+	}
+
+	var authContext *object.AuthContext
+	if c.GetSessionUsername() == userId {
+		authContext = c.GetSessionAuthContext()
+	}
+
+	c.Data["json"] = object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, host, acrValues, maxAge, authContext)
 	c.ServeJSON()
 }
 
@@ -33,19 +33,21 @@ import (
 func (c *ApiController) GetGlobalUsers() {
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetMaskedUsers(object.GetGlobalUsers())
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetGlobalUserCount(field, value)))
-		users := object.GetPaginationGlobalUsers(paginator.Offset(), limit, field, value, sortField, sortOrder)
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetGlobalUserCount(query)))
+		users := object.GetPaginationGlobalUsers(paginator.Offset(), limit, query)
 		users = object.GetMaskedUsers(users)
-		c.ResponseOk(users, paginator.Nums())
+		c.ResponsePage(users, paginator, query)
 	}
 }
 
@@ -60,19 +62,21 @@ func (c *ApiController) GetUsers() {
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
 		c.Data["json"] = object.GetMaskedUsers(object.GetUsers(owner))
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetUserCount(owner, field, value)))
-		users := object.GetPaginationUsers(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetUserCount(owner, query)))
+		users := object.GetPaginationUsers(owner, paginator.Offset(), limit, query)
 		users = object.GetMaskedUsers(users)
-		c.ResponseOk(users, paginator.Nums())
+		c.ResponsePage(users, paginator, query)
 	}
 }
 
@@ -142,10 +146,12 @@ func (c *ApiController) UpdateUser() {
 		columns = strings.Split(columnsStr, ",")
 	}
 
+	oldUser := object.GetUser(id)
 	isGlobalAdmin := c.IsGlobalAdmin()
 	affected := object.UpdateUser(id, &user, columns, isGlobalAdmin)
 	if affected {
 		object.UpdateUserToOriginalDatabase(&user)
+		c.addAuditLog(object.AuditOperationUpdate, "user", id, oldUser, object.GetUser(user.GetId()))
 	}
 
 	c.Data["json"] = wrapActionResponse(affected)
@@ -166,7 +172,12 @@ func (c *ApiController) AddUser() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.AddUser(&user))
+	affected := object.AddUser(&user)
+	if affected {
+		c.addAuditLog(object.AuditOperationAdd, "user", user.GetId(), nil, object.GetUser(user.GetId()))
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -184,7 +195,13 @@ func (c *ApiController) DeleteUser() {
 		panic(err)
 	}
 
-	c.Data["json"] = wrapActionResponse(object.DeleteUser(&user))
+	oldUser := object.GetUser(user.GetId())
+	affected := object.DeleteUser(&user)
+	if affected {
+		c.addAuditLog(object.AuditOperationDelete, "user", user.GetId(), oldUser, nil)
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
 	c.ServeJSON()
 }
 
@@ -272,8 +289,11 @@ func (c *ApiController) SetPassword() {
 		return
 	}
 
+	oldUser := *targetUser
 	targetUser.Password = newPassword
-	object.SetUserField(targetUser, "password", targetUser.Password)
+	if object.SetUserField(targetUser, "password", targetUser.Password) {
+		c.addAuditLog(object.AuditOperationUpdate, "user", userId, &oldUser, object.GetUser(userId))
+	}
 	c.Data["json"] = Response{Status: "ok"}
 	c.ServeJSON()
 }
@@ -328,7 +348,7 @@ func (c *ApiController) GetUserCount() {
 
 	count := 0
 	if isOnline == "" {
-		count = object.GetUserCount(owner, "", "")
+		count = object.GetUserCount(owner, nil)
 	} else {
 		count = object.GetOnlineUserCount(owner, util.ParseInt(isOnline))
 	}
@@ -0,0 +1,102 @@
+// Copyright 2021 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+const deviceCookieName = "casdoor_device_id"
+
+// getDeviceId returns the id of the browser kept in a long-lived cookie, a browser seen
+// for the first time gets a new one
+func (c *ApiController) getDeviceId() string {
+	deviceId := c.Ctx.GetCookie(deviceCookieName)
+	if deviceId == "" {
+		deviceId = util.GenerateId()
+		c.Ctx.SetCookie(deviceCookieName, deviceId, 3600*24*365*5, "/", "", c.Ctx.Input.Scheme() == "https", true)
+	}
+	return deviceId
+}
+
+// checkUserDevice remembers the device of a successful sign-in and notifies the user of a new one
+func (c *ApiController) checkUserDevice(application *object.Application, user *object.User) {
+	userAgent := c.Ctx.Request.UserAgent()
+	if len(userAgent) > 500 {
+		userAgent = userAgent[0:500]
+	}
+
+	signin := &object.UserDeviceSignin{
+		DeviceId:  c.getDeviceId(),
+		UserAgent: userAgent,
+		ClientIp:  strings.Replace(util.GetIPFromRequest(c.Ctx.Request), ": ", "", -1),
+	}
+	if c.CruSession != nil {
+		signin.SessionId = c.CruSession.SessionID()
+	}
+
+	util.SafeGoroutine(func() { object.CheckUserDevice(user, application, signin) })
+}
+
+// GetUserDevices
+// @Title GetUserDevices
+// @Tag User API
+// @Description get the devices a user has signed in from
+// @Param   id     query    string  true        "The id of the user"
+// @Success 200 {array} object.UserDevice The Response object
+// @router /get-user-devices [get]
+func (c *ApiController) GetUserDevices() {
+	id := c.Input().Get("id")
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+
+	c.Data["json"] = object.GetUserDevices(owner, name)
+	c.ServeJSON()
+}
+
+// ReportUserDevice
+// @Title ReportUserDevice
+// @Tag User API
+// @Description handle the "this wasn't me" link of a new device notification: sign out all the sessions of the user and reset the password
+// @Param   token     formData    string  true        "The token of the link"
+// @Success 200 {object} controllers.Response The Response object
+// @router /report-device [post]
+func (c *ApiController) ReportUserDevice() {
+	token := c.Ctx.Request.Form.Get("token")
+
+	device, sessionIds, err := object.ReportUserDevice(token)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	for _, sessionId := range sessionIds {
+		err = beego.GlobalSessions.GetProvider().SessionDestroy(sessionId)
+		if err != nil {
+			logs.Error("failed to destroy the session of a reported device: %s", err.Error())
+		}
+	}
+
+	userId := fmt.Sprintf("%s/%s", device.Owner, device.User)
+	util.SafeGoroutine(func() { object.SendCasLogoutRequests(userId) })
+
+	util.LogInfo(c.Ctx, "API: [%s] reported a device and was signed out everywhere", userId)
+	c.ResponseOk(device.Application)
+}
@@ -51,8 +51,11 @@ func (c *ApiController) UploadUsers() {
 	util.EnsureFileFolderExists(path)
 	saveFile(path, &file)
 
-	affected := object.UploadUsers(owner, fileId)
+	users, affected := object.UploadUsers(owner, fileId)
 	if affected {
+		for _, user := range users {
+			c.addAuditLog(object.AuditOperationAdd, "user", user.GetId(), nil, user)
+		}
 		c.ResponseOk()
 	} else {
 		c.ResponseError("Failed to import users")
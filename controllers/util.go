@@ -16,8 +16,10 @@ package controllers
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 
+	"github.com/astaxie/beego/utils/pagination"
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
@@ -51,6 +53,47 @@ func (c *ApiController) ResponseError(error string, data ...interface{}) {
 	c.ServeJSON()
 }
 
+// ResponsePage responds a page of items with the total count as data2, and the cursor
+// to pass back as the "cursor" parameter to get the next page when the page is full.
+func (c *ApiController) ResponsePage(items interface{}, paginator *pagination.Paginator, query *object.Query) {
+	resp := Response{Status: "ok", Data: items, Data2: paginator.Nums()}
+	if reflect.ValueOf(items).Len() == paginator.PerPageNums {
+		resp.Cursor = object.GetQueryCursor(items, query)
+	}
+	c.Data["json"] = resp
+	c.ServeJSON()
+}
+
+// getQuery parses the list parameters shared by all the paginated APIs: "field" and "value"
+// for the fuzzy search, "sortField" and "sortOrder", repeated "filter" parameters like
+// "clientIp:eq:10.0.0.1", "startTime" and "endTime" on the created time, and "cursor".
+func (c *ApiController) getQuery() (*object.Query, error) {
+	query := &object.Query{
+		Field:     c.Input().Get("field"),
+		Value:     c.Input().Get("value"),
+		SortField: c.Input().Get("sortField"),
+		SortOrder: c.Input().Get("sortOrder"),
+		Filters:   []*object.QueryFilter{},
+		StartTime: c.Input().Get("startTime"),
+		EndTime:   c.Input().Get("endTime"),
+		Cursor:    c.Input().Get("cursor"),
+	}
+
+	for _, s := range c.Input()["filter"] {
+		filter, err := object.ParseQueryFilter(s)
+		if err != nil {
+			return nil, err
+		}
+		query.Filters = append(query.Filters, filter)
+	}
+
+	err := query.Check()
+	if err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
 // SetTokenErrorHttpStatus ...
 func (c *ApiController) SetTokenErrorHttpStatus() {
 	_, ok := c.Data["json"].(*object.TokenError)
@@ -16,6 +16,8 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/astaxie/beego/utils/pagination"
 	"github.com/casdoor/casdoor/object"
@@ -30,21 +32,24 @@ import (
 // @Success 200 {array} object.Webhook The Response object
 // @router /get-webhooks [get]
 func (c *ApiController) GetWebhooks() {
+	userId := c.GetSessionUsername()
 	owner := c.Input().Get("owner")
 	limit := c.Input().Get("pageSize")
 	page := c.Input().Get("p")
-	field := c.Input().Get("field")
-	value := c.Input().Get("value")
-	sortField := c.Input().Get("sortField")
-	sortOrder := c.Input().Get("sortOrder")
 	if limit == "" || page == "" {
-		c.Data["json"] = object.GetWebhooks(owner)
+		c.Data["json"] = object.GetMaskedWebhooks(object.GetWebhooks(owner), userId)
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetWebhookCount(owner, field, value)))
-		webhooks := object.GetPaginationWebhooks(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
-		c.ResponseOk(webhooks, paginator.Nums())
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetWebhookCount(owner, query)))
+		webhooks := object.GetMaskedWebhooks(object.GetPaginationWebhooks(owner, paginator.Offset(), limit, query), userId)
+		c.ResponsePage(webhooks, paginator, query)
 	}
 }
 
@@ -55,9 +60,10 @@ func (c *ApiController) GetWebhooks() {
 // @Success 200 {object} object.Webhook The Response object
 // @router /get-webhook [get]
 func (c *ApiController) GetWebhook() {
+	userId := c.GetSessionUsername()
 	id := c.Input().Get("id")
 
-	c.Data["json"] = object.GetWebhook(id)
+	c.Data["json"] = object.GetMaskedWebhook(object.GetWebhook(id), userId)
 	c.ServeJSON()
 }
 
@@ -114,3 +120,119 @@ func (c *ApiController) DeleteWebhook() {
 	c.Data["json"] = wrapActionResponse(object.DeleteWebhook(&webhook))
 	c.ServeJSON()
 }
+
+// @Title GetWebhookEvents
+// @Tag Webhook API
+// @Description get the events queued for a webhook with their delivery state
+// @Param   id    query    string  true        "The id of the webhook"
+// @Success 200 {array} object.WebhookEvent The Response object
+// @router /get-webhook-events [get]
+func (c *ApiController) GetWebhookEvents() {
+	id := c.Input().Get("id")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+
+	webhook := object.GetWebhook(id)
+	if webhook == nil {
+		c.ResponseError(fmt.Sprintf("The webhook: %s doesn't exist", id))
+		return
+	}
+
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetWebhookEvents(webhook)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		query, err := c.getQuery()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetWebhookEventCount(webhook, query)))
+		events := object.GetPaginationWebhookEvents(webhook, paginator.Offset(), limit, query)
+		c.ResponsePage(events, paginator, query)
+	}
+}
+
+// @Title GetWebhookDeliveries
+// @Tag Webhook API
+// @Description get the delivery attempts of a webhook event
+// @Param   id    query    string  true        "The id of the webhook event"
+// @Success 200 {array} object.WebhookDelivery The Response object
+// @router /get-webhook-deliveries [get]
+func (c *ApiController) GetWebhookDeliveries() {
+	id := c.Input().Get("id")
+
+	event := object.GetWebhookEvent(util.ParseInt(id))
+	if event == nil {
+		c.ResponseError(fmt.Sprintf("The webhook event: %s doesn't exist", id))
+		return
+	}
+
+	c.ResponseOk(object.GetWebhookDeliveries(event))
+}
+
+// @Title RedeliverWebhookEvent
+// @Tag Webhook API
+// @Description queue a webhook event again for delivery
+// @Param   id    query    string  true        "The id of the webhook event"
+// @Success 200 {object} controllers.Response The Response object
+// @router /redeliver-webhook-event [post]
+func (c *ApiController) RedeliverWebhookEvent() {
+	id := c.Input().Get("id")
+
+	event := object.GetWebhookEvent(util.ParseInt(id))
+	if event == nil {
+		c.ResponseError(fmt.Sprintf("The webhook event: %s doesn't exist", id))
+		return
+	}
+
+	object.RedeliverWebhookEvent(event)
+	c.ResponseOk()
+}
+
+// @Title TestWebhook
+// @Tag Webhook API
+// @Description render a sample event with the filter and the payload template of the webhook without delivering it
+// @Param   event    query    string  true        "The event type or the action of the sample event"
+// @Param   body    body   object.Webhook  true        "The details of the webhook"
+// @Success 200 {object} object.WebhookTestResult The Response object
+// @router /test-webhook [post]
+func (c *ApiController) TestWebhook() {
+	eventType := c.Input().Get("event")
+
+	var webhook object.Webhook
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &webhook)
+	if err != nil {
+		panic(err)
+	}
+
+	if eventType == "" {
+		eventType = object.EventUserUpdated
+		if len(webhook.Events) != 0 {
+			eventType = webhook.Events[0]
+		}
+	}
+
+	c.ResponseOk(object.TestWebhook(&webhook, eventType, c.getCurrentUser()))
+}
+
+// @Title GetEventTypes
+// @Tag Webhook API
+// @Description get the catalog of the events the webhooks can subscribe to
+// @Success 200 {array} object.EventType The Response object
+// @router /get-event-types [get]
+func (c *ApiController) GetEventTypes() {
+	c.ResponseOk(object.EventTypes)
+}
+
+// publishEvent fills in the client IP and the signed-in user of the request and publishes the event in the background
+func (c *ApiController) publishEvent(event *object.Event) {
+	event.ClientIp = strings.Replace(util.GetIPFromRequest(c.Ctx.Request), ": ", "", -1)
+	if event.Actor == "" {
+		event.Actor = c.GetSessionUsername()
+	}
+
+	util.SafeGoroutine(func() { object.PublishEvent(event) })
+}
@@ -0,0 +1,405 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+type ldapAttribute struct {
+	name   string
+	values []string
+}
+
+type ldapEntry struct {
+	dn         string
+	rdns       []string
+	attributes []*ldapAttribute
+}
+
+func newLdapEntry(dn string) *ldapEntry {
+	rdns, err := normalizeLdapDn(dn)
+	if err != nil {
+		panic(err)
+	}
+	return &ldapEntry{dn: dn, rdns: rdns}
+}
+
+// addAttribute ignores the empty values, LDAP attributes can't hold them
+func (e *ldapEntry) addAttribute(name string, values ...string) {
+	attribute := &ldapAttribute{name: name}
+	for _, value := range values {
+		if value != "" {
+			attribute.values = append(attribute.values, value)
+		}
+	}
+
+	if len(attribute.values) != 0 {
+		e.attributes = append(e.attributes, attribute)
+	}
+}
+
+func (e *ldapEntry) getValues(name string) []string {
+	for _, attribute := range e.attributes {
+		if strings.EqualFold(attribute.name, name) {
+			return attribute.values
+		}
+	}
+	return nil
+}
+
+func (e *ldapEntry) isInScope(baseRdns []string, scope int64) bool {
+	if len(e.rdns) < len(baseRdns) {
+		return false
+	}
+	for i := range baseRdns {
+		if e.rdns[len(e.rdns)-len(baseRdns)+i] != baseRdns[i] {
+			return false
+		}
+	}
+
+	switch scope {
+	case goldap.ScopeBaseObject:
+		return len(e.rdns) == len(baseRdns)
+	case goldap.ScopeSingleLevel:
+		return len(e.rdns) == len(baseRdns)+1
+	default:
+		return true
+	}
+}
+
+// match evaluates a search filter of RFC 4511, the values are compared case-insensitively
+func (e *ldapEntry) match(filter *ber.Packet) bool {
+	switch filter.Tag {
+	case goldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !e.match(child) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, child := range filter.Children {
+			if e.match(child) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return len(filter.Children) == 1 && !e.match(filter.Children[0])
+	case goldap.FilterPresent:
+		return len(e.getValues(ber.DecodeString(filter.Data.Bytes()))) != 0
+	case goldap.FilterSubstrings:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		for _, value := range e.getValues(ber.DecodeString(filter.Children[0].Data.Bytes())) {
+			if matchLdapSubstrings(value, filter.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterEqualityMatch, goldap.FilterApproxMatch, goldap.FilterGreaterOrEqual, goldap.FilterLessOrEqual:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		assertion := strings.ToLower(ber.DecodeString(filter.Children[1].Data.Bytes()))
+		for _, value := range e.getValues(ber.DecodeString(filter.Children[0].Data.Bytes())) {
+			value = strings.ToLower(value)
+			if (filter.Tag == goldap.FilterGreaterOrEqual && value >= assertion) ||
+				(filter.Tag == goldap.FilterLessOrEqual && value <= assertion) ||
+				value == assertion {
+				return true
+			}
+		}
+		return false
+	default:
+		// extensible matches are not supported
+		return false
+	}
+}
+
+func matchLdapSubstrings(value string, substrings []*ber.Packet) bool {
+	value = strings.ToLower(value)
+	for _, substring := range substrings {
+		part := strings.ToLower(ber.DecodeString(substring.Data.Bytes()))
+		switch substring.Tag {
+		case goldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(value, part) {
+				return false
+			}
+			value = value[len(part):]
+		case goldap.FilterSubstringsAny:
+			index := strings.Index(value, part)
+			if index == -1 {
+				return false
+			}
+			value = value[index+len(part):]
+		case goldap.FilterSubstringsFinal:
+			if !strings.HasSuffix(value, part) {
+				return false
+			}
+			value = value[:len(value)-len(part)]
+		}
+	}
+	return true
+}
+
+// toPacket returns the SearchResultEntry with the requested attributes, all the attributes are returned for none or "*"
+func (e *ldapEntry) toPacket(messageId int64, names []string, typesOnly bool) *ber.Packet {
+	isAll := len(names) == 0
+	requested := map[string]bool{}
+	for _, name := range names {
+		if name == "*" {
+			isAll = true
+		}
+		requested[strings.ToLower(name)] = true
+	}
+
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, "Object Name"))
+	attributes := ber.NewSequence("Attributes")
+	for _, attribute := range e.attributes {
+		if !isAll && !requested[strings.ToLower(attribute.name)] {
+			continue
+		}
+
+		attributePacket := ber.NewSequence("Attribute")
+		attributePacket.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, attribute.name, "Type"))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		if !typesOnly {
+			for _, value := range attribute.values {
+				values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+			}
+		}
+		attributePacket.AppendChild(values)
+		attributes.AppendChild(attributePacket)
+	}
+	entry.AppendChild(attributes)
+	return newLdapMessage(messageId, entry)
+}
+
+// normalizeLdapDn returns the RDNs of the DN in lower case, so that the DNs can be compared
+func normalizeLdapDn(dn string) ([]string, error) {
+	parsedDn, err := goldap.ParseDN(dn)
+	if err != nil {
+		return nil, err
+	}
+
+	rdns := []string{}
+	for _, rdn := range parsedDn.RDNs {
+		values := []string{}
+		for _, attribute := range rdn.Attributes {
+			values = append(values, fmt.Sprintf("%s=%s", strings.ToLower(attribute.Type), strings.ToLower(attribute.Value)))
+		}
+		rdns = append(rdns, strings.Join(values, "+"))
+	}
+	return rdns, nil
+}
+
+// escapeLdapDnValue escapes the special characters of an attribute value in a DN, see RFC 4514
+func escapeLdapDnValue(value string) string {
+	var builder strings.Builder
+	for i, c := range value {
+		if strings.ContainsRune(",+\"\\<>;=", c) || (i == 0 && (c == ' ' || c == '#')) || (i == len(value)-1 && c == ' ') {
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(c)
+	}
+	return builder.String()
+}
+
+func getOrganizationDn(organization string, baseDn string) string {
+	return fmt.Sprintf("ou=%s,%s", escapeLdapDnValue(organization), baseDn)
+}
+
+func getUserDn(owner string, name string, baseDn string) string {
+	return fmt.Sprintf("uid=%s,ou=people,%s", escapeLdapDnValue(name), getOrganizationDn(owner, baseDn))
+}
+
+func getGroupDn(owner string, name string, baseDn string) string {
+	return fmt.Sprintf("cn=%s,ou=groups,%s", escapeLdapDnValue(name), getOrganizationDn(owner, baseDn))
+}
+
+// parseUserDn returns the organization and the name of a user DN: "uid=alice,ou=people,ou=built-in,dc=example,dc=com"
+func parseUserDn(dn string, baseDn string) (string, string, error) {
+	parsedDn, err := goldap.ParseDN(dn)
+	if err != nil {
+		return "", "", err
+	}
+	rdns, err := normalizeLdapDn(dn)
+	if err != nil {
+		return "", "", err
+	}
+	baseRdns, err := normalizeLdapDn(baseDn)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(rdns) != len(baseRdns)+3 || strings.Join(rdns[3:], ",") != strings.Join(baseRdns, ",") || rdns[1] != "ou=people" {
+		return "", "", fmt.Errorf("the DN: %s is not a user DN under: %s", dn, baseDn)
+	}
+
+	nameRdn := parsedDn.RDNs[0].Attributes[0]
+	organizationRdn := parsedDn.RDNs[2].Attributes[0]
+	if (!strings.EqualFold(nameRdn.Type, "uid") && !strings.EqualFold(nameRdn.Type, "cn")) || !strings.EqualFold(organizationRdn.Type, "ou") {
+		return "", "", fmt.Errorf("the DN: %s is not a user DN under: %s", dn, baseDn)
+	}
+	return organizationRdn.Value, nameRdn.Value, nil
+}
+
+// getOrganizationByDn returns the organization that the DN belongs to, or "" if the DN is above the organizations
+func getOrganizationByDn(dn string, baseDn string) string {
+	parsedDn, err := goldap.ParseDN(dn)
+	if err != nil {
+		return ""
+	}
+	baseRdns, err := normalizeLdapDn(baseDn)
+	if err != nil {
+		return ""
+	}
+
+	index := len(parsedDn.RDNs) - len(baseRdns) - 1
+	if index < 0 {
+		return ""
+	}
+	return parsedDn.RDNs[index].Attributes[0].Value
+}
+
+func getBaseEntry(baseDn string) *ldapEntry {
+	entry := newLdapEntry(baseDn)
+	entry.addAttribute("objectClass", "top", "domain")
+	parsedDn, err := goldap.ParseDN(baseDn)
+	if err == nil && len(parsedDn.RDNs) != 0 {
+		attribute := parsedDn.RDNs[0].Attributes[0]
+		entry.addAttribute(attribute.Type, attribute.Value)
+	}
+	return entry
+}
+
+func getOrganizationEntries(organization *object.Organization, baseDn string) []*ldapEntry {
+	organizationDn := getOrganizationDn(organization.Name, baseDn)
+	organizationEntry := newLdapEntry(organizationDn)
+	organizationEntry.addAttribute("objectClass", "top", "organizationalUnit")
+	organizationEntry.addAttribute("ou", organization.Name)
+	organizationEntry.addAttribute("description", organization.DisplayName)
+
+	peopleEntry := newLdapEntry(fmt.Sprintf("ou=people,%s", organizationDn))
+	peopleEntry.addAttribute("objectClass", "top", "organizationalUnit")
+	peopleEntry.addAttribute("ou", "people")
+
+	groupsEntry := newLdapEntry(fmt.Sprintf("ou=groups,%s", organizationDn))
+	groupsEntry.addAttribute("objectClass", "top", "organizationalUnit")
+	groupsEntry.addAttribute("ou", "groups")
+
+	return []*ldapEntry{organizationEntry, peopleEntry, groupsEntry}
+}
+
+func getUserEntry(user *object.User, memberOf []string, baseDn string) *ldapEntry {
+	entry := newLdapEntry(getUserDn(user.Owner, user.Name, baseDn))
+	entry.addAttribute("objectClass", "top", "person", "organizationalPerson", "inetOrgPerson")
+	entry.addAttribute("uid", user.Name)
+	if user.DisplayName != "" {
+		entry.addAttribute("cn", user.DisplayName)
+	} else {
+		entry.addAttribute("cn", user.Name)
+	}
+	if user.LastName != "" {
+		entry.addAttribute("sn", user.LastName)
+	} else {
+		entry.addAttribute("sn", user.Name)
+	}
+	entry.addAttribute("givenName", user.FirstName)
+	entry.addAttribute("displayName", user.DisplayName)
+	entry.addAttribute("mail", user.Email)
+	entry.addAttribute("telephoneNumber", user.Phone)
+	entry.addAttribute("entryUUID", user.Id)
+	entry.addAttribute("memberOf", memberOf...)
+	return entry
+}
+
+func getGroupEntry(role *object.Role, baseDn string) *ldapEntry {
+	entry := newLdapEntry(getGroupDn(role.Owner, role.Name, baseDn))
+	entry.addAttribute("objectClass", "top", "groupOfNames")
+	entry.addAttribute("cn", role.Name)
+	entry.addAttribute("description", role.DisplayName)
+
+	members := []string{}
+	for _, userId := range role.Users {
+		if owner, name := splitUserId(userId); owner != "" {
+			members = append(members, getUserDn(owner, name, baseDn))
+		}
+	}
+	entry.addAttribute("member", members...)
+	return entry
+}
+
+func splitUserId(userId string) (string, string) {
+	tokens := strings.SplitN(userId, "/", 2)
+	if len(tokens) != 2 {
+		return "", ""
+	}
+	return tokens[0], tokens[1]
+}
+
+// getLdapEntries returns the entries that the bound user can see: the base entry and the entries of the
+// organizations, the users of an organization are listed under "ou=people" and its roles under "ou=groups"
+func getLdapEntries(user *object.User, searchDn string, baseDn string) []*ldapEntry {
+	organizations := []*object.Organization{}
+	if user.Owner == "built-in" || user.IsGlobalAdmin {
+		organizations = object.GetOrganizations("admin")
+	} else {
+		organization := object.GetOrganization(fmt.Sprintf("admin/%s", user.Owner))
+		if organization != nil {
+			organizations = append(organizations, organization)
+		}
+	}
+
+	entries := []*ldapEntry{getBaseEntry(baseDn)}
+	searchOrganization := getOrganizationByDn(searchDn, baseDn)
+	for _, organization := range organizations {
+		if searchOrganization != "" && !strings.EqualFold(searchOrganization, organization.Name) {
+			continue
+		}
+
+		entries = append(entries, getOrganizationEntries(organization, baseDn)...)
+
+		memberOf := map[string][]string{}
+		for _, role := range object.GetRoles(organization.Name) {
+			if !role.IsEnabled {
+				continue
+			}
+
+			groupDn := getGroupDn(role.Owner, role.Name, baseDn)
+			for _, userId := range role.Users {
+				memberOf[userId] = append(memberOf[userId], groupDn)
+			}
+			entries = append(entries, getGroupEntry(role, baseDn))
+		}
+
+		for _, organizationUser := range object.GetUsers(organization.Name) {
+			if organizationUser.IsDeleted {
+				continue
+			}
+			entries = append(entries, getUserEntry(organizationUser, memberOf[organizationUser.GetId()], baseDn))
+		}
+	}
+	return entries
+}
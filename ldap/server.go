@@ -0,0 +1,328 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const ldapStartTlsOid = "1.3.6.1.4.1.1466.20037"
+
+type ldapServer struct {
+	baseDn    string
+	tlsConfig *tls.Config
+}
+
+type ldapSession struct {
+	server *ldapServer
+	conn   net.Conn
+	isTls  bool
+	// user is nil for an anonymous session
+	user *object.User
+}
+
+// StartLdapServer exposes the users and the roles of the organizations with LDAP when ldapServerPort or ldapsServerPort is set,
+// ldapServerCert is the ID of the cert used by LDAPS and StartTLS, like: "admin/cert-built-in"
+func StartLdapServer() {
+	port := conf.GetConfigString("ldapServerPort")
+	ldapsPort := conf.GetConfigString("ldapsServerPort")
+	if port == "" && ldapsPort == "" {
+		return
+	}
+
+	baseDn := conf.GetConfigString("ldapServerBaseDn")
+	if baseDn == "" {
+		baseDn = "dc=casdoor,dc=com"
+	}
+	tlsConfig, err := getLdapTlsConfig(conf.GetConfigString("ldapServerCert"))
+	if err != nil {
+		panic(err)
+	}
+	server := &ldapServer{baseDn: baseDn, tlsConfig: tlsConfig}
+
+	if port != "" {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+		if err != nil {
+			panic(err)
+		}
+		util.SafeGoroutine(func() { server.serve(listener, false) })
+	}
+
+	if ldapsPort != "" {
+		if tlsConfig == nil {
+			panic(fmt.Errorf("ldapServerCert should be set to listen on ldapsServerPort"))
+		}
+		listener, err := tls.Listen("tcp", fmt.Sprintf(":%s", ldapsPort), tlsConfig)
+		if err != nil {
+			panic(err)
+		}
+		util.SafeGoroutine(func() { server.serve(listener, true) })
+	}
+}
+
+func getLdapTlsConfig(certId string) (*tls.Config, error) {
+	if certId == "" {
+		return nil, nil
+	}
+
+	cert := object.GetCert(certId)
+	if cert == nil {
+		return nil, fmt.Errorf("the cert: %s of the LDAP server doesn't exist", certId)
+	}
+	keyPair, err := tls.X509KeyPair([]byte(cert.PublicKey), []byte(cert.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{keyPair}, MinVersion: tls.VersionTLS12}, nil
+}
+
+func (s *ldapServer) serve(listener net.Listener, isTls bool) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			logs.Error("LDAP server stopped: %s", err.Error())
+			return
+		}
+
+		session := &ldapSession{server: s, conn: conn, isTls: isTls}
+		util.SafeGoroutine(session.serve)
+	}
+}
+
+func (s *ldapSession) serve() {
+	defer func() {
+		s.conn.Close()
+	}()
+
+	for {
+		packet, err := ber.ReadPacket(s.conn)
+		if err != nil {
+			return
+		}
+		if len(packet.Children) < 2 {
+			return
+		}
+		messageId, ok := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+		if !ok || request.ClassType != ber.ClassApplication {
+			return
+		}
+
+		switch request.Tag {
+		case goldap.ApplicationUnbindRequest:
+			return
+		case goldap.ApplicationAbandonRequest:
+			// the requests are handled one by one, there is nothing to abandon
+			continue
+		}
+
+		responses, isStartTls := s.handleRequest(messageId, request)
+		for _, response := range responses {
+			_, err = s.conn.Write(response.Bytes())
+			if err != nil {
+				return
+			}
+		}
+
+		if isStartTls {
+			tlsConn := tls.Server(s.conn, s.server.tlsConfig)
+			err = tlsConn.Handshake()
+			if err != nil {
+				return
+			}
+			s.conn = tlsConn
+			s.isTls = true
+		}
+	}
+}
+
+func (s *ldapSession) handleRequest(messageId int64, request *ber.Packet) (responses []*ber.Packet, isStartTls bool) {
+	responseTag := getLdapResponseTag(request.Tag)
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Error("LDAP server failed to handle the request: %v", r)
+			responses = []*ber.Packet{newLdapResult(messageId, responseTag, goldap.LDAPResultOperationsError, fmt.Sprintf("%v", r))}
+			isStartTls = false
+		}
+	}()
+
+	switch request.Tag {
+	case goldap.ApplicationBindRequest:
+		return []*ber.Packet{s.handleBind(messageId, request)}, false
+	case goldap.ApplicationSearchRequest:
+		return s.handleSearch(messageId, request), false
+	case goldap.ApplicationExtendedRequest:
+		return s.handleExtended(messageId, request)
+	default:
+		return []*ber.Packet{newLdapResult(messageId, responseTag, goldap.LDAPResultUnwillingToPerform, "the directory is read-only")}, false
+	}
+}
+
+func (s *ldapSession) handleBind(messageId int64, request *ber.Packet) *ber.Packet {
+	if len(request.Children) < 3 {
+		return newLdapResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultProtocolError, "invalid bind request")
+	}
+	if version, ok := request.Children[0].Value.(int64); !ok || version != 3 {
+		return newLdapResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultProtocolError, "only LDAPv3 is supported")
+	}
+
+	s.user = nil
+	dn := ber.DecodeString(request.Children[1].Data.Bytes())
+	authentication := request.Children[2]
+	if authentication.ClassType != ber.ClassContext || authentication.Tag != 0 {
+		return newLdapResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultAuthMethodNotSupported, "only simple bind is supported")
+	}
+
+	password := ber.DecodeString(authentication.Data.Bytes())
+	if dn == "" && password == "" {
+		return newLdapResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultSuccess, "")
+	}
+	if password == "" {
+		return newLdapResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials, "the password can't be empty")
+	}
+
+	organization, name, err := parseUserDn(dn, s.server.baseDn)
+	if err != nil {
+		return newLdapResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials, err.Error())
+	}
+	user, msg := object.CheckUserPassword(organization, name, password)
+	if msg != "" {
+		return newLdapResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials, msg)
+	}
+
+	s.user = user
+	return newLdapResult(messageId, goldap.ApplicationBindResponse, goldap.LDAPResultSuccess, "")
+}
+
+func (s *ldapSession) handleSearch(messageId int64, request *ber.Packet) []*ber.Packet {
+	if len(request.Children) < 8 {
+		return []*ber.Packet{newLdapResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultProtocolError, "invalid search request")}
+	}
+
+	searchDn := ber.DecodeString(request.Children[0].Data.Bytes())
+	scope, _ := request.Children[1].Value.(int64)
+	sizeLimit, _ := request.Children[3].Value.(int64)
+	typesOnly, _ := request.Children[5].Value.(bool)
+	filter := request.Children[6]
+	attributes := []string{}
+	for _, attribute := range request.Children[7].Children {
+		attributes = append(attributes, ber.DecodeString(attribute.Data.Bytes()))
+	}
+
+	searchRdns, err := normalizeLdapDn(searchDn)
+	if err != nil {
+		return []*ber.Packet{newLdapResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultInvalidDNSyntax, err.Error())}
+	}
+
+	var entries []*ldapEntry
+	if searchDn == "" && scope == goldap.ScopeBaseObject {
+		// the root DSE can be read anonymously to discover the naming context and StartTLS
+		entries = []*ldapEntry{s.getRootDse()}
+	} else if s.user == nil {
+		return []*ber.Packet{newLdapResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultInsufficientAccessRights, "please bind before searching")}
+	} else {
+		entries = getLdapEntries(s.user, searchDn, s.server.baseDn)
+	}
+
+	responses := []*ber.Packet{}
+	isFound := false
+	for _, entry := range entries {
+		if !entry.isInScope(searchRdns, goldap.ScopeWholeSubtree) {
+			continue
+		}
+		isFound = true
+
+		if !entry.isInScope(searchRdns, scope) || !entry.match(filter) {
+			continue
+		}
+		if sizeLimit > 0 && int64(len(responses)) >= sizeLimit {
+			return append(responses, newLdapResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultSizeLimitExceeded, ""))
+		}
+		responses = append(responses, entry.toPacket(messageId, attributes, typesOnly))
+	}
+
+	if !isFound {
+		return []*ber.Packet{newLdapResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultNoSuchObject, fmt.Sprintf("the DN: %s doesn't exist", searchDn))}
+	}
+	return append(responses, newLdapResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess, ""))
+}
+
+func (s *ldapSession) handleExtended(messageId int64, request *ber.Packet) ([]*ber.Packet, bool) {
+	oid := ""
+	if len(request.Children) != 0 {
+		oid = ber.DecodeString(request.Children[0].Data.Bytes())
+	}
+	if oid != ldapStartTlsOid {
+		return []*ber.Packet{newLdapResult(messageId, goldap.ApplicationExtendedResponse, goldap.LDAPResultProtocolError, fmt.Sprintf("the extended operation: %s is not supported", oid))}, false
+	}
+
+	if s.server.tlsConfig == nil {
+		return []*ber.Packet{newLdapResult(messageId, goldap.ApplicationExtendedResponse, goldap.LDAPResultUnavailable, "StartTLS is not configured")}, false
+	}
+	if s.isTls {
+		return []*ber.Packet{newLdapResult(messageId, goldap.ApplicationExtendedResponse, goldap.LDAPResultOperationsError, "TLS is already established")}, false
+	}
+
+	response := newLdapResult(messageId, goldap.ApplicationExtendedResponse, goldap.LDAPResultSuccess, "")
+	response.Children[1].AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 10, ldapStartTlsOid, "Response Name"))
+	return []*ber.Packet{response}, true
+}
+
+func (s *ldapSession) getRootDse() *ldapEntry {
+	entry := &ldapEntry{dn: "", rdns: []string{}}
+	entry.addAttribute("objectClass", "top")
+	entry.addAttribute("namingContexts", s.server.baseDn)
+	entry.addAttribute("supportedLDAPVersion", "3")
+	if s.server.tlsConfig != nil {
+		entry.addAttribute("supportedExtension", ldapStartTlsOid)
+	}
+	entry.addAttribute("vendorName", "Casdoor")
+	return entry
+}
+
+func getLdapResponseTag(requestTag ber.Tag) ber.Tag {
+	switch requestTag {
+	case goldap.ApplicationSearchRequest:
+		return goldap.ApplicationSearchResultDone
+	case goldap.ApplicationExtendedRequest:
+		return goldap.ApplicationExtendedResponse
+	default:
+		return requestTag + 1
+	}
+}
+
+func newLdapMessage(messageId int64, protocolOp *ber.Packet) *ber.Packet {
+	message := ber.NewSequence("LDAP Response")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "Message ID"))
+	message.AppendChild(protocolOp)
+	return message
+}
+
+func newLdapResult(messageId int64, tag ber.Tag, resultCode uint16, diagnosticMessage string) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "LDAP Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(resultCode), "Result Code"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, diagnosticMessage, "Diagnostic Message"))
+	return newLdapMessage(messageId, result)
+}
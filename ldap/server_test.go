@@ -0,0 +1,85 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"net"
+	"testing"
+
+	"github.com/casdoor/casdoor/object"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLdapEntryMatch(t *testing.T) {
+	user := &object.User{Owner: "built-in", Name: "alice", DisplayName: "Alice Liddell", Email: "alice@example.com"}
+	entry := getUserEntry(user, []string{getGroupDn("built-in", "admin", "dc=example,dc=com")}, "dc=example,dc=com")
+	assert.Equal(t, "uid=alice,ou=people,ou=built-in,dc=example,dc=com", entry.dn)
+
+	filters := map[string]bool{
+		"(objectClass=*)": true,
+		"(uid=ALICE)":     true,
+		"(&(objectClass=person)(mail=*@example.com))": true,
+		"(|(uid=bob)(cn=alice*))":                     true,
+		"(cn=*lid*ll)":                                true,
+		"(!(uid=alice))":                              false,
+		"(memberOf=cn=admin,ou=groups,ou=built-in,dc=example,dc=com)": true,
+		"(telephoneNumber=*)": false,
+	}
+	for filter, expected := range filters {
+		packet, err := goldap.CompileFilter(filter)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, entry.match(packet), filter)
+	}
+
+	baseRdns, err := normalizeLdapDn("OU=People,ou=built-in,DC=example,dc=com")
+	assert.Nil(t, err)
+	assert.True(t, entry.isInScope(baseRdns, goldap.ScopeSingleLevel))
+	assert.False(t, entry.isInScope(baseRdns, goldap.ScopeBaseObject))
+}
+
+func TestParseUserDn(t *testing.T) {
+	organization, name, err := parseUserDn("UID=alice,ou=People,ou=built-in,dc=example,dc=com", "dc=example,dc=com")
+	assert.Nil(t, err)
+	assert.Equal(t, "built-in", organization)
+	assert.Equal(t, "alice", name)
+
+	_, _, err = parseUserDn("uid=alice,ou=built-in,dc=example,dc=com", "dc=example,dc=com")
+	assert.NotNil(t, err)
+	_, _, err = parseUserDn("uid=alice,ou=people,ou=built-in,dc=other,dc=com", "dc=example,dc=com")
+	assert.NotNil(t, err)
+}
+
+func TestLdapRootDse(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	session := &ldapSession{server: &ldapServer{baseDn: "dc=example,dc=com"}, conn: serverConn}
+	go session.serve()
+
+	conn := goldap.NewConn(clientConn, false)
+	conn.Start()
+	defer conn.Close()
+
+	result, err := conn.Search(goldap.NewSearchRequest("", goldap.ScopeBaseObject, goldap.NeverDerefAliases, 0, 0, false, "(objectClass=*)", []string{"namingContexts"}, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(result.Entries))
+	assert.Equal(t, "dc=example,dc=com", result.Entries[0].GetAttributeValue("namingContexts"))
+	assert.Equal(t, "", result.Entries[0].GetAttributeValue("vendorName"))
+
+	_, err = conn.Search(goldap.NewSearchRequest("dc=example,dc=com", goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false, "(uid=alice)", nil, nil))
+	assert.True(t, goldap.IsErrorWithCode(err, goldap.LDAPResultInsufficientAccessRights))
+
+	err = conn.StartTLS(nil)
+	assert.NotNil(t, err)
+}
@@ -23,6 +23,7 @@ import (
 	_ "github.com/astaxie/beego/session/redis"
 	"github.com/casdoor/casdoor/authz"
 	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/ldap"
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/proxy"
 	"github.com/casdoor/casdoor/routers"
@@ -39,8 +40,13 @@ func main() {
 	object.InitFromFile()
 	object.InitDefaultStorageProvider()
 	object.InitLdapAutoSynchronizer()
+	object.InitScimProvisioner()
+	object.InitWebhookDispatcher()
+	object.InitRecordCheckpointer()
+	object.InitRecordArchiver()
 	proxy.InitHttpClient()
 	authz.InitAuthz()
+	ldap.StartLdapServer()
 
 	util.SafeGoroutine(func() {object.RunSyncUsersJob()})
 
@@ -20,7 +20,6 @@ import (
 
 	"github.com/astaxie/beego"
 	"github.com/casdoor/casdoor/conf"
-	"github.com/casdoor/casdoor/util"
 	//_ "github.com/denisenkom/go-mssqldb" // db = mssql
 	_ "github.com/go-sql-driver/mysql" // db = mysql
 	//_ "github.com/lib/pq"                // db = postgres
@@ -202,28 +201,98 @@ func (a *Adapter) createTable() {
 	if err != nil {
 		panic(err)
 	}
+
+	err = a.Engine.Sync2(new(SamlSp))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(CasTicket))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ProvisioningStatus))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(SyncerDecision))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(SyncedUser))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(SyncerRun))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(WebhookEvent))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(WebhookDelivery))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(AuditLog))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(RecordCheckpoint))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(RecordChainHead))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(RecordArchive))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(RecordSink))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(LoginAttempt))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(UserDevice))
+	if err != nil {
+		panic(err)
+	}
 }
 
-func GetSession(owner string, offset, limit int, field, value, sortField, sortOrder string) *xorm.Session {
+func GetSession(bean interface{}, owner string, offset, limit int, query *Query) *xorm.Session {
+	if query == nil {
+		query = &Query{}
+	}
+
 	session := adapter.Engine.Prepare()
-	if offset != -1 && limit != -1 {
+	isPaged := offset != -1 && limit != -1
+	if isPaged {
+		if query.Cursor != "" {
+			offset = 0
+		}
 		session.Limit(limit, offset)
 	}
 	if owner != "" {
 		session = session.And("owner=?", owner)
 	}
-	if field != "" && value != "" {
-		if filterField(field) {
-			session = session.And(fmt.Sprintf("%s like ?", util.SnakeString(field)), fmt.Sprintf("%%%s%%", value))
-		}
-	}
-	if sortField == "" || sortOrder == "" {
-		sortField = "created_time"
-	}
-	if sortOrder == "ascend" {
-		session = session.Asc(util.SnakeString(sortField))
-	} else {
-		session = session.Desc(util.SnakeString(sortField))
-	}
-	return session
+	return applyQuery(session, bean, query, isPaged)
 }
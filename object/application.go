@@ -50,8 +50,11 @@ type Application struct {
 	Providers           []*ProviderItem `xorm:"mediumtext" json:"providers"`
 	SignupItems         []*SignupItem   `xorm:"varchar(1000)" json:"signupItems"`
 	GrantTypes          []string        `xorm:"varchar(1000)" json:"grantTypes"`
+	AcrItems            []*AcrItem      `xorm:"varchar(1000)" json:"acrItems"`
 	OrganizationObj     *Organization   `xorm:"-" json:"organizationObj"`
 
+	RequireSamlSignedRequest bool `json:"requireSamlSignedRequest"`
+
 	ClientId             string   `xorm:"varchar(100)" json:"clientId"`
 	ClientSecret         string   `xorm:"varchar(100)" json:"clientSecret"`
 	RedirectUris         []string `xorm:"varchar(1000)" json:"redirectUris"`
@@ -65,10 +68,20 @@ type Application struct {
 	TermsOfUse           string   `xorm:"varchar(100)" json:"termsOfUse"`
 	SignupHtml           string   `xorm:"mediumtext" json:"signupHtml"`
 	SigninHtml           string   `xorm:"mediumtext" json:"signinHtml"`
+
+	EnableScimServer       bool                    `json:"enableScimServer"`
+	EnableScimProvisioning bool                    `json:"enableScimProvisioning"`
+	ScimBaseUrl            string                  `xorm:"varchar(200)" json:"scimBaseUrl"`
+	ScimAuthType           string                  `xorm:"varchar(100)" json:"scimAuthType"`
+	ScimToken              string                  `xorm:"varchar(1000)" json:"scimToken"`
+	ScimUsername           string                  `xorm:"varchar(100)" json:"scimUsername"`
+	ScimPassword           string                  `xorm:"varchar(100)" json:"scimPassword"`
+	ScimAttributeMappings  []*ScimAttributeMapping `xorm:"mediumtext" json:"scimAttributeMappings"`
+	ScimDeletionAction     string                  `xorm:"varchar(100)" json:"scimDeletionAction"`
 }
 
-func GetApplicationCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetApplicationCount(owner string, query *Query) int {
+	session := GetSession(&Application{}, owner, -1, -1, query)
 	count, err := session.Count(&Application{})
 	if err != nil {
 		panic(err)
@@ -87,9 +100,9 @@ func GetApplications(owner string) []*Application {
 	return applications
 }
 
-func GetPaginationApplications(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Application {
+func GetPaginationApplications(owner string, offset, limit int, query *Query) []*Application {
 	applications := []*Application{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Application{}, owner, offset, limit, query)
 	err := session.Find(&applications)
 	if err != nil {
 		panic(err)
@@ -227,6 +240,12 @@ func GetMaskedApplication(application *Application, userId string) *Application
 	if application.ClientSecret != "" {
 		application.ClientSecret = "***"
 	}
+	if application.ScimToken != "" {
+		application.ScimToken = "***"
+	}
+	if application.ScimPassword != "" {
+		application.ScimPassword = "***"
+	}
 
 	if application.OrganizationObj != nil {
 		if application.OrganizationObj.MasterPassword != "" {
@@ -271,6 +290,12 @@ func UpdateApplication(id string, application *Application) bool {
 	if application.ClientSecret == "***" {
 		session.Omit("client_secret")
 	}
+	if application.ScimToken == "***" {
+		session.Omit("scim_token")
+	}
+	if application.ScimPassword == "***" {
+		session.Omit("scim_password")
+	}
 	affected, err := session.Update(application)
 	if err != nil {
 		panic(err)
@@ -308,6 +333,13 @@ func DeleteApplication(application *Application) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		_, err = adapter.Engine.Delete(&ProvisioningStatus{Application: application.Name})
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	return affected != 0
 }
 
@@ -0,0 +1,259 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	AuditOperationAdd    = "add"
+	AuditOperationUpdate = "update"
+	AuditOperationDelete = "delete"
+
+	auditRedactedValue = "***"
+)
+
+// auditRedactedFields are the JSON names of the secrets, their values are never stored in the diffs,
+// only the fact that they have changed
+var auditRedactedFields = getAuditRedactedFields()
+
+// getAuditRedactedFields returns the secrets that are never returned by the API, plus the fields that the
+// GetMasked* functions mask, found by masking a probe of each object, so that a newly masked field can't be missed
+func getAuditRedactedFields() map[string]bool {
+	fields := map[string]bool{
+		"password":       true,
+		"passwordSalt":   true,
+		"hash":           true,
+		"preHash":        true,
+		"clientSecret":   true,
+		"clientSecret2":  true,
+		"privateKey":     true,
+		"masterPassword": true,
+		"secret":         true,
+		"accessToken":    true,
+		"refreshToken":   true,
+	}
+
+	probes := []interface{}{
+		GetMaskedApplication(newAuditProbe(&Application{}).(*Application), ""),
+		GetMaskedOrganization(newAuditProbe(&Organization{}).(*Organization)),
+		GetMaskedProvider(newAuditProbe(&Provider{}).(*Provider)),
+		GetMaskedSyncer(newAuditProbe(&Syncer{}).(*Syncer)),
+		GetMaskedUser(newAuditProbe(&User{}).(*User)),
+		GetMaskedWebhook(newAuditProbe(&Webhook{}).(*Webhook), ""),
+	}
+	for _, probe := range probes {
+		v := reflect.ValueOf(probe).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).Kind() == reflect.String && v.Field(i).String() == auditRedactedValue {
+				fields[getAuditFieldName(v.Type().Field(i))] = true
+			}
+		}
+	}
+	return fields
+}
+
+// newAuditProbe sets all the string fields of the object to a non-empty value
+func newAuditProbe(bean interface{}) interface{} {
+	v := reflect.ValueOf(bean).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Kind() == reflect.String && v.Field(i).CanSet() {
+			v.Field(i).SetString("probe")
+		}
+	}
+	return bean
+}
+
+func getAuditFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// AuditDiff is the change of a field, Old is null for a creation and New is null for a deletion
+type AuditDiff struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// AuditLog is the entry of a change made to an object through the API, with the field-level diff of the object
+type AuditLog struct {
+	Id          int    `xorm:"int notnull pk autoincr" json:"id"`
+	Owner       string `xorm:"varchar(100) index" json:"owner"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Actor      string       `xorm:"varchar(100) index" json:"actor"`
+	ClientIp   string       `xorm:"varchar(100)" json:"clientIp"`
+	Operation  string       `xorm:"varchar(100)" json:"operation"`
+	ObjectType string       `xorm:"varchar(100) index" json:"objectType"`
+	ObjectId   string       `xorm:"varchar(100) index" json:"objectId"`
+	Diffs      []*AuditDiff `xorm:"mediumtext" json:"diffs"`
+}
+
+func GetAuditLogCount(owner string, query *Query) int {
+	session := GetSession(&AuditLog{}, owner, -1, -1, query)
+	count, err := session.Count(&AuditLog{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetAuditLogs(owner string) []*AuditLog {
+	auditLogs := []*AuditLog{}
+	err := adapter.Engine.Desc("id").Find(&auditLogs, &AuditLog{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return auditLogs
+}
+
+func GetPaginationAuditLogs(owner string, offset, limit int, query *Query) []*AuditLog {
+	query.setDefaultSort("id", "descend")
+
+	auditLogs := []*AuditLog{}
+	session := GetSession(&AuditLog{}, owner, offset, limit, query)
+	err := session.Find(&auditLogs)
+	if err != nil {
+		panic(err)
+	}
+
+	return auditLogs
+}
+
+// AddAuditLog saves the entry with the diff between the object before and after the operation,
+// an update that changes nothing is not saved
+func AddAuditLog(auditLog *AuditLog, before interface{}, after interface{}) bool {
+	auditLog.Diffs = getAuditDiffs(before, after)
+	if auditLog.Operation == AuditOperationUpdate && len(auditLog.Diffs) == 0 {
+		return false
+	}
+
+	auditLog.Owner, _ = util.GetOwnerAndNameFromIdNoCheck(auditLog.ObjectId)
+	auditLog.CreatedTime = util.GetCurrentTime()
+	affected, err := adapter.Engine.Insert(auditLog)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func getAuditFields(object interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	if !isNilEvent(object) {
+		_ = json.Unmarshal([]byte(util.StructToJson(object)), &res)
+	}
+	return res
+}
+
+// getAuditDiffs compares the JSON fields of the two objects, the secrets are redacted after the comparison so that
+// a changed secret is still listed. The empty fields are left out of a creation or a deletion
+func getAuditDiffs(before interface{}, after interface{}) []*AuditDiff {
+	m, oM := getAuditFields(before), getAuditFields(after)
+	isCreatedOrDeleted := isNilEvent(before) || isNilEvent(after)
+
+	fields := map[string]bool{}
+	for key := range m {
+		fields[key] = true
+	}
+	for key := range oM {
+		fields[key] = true
+	}
+
+	diffs := []*AuditDiff{}
+	for field := range fields {
+		oldValue, newValue := m[field], oM[field]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if isCreatedOrDeleted && isAuditZero(oldValue) && isAuditZero(newValue) {
+			continue
+		}
+
+		if auditRedactedFields[field] {
+			oldValue, newValue = redactAuditValue(oldValue), redactAuditValue(newValue)
+		} else {
+			oldValue, newValue = redactAuditSecrets(oldValue), redactAuditSecrets(newValue)
+		}
+		diffs = append(diffs, &AuditDiff{Field: field, Old: oldValue, New: newValue})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Field < diffs[j].Field
+	})
+	return diffs
+}
+
+func isAuditZero(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+func redactAuditValue(value interface{}) interface{} {
+	if isAuditZero(value) {
+		return value
+	}
+	return auditRedactedValue
+}
+
+// redactAuditSecrets redacts the secrets nested in a field, like the client secrets of the providers of an application
+func redactAuditSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		res := map[string]interface{}{}
+		for key, child := range v {
+			if auditRedactedFields[key] {
+				res[key] = redactAuditValue(child)
+			} else {
+				res[key] = redactAuditSecrets(child)
+			}
+		}
+		return res
+	case []interface{}:
+		res := []interface{}{}
+		for _, child := range v {
+			res = append(res, redactAuditSecrets(child))
+		}
+		return res
+	default:
+		return value
+	}
+}
@@ -54,8 +54,8 @@ func GetMaskedCerts(certs []*Cert) []*Cert {
 	return certs
 }
 
-func GetCertCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetCertCount(owner string, query *Query) int {
+	session := GetSession(&Cert{}, owner, -1, -1, query)
 	count, err := session.Count(&Cert{})
 	if err != nil {
 		panic(err)
@@ -74,9 +74,9 @@ func GetCerts(owner string) []*Cert {
 	return certs
 }
 
-func GetPaginationCerts(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Cert {
+func GetPaginationCerts(owner string, offset, limit int, query *Query) []*Cert {
 	certs := []*Cert{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Cert{}, owner, offset, limit, query)
 	err := session.Find(&certs)
 	if err != nil {
 		panic(err)
@@ -135,40 +135,56 @@ func CheckPassword(user *User, password string) string {
 	}
 }
 
+// checkLdapUserPasswordWithLdap binds as the user and returns its mapped fields, or nil if the user isn't in the LDAP server
+func checkLdapUserPasswordWithLdap(ldapServer *Ldap, user *User, password string) (map[string]string, error) {
+	conn, err := GetLdapConn(ldapServer)
+	if err != nil {
+		return nil, nil
+	}
+	defer conn.Conn.Close()
+
+	SearchFilter := fmt.Sprintf("(&%s(%s=%s))", ldapServer.getUserFilter(conn.IsAD), ldapServer.getNameAttribute(conn.IsAD), goldap.EscapeFilter(user.Name))
+	searchReq := goldap.NewSearchRequest(ldapServer.BaseDn,
+		ldapServer.getSearchScope(), goldap.NeverDerefAliases, 0, 0, false,
+		SearchFilter, ldapServer.getSearchAttributes(conn.IsAD), nil)
+	searchResult, err := conn.Conn.Search(searchReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(searchResult.Entries) == 0 {
+		return nil, nil
+	} else if len(searchResult.Entries) > 1 {
+		return nil, fmt.Errorf("Error: multiple accounts with same uid, please check your ldap server")
+	}
+
+	entry := searchResult.Entries[0]
+	if err := conn.Conn.Bind(entry.DN, password); err != nil {
+		return nil, nil
+	}
+
+	fields := ldapServer.getUserFields(entry.Attributes, conn.IsAD)
+	delete(fields, "name")
+	return fields, nil
+}
+
 func checkLdapUserPassword(user *User, password string) (*User, string) {
 	ldaps := GetLdaps(user.Owner)
-	ldapLoginSuccess := false
 	for _, ldapServer := range ldaps {
-		conn, err := GetLdapConn(ldapServer.Host, ldapServer.Port, ldapServer.Admin, ldapServer.Passwd)
-		if err != nil {
-			continue
-		}
-		SearchFilter := fmt.Sprintf("(&(objectClass=posixAccount)(uid=%s))", user.Name)
-		searchReq := goldap.NewSearchRequest(ldapServer.BaseDn,
-			goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
-			SearchFilter, []string{}, nil)
-		searchResult, err := conn.Conn.Search(searchReq)
+		fields, err := checkLdapUserPasswordWithLdap(ldapServer, user, password)
 		if err != nil {
 			return nil, err.Error()
 		}
-
-		if len(searchResult.Entries) == 0 {
+		if fields == nil {
 			continue
-		} else if len(searchResult.Entries) > 1 {
-			return nil, "Error: multiple accounts with same uid, please check your ldap server"
 		}
 
-		dn := searchResult.Entries[0].DN
-		if err := conn.Conn.Bind(dn, password); err == nil {
-			ldapLoginSuccess = true
-			break
-		}
+		// the profile is refreshed from the directory with the same mapping as the sync
+		updateUserByLdapFields(user, fields)
+		return user, ""
 	}
 
-	if !ldapLoginSuccess {
-		return nil, "ldap user name or password incorrect"
-	}
-	return user, ""
+	return nil, "ldap user name or password incorrect"
 }
 
 func CheckUserPassword(organization string, username string, password string) (*User, string) {
@@ -229,4 +245,4 @@ func CheckUserPermission(requestUserId, userId string, strict bool) (bool, error
 	}
 
 	return hasPermission, fmt.Errorf("you don't have the permission to do this")
-}
\ No newline at end of file
+}
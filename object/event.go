@@ -0,0 +1,210 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const EventVersion = 1
+
+const (
+	EventUserCreated           = "user.created"
+	EventUserUpdated           = "user.updated"
+	EventUserDeleted           = "user.deleted"
+	EventUserLoginSucceeded    = "user.login.succeeded"
+	EventUserLoginFailed       = "user.login.failed"
+	EventUserLogout            = "user.logout"
+	EventTokenIssued           = "token.issued"
+	EventTokenRevoked          = "token.revoked"
+	EventRoleMembershipChanged = "role.membership.changed"
+	EventPaymentPaid           = "payment.paid"
+	EventPaymentFailed         = "payment.failed"
+)
+
+// EventType is an entry of the catalog of the events a webhook can subscribe to
+type EventType struct {
+	Name        string `json:"name"`
+	ObjectType  string `json:"objectType"`
+	Description string `json:"description"`
+}
+
+var EventTypes = []*EventType{
+	{EventUserCreated, "user", "A user has been created by a signup, an admin, a syncer or a provisioning client"},
+	{EventUserUpdated, "user", "The fields of a user have changed, the changed fields are listed in changedFields"},
+	{EventUserDeleted, "user", "A user has been deleted"},
+	{EventUserLoginSucceeded, "user", "A user has signed in to an application"},
+	{EventUserLoginFailed, "user", "A sign-in has been rejected, the reason is in data.reason"},
+	{EventUserLogout, "user", "A user has signed out"},
+	{EventTokenIssued, "token", "An access token has been issued to an application, the token values are not included"},
+	{EventTokenRevoked, "token", "An access token has been revoked"},
+	{EventRoleMembershipChanged, "role", "Users or roles have been added to or removed from a role, see data.addedUsers and data.removedUsers"},
+	{EventPaymentPaid, "payment", "A payment has been confirmed by the payment provider"},
+	{EventPaymentFailed, "payment", "The notification of a payment provider has been rejected"},
+}
+
+// Event is the payload delivered to the webhooks: a typed change of an object with its state before and after,
+// Before is null for a creation and After is null for a deletion. Version is increased on breaking payload changes
+type Event struct {
+	Id            string                 `json:"id"`
+	Type          string                 `json:"type"`
+	Version       int                    `json:"version"`
+	CreatedTime   string                 `json:"createdTime"`
+	Organization  string                 `json:"organization"`
+	Actor         string                 `json:"actor"`
+	ClientIp      string                 `json:"clientIp"`
+	ObjectType    string                 `json:"objectType"`
+	ObjectId      string                 `json:"objectId"`
+	Before        interface{}            `json:"before"`
+	After         interface{}            `json:"after"`
+	ChangedFields []string               `json:"changedFields"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+func getEventObjectType(eventType string) string {
+	for _, t := range EventTypes {
+		if t.Name == eventType {
+			return t.ObjectType
+		}
+	}
+	return ""
+}
+
+func NewEvent(eventType string, organization string, objectId string, before interface{}, after interface{}) *Event {
+	return &Event{
+		Id:            util.GenerateId(),
+		Type:          eventType,
+		Version:       EventVersion,
+		CreatedTime:   util.GetCurrentTime(),
+		Organization:  organization,
+		ObjectType:    getEventObjectType(eventType),
+		ObjectId:      objectId,
+		Before:        before,
+		After:         after,
+		ChangedFields: getChangedFields(before, after),
+		Data:          map[string]interface{}{},
+	}
+}
+
+// getChangedFields returns the sorted JSON names of the fields that differ between the two objects
+func getChangedFields(before interface{}, after interface{}) []string {
+	res := []string{}
+	if isNilEvent(before) || isNilEvent(after) {
+		return res
+	}
+
+	m, oM := map[string]interface{}{}, map[string]interface{}{}
+	_ = json.Unmarshal([]byte(util.StructToJson(before)), &m)
+	_ = json.Unmarshal([]byte(util.StructToJson(after)), &oM)
+	for key, value := range oM {
+		if !reflect.DeepEqual(m[key], value) {
+			res = append(res, key)
+		}
+	}
+	for key := range m {
+		if _, ok := oM[key]; !ok {
+			res = append(res, key)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func isNilEvent(object interface{}) bool {
+	if object == nil {
+		return true
+	}
+	v := reflect.ValueOf(object)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// getEventUser returns a copy of the user without the secrets and the internal sync state
+func getEventUser(user *User) *User {
+	if user == nil {
+		return nil
+	}
+
+	u := *user
+	if u.Password != "" {
+		u.Password = "***"
+	}
+	u.PasswordSalt = ""
+	u.Hash = ""
+	u.PreHash = ""
+	return &u
+}
+
+// getEventToken returns a copy of the token without the token values
+func getEventToken(token *Token) *Token {
+	if token == nil {
+		return nil
+	}
+
+	t := *token
+	t.Code = ""
+	t.AccessToken = ""
+	t.RefreshToken = ""
+	t.CodeChallenge = ""
+	return &t
+}
+
+func publishUserEvent(eventType string, before *User, after *User) {
+	user := after
+	if user == nil {
+		user = before
+	}
+
+	event := NewEvent(eventType, user.Owner, user.GetId(), getEventUser(before), getEventUser(after))
+	if before != nil && after != nil && before.Password != after.Password && !util.InSlice(event.ChangedFields, "password") {
+		event.ChangedFields = append(event.ChangedFields, "password")
+		sort.Strings(event.ChangedFields)
+	}
+	if eventType == EventUserUpdated && len(event.ChangedFields) == 0 {
+		return
+	}
+
+	PublishEvent(event)
+}
+
+// PublishEvent queues the event for the webhooks of its organization subscribed to its type and accepting it
+func PublishEvent(event *Event) {
+	webhooks := getSubscribedWebhooks(event.Organization, event.Type)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload := util.StructToJson(event)
+	events := []*WebhookEvent{}
+	for _, webhook := range webhooks {
+		webhookEvent := newWebhookEvent(webhook, event.Organization, event.Type, payload)
+		if webhookEvent != nil {
+			events = append(events, webhookEvent)
+		}
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	_, err := adapter.Engine.Insert(events)
+	if err != nil {
+		panic(err)
+	}
+
+	wakeWebhookDispatcher()
+}
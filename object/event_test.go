@@ -0,0 +1,42 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetChangedFields(t *testing.T) {
+	before := &User{Owner: "built-in", Name: "alice", DisplayName: "Alice", Password: "123", Hash: "a", Properties: map[string]string{"k": "1"}}
+	after := &User{Owner: "built-in", Name: "alice", DisplayName: "Alice L.", Password: "456", Hash: "b", Properties: map[string]string{"k": "2"}}
+
+	assert.Equal(t, []string{"displayName", "hash", "password", "properties"}, getChangedFields(before, after))
+	assert.Equal(t, []string{"displayName", "properties"}, getChangedFields(getEventUser(before), getEventUser(after)))
+	assert.Equal(t, []string{}, getChangedFields(nil, getEventUser(after)))
+	assert.Equal(t, []string{}, getChangedFields(getEventUser(before), (*User)(nil)))
+
+	eventUser := getEventUser(after)
+	assert.Equal(t, "***", eventUser.Password)
+	assert.Equal(t, "", eventUser.Hash)
+	assert.Equal(t, "456", after.Password)
+
+	event := NewEvent(EventTokenIssued, "built-in", "admin/token", nil, getEventToken(&Token{AccessToken: "secret", RefreshToken: "secret", Scope: "read"}))
+	assert.Equal(t, "token", event.ObjectType)
+	assert.Equal(t, EventVersion, event.Version)
+	assert.Equal(t, "", event.After.(*Token).AccessToken)
+	assert.Equal(t, "read", event.After.(*Token).Scope)
+}
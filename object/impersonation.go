@@ -0,0 +1,102 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "fmt"
+
+// ActorClaim is the "act" claim of a token issued to an impersonated session, see: https://datatracker.ietf.org/doc/html/rfc8693#section-4.1
+type ActorClaim struct {
+	Sub  string `json:"sub"`
+	Name string `json:"name"`
+}
+
+func isAdminUser(user *User) bool {
+	return user.Owner == "built-in" || user.IsGlobalAdmin || user.IsAdmin
+}
+
+func isGlobalAdminUser(user *User) bool {
+	return user.Owner == "built-in" || user.IsGlobalAdmin
+}
+
+// CheckImpersonationPermission returns an error message when the admin is not allowed to impersonate the target user.
+// Global admins can impersonate users of any organization, organization admins only the users of their own organization.
+// Admins can only be impersonated by global admins, and only when the target organization explicitly allows it.
+func CheckImpersonationPermission(admin *User, target *User) string {
+	if admin == nil {
+		return "please sign in first"
+	}
+	if target == nil || target.IsDeleted {
+		return "the user to impersonate does not exist"
+	}
+	if admin.GetId() == target.GetId() {
+		return "you cannot impersonate yourself"
+	}
+	if target.IsForbidden {
+		return "the user to impersonate is forbidden to sign in"
+	}
+
+	isGlobalAdmin := isGlobalAdminUser(admin)
+	if !isGlobalAdmin && !(admin.IsAdmin && admin.Owner == target.Owner) {
+		return fmt.Sprintf("you don't have the permission to impersonate the user: %s", target.GetId())
+	}
+
+	// an organization admin can switch EnableAdminImpersonation on, so it must never
+	// let them impersonate a user as privileged as themselves or more
+	if isAdminUser(target) {
+		if !isGlobalAdmin {
+			return fmt.Sprintf("you don't have the permission to impersonate the admin: %s", target.GetId())
+		}
+
+		organization := GetOrganizationByUser(target)
+		if organization == nil || !organization.EnableAdminImpersonation {
+			return fmt.Sprintf("the user: %s is an admin and the organization does not allow impersonating admins", target.GetId())
+		}
+	}
+
+	return ""
+}
+
+// StartImpersonation returns the auth context of the impersonated session, it keeps how and when the admin authenticated.
+func StartImpersonation(admin *User, target *User, adminAuthContext *AuthContext) (*AuthContext, string) {
+	if adminAuthContext != nil && adminAuthContext.Impersonator != "" {
+		return nil, "please stop the current impersonation first"
+	}
+
+	msg := CheckImpersonationPermission(admin, target)
+	if msg != "" {
+		return nil, msg
+	}
+
+	authContext := NewAuthContext()
+	if adminAuthContext != nil {
+		authContext.AuthTime = adminAuthContext.AuthTime
+		authContext.Methods = adminAuthContext.Methods
+	}
+	authContext.Impersonator = admin.GetId()
+	return authContext, ""
+}
+
+func getActorClaim(authContext *AuthContext) *ActorClaim {
+	if authContext == nil || authContext.Impersonator == "" {
+		return nil
+	}
+
+	actor := &ActorClaim{Name: authContext.Impersonator}
+	admin := GetUser(authContext.Impersonator)
+	if admin != nil {
+		actor.Sub = admin.Id
+	}
+	return actor
+}
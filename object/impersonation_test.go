@@ -0,0 +1,45 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckImpersonationPermission(t *testing.T) {
+	orgAdmin := &User{Owner: "org", Name: "admin", IsAdmin: true}
+	user := &User{Owner: "org", Name: "alice"}
+	otherUser := &User{Owner: "other", Name: "bob"}
+	otherAdmin := &User{Owner: "org", Name: "admin2", IsAdmin: true}
+	globalAdmin := &User{Owner: "org", Name: "root", IsGlobalAdmin: true}
+	builtInAdmin := &User{Owner: "built-in", Name: "admin"}
+
+	assert.Equal(t, "", CheckImpersonationPermission(orgAdmin, user))
+	assert.Equal(t, "", CheckImpersonationPermission(globalAdmin, otherUser))
+	assert.Equal(t, "", CheckImpersonationPermission(builtInAdmin, user))
+
+	assert.NotEqual(t, "", CheckImpersonationPermission(nil, user))
+	assert.NotEqual(t, "", CheckImpersonationPermission(orgAdmin, orgAdmin))
+	assert.NotEqual(t, "", CheckImpersonationPermission(orgAdmin, otherUser))
+	assert.NotEqual(t, "", CheckImpersonationPermission(user, otherUser))
+
+	// an organization admin cannot impersonate an admin of the same organization,
+	// let alone a global admin, whatever the organization allows
+	assert.NotEqual(t, "", CheckImpersonationPermission(orgAdmin, otherAdmin))
+	assert.NotEqual(t, "", CheckImpersonationPermission(orgAdmin, globalAdmin))
+	assert.NotEqual(t, "", CheckImpersonationPermission(orgAdmin, builtInAdmin))
+}
@@ -29,6 +29,9 @@ func InitDb() {
 		initBuiltInCert()
 		initBuiltInLdap()
 	}
+
+	migrateApplicationSamlSps()
+	migrateUserIds()
 }
 
 func initBuiltInOrganization() bool {
@@ -17,6 +17,8 @@ package object
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/astaxie/beego"
@@ -37,8 +39,23 @@ type Ldap struct {
 	Passwd     string `xorm:"varchar(100)" json:"passwd"`
 	BaseDn     string `xorm:"varchar(100)" json:"baseDn"`
 
+	TlsMode         string `xorm:"varchar(100)" json:"tlsMode"`
+	CaCert          string `xorm:"varchar(100)" json:"caCert"`
+	ClientCert      string `xorm:"varchar(100)" json:"clientCert"`
+	TlsServerName   string `xorm:"varchar(100)" json:"tlsServerName"`
+	TlsVerification string `xorm:"varchar(100)" json:"tlsVerification"`
+
+	Filter            string                  `xorm:"varchar(500)" json:"filter"`
+	SearchScope       string                  `xorm:"varchar(100)" json:"searchScope"`
+	PageSize          int                     `json:"pageSize"`
+	AttributeMappings []*LdapAttributeMapping `xorm:"mediumtext" json:"attributeMappings"`
+
 	AutoSync int    `json:"autoSync"`
 	LastSync string `xorm:"varchar(100)" json:"lastSync"`
+
+	DeletionPolicy string             `xorm:"varchar(100)" json:"deletionPolicy"`
+	SyncWatermark  string             `xorm:"varchar(100)" json:"syncWatermark"`
+	SyncSummaries  []*LdapSyncSummary `xorm:"mediumtext" json:"syncSummaries"`
 }
 
 type ldapConn struct {
@@ -46,12 +63,17 @@ type ldapConn struct {
 	IsAD bool
 }
 
-//type ldapGroup struct {
-//	GidNumber string
-//	Cn        string
-//}
+type ldapGroup struct {
+	Dn        string
+	Cn        string
+	GidNumber string
+	// Members are the DNs of the member users and groups, MemberUids are the uids of a posixGroup
+	Members    []string
+	MemberUids []string
+}
 
 type ldapUser struct {
+	Dn        string
 	UidNumber string
 	Uid       string
 	Cn        string
@@ -66,6 +88,11 @@ type ldapUser struct {
 	MobileTelephoneNumber string
 	RegisteredAddress     string
 	PostalAddress         string
+	ModifyTimestamp       string
+	UsnChanged            string
+	IsDisabled            bool
+	// Fields are the User fields mapped from the attributes
+	Fields map[string]string
 }
 
 type LdapRespUser struct {
@@ -78,6 +105,8 @@ type LdapRespUser struct {
 	Email   string `json:"email"`
 	Phone   string `json:"phone"`
 	Address string `json:"address"`
+
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
 type ldapServerType struct {
@@ -108,6 +137,8 @@ func LdapUsersToLdapRespUsers(users []ldapUser) []LdapRespUser {
 			Email:     returnAnyNotEmpty(user.Email, user.EmailAddress, user.Mail),
 			Phone:     returnAnyNotEmpty(user.Mobile, user.MobileTelephoneNumber, user.TelephoneNumber),
 			Address:   returnAnyNotEmpty(user.PostalAddress, user.RegisteredAddress),
+
+			Attributes: user.Fields,
 		})
 	}
 	return res
@@ -152,90 +183,100 @@ func isMicrosoftAD(Conn *goldap.Conn) (bool, error) {
 	return isMicrosoft, err
 }
 
-func GetLdapConn(host string, port int, adminUser string, adminPasswd string) (*ldapConn, error) {
-	conn, err := goldap.Dial("tcp", fmt.Sprintf("%s:%d", host, port))
+func GetLdapConn(ldap *Ldap) (*ldapConn, error) {
+	tlsConfig, err := ldap.getTlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialLdap(ldap, tlsConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	err = conn.Bind(adminUser, adminPasswd)
+	err = conn.Bind(ldap.Admin, ldap.Passwd)
 	if err != nil {
-		return nil, fmt.Errorf("fail to login Ldap server with [%s]", adminUser)
+		conn.Close()
+		return nil, fmt.Errorf("fail to login Ldap server with [%s]", ldap.Admin)
 	}
 
 	isAD, err := isMicrosoftAD(conn)
 	if err != nil {
-		return nil, fmt.Errorf("fail to get Ldap server type [%s]", adminUser)
+		conn.Close()
+		return nil, fmt.Errorf("fail to get Ldap server type [%s]", ldap.Admin)
 	}
 	return &ldapConn{Conn: conn, IsAD: isAD}, nil
 }
 
-//FIXME: The Base DN does not necessarily contain the Group
-//func (l *ldapConn) GetLdapGroups(baseDn string) (map[string]ldapGroup, error) {
-//	SearchFilter := "(objectClass=posixGroup)"
-//	SearchAttributes := []string{"cn", "gidNumber"}
-//	groupMap := make(map[string]ldapGroup)
-//
-//	searchReq := goldap.NewSearchRequest(baseDn,
-//		goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
-//		SearchFilter, SearchAttributes, nil)
-//	searchResult, err := l.Conn.Search(searchReq)
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	if len(searchResult.Entries) == 0 {
-//		return nil, errors.New("no result")
-//	}
-//
-//	for _, entry := range searchResult.Entries {
-//		var ldapGroupItem ldapGroup
-//		for _, attribute := range entry.Attributes {
-//			switch attribute.Name {
-//			case "gidNumber":
-//				ldapGroupItem.GidNumber = attribute.Values[0]
-//				break
-//			case "cn":
-//				ldapGroupItem.Cn = attribute.Values[0]
-//				break
-//			}
-//		}
-//		groupMap[ldapGroupItem.GidNumber] = ldapGroupItem
-//	}
-//
-//	return groupMap, nil
-//}
-
-func (l *ldapConn) GetLdapUsers(baseDn string) ([]ldapUser, error) {
-	SearchFilter := "(objectClass=posixAccount)"
-	SearchAttributes := []string{"uidNumber", "uid", "cn", "gidNumber", "entryUUID", "mail", "email",
-		"emailAddress", "telephoneNumber", "mobile", "mobileTelephoneNumber", "registeredAddress", "postalAddress"}
-	SearchFilterMsAD := "(objectClass=user)"
-	SearchAttributesMsAD := []string{"uidNumber", "sAMAccountName", "cn", "gidNumber", "entryUUID", "mail", "email",
-		"emailAddress", "telephoneNumber", "mobile", "mobileTelephoneNumber", "registeredAddress", "postalAddress"}
-	var searchReq *goldap.SearchRequest
+// GetLdapGroups returns the posixGroup, groupOfNames, groupOfUniqueNames and AD groups under the base DN
+func (l *ldapConn) GetLdapGroups(baseDn string) ([]ldapGroup, error) {
+	SearchFilter := "(|(objectClass=posixGroup)(objectClass=groupOfNames)(objectClass=groupOfUniqueNames))"
+	SearchFilterMsAD := "(objectClass=group)"
+	SearchAttributes := []string{"cn", "gidNumber", "member", "uniqueMember", "memberUid"}
+
+	filter := SearchFilter
 	if l.IsAD {
-		searchReq = goldap.NewSearchRequest(baseDn,
-			goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
-			SearchFilterMsAD, SearchAttributesMsAD, nil)
-	} else {
-		searchReq = goldap.NewSearchRequest(baseDn,
-			goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
-			SearchFilter, SearchAttributes, nil)
+		filter = SearchFilterMsAD
 	}
+	searchReq := goldap.NewSearchRequest(baseDn,
+		goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
+		filter, SearchAttributes, nil)
 	searchResult, err := l.Conn.SearchWithPaging(searchReq, 100)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(searchResult.Entries) == 0 {
+	var ldapGroups []ldapGroup
+	for _, entry := range searchResult.Entries {
+		ldapGroupItem := ldapGroup{Dn: entry.DN}
+		for _, attribute := range entry.Attributes {
+			switch attribute.Name {
+			case "cn":
+				ldapGroupItem.Cn = attribute.Values[0]
+			case "gidNumber":
+				ldapGroupItem.GidNumber = attribute.Values[0]
+			case "member":
+				ldapGroupItem.Members = append(ldapGroupItem.Members, attribute.Values...)
+			case "uniqueMember":
+				// the value of uniqueMember can end with an optional UID: "uid=alice,dc=example,dc=com#'0101'B"
+				for _, value := range attribute.Values {
+					ldapGroupItem.Members = append(ldapGroupItem.Members, strings.SplitN(value, "#", 2)[0])
+				}
+			case "memberUid":
+				ldapGroupItem.MemberUids = append(ldapGroupItem.MemberUids, attribute.Values...)
+			}
+		}
+		ldapGroups = append(ldapGroups, ldapGroupItem)
+	}
+
+	return ldapGroups, nil
+}
+
+func (l *ldapConn) GetLdapUsers(ldap *Ldap) ([]ldapUser, error) {
+	ldapUsers, err := l.searchLdapUsers(ldap, ldap.getUserFilter(l.IsAD), ldap.getSearchAttributes(l.IsAD))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ldapUsers) == 0 {
 		return nil, errors.New("no result")
 	}
+	return ldapUsers, nil
+}
 
-	var ldapUsers []ldapUser
+func (l *ldapConn) searchLdapUsers(ldap *Ldap, filter string, attributes []string) ([]ldapUser, error) {
+	searchReq := goldap.NewSearchRequest(ldap.BaseDn,
+		ldap.getSearchScope(), goldap.NeverDerefAliases, 0, 0, false,
+		filter, attributes, nil)
+	searchResult, err := l.Conn.SearchWithPaging(searchReq, ldap.getPageSize())
+	if err != nil {
+		return nil, err
+	}
+
+	ldapUsers := []ldapUser{}
 
 	for _, entry := range searchResult.Entries {
-		var ldapUserItem ldapUser
+		ldapUserItem := ldapUser{Dn: entry.DN}
 		for _, attribute := range entry.Attributes {
 			switch attribute.Name {
 			case "uidNumber":
@@ -251,7 +292,7 @@ func (l *ldapConn) GetLdapUsers(baseDn string) ([]ldapUser, error) {
 			case "entryUUID":
 				ldapUserItem.Uuid = attribute.Values[0]
 			case "objectGUID":
-				ldapUserItem.Uuid = attribute.Values[0]
+				ldapUserItem.Uuid = getLdapObjectGuid(attribute.ByteValues[0])
 			case "mail":
 				ldapUserItem.Mail = attribute.Values[0]
 			case "email":
@@ -268,8 +309,22 @@ func (l *ldapConn) GetLdapUsers(baseDn string) ([]ldapUser, error) {
 				ldapUserItem.RegisteredAddress = attribute.Values[0]
 			case "postalAddress":
 				ldapUserItem.PostalAddress = attribute.Values[0]
+			case "modifyTimestamp":
+				ldapUserItem.ModifyTimestamp = attribute.Values[0]
+			case "uSNChanged":
+				ldapUserItem.UsnChanged = attribute.Values[0]
+			case "userAccountControl":
+				// ACCOUNTDISABLE flag of AD
+				userAccountControl, _ := strconv.Atoi(attribute.Values[0])
+				ldapUserItem.IsDisabled = userAccountControl&0x2 != 0
 			}
 		}
+
+		ldapUserItem.Fields = ldap.getUserFields(entry.Attributes, l.IsAD)
+		if ldapUserItem.Fields["name"] != "" {
+			ldapUserItem.Uid = ldapUserItem.Fields["name"]
+		}
+		delete(ldapUserItem.Fields, "name")
 		ldapUsers = append(ldapUsers, ldapUserItem)
 	}
 
@@ -343,12 +398,22 @@ func GetLdap(id string) *Ldap {
 }
 
 func UpdateLdap(ldap *Ldap) bool {
-	if GetLdap(ldap.Id) == nil {
+	oldLdap := GetLdap(ldap.Id)
+	if oldLdap == nil {
 		return false
 	}
 
-	affected, err := adapter.Engine.ID(ldap.Id).Cols("owner", "server_name", "host",
-		"port", "admin", "passwd", "base_dn", "auto_sync").Update(ldap)
+	columns := []string{"owner", "server_name", "host", "port", "admin", "passwd", "base_dn", "filter", "search_scope",
+		"page_size", "attribute_mappings", "auto_sync", "deletion_policy", "tls_mode", "ca_cert", "client_cert", "tls_server_name",
+		"tls_verification"}
+	// the next auto sync reads all the users again when the entries or their mapping change
+	if ldap.Host != oldLdap.Host || ldap.BaseDn != oldLdap.BaseDn || ldap.Filter != oldLdap.Filter ||
+		ldap.SearchScope != oldLdap.SearchScope || !reflect.DeepEqual(ldap.AttributeMappings, oldLdap.AttributeMappings) {
+		ldap.SyncWatermark = ""
+		columns = append(columns, "sync_watermark")
+	}
+
+	affected, err := adapter.Engine.ID(ldap.Id).Cols(columns...).Update(ldap)
 	if err != nil {
 		panic(err)
 	}
@@ -405,7 +470,11 @@ func SyncLdapUsers(owner string, users []LdapRespUser, ldapId string) (*[]LdapRe
 				}
 			}
 		}
-		if !found && !AddUser(&User{
+		if found {
+			continue
+		}
+
+		newUser := &User{
 			Owner:       owner,
 			Name:        buildLdapUserName(user.Uid, user.UidNumber),
 			CreatedTime: util.GetCurrentTime(),
@@ -418,7 +487,9 @@ func SyncLdapUsers(owner string, users []LdapRespUser, ldapId string) (*[]LdapRe
 			Tag:         tag,
 			Score:       beego.AppConfig.DefaultInt("initScore", 2000),
 			Ldap:        user.Uuid,
-		}) {
+		}
+		setUserFields(newUser, user.Attributes)
+		if !AddUser(newUser) {
 			failedUsers = append(failedUsers, user)
 			continue
 		}
@@ -0,0 +1,171 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	LdapScopeSubtree  = "Subtree"
+	LdapScopeOneLevel = "OneLevel"
+
+	ldapDefaultPageSize = 100
+)
+
+// LdapAttributeMapping maps an LDAP attribute to a JSON field of User, like "email", or to a property: "properties.department".
+// The attribute mapped to "name" is the user name used to sign in
+type LdapAttributeMapping struct {
+	Attribute string `json:"attribute"`
+	Field     string `json:"field"`
+}
+
+// the fields that identify the user or grant permissions can't be filled by the directory
+var ldapReservedUserFields = []string{"owner", "name", "id", "createdTime", "updatedTime", "type", "password", "passwordSalt",
+	"ldap", "isAdmin", "isGlobalAdmin", "isForbidden", "isDeleted", "signupApplication", "hash", "preHash"}
+
+func getDefaultLdapAttributeMappings(isAD bool) []*LdapAttributeMapping {
+	nameAttribute := "uid"
+	if isAD {
+		nameAttribute = "sAMAccountName"
+	}
+
+	return []*LdapAttributeMapping{
+		{Attribute: nameAttribute, Field: "name"},
+		{Attribute: "cn", Field: "displayName"},
+		{Attribute: "email", Field: "email"},
+		{Attribute: "emailAddress", Field: "email"},
+		{Attribute: "mail", Field: "email"},
+		{Attribute: "mobile", Field: "phone"},
+		{Attribute: "mobileTelephoneNumber", Field: "phone"},
+		{Attribute: "telephoneNumber", Field: "phone"},
+		{Attribute: "postalAddress", Field: "address"},
+		{Attribute: "registeredAddress", Field: "address"},
+	}
+}
+
+func (ldap *Ldap) getAttributeMappings(isAD bool) []*LdapAttributeMapping {
+	if len(ldap.AttributeMappings) == 0 {
+		return getDefaultLdapAttributeMappings(isAD)
+	}
+	return ldap.AttributeMappings
+}
+
+func (ldap *Ldap) getNameAttribute(isAD bool) string {
+	for _, mapping := range ldap.getAttributeMappings(isAD) {
+		if mapping.Field == "name" {
+			return mapping.Attribute
+		}
+	}
+	return getDefaultLdapAttributeMappings(isAD)[0].Attribute
+}
+
+func (ldap *Ldap) getUserFilter(isAD bool) string {
+	if ldap.Filter != "" {
+		return ldap.Filter
+	}
+	if isAD {
+		return "(objectClass=user)"
+	}
+	return "(objectClass=posixAccount)"
+}
+
+func (ldap *Ldap) getSearchScope() int {
+	if ldap.SearchScope == LdapScopeOneLevel {
+		return goldap.ScopeSingleLevel
+	}
+	return goldap.ScopeWholeSubtree
+}
+
+func (ldap *Ldap) getPageSize() uint32 {
+	if ldap.PageSize <= 0 {
+		return ldapDefaultPageSize
+	}
+	return uint32(ldap.PageSize)
+}
+
+// getSearchAttributes returns the attributes read by the sync: the fixed ones and the mapped ones
+func (ldap *Ldap) getSearchAttributes(isAD bool) []string {
+	attributes := []string{"uidNumber", "uid", "sAMAccountName", "cn", "gidNumber", "entryUUID", "objectGUID", "mail", "email",
+		"emailAddress", "telephoneNumber", "mobile", "mobileTelephoneNumber", "registeredAddress", "postalAddress",
+		"modifyTimestamp", "uSNChanged", "userAccountControl"}
+	for _, mapping := range ldap.getAttributeMappings(isAD) {
+		if !util.InSlice(attributes, mapping.Attribute) {
+			attributes = append(attributes, mapping.Attribute)
+		}
+	}
+	return attributes
+}
+
+// getListAttributes returns the attributes needed to list the users of the directory and their groups
+func (ldap *Ldap) getListAttributes(isAD bool) []string {
+	attributes := []string{"uid", "gidNumber", "entryUUID", "objectGUID"}
+	if nameAttribute := ldap.getNameAttribute(isAD); !util.InSlice(attributes, nameAttribute) {
+		attributes = append(attributes, nameAttribute)
+	}
+	return attributes
+}
+
+// getUserFields maps the attributes of an LDAP entry to the User fields, the first non-empty attribute of a field wins
+func (ldap *Ldap) getUserFields(attributes []*goldap.EntryAttribute, isAD bool) map[string]string {
+	values := map[string]string{}
+	for _, attribute := range attributes {
+		if len(attribute.Values) != 0 {
+			values[strings.ToLower(attribute.Name)] = attribute.Values[0]
+		}
+	}
+
+	fields := map[string]string{}
+	for _, mapping := range ldap.getAttributeMappings(isAD) {
+		value := values[strings.ToLower(mapping.Attribute)]
+		if value != "" && fields[mapping.Field] == "" {
+			fields[mapping.Field] = value
+		}
+	}
+	return fields
+}
+
+// setUserFields fills the user with the mapped values, only the string fields, "address" and the properties can be set
+func setUserFields(user *User, fields map[string]string) {
+	userValue := reflect.ValueOf(user).Elem()
+	for field, value := range fields {
+		if strings.HasPrefix(field, "properties.") {
+			if user.Properties == nil {
+				user.Properties = map[string]string{}
+			}
+			user.Properties[strings.TrimPrefix(field, "properties.")] = value
+			continue
+		}
+		if field == "address" {
+			user.Address = []string{value}
+			continue
+		}
+		if util.InSlice(ldapReservedUserFields, field) {
+			continue
+		}
+
+		for i := 0; i < userValue.NumField(); i++ {
+			structField := userValue.Type().Field(i)
+			if strings.Split(structField.Tag.Get("json"), ",")[0] == field && structField.Type.Kind() == reflect.String {
+				userValue.Field(i).SetString(value)
+				break
+			}
+		}
+	}
+}
@@ -73,27 +73,33 @@ func (l *LdapAutoSynchronizer) syncRoutine(ldap *Ldap, stopChan chan struct{}) {
 		case <-ticker.C:
 		}
 
-		UpdateLdapSyncTime(ldap.Id)
-		//fetch all users
-		conn, err := GetLdapConn(ldap.Host, ldap.Port, ldap.Admin, ldap.Passwd)
-		if err != nil {
-			logs.Warning(fmt.Sprintf("autoSync failed for %s, error %s", ldap.Id, err))
+		// the watermark and the settings can have changed since the last run,
+		// the routine keeps waiting for the stop signal if the server was deleted
+		currentLdap := GetLdap(ldap.Id)
+		if currentLdap == nil {
 			continue
 		}
+		ldap = currentLdap
 
-		users, err := conn.GetLdapUsers(ldap.BaseDn)
-		if err != nil {
-			logs.Warning(fmt.Sprintf("autoSync failed for %s, error %s", ldap.Id, err))
-			continue
-		}
-		existed, failed := SyncLdapUsers(ldap.Owner, LdapUsersToLdapRespUsers(users), ldap.Id)
-		if len(*failed) != 0 {
-			logs.Warning(fmt.Sprintf("ldap autosync,%d new users,but %d user failed during :", len(users)-len(*existed)-len(*failed), len(*failed)), *failed)
+		summary, watermark := syncLdapOnce(ldap)
+		updateLdapSyncResult(ldap, watermark, summary)
+		if summary.Error != "" {
+			logs.Warning(fmt.Sprintf("autoSync failed for %s, error %s", ldap.Id, summary.Error))
 		} else {
-			logs.Info(fmt.Sprintf("ldap autosync success, %d new users, %d existing users", len(users)-len(*existed), len(*existed)))
+			logs.Info(fmt.Sprintf("ldap autosync success for %s, %d changed, %d new, %d updated, %d disabled, %d deleted, %d failed users",
+				ldap.Id, summary.Changed, summary.Added, summary.Updated, summary.Disabled, summary.Deleted, summary.Failed))
 		}
 	}
+}
+
+func syncLdapOnce(ldap *Ldap) (*LdapSyncSummary, string) {
+	conn, err := GetLdapConn(ldap)
+	if err != nil {
+		return &LdapSyncSummary{StartTime: util.GetCurrentTime(), Error: err.Error()}, ldap.SyncWatermark
+	}
+	defer conn.Conn.Close()
 
+	return conn.syncLdap(ldap)
 }
 
 //start all autosync goroutine for existing ldap servers in each organizations
@@ -0,0 +1,247 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// getLdapDnKey returns the DN in a canonical form, so that the member DNs can be compared with the entry DNs
+func getLdapDnKey(dn string) string {
+	parsedDn, err := goldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(dn)
+	}
+
+	rdns := []string{}
+	for _, rdn := range parsedDn.RDNs {
+		for _, attribute := range rdn.Attributes {
+			rdns = append(rdns, fmt.Sprintf("%s=%s", strings.ToLower(attribute.Type), strings.ToLower(attribute.Value)))
+		}
+	}
+	return strings.Join(rdns, ",")
+}
+
+// getLdapRoleMembers returns the Casdoor user IDs of every group by the key of its DN, the members of the nested groups
+// are included. userIdByUuid maps the UUIDs of the synced LDAP users to their Casdoor user IDs
+func getLdapRoleMembers(users []ldapUser, groups []ldapGroup, userIdByUuid map[string]string) map[string][]string {
+	uuidByDn := map[string]string{}
+	uuidByUid := map[string]string{}
+	uuidsByGid := map[string][]string{}
+	for _, user := range users {
+		if user.Dn != "" {
+			uuidByDn[getLdapDnKey(user.Dn)] = user.Uuid
+		}
+		uuidByUid[user.Uid] = user.Uuid
+		if user.GidNumber != "" {
+			uuidsByGid[user.GidNumber] = append(uuidsByGid[user.GidNumber], user.Uuid)
+		}
+	}
+
+	groupByDn := map[string]*ldapGroup{}
+	for i := range groups {
+		groupByDn[getLdapDnKey(groups[i].Dn)] = &groups[i]
+	}
+
+	var collect func(group *ldapGroup, visited map[string]bool, uuids map[string]bool)
+	collect = func(group *ldapGroup, visited map[string]bool, uuids map[string]bool) {
+		key := getLdapDnKey(group.Dn)
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+
+		for _, member := range group.Members {
+			memberKey := getLdapDnKey(member)
+			if uuid, ok := uuidByDn[memberKey]; ok {
+				uuids[uuid] = true
+			} else if subGroup, ok := groupByDn[memberKey]; ok {
+				collect(subGroup, visited, uuids)
+			}
+		}
+		for _, memberUid := range group.MemberUids {
+			if uuid, ok := uuidByUid[memberUid]; ok {
+				uuids[uuid] = true
+			}
+		}
+		// the users of a posixGroup also include the users whose primary group is it
+		if group.GidNumber != "" {
+			for _, uuid := range uuidsByGid[group.GidNumber] {
+				uuids[uuid] = true
+			}
+		}
+	}
+
+	roleMembers := map[string][]string{}
+	for i := range groups {
+		group := &groups[i]
+		if group.Cn == "" {
+			continue
+		}
+
+		uuids := map[string]bool{}
+		collect(group, map[string]bool{}, uuids)
+
+		members := []string{}
+		for uuid := range uuids {
+			if userId, ok := userIdByUuid[uuid]; ok {
+				members = append(members, userId)
+			}
+		}
+		sort.Strings(members)
+		roleMembers[getLdapDnKey(group.Dn)] = members
+	}
+	return roleMembers
+}
+
+func getLdapUserIdsByUuid(owner string) map[string]string {
+	userIdByUuid := map[string]string{}
+	for uuid, user := range getLdapUsersByUuid(owner) {
+		userIdByUuid[uuid] = user.GetId()
+	}
+	return userIdByUuid
+}
+
+func getLdapRoles(ldap *Ldap) []*Role {
+	roles := []*Role{}
+	err := adapter.Engine.Find(&roles, &Role{Owner: ldap.Owner, Ldap: ldap.Id})
+	if err != nil {
+		panic(err)
+	}
+
+	return roles
+}
+
+// getLdapRoleUsers replaces the LDAP users of the role with the members, the users added to the role by hand are kept
+func getLdapRoleUsers(role *Role, members []string, ldapUserIds map[string]bool) []string {
+	roleUsers := []string{}
+	for _, userId := range role.Users {
+		if !ldapUserIds[userId] && !util.InSlice(members, userId) {
+			roleUsers = append(roleUsers, userId)
+		}
+	}
+	return append(roleUsers, members...)
+}
+
+// syncLdapGroups creates a role named after the cn of every LDAP group and keeps its LDAP users in step with the group members.
+// The roles are matched by the DN of their group, a role that isn't synced from the LDAP server is never taken over,
+// and the role of a group that has disappeared loses its LDAP users and is disabled.
+func (l *ldapConn) syncLdapGroups(ldap *Ldap, users []ldapUser) error {
+	groups, err := l.GetLdapGroups(ldap.BaseDn)
+	if err != nil {
+		return err
+	}
+
+	userIdByUuid := getLdapUserIdsByUuid(ldap.Owner)
+	ldapUserIds := map[string]bool{}
+	for _, user := range users {
+		if userId, ok := userIdByUuid[user.Uuid]; ok {
+			ldapUserIds[userId] = true
+		}
+	}
+
+	roleByDn := map[string]*Role{}
+	for _, role := range getLdapRoles(ldap) {
+		roleByDn[getLdapDnKey(role.LdapDn)] = role
+	}
+
+	groupByDn := map[string]*ldapGroup{}
+	for i := range groups {
+		groupByDn[getLdapDnKey(groups[i].Dn)] = &groups[i]
+	}
+
+	roleMembers := getLdapRoleMembers(users, groups, userIdByUuid)
+	dns := []string{}
+	for dn := range roleMembers {
+		dns = append(dns, dn)
+	}
+	sort.Strings(dns)
+
+	errs := []string{}
+	for _, dn := range dns {
+		group := groupByDn[dn]
+		members := roleMembers[dn]
+
+		role, ok := roleByDn[dn]
+		if !ok {
+			if existingRole := getRole(ldap.Owner, group.Cn); existingRole != nil {
+				errs = append(errs, fmt.Sprintf("the LDAP group: %s is not synced, the role: %s already exists", group.Dn, existingRole.GetId()))
+				continue
+			}
+
+			AddRole(&Role{
+				Owner:       ldap.Owner,
+				Name:        group.Cn,
+				CreatedTime: util.GetCurrentTime(),
+				DisplayName: group.Cn,
+				Users:       members,
+				Roles:       []string{},
+				IsEnabled:   true,
+				Ldap:        ldap.Id,
+				LdapDn:      group.Dn,
+			})
+			continue
+		}
+
+		roleUsers := getLdapRoleUsers(role, members, ldapUserIds)
+		if strings.Join(roleUsers, ",") != strings.Join(role.Users, ",") {
+			role.Users = roleUsers
+			UpdateRole(role.GetId(), role)
+		}
+	}
+
+	for dn, role := range roleByDn {
+		if _, ok := groupByDn[dn]; ok {
+			continue
+		}
+
+		roleUsers := getLdapRoleUsers(role, []string{}, ldapUserIds)
+		if role.IsEnabled || strings.Join(roleUsers, ",") != strings.Join(role.Users, ",") {
+			role.Users = roleUsers
+			role.IsEnabled = false
+			UpdateRole(role.GetId(), role)
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// SyncLdapGroups keeps the roles of the organization in step with the groups of the LDAP server
+func SyncLdapGroups(ldapId string) error {
+	ldap := GetLdap(ldapId)
+	if ldap == nil {
+		return fmt.Errorf("the LDAP server: %s doesn't exist", ldapId)
+	}
+
+	conn, err := GetLdapConn(ldap)
+	if err != nil {
+		return err
+	}
+	defer conn.Conn.Close()
+
+	users, err := conn.GetLdapUsers(ldap)
+	if err != nil {
+		return err
+	}
+	return conn.syncLdapGroups(ldap, users)
+}
@@ -0,0 +1,297 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	// LdapDeletionPolicyKeep leaves the users removed from the directory untouched
+	LdapDeletionPolicyKeep = "Keep"
+	// LdapDeletionPolicyDisable forbids the users removed or disabled in the directory to sign in
+	LdapDeletionPolicyDisable = "Disable"
+	// LdapDeletionPolicyDelete soft-deletes the users removed or disabled in the directory
+	LdapDeletionPolicyDelete = "Delete"
+
+	ldapSyncSummaryLimit = 10
+)
+
+// LdapSyncSummary is the result of one auto sync run, the latest runs are saved on the Ldap
+type LdapSyncSummary struct {
+	StartTime     string `json:"startTime"`
+	EndTime       string `json:"endTime"`
+	IsIncremental bool   `json:"isIncremental"`
+
+	Changed  int `json:"changed"`
+	Added    int `json:"added"`
+	Updated  int `json:"updated"`
+	Disabled int `json:"disabled"`
+	Deleted  int `json:"deleted"`
+	Failed   int `json:"failed"`
+
+	Error string `json:"error"`
+}
+
+// getLdapObjectGuid formats the binary objectGUID of AD like AD itself does, the first three groups are little-endian
+func getLdapObjectGuid(raw []byte) string {
+	if len(raw) != 16 {
+		return fmt.Sprintf("%x", raw)
+	}
+
+	return fmt.Sprintf("%x%x%x%x-%x%x-%x%x-%x-%x",
+		raw[3:4], raw[2:3], raw[1:2], raw[0:1], raw[5:6], raw[4:5], raw[7:8], raw[6:7], raw[8:10], raw[10:16])
+}
+
+// getLdapWatermarkFilter returns the filter of the entries changed since the watermark of the last run
+func getLdapWatermarkFilter(watermark string, isAD bool) string {
+	if isAD {
+		usn, err := strconv.ParseInt(watermark, 10, 64)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("(uSNChanged>=%d)", usn+1)
+	}
+	return fmt.Sprintf("(modifyTimestamp>=%s)", watermark)
+}
+
+// getLdapWatermark returns the highest uSNChanged (AD) or modifyTimestamp of the users, or the given watermark if none is higher
+func getLdapWatermark(users []ldapUser, watermark string, isAD bool) string {
+	for _, user := range users {
+		if isAD {
+			usn, err := strconv.ParseInt(user.UsnChanged, 10, 64)
+			if err != nil {
+				continue
+			}
+			if current, err := strconv.ParseInt(watermark, 10, 64); err != nil || usn > current {
+				watermark = user.UsnChanged
+			}
+		} else if user.ModifyTimestamp > watermark {
+			// the generalized time of the same server always has the same format, so it can be compared as a string
+			watermark = user.ModifyTimestamp
+		}
+	}
+	return watermark
+}
+
+func (ldap *Ldap) getDeletionPolicy() string {
+	if ldap.DeletionPolicy == "" {
+		return LdapDeletionPolicyKeep
+	}
+	return ldap.DeletionPolicy
+}
+
+func getLdapUsersByUuid(owner string) map[string]*User {
+	users := []*User{}
+	err := adapter.Engine.Where("owner = ? and ldap != ?", owner, "").Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	userByUuid := map[string]*User{}
+	for _, user := range users {
+		userByUuid[user.Ldap] = user
+	}
+	return userByUuid
+}
+
+// updateUserByLdapFields refreshes the user with the mapped values of the directory and saves it if anything changed
+func updateUserByLdapFields(user *User, fields map[string]string) bool {
+	oldUser, err := json.Marshal(user)
+	if err != nil {
+		panic(err)
+	}
+
+	setUserFields(user, fields)
+
+	newUser, err := json.Marshal(user)
+	if err != nil {
+		panic(err)
+	}
+	if string(oldUser) == string(newUser) {
+		return false
+	}
+	return UpdateUserForAllFields(user.GetId(), user)
+}
+
+// applyLdapDeletionPolicy disables or soft-deletes the user according to the policy,
+// it returns false if the user was already in that state
+func applyLdapDeletionPolicy(user *User, policy string) bool {
+	switch policy {
+	case LdapDeletionPolicyDisable:
+		if user.IsForbidden {
+			return false
+		}
+		user.IsForbidden = true
+	case LdapDeletionPolicyDelete:
+		if user.IsDeleted {
+			return false
+		}
+		user.IsDeleted = true
+	default:
+		return false
+	}
+	return UpdateUserForAllFields(user.GetId(), user)
+}
+
+func (summary *LdapSyncSummary) countDeletion(policy string) {
+	if policy == LdapDeletionPolicyDelete {
+		summary.Deleted++
+	} else {
+		summary.Disabled++
+	}
+}
+
+// listLdapUuids returns the UUIDs of all the users of an LDAP server
+func listLdapUuids(ldap *Ldap) (map[string]bool, error) {
+	conn, err := GetLdapConn(ldap)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Conn.Close()
+
+	users, err := conn.searchLdapUsers(ldap, ldap.getUserFilter(conn.IsAD), ldap.getListAttributes(conn.IsAD))
+	if err != nil {
+		return nil, err
+	}
+
+	uuids := map[string]bool{}
+	for _, user := range users {
+		uuids[user.Uuid] = true
+	}
+	return uuids, nil
+}
+
+// syncLdap reads the users changed since the watermark, adds the new ones and updates the existing ones,
+// then applies the deletion policy to the users that are no longer in any LDAP server of the organization.
+// It returns the summary of the run and the new watermark
+func (l *ldapConn) syncLdap(ldap *Ldap) (*LdapSyncSummary, string) {
+	summary := &LdapSyncSummary{StartTime: util.GetCurrentTime()}
+	policy := ldap.getDeletionPolicy()
+
+	filter := ldap.getUserFilter(l.IsAD)
+	if ldap.SyncWatermark != "" {
+		if watermarkFilter := getLdapWatermarkFilter(ldap.SyncWatermark, l.IsAD); watermarkFilter != "" {
+			filter = fmt.Sprintf("(&%s%s)", filter, watermarkFilter)
+			summary.IsIncremental = true
+		}
+	}
+
+	changedUsers, err := l.searchLdapUsers(ldap, filter, ldap.getSearchAttributes(l.IsAD))
+	if err != nil {
+		summary.Error = err.Error()
+		return summary, ldap.SyncWatermark
+	}
+	summary.Changed = len(changedUsers)
+
+	userByUuid := getLdapUsersByUuid(ldap.Owner)
+	newUsers := []ldapUser{}
+	for _, changedUser := range changedUsers {
+		user, ok := userByUuid[changedUser.Uuid]
+		if !ok {
+			if !changedUser.IsDisabled || policy == LdapDeletionPolicyKeep {
+				newUsers = append(newUsers, changedUser)
+			}
+			continue
+		}
+
+		if updateUserByLdapFields(user, changedUser.Fields) {
+			summary.Updated++
+		}
+		if changedUser.IsDisabled && applyLdapDeletionPolicy(user, policy) {
+			summary.countDeletion(policy)
+		}
+	}
+
+	if len(newUsers) != 0 {
+		_, failed := SyncLdapUsers(ldap.Owner, LdapUsersToLdapRespUsers(newUsers), ldap.Id)
+		summary.Failed = len(*failed)
+		summary.Added = len(newUsers) - summary.Failed
+	}
+
+	// a user that failed to be added would fall behind a new watermark and never be read again, so the old one is kept
+	watermark := ldap.SyncWatermark
+	if summary.Failed == 0 {
+		watermark = getLdapWatermark(changedUsers, ldap.SyncWatermark, l.IsAD)
+	}
+
+	// the removed entries are not returned by the incremental search, so the whole directory is listed with few attributes
+	allUsers, err := l.searchLdapUsers(ldap, ldap.getUserFilter(l.IsAD), ldap.getListAttributes(l.IsAD))
+	if err != nil {
+		summary.Error = err.Error()
+		return summary, watermark
+	}
+
+	// an empty listing is more likely a wrong filter than an empty directory, nobody is removed then
+	if policy != LdapDeletionPolicyKeep && len(allUsers) != 0 {
+		uuids := map[string]bool{}
+		for _, user := range allUsers {
+			uuids[user.Uuid] = true
+		}
+
+		// the users of the organization can come from several LDAP servers
+		for _, otherLdap := range GetLdaps(ldap.Owner) {
+			if otherLdap.Id == ldap.Id {
+				continue
+			}
+
+			otherUuids, err := listLdapUuids(otherLdap)
+			if err != nil {
+				summary.Error = fmt.Sprintf("failed to list the users of LDAP server: %s, no user is removed: %s", otherLdap.Id, err.Error())
+				uuids = nil
+				break
+			}
+			for uuid := range otherUuids {
+				uuids[uuid] = true
+			}
+		}
+
+		if uuids != nil {
+			for uuid, user := range getLdapUsersByUuid(ldap.Owner) {
+				if !uuids[uuid] && applyLdapDeletionPolicy(user, policy) {
+					summary.countDeletion(policy)
+				}
+			}
+		}
+	}
+
+	err = l.syncLdapGroups(ldap, allUsers)
+	if err != nil && summary.Error == "" {
+		summary.Error = err.Error()
+	}
+	return summary, watermark
+}
+
+// updateLdapSyncResult saves the watermark and the summary of a run, only the latest summaries are kept
+func updateLdapSyncResult(ldap *Ldap, watermark string, summary *LdapSyncSummary) {
+	summary.EndTime = util.GetCurrentTime()
+
+	summaries := append([]*LdapSyncSummary{summary}, ldap.SyncSummaries...)
+	if len(summaries) > ldapSyncSummaryLimit {
+		summaries = summaries[:ldapSyncSummaryLimit]
+	}
+
+	ldap.LastSync = summary.StartTime
+	ldap.SyncWatermark = watermark
+	ldap.SyncSummaries = summaries
+	_, err := adapter.Engine.ID(ldap.Id).Cols("last_sync", "sync_watermark", "sync_summaries").Update(ldap)
+	if err != nil {
+		panic(err)
+	}
+}
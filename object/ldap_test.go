@@ -0,0 +1,106 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLdapRoleMembers(t *testing.T) {
+	users := []ldapUser{
+		{Dn: "uid=alice,ou=people,dc=example,dc=com", Uid: "alice", Uuid: "uuid-alice"},
+		{Dn: "uid=bob,ou=people,dc=example,dc=com", Uid: "bob", Uuid: "uuid-bob", GidNumber: "500"},
+		{Dn: "CN=Carol,OU=People,DC=example,DC=com", Uid: "carol", Uuid: "uuid-carol"},
+	}
+	groups := []ldapGroup{
+		{Dn: "cn=admins,ou=groups,dc=example,dc=com", Cn: "admins", Members: []string{"uid=alice, ou=people, dc=example, dc=com", "cn=ops,ou=groups,dc=example,dc=com"}},
+		{Dn: "cn=ops,ou=groups,dc=example,dc=com", Cn: "ops", Members: []string{"cn=carol,ou=people,dc=example,dc=com", "cn=admins,ou=groups,dc=example,dc=com"}},
+		{Dn: "cn=staff,ou=groups,dc=example,dc=com", Cn: "staff", GidNumber: "500", MemberUids: []string{"alice", "unknown"}},
+	}
+	userIdByUuid := map[string]string{
+		"uuid-alice": "example/alice",
+		"uuid-bob":   "example/bob",
+		"uuid-carol": "example/carol",
+	}
+
+	roleMembers := getLdapRoleMembers(users, groups, userIdByUuid)
+	assert.Equal(t, []string{"example/alice", "example/carol"}, roleMembers["cn=admins,ou=groups,dc=example,dc=com"])
+	assert.Equal(t, []string{"example/alice", "example/carol"}, roleMembers["cn=ops,ou=groups,dc=example,dc=com"])
+	assert.Equal(t, []string{"example/alice", "example/bob"}, roleMembers["cn=staff,ou=groups,dc=example,dc=com"])
+}
+
+func TestGetLdapRoleUsers(t *testing.T) {
+	role := &Role{Users: []string{"example/alice", "example/manual", "example/bob"}}
+	ldapUserIds := map[string]bool{"example/alice": true, "example/bob": true, "example/carol": true}
+
+	assert.Equal(t, []string{"example/manual", "example/bob", "example/carol"}, getLdapRoleUsers(role, []string{"example/bob", "example/carol"}, ldapUserIds))
+	assert.Equal(t, []string{"example/manual"}, getLdapRoleUsers(role, []string{}, ldapUserIds))
+}
+
+func TestLdapAttributeMappings(t *testing.T) {
+	attributes := []*goldap.EntryAttribute{
+		goldap.NewEntryAttribute("sAMAccountName", []string{"alice"}),
+		goldap.NewEntryAttribute("displayName", []string{"Alice Liddell"}),
+		goldap.NewEntryAttribute("mail", []string{""}),
+		goldap.NewEntryAttribute("userPrincipalName", []string{"alice@example.com"}),
+		goldap.NewEntryAttribute("department", []string{"R&D"}),
+	}
+
+	ldap := &Ldap{}
+	fields := ldap.getUserFields(attributes, true)
+	assert.Equal(t, "alice", fields["name"])
+	assert.Equal(t, "sAMAccountName", ldap.getNameAttribute(true))
+
+	ldap.AttributeMappings = []*LdapAttributeMapping{
+		{Attribute: "sAMAccountName", Field: "name"},
+		{Attribute: "displayName", Field: "displayName"},
+		{Attribute: "mail", Field: "email"},
+		{Attribute: "userPrincipalName", Field: "email"},
+		{Attribute: "department", Field: "properties.department"},
+		{Attribute: "department", Field: "isAdmin"},
+		{Attribute: "department", Field: "password"},
+	}
+	fields = ldap.getUserFields(attributes, true)
+	assert.Equal(t, "alice@example.com", fields["email"])
+
+	user := &User{Owner: "example", Name: "alice", Password: "secret"}
+	setUserFields(user, fields)
+	assert.Equal(t, "alice", user.Name)
+	assert.Equal(t, "Alice Liddell", user.DisplayName)
+	assert.Equal(t, "alice@example.com", user.Email)
+	assert.Equal(t, "R&D", user.Properties["department"])
+	assert.Equal(t, "secret", user.Password)
+	assert.False(t, user.IsAdmin)
+}
+
+func TestLdapSyncWatermark(t *testing.T) {
+	raw := []byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	assert.Equal(t, "00112233-4455-6677-8899-aabbccddeeff", getLdapObjectGuid(raw))
+
+	users := []ldapUser{
+		{ModifyTimestamp: "20220301101500Z", UsnChanged: "12850"},
+		{ModifyTimestamp: "20220302081000Z", UsnChanged: "9999"},
+		{ModifyTimestamp: "20220215000000Z", UsnChanged: "12851"},
+	}
+	assert.Equal(t, "20220302081000Z", getLdapWatermark(users, "", false))
+	assert.Equal(t, "20220401000000Z", getLdapWatermark(users, "20220401000000Z", false))
+	assert.Equal(t, "12851", getLdapWatermark(users, "10000", true))
+	assert.Equal(t, "(modifyTimestamp>=20220302081000Z)", getLdapWatermarkFilter("20220302081000Z", false))
+	assert.Equal(t, "(uSNChanged>=12852)", getLdapWatermarkFilter("12851", true))
+	assert.Equal(t, "", getLdapWatermarkFilter("invalid", true))
+}
@@ -0,0 +1,155 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	LdapTlsModeNone     = "None"
+	LdapTlsModeLdaps    = "LDAPS"
+	LdapTlsModeStartTls = "StartTLS"
+
+	// LdapTlsVerificationFull verifies the certificate chain and the hostname
+	LdapTlsVerificationFull = "Full"
+	// LdapTlsVerificationCaOnly verifies the certificate chain but not the hostname, for servers reached by an IP or an alias
+	LdapTlsVerificationCaOnly = "CaOnly"
+	// LdapTlsVerificationNone accepts any certificate, the connection is encrypted but not authenticated
+	LdapTlsVerificationNone = "None"
+)
+
+func (ldap *Ldap) getTlsMode() string {
+	if ldap.TlsMode == "" {
+		return LdapTlsModeNone
+	}
+	return ldap.TlsMode
+}
+
+// getTlsConfig returns the TLS config of the connection to the LDAP server, or nil if the connection is in plain text.
+// CaCert and ClientCert are the names of the certs of "admin", like the cert of an application
+func (ldap *Ldap) getTlsConfig() (*tls.Config, error) {
+	if ldap.getTlsMode() == LdapTlsModeNone {
+		return nil, nil
+	}
+
+	var caCert, clientCert *Cert
+	if ldap.CaCert != "" {
+		caCert = getCert("admin", ldap.CaCert)
+		if caCert == nil {
+			return nil, fmt.Errorf("the CA cert: %s of the LDAP server doesn't exist", ldap.CaCert)
+		}
+	}
+	if ldap.ClientCert != "" {
+		clientCert = getCert("admin", ldap.ClientCert)
+		if clientCert == nil {
+			return nil, fmt.Errorf("the client cert: %s of the LDAP server doesn't exist", ldap.ClientCert)
+		}
+	}
+	return newLdapTlsConfig(ldap, caCert, clientCert)
+}
+
+func newLdapTlsConfig(ldap *Ldap, caCert *Cert, clientCert *Cert) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: ldap.Host,
+		MinVersion: tls.VersionTLS12,
+	}
+	if ldap.TlsServerName != "" {
+		config.ServerName = ldap.TlsServerName
+	}
+
+	// without a CA cert, the server certificate is verified with the system roots
+	if caCert != nil {
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM([]byte(caCert.PublicKey)) {
+			return nil, fmt.Errorf("the CA cert: %s of the LDAP server has no valid certificate", caCert.Name)
+		}
+	}
+
+	if clientCert != nil {
+		keyPair, err := tls.X509KeyPair([]byte(clientCert.PublicKey), []byte(clientCert.PrivateKey))
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{keyPair}
+	}
+
+	switch ldap.TlsVerification {
+	case "", LdapTlsVerificationFull:
+	case LdapTlsVerificationCaOnly:
+		// the built-in verification always checks the hostname, so it is replaced by a verification of the chain only
+		roots := config.RootCAs
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyLdapCertificateChain(rawCerts, roots)
+		}
+	case LdapTlsVerificationNone:
+		config.InsecureSkipVerify = true
+	default:
+		return nil, fmt.Errorf("unknown TLS verification: %s", ldap.TlsVerification)
+	}
+	return config, nil
+}
+
+func verifyLdapCertificateChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("the LDAP server has no certificate")
+	}
+
+	certs := []*x509.Certificate{}
+	for _, rawCert := range rawCerts {
+		cert, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+	return err
+}
+
+// dialLdap connects to the LDAP server in plain text, over LDAPS or upgrades the connection with StartTLS
+func dialLdap(ldap *Ldap, tlsConfig *tls.Config) (*goldap.Conn, error) {
+	address := fmt.Sprintf("%s:%d", ldap.Host, ldap.Port)
+	switch ldap.getTlsMode() {
+	case LdapTlsModeNone:
+		return goldap.Dial("tcp", address)
+	case LdapTlsModeLdaps:
+		return goldap.DialTLS("tcp", address, tlsConfig)
+	case LdapTlsModeStartTls:
+		conn, err := goldap.Dial("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+
+		err = conn.StartTLS(tlsConfig)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	default:
+		return nil, fmt.Errorf("unknown TLS mode: %s", ldap.TlsMode)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestLdapCert(t *testing.T) *Cert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldap.example.com"},
+		DNSNames:              []string{"ldap.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	return &Cert{
+		Name:       "cert-ldap",
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})),
+	}
+}
+
+// startTestLdapServer accepts one connection, answers the StartTLS request if needed and completes the TLS handshake
+func startTestLdapServer(t *testing.T, cert *Cert, isStartTls bool) (int, chan error) {
+	keyPair, err := tls.X509KeyPair([]byte(cert.PublicKey), []byte(cert.PrivateKey))
+	assert.Nil(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	result := make(chan error, 1)
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			result <- err
+			return
+		}
+		defer conn.Close()
+
+		if isStartTls {
+			request, err := ber.ReadPacket(conn)
+			if err != nil {
+				result <- err
+				return
+			}
+
+			response := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+			response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, request.Children[0].Value, "Message ID"))
+			extendedResponse := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationExtendedResponse, nil, "Extended Response")
+			extendedResponse.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(goldap.LDAPResultSuccess), "Result Code"))
+			extendedResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+			extendedResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+			response.AppendChild(extendedResponse)
+			_, err = conn.Write(response.Bytes())
+			if err != nil {
+				result <- err
+				return
+			}
+		}
+
+		tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{keyPair}})
+		result <- tlsConn.Handshake()
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, result
+}
+
+func TestLdapTlsConnection(t *testing.T) {
+	cert := getTestLdapCert(t)
+
+	for _, tlsMode := range []string{LdapTlsModeLdaps, LdapTlsModeStartTls} {
+		port, result := startTestLdapServer(t, cert, tlsMode == LdapTlsModeStartTls)
+		ldap := &Ldap{Host: "127.0.0.1", Port: port, TlsMode: tlsMode, TlsServerName: "ldap.example.com"}
+		tlsConfig, err := newLdapTlsConfig(ldap, cert, nil)
+		assert.Nil(t, err)
+
+		conn, err := dialLdap(ldap, tlsConfig)
+		assert.Nil(t, err, tlsMode)
+		assert.Nil(t, <-result, tlsMode)
+		if conn != nil {
+			_, isTls := conn.TLSConnectionState()
+			assert.True(t, isTls, tlsMode)
+			conn.Close()
+		}
+	}
+
+	// the certificate is not issued for the IP
+	port, result := startTestLdapServer(t, cert, false)
+	ldap := &Ldap{Host: "127.0.0.1", Port: port, TlsMode: LdapTlsModeLdaps}
+	tlsConfig, err := newLdapTlsConfig(ldap, cert, nil)
+	assert.Nil(t, err)
+	_, err = dialLdap(ldap, tlsConfig)
+	assert.NotNil(t, err)
+	<-result
+
+	port, result = startTestLdapServer(t, cert, false)
+	ldap = &Ldap{Host: "127.0.0.1", Port: port, TlsMode: LdapTlsModeLdaps, TlsVerification: LdapTlsVerificationCaOnly}
+	tlsConfig, err = newLdapTlsConfig(ldap, cert, nil)
+	assert.Nil(t, err)
+	conn, err := dialLdap(ldap, tlsConfig)
+	assert.Nil(t, err)
+	assert.Nil(t, <-result)
+	if conn != nil {
+		conn.Close()
+	}
+
+	// the certificate is not trusted without the CA cert
+	port, result = startTestLdapServer(t, cert, false)
+	ldap = &Ldap{Host: "127.0.0.1", Port: port, TlsMode: LdapTlsModeLdaps, TlsVerification: LdapTlsVerificationCaOnly}
+	tlsConfig, err = newLdapTlsConfig(ldap, nil, nil)
+	assert.Nil(t, err)
+	_, err = dialLdap(ldap, tlsConfig)
+	assert.NotNil(t, err)
+	<-result
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+
+	"github.com/astaxie/beego/context"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	LoginMethodPassword = "Password"
+	LoginMethodCode     = "Code"
+	LoginMethodProvider = "Provider"
+	LoginMethodSession  = "Session"
+)
+
+// LoginAttempt is a structured record of each sign-in to an application, successful or not.
+// Owner is the organization of the user.
+type LoginAttempt struct {
+	Id          int    `xorm:"int notnull pk autoincr" json:"id"`
+	Owner       string `xorm:"varchar(100) index" json:"owner"`
+	CreatedTime string `xorm:"varchar(100) index" json:"createdTime"`
+
+	Application  string `xorm:"varchar(100) index" json:"application"`
+	User         string `xorm:"varchar(100)" json:"user"`
+	Method       string `xorm:"varchar(100)" json:"method"`
+	Provider     string `xorm:"varchar(100)" json:"provider"`
+	ProviderType string `xorm:"varchar(100)" json:"providerType"`
+	ClientIp     string `xorm:"varchar(100) index" json:"clientIp"`
+	UserAgent    string `xorm:"varchar(500)" json:"userAgent"`
+	IsSuccess    bool   `json:"isSuccess"`
+	Reason       string `xorm:"varchar(1000)" json:"reason"`
+}
+
+func NewLoginAttempt(ctx *context.Context) *LoginAttempt {
+	ip := strings.Replace(util.GetIPFromRequest(ctx.Request), ": ", "", -1)
+	userAgent := ctx.Request.UserAgent()
+	if len(userAgent) > 500 {
+		userAgent = userAgent[0:500]
+	}
+
+	attempt := LoginAttempt{
+		CreatedTime: util.GetCurrentTime(),
+		ClientIp:    ip,
+		UserAgent:   userAgent,
+	}
+	return &attempt
+}
+
+func GetLoginAttemptCount(owner string, query *Query) int {
+	session := GetSession(&LoginAttempt{}, owner, -1, -1, query)
+	count, err := session.Count(&LoginAttempt{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetPaginationLoginAttempts(owner string, offset, limit int, query *Query) []*LoginAttempt {
+	query.setDefaultSort("id", "descend")
+
+	attempts := []*LoginAttempt{}
+	session := GetSession(&LoginAttempt{}, owner, offset, limit, query)
+	err := session.Find(&attempts)
+	if err != nil {
+		panic(err)
+	}
+
+	return attempts
+}
+
+func AddLoginAttempt(attempt *LoginAttempt) bool {
+	if len(attempt.Reason) > 1000 {
+		attempt.Reason = attempt.Reason[0:1000]
+	}
+
+	affected, err := adapter.Engine.Insert(attempt)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"xorm.io/xorm"
+)
+
+// created_time is an RFC 3339 string, so the time buckets are its prefixes, which
+// group the same way on MySQL, Postgres and SQLite: "2022-03-01T10", "2022-03-01" and "2022-03"
+var loginStatsBucketLengths = map[string]int{
+	"hour":  13,
+	"day":   10,
+	"month": 7,
+}
+
+// LoginStatsFilter selects the login attempts and the signups to aggregate, StartTime is
+// inclusive and EndTime exclusive, Limit is the size of the top lists
+type LoginStatsFilter struct {
+	Organization string
+	Application  string
+	StartTime    string
+	EndTime      string
+	Bucket       string
+	Limit        int
+}
+
+type LoginStatsBucket struct {
+	Time        string  `json:"time"`
+	Application string  `json:"application"`
+	Total       int     `json:"total"`
+	Failed      int     `json:"failed"`
+	FailureRate float64 `json:"failureRate"`
+}
+
+type LoginStatsCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type LoginStats struct {
+	Logins       []*LoginStatsBucket `json:"logins"`
+	TopFailedIps []*LoginStatsCount  `json:"topFailedIps"`
+	Providers    []*LoginStatsCount  `json:"providers"`
+	Signups      []*LoginStatsCount  `json:"signups"`
+}
+
+func CheckLoginStatsFilter(filter *LoginStatsFilter) error {
+	if _, ok := loginStatsBucketLengths[filter.Bucket]; !ok {
+		return fmt.Errorf("the time bucket: %s is not supported, use hour, day or month", filter.Bucket)
+	}
+
+	for _, t := range []string{filter.StartTime, filter.EndTime} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, t); err != nil {
+			return fmt.Errorf("the time: %s should be in RFC 3339 format", t)
+		}
+	}
+
+	if filter.Limit <= 0 {
+		return fmt.Errorf("the limit: %d should be positive", filter.Limit)
+	}
+	return nil
+}
+
+func (filter *LoginStatsFilter) getBucketExpr() string {
+	return fmt.Sprintf("substr(created_time, 1, %d)", loginStatsBucketLengths[filter.Bucket])
+}
+
+func (filter *LoginStatsFilter) getSession(bean interface{}, applicationColumn string) *xorm.Session {
+	session := adapter.Engine.Table(bean)
+	if filter.Organization != "" {
+		session = session.And("owner = ?", filter.Organization)
+	}
+	if filter.Application != "" {
+		session = session.And(fmt.Sprintf("%s = ?", applicationColumn), filter.Application)
+	}
+	if filter.StartTime != "" {
+		session = session.And("created_time >= ?", getQueryTime(filter.StartTime))
+	}
+	if filter.EndTime != "" {
+		session = session.And("created_time < ?", getQueryTime(filter.EndTime))
+	}
+	return session
+}
+
+func queryLoginStats(session *xorm.Session) []map[string]string {
+	rows, err := session.QueryString()
+	if err != nil {
+		panic(err)
+	}
+
+	return rows
+}
+
+func getLoginStatsCounts(rows []map[string]string, getName func(row map[string]string) string) []*LoginStatsCount {
+	counts := []*LoginStatsCount{}
+	for _, row := range rows {
+		counts = append(counts, &LoginStatsCount{Name: getName(row), Count: util.ParseInt(row["total"])})
+	}
+	return counts
+}
+
+func sortLoginStatsCounts(counts []*LoginStatsCount) {
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+}
+
+func getLoginStatsBuckets(totalRows []map[string]string, failedRows []map[string]string) []*LoginStatsBucket {
+	buckets := []*LoginStatsBucket{}
+	bucketMap := map[string]*LoginStatsBucket{}
+	for _, row := range totalRows {
+		bucket := &LoginStatsBucket{
+			Time:        row["time"],
+			Application: row["application"],
+			Total:       util.ParseInt(row["total"]),
+		}
+		buckets = append(buckets, bucket)
+		bucketMap[fmt.Sprintf("%s/%s", bucket.Time, bucket.Application)] = bucket
+	}
+
+	for _, row := range failedRows {
+		bucket, ok := bucketMap[fmt.Sprintf("%s/%s", row["time"], row["application"])]
+		if ok {
+			bucket.Failed = util.ParseInt(row["total"])
+		}
+	}
+
+	for _, bucket := range buckets {
+		if bucket.Total != 0 {
+			bucket.FailureRate = float64(bucket.Failed) / float64(bucket.Total)
+		}
+	}
+
+	sort.SliceStable(buckets, func(i, j int) bool {
+		if buckets[i].Time != buckets[j].Time {
+			return buckets[i].Time < buckets[j].Time
+		}
+		return buckets[i].Application < buckets[j].Application
+	})
+	return buckets
+}
+
+func GetLoginStats(filter *LoginStatsFilter) *LoginStats {
+	bucketExpr := filter.getBucketExpr()
+	loginSelect := fmt.Sprintf("%s as time, application, count(*) as total", bucketExpr)
+	loginGroupBy := fmt.Sprintf("%s, application", bucketExpr)
+
+	totalRows := queryLoginStats(filter.getSession(&LoginAttempt{}, "application").Select(loginSelect).GroupBy(loginGroupBy))
+	failedRows := queryLoginStats(filter.getSession(&LoginAttempt{}, "application").And("is_success = ?", false).Select(loginSelect).GroupBy(loginGroupBy))
+
+	ipRows := queryLoginStats(filter.getSession(&LoginAttempt{}, "application").And("is_success = ?", false).
+		Select("client_ip, count(*) as total").GroupBy("client_ip").OrderBy("count(*) desc").Limit(filter.Limit))
+	topFailedIps := getLoginStatsCounts(ipRows, func(row map[string]string) string {
+		return row["client_ip"]
+	})
+
+	// password, code and session sign-ins have no provider, so they are counted by the method
+	providerRows := queryLoginStats(filter.getSession(&LoginAttempt{}, "application").And("is_success = ?", true).
+		Select("method, provider, count(*) as total").GroupBy("method, provider"))
+	providers := getLoginStatsCounts(providerRows, func(row map[string]string) string {
+		if row["provider"] != "" {
+			return row["provider"]
+		}
+		return row["method"]
+	})
+
+	signupRows := queryLoginStats(filter.getSession(&User{}, "signup_application").
+		Select(fmt.Sprintf("%s as time, count(*) as total", bucketExpr)).GroupBy(bucketExpr))
+	signups := getLoginStatsCounts(signupRows, func(row map[string]string) string {
+		return row["time"]
+	})
+
+	sortLoginStatsCounts(topFailedIps)
+	sortLoginStatsCounts(providers)
+	sort.SliceStable(signups, func(i, j int) bool {
+		return signups[i].Name < signups[j].Name
+	})
+
+	stats := LoginStats{
+		Logins:       getLoginStatsBuckets(totalRows, failedRows),
+		TopFailedIps: topFailedIps,
+		Providers:    providers,
+		Signups:      signups,
+	}
+	return &stats
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLoginStatsFilter(t *testing.T) {
+	filter := &LoginStatsFilter{Bucket: "day", StartTime: "2022-03-01T00:00:00Z", Limit: 10}
+	assert.Nil(t, CheckLoginStatsFilter(filter))
+	assert.Equal(t, "substr(created_time, 1, 10)", filter.getBucketExpr())
+
+	assert.NotNil(t, CheckLoginStatsFilter(&LoginStatsFilter{Bucket: "week", Limit: 10}))
+	assert.NotNil(t, CheckLoginStatsFilter(&LoginStatsFilter{Bucket: "day", EndTime: "2022-03-01", Limit: 10}))
+	assert.NotNil(t, CheckLoginStatsFilter(&LoginStatsFilter{Bucket: "day", Limit: 0}))
+}
+
+func TestGetLoginStatsBuckets(t *testing.T) {
+	totalRows := []map[string]string{
+		{"time": "2022-03-02", "application": "app-built-in", "total": "5"},
+		{"time": "2022-03-01", "application": "app-built-in", "total": "4"},
+		{"time": "2022-03-01", "application": "app-example", "total": "2"},
+	}
+	failedRows := []map[string]string{
+		{"time": "2022-03-01", "application": "app-built-in", "total": "1"},
+	}
+
+	buckets := getLoginStatsBuckets(totalRows, failedRows)
+	assert.Equal(t, []*LoginStatsBucket{
+		{Time: "2022-03-01", Application: "app-built-in", Total: 4, Failed: 1, FailureRate: 0.25},
+		{Time: "2022-03-01", Application: "app-example", Total: 2},
+		{Time: "2022-03-02", Application: "app-built-in", Total: 5},
+	}, buckets)
+
+	counts := getLoginStatsCounts([]map[string]string{{"client_ip": "10.0.0.2", "total": "3"}, {"client_ip": "10.0.0.1", "total": "3"}, {"client_ip": "10.0.0.3", "total": "7"}}, func(row map[string]string) string {
+		return row["client_ip"]
+	})
+	sortLoginStatsCounts(counts)
+	assert.Equal(t, []*LoginStatsCount{{Name: "10.0.0.3", Count: 7}, {Name: "10.0.0.1", Count: 3}, {Name: "10.0.0.2", Count: 3}}, counts)
+}
@@ -31,8 +31,8 @@ type Model struct {
 	IsEnabled 	bool   `json:"isEnabled"`
 }
 
-func GetModelCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetModelCount(owner string, query *Query) int {
+	session := GetSession(&Model{}, owner, -1, -1, query)
 	count, err := session.Count(&Model{})
 	if err != nil {
 		panic(err)
@@ -51,9 +51,9 @@ func GetModels(owner string) []*Model {
 	return models
 }
 
-func GetPaginationModels(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Model {
+func GetPaginationModels(owner string, offset, limit int, query *Query) []*Model {
 	models := []*Model{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Model{}, owner, offset, limit, query)
 	err := session.Find(&models)
 	if err != nil {
 		panic(err)
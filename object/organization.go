@@ -44,11 +44,17 @@ type Organization struct {
 	EnableSoftDeletion bool     `json:"enableSoftDeletion"`
 	IsProfilePublic    bool     `json:"isProfilePublic"`
 
+	EnableAdminImpersonation bool `json:"enableAdminImpersonation"`
+	EnableDeviceNotification bool `json:"enableDeviceNotification"`
+
+	RecordRetentionDays   int    `json:"recordRetentionDays"`
+	RecordArchiveProvider string `xorm:"varchar(100)" json:"recordArchiveProvider"`
+
 	AccountItems []*AccountItem `xorm:"varchar(2000)" json:"accountItems"`
 }
 
-func GetOrganizationCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetOrganizationCount(owner string, query *Query) int {
+	session := GetSession(&Organization{}, owner, -1, -1, query)
 	count, err := session.Count(&Organization{})
 	if err != nil {
 		panic(err)
@@ -67,9 +73,9 @@ func GetOrganizations(owner string) []*Organization {
 	return organizations
 }
 
-func GetPaginationOrganizations(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Organization {
+func GetPaginationOrganizations(owner string, offset, limit int, query *Query) []*Organization {
 	organizations := []*Organization{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Organization{}, owner, offset, limit, query)
 	err := session.Find(&organizations)
 	if err != nil {
 		panic(err)
@@ -56,8 +56,8 @@ type Payment struct {
 	InvoiceUrl    string `xorm:"varchar(255)" json:"invoiceUrl"`
 }
 
-func GetPaymentCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetPaymentCount(owner string, query *Query) int {
+	session := GetSession(&Payment{}, owner, -1, -1, query)
 	count, err := session.Count(&Payment{})
 	if err != nil {
 		panic(err)
@@ -86,9 +86,9 @@ func GetUserPayments(owner string, organization string, user string) []*Payment
 	return payments
 }
 
-func GetPaginationPayments(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Payment {
+func GetPaginationPayments(owner string, offset, limit int, query *Query) []*Payment {
 	payments := []*Payment{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Payment{}, owner, offset, limit, query)
 	err := session.Find(&payments)
 	if err != nil {
 		panic(err)
@@ -193,14 +193,19 @@ func NotifyPayment(request *http.Request, body []byte, owner string, providerNam
 	payment, err := notifyPayment(request, body, owner, providerName, productName, paymentName)
 
 	if payment != nil {
+		oldPayment := *payment
+		eventType := EventPaymentPaid
 		if err != nil {
 			payment.State = "Error"
 			payment.Message = err.Error()
+			eventType = EventPaymentFailed
 		} else {
 			payment.State = "Paid"
 		}
 
-		UpdatePayment(payment.GetId(), payment)
+		if UpdatePayment(payment.GetId(), payment) {
+			PublishEvent(NewEvent(eventType, payment.Organization, payment.GetId(), &oldPayment, payment))
+		}
 	}
 
 	ok := err == nil
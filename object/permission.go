@@ -39,8 +39,8 @@ type Permission struct {
 	IsEnabled bool `json:"isEnabled"`
 }
 
-func GetPermissionCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetPermissionCount(owner string, query *Query) int {
+	session := GetSession(&Permission{}, owner, -1, -1, query)
 	count, err := session.Count(&Permission{})
 	if err != nil {
 		panic(err)
@@ -59,9 +59,9 @@ func GetPermissions(owner string) []*Permission {
 	return permissions
 }
 
-func GetPaginationPermissions(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Permission {
+func GetPaginationPermissions(owner string, offset, limit int, query *Query) []*Permission {
 	permissions := []*Permission{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Permission{}, owner, offset, limit, query)
 	err := session.Find(&permissions)
 	if err != nil {
 		panic(err)
@@ -40,8 +40,8 @@ type Product struct {
 	State string `xorm:"varchar(100)" json:"state"`
 }
 
-func GetProductCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetProductCount(owner string, query *Query) int {
+	session := GetSession(&Product{}, owner, -1, -1, query)
 	count, err := session.Count(&Product{})
 	if err != nil {
 		panic(err)
@@ -60,9 +60,9 @@ func GetProducts(owner string) []*Product {
 	return products
 }
 
-func GetPaginationProducts(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Product {
+func GetPaginationProducts(owner string, offset, limit int, query *Query) []*Product {
 	products := []*Product{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Product{}, owner, offset, limit, query)
 	err := session.Find(&products)
 	if err != nil {
 		panic(err)
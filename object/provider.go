@@ -88,8 +88,8 @@ func GetMaskedProviders(providers []*Provider) []*Provider {
 	return providers
 }
 
-func GetProviderCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetProviderCount(owner string, query *Query) int {
+	session := GetSession(&Provider{}, owner, -1, -1, query)
 	count, err := session.Count(&Provider{})
 	if err != nil {
 		panic(err)
@@ -108,9 +108,9 @@ func GetProviders(owner string) []*Provider {
 	return providers
 }
 
-func GetPaginationProviders(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Provider {
+func GetPaginationProviders(owner string, offset, limit int, query *Query) []*Provider {
 	providers := []*Provider{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Provider{}, owner, offset, limit, query)
 	err := session.Find(&providers)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,302 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"xorm.io/xorm"
+)
+
+var queryOperators = map[string]string{
+	"eq":     "=",
+	"ne":     "<>",
+	"gt":     ">",
+	"gte":    ">=",
+	"lt":     "<",
+	"lte":    "<=",
+	"prefix": "like",
+}
+
+// querySecretColumns can't be filtered or sorted on, otherwise the comparison operators would reveal the secrets
+// one character at a time. They are the redacted fields of the audit logs and the columns never returned by the API.
+var querySecretColumns = getQuerySecretColumns()
+
+func getQuerySecretColumns() map[string]bool {
+	columns := map[string]bool{
+		"session_id":   true,
+		"report_token": true,
+	}
+	for field := range auditRedactedFields {
+		columns[util.SnakeString(field)] = true
+	}
+	return columns
+}
+
+// isQueryFieldAllowed is the check of all the fields of a query: the search field, the sort field and the filters
+func isQueryFieldAllowed(field string) bool {
+	return filterField(field) && !querySecretColumns[util.SnakeString(field)]
+}
+
+// QueryFilter is a single condition like "clientIp:eq:10.0.0.1", all the filters of a query are ANDed.
+type QueryFilter struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// Query is the shared filter of all the GetPagination* and Get*Count functions.
+// Field and Value are the legacy "field like %value%" search of the list pages.
+type Query struct {
+	Field     string         `json:"field"`
+	Value     string         `json:"value"`
+	SortField string         `json:"sortField"`
+	SortOrder string         `json:"sortOrder"`
+	Filters   []*QueryFilter `json:"filters"`
+	StartTime string         `json:"startTime"`
+	EndTime   string         `json:"endTime"`
+	Cursor    string         `json:"cursor"`
+}
+
+func ParseQueryFilter(s string) (*QueryFilter, error) {
+	tokens := strings.SplitN(s, ":", 3)
+	if len(tokens) != 3 {
+		return nil, fmt.Errorf("the filter: %s should be like \"field:operator:value\"", s)
+	}
+
+	filter := &QueryFilter{
+		Field:    tokens[0],
+		Operator: tokens[1],
+		Value:    tokens[2],
+	}
+	return filter, nil
+}
+
+func (query *Query) Check() error {
+	if query.Field != "" && !isQueryFieldAllowed(query.Field) {
+		return fmt.Errorf("the field: %s is not allowed", query.Field)
+	}
+	if query.SortField != "" && !isQueryFieldAllowed(query.SortField) {
+		return fmt.Errorf("the sort field: %s is not allowed", query.SortField)
+	}
+	if query.SortOrder != "" && query.SortOrder != "ascend" && query.SortOrder != "descend" {
+		return fmt.Errorf("the sort order: %s should be \"ascend\" or \"descend\"", query.SortOrder)
+	}
+
+	for _, filter := range query.Filters {
+		if !isQueryFieldAllowed(filter.Field) {
+			return fmt.Errorf("the field: %s is not allowed", filter.Field)
+		}
+		if _, ok := queryOperators[filter.Operator]; !ok {
+			return fmt.Errorf("the operator: %s is not supported", filter.Operator)
+		}
+	}
+
+	for _, t := range []string{query.StartTime, query.EndTime} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, t); err != nil {
+			return fmt.Errorf("the time: %s should be in RFC 3339 format", t)
+		}
+	}
+
+	if query.Cursor != "" {
+		if _, err := decodeQueryCursor(query.Cursor); err != nil {
+			return fmt.Errorf("the cursor: %s is invalid", query.Cursor)
+		}
+	}
+	return nil
+}
+
+// setDefaultSort updates the query in place, so that GetQueryCursor() builds the cursor
+// with the same columns as the page.
+func (query *Query) setDefaultSort(sortField, sortOrder string) {
+	if query.SortField == "" || query.SortOrder == "" {
+		query.SortField, query.SortOrder = sortField, sortOrder
+	}
+}
+
+func (query *Query) getSortColumn() string {
+	if query.SortField == "" || query.SortOrder == "" || !isQueryFieldAllowed(query.SortField) {
+		return "created_time"
+	}
+	return util.SnakeString(query.SortField)
+}
+
+func (query *Query) isAscending() bool {
+	return query.SortOrder == "ascend"
+}
+
+func escapeLikeValue(value string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return replacer.Replace(value)
+}
+
+func (filter *QueryFilter) getCondition() (string, string) {
+	column := util.SnakeString(filter.Field)
+	if filter.Operator == "prefix" {
+		return fmt.Sprintf("%s like ? escape '!'", column), escapeLikeValue(filter.Value) + "%"
+	}
+	return fmt.Sprintf("%s %s ?", column, queryOperators[filter.Operator]), filter.Value
+}
+
+// getQueryTime converts the time to the local time zone, because created_time is
+// stored as an RFC 3339 string in the local time zone and compared as a string.
+func getQueryTime(t string) string {
+	tm, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return t
+	}
+	return tm.Local().Format(time.RFC3339)
+}
+
+// getQueryColumns returns the sort column followed by the primary keys of the table
+// as the tie-breakers, so that the order and the cursor are both deterministic.
+func getQueryColumns(bean interface{}, query *Query) []string {
+	sortColumn := query.getSortColumn()
+	res := []string{sortColumn}
+
+	table, err := adapter.Engine.TableInfo(bean)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, key := range table.PrimaryKeys {
+		if key != sortColumn {
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+func encodeQueryCursor(values []string) string {
+	data, _ := json.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeQueryCursor(cursor string) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("the cursor is empty")
+	}
+	return values, nil
+}
+
+// getCursorCondition returns the keyset condition of the rows after the cursor, like:
+// (a < ?) or (a = ? and b < ?) or (a = ? and b = ? and c < ?)
+func getCursorCondition(columns []string, values []string, isAscending bool) (string, []interface{}) {
+	operator := "<"
+	if isAscending {
+		operator = ">"
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+	for i := range columns {
+		parts := []string{}
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = ?", columns[j]))
+			args = append(args, values[j])
+		}
+		parts = append(parts, fmt.Sprintf("%s %s ?", columns[i], operator))
+		args = append(args, values[i])
+		conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(parts, " and ")))
+	}
+	return strings.Join(conditions, " or "), args
+}
+
+func applyQuery(session *xorm.Session, bean interface{}, query *Query, isPaged bool) *xorm.Session {
+	if query.Field != "" && query.Value != "" {
+		if isQueryFieldAllowed(query.Field) {
+			session = session.And(fmt.Sprintf("%s like ?", util.SnakeString(query.Field)), fmt.Sprintf("%%%s%%", query.Value))
+		}
+	}
+	for _, filter := range query.Filters {
+		if _, ok := queryOperators[filter.Operator]; ok && isQueryFieldAllowed(filter.Field) {
+			condition, arg := filter.getCondition()
+			session = session.And(condition, arg)
+		}
+	}
+	if query.StartTime != "" {
+		session = session.And("created_time >= ?", getQueryTime(query.StartTime))
+	}
+	if query.EndTime != "" {
+		session = session.And("created_time < ?", getQueryTime(query.EndTime))
+	}
+
+	columns := getQueryColumns(bean, query)
+	if isPaged && query.Cursor != "" {
+		values, err := decodeQueryCursor(query.Cursor)
+		if err == nil && len(values) == len(columns) {
+			condition, args := getCursorCondition(columns, values, query.isAscending())
+			session = session.And(condition, args...)
+		}
+	}
+
+	for _, column := range columns {
+		if query.isAscending() {
+			session = session.Asc(column)
+		} else {
+			session = session.Desc(column)
+		}
+	}
+	return session
+}
+
+// GetQueryCursor returns the cursor pointing after the last item of a page, which is
+// passed back as the "cursor" parameter to get the next page.
+func GetQueryCursor(items interface{}, query *Query) string {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice || v.Len() == 0 {
+		return ""
+	}
+
+	item := v.Index(v.Len() - 1).Interface()
+	table, err := adapter.Engine.TableInfo(item)
+	if err != nil {
+		panic(err)
+	}
+
+	values := []string{}
+	for _, name := range getQueryColumns(item, query) {
+		column := table.GetColumn(name)
+		if column == nil {
+			return ""
+		}
+
+		value, err := column.ValueOf(item)
+		if err != nil {
+			panic(err)
+		}
+		values = append(values, fmt.Sprintf("%v", value.Interface()))
+	}
+	return encodeQueryCursor(values)
+}
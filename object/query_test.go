@@ -0,0 +1,82 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCheck(t *testing.T) {
+	filter, err := ParseQueryFilter("createdTime:gte:2022-03-01T00:00:00+08:00")
+	assert.Nil(t, err)
+	assert.Equal(t, &QueryFilter{Field: "createdTime", Operator: "gte", Value: "2022-03-01T00:00:00+08:00"}, filter)
+
+	_, err = ParseQueryFilter("clientIp")
+	assert.NotNil(t, err)
+
+	query := &Query{Filters: []*QueryFilter{filter}, StartTime: "2022-03-01T00:00:00Z", Cursor: encodeQueryCursor([]string{"2022-03-01T00:00:00Z", "42"})}
+	assert.Nil(t, query.Check())
+
+	query = &Query{Filters: []*QueryFilter{{Field: "name;drop table user", Operator: "eq", Value: "admin"}}}
+	assert.NotNil(t, query.Check())
+
+	query = &Query{Filters: []*QueryFilter{{Field: "name", Operator: "regexp", Value: "admin"}}}
+	assert.NotNil(t, query.Check())
+
+	query = &Query{SortField: "id desc, name", SortOrder: "ascend"}
+	assert.NotNil(t, query.Check())
+
+	// the secrets can't be compared, sorted or searched
+	for _, field := range []string{"password", "passwordSalt", "clientSecret", "client_secret", "masterPassword", "privateKey", "scimToken", "reportToken"} {
+		query = &Query{Filters: []*QueryFilter{{Field: field, Operator: "gt", Value: "m"}}}
+		assert.NotNil(t, query.Check(), field)
+		query = &Query{SortField: field, SortOrder: "ascend"}
+		assert.NotNil(t, query.Check(), field)
+		query = &Query{Field: field, Value: "a"}
+		assert.NotNil(t, query.Check(), field)
+	}
+
+	query = &Query{EndTime: "yesterday"}
+	assert.NotNil(t, query.Check())
+
+	query = &Query{Cursor: "not a cursor"}
+	assert.NotNil(t, query.Check())
+}
+
+func TestQueryFilterCondition(t *testing.T) {
+	condition, arg := (&QueryFilter{Field: "clientIp", Operator: "ne", Value: "10.0.0.1"}).getCondition()
+	assert.Equal(t, "client_ip <> ?", condition)
+	assert.Equal(t, "10.0.0.1", arg)
+
+	condition, arg = (&QueryFilter{Field: "name", Operator: "prefix", Value: "app_100%"}).getCondition()
+	assert.Equal(t, "name like ? escape '!'", condition)
+	assert.Equal(t, "app!_100!%%", arg)
+}
+
+func TestGetCursorCondition(t *testing.T) {
+	condition, args := getCursorCondition([]string{"created_time", "owner", "name"}, []string{"t", "o", "n"}, false)
+	assert.Equal(t, "(created_time < ?) or (created_time = ? and owner < ?) or (created_time = ? and owner = ? and name < ?)", condition)
+	assert.Equal(t, []interface{}{"t", "t", "o", "t", "o", "n"}, args)
+
+	condition, args = getCursorCondition([]string{"id"}, []string{"42"}, true)
+	assert.Equal(t, "(id > ?)", condition)
+	assert.Equal(t, []interface{}{"42"}, args)
+
+	values, err := decodeQueryCursor(encodeQueryCursor([]string{"t", "42"}))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"t", "42"}, values)
+}
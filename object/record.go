@@ -15,7 +15,6 @@
 package object
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/astaxie/beego/context"
@@ -46,9 +45,15 @@ type Record struct {
 	Method       string `xorm:"varchar(100)" json:"method"`
 	RequestUri   string `xorm:"varchar(1000)" json:"requestUri"`
 	Action       string `xorm:"varchar(1000)" json:"action"`
+	Impersonator string `xorm:"varchar(100)" json:"impersonator"`
 
 	ExtendedUser *User `xorm:"-" json:"extendedUser"`
 
+	// PrevHash and Hash chain the records of an organization, see VerifyRecordChain
+	PrevHash string `xorm:"varchar(100)" json:"prevHash"`
+	Hash     string `xorm:"varchar(100)" json:"hash"`
+
+	// IsTriggered tells if the record has been queued for some webhooks, the deliveries are in WebhookEvent
 	IsTriggered bool `json:"isTriggered"`
 }
 
@@ -70,6 +75,17 @@ func NewRecord(ctx *context.Context) *Record {
 		Action:      action,
 		IsTriggered: false,
 	}
+
+	// requests made in an impersonated session are attributed to the real admin as well
+	if ctx.Input.CruSession != nil {
+		if session, ok := ctx.Input.Session("authContext").(string); ok {
+			authContext := &AuthContext{}
+			if util.JsonToStruct(session, authContext) == nil {
+				record.Impersonator = authContext.Impersonator
+			}
+		}
+	}
+
 	return &record
 }
 
@@ -86,23 +102,19 @@ func AddRecord(record *Record) bool {
 
 	record.Owner = record.Organization
 
-	errWebhook := SendWebhooks(record)
-	if errWebhook == nil {
-		record.IsTriggered = true
-	} else {
-		fmt.Println(errWebhook)
-	}
+	webhooks := getSubscribedWebhooks(record.Organization, record.Action)
+	record.IsTriggered = len(webhooks) != 0
 
-	affected, err := adapter.Engine.Insert(record)
-	if err != nil {
-		panic(err)
-	}
+	affected := insertChainedRecord(record)
+	sendRecordToSinks(record)
+
+	enqueueWebhookEvents(record, webhooks)
 
-	return affected != 0
+	return affected
 }
 
-func GetRecordCount(field, value string) int {
-	session := GetSession("", -1, -1, field, value, "", "")
+func GetRecordCount(query *Query) int {
+	session := GetSession(&Record{}, "", -1, -1, query)
 	count, err := session.Count(&Record{})
 	if err != nil {
 		panic(err)
@@ -121,9 +133,9 @@ func GetRecords() []*Record {
 	return records
 }
 
-func GetPaginationRecords(offset, limit int, field, value, sortField, sortOrder string) []*Record {
+func GetPaginationRecords(offset, limit int, query *Query) []*Record {
 	records := []*Record{}
-	session := GetSession("", offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Record{}, "", offset, limit, query)
 	err := session.Find(&records)
 	if err != nil {
 		panic(err)
@@ -141,34 +153,3 @@ func GetRecordsByField(record *Record) []*Record {
 
 	return records
 }
-
-func SendWebhooks(record *Record) error {
-	webhooks := getWebhooksByOrganization(record.Organization)
-	for _, webhook := range webhooks {
-		if !webhook.IsEnabled {
-			continue
-		}
-
-		matched := false
-		for _, event := range webhook.Events {
-			if record.Action == event {
-				matched = true
-				break
-			}
-		}
-
-		if matched {
-			if webhook.IsUserExtended {
-				user := getUser(record.Organization, record.User)
-				record.ExtendedUser = user
-			}
-
-			err := sendWebhook(webhook, record)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
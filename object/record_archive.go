@@ -0,0 +1,210 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	recordArchiveInterval = 24 * time.Hour
+	recordArchiveMaxCount = 100000
+)
+
+// RecordArchive is a file of records moved out of the record table by the retention policy of an organization,
+// LastHash is the hash of the last archived record so that the chain of the remaining records can still be verified
+type RecordArchive struct {
+	Id          int    `xorm:"int notnull pk autoincr" json:"id"`
+	Owner       string `xorm:"varchar(100) index" json:"owner"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Provider      string `xorm:"varchar(100)" json:"provider"`
+	Url           string `xorm:"varchar(500)" json:"url"`
+	StartRecordId int    `json:"startRecordId"`
+	EndRecordId   int    `json:"endRecordId"`
+	Count         int    `json:"count"`
+	StartTime     string `xorm:"varchar(100)" json:"startTime"`
+	EndTime       string `xorm:"varchar(100)" json:"endTime"`
+	LastHash      string `xorm:"varchar(100)" json:"lastHash"`
+}
+
+func GetRecordArchives(owner string) []*RecordArchive {
+	archives := []*RecordArchive{}
+	err := adapter.Engine.Asc("id").Find(&archives, &RecordArchive{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return archives
+}
+
+func getLastRecordArchive(owner string) *RecordArchive {
+	archive := RecordArchive{}
+	existed, err := adapter.Engine.Where("owner = ?", owner).Desc("id").Get(&archive)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &archive
+	}
+	return nil
+}
+
+// getExpiredRecords returns the oldest records of the organization created before the cutoff time, in id order and
+// without a gap so that the records left in the table still form a chain
+func getExpiredRecords(owner string, cutoffTime string) []*Record {
+	res := []*Record{}
+	lastId := 0
+	for len(res) < recordArchiveMaxCount {
+		records := []*Record{}
+		err := adapter.Engine.Where("owner = ? and id > ?", owner, lastId).Asc("id").Limit(recordChainBatchSize).Find(&records)
+		if err != nil {
+			panic(err)
+		}
+
+		for _, record := range records {
+			if record.CreatedTime >= cutoffTime || len(res) == recordArchiveMaxCount {
+				return res
+			}
+			res = append(res, record)
+			lastId = record.Id
+		}
+
+		if len(records) < recordChainBatchSize {
+			break
+		}
+	}
+	return res
+}
+
+func getRecordArchiveData(records []*Record) (*bytes.Buffer, error) {
+	buf := &bytes.Buffer{}
+	writer := gzip.NewWriter(buf)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		err := encoder.Encode(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ArchiveRecords moves the records older than the retention of the organization to its archive provider as
+// a gzipped JSONL file, then deletes them. Without an archive provider the records are only deleted.
+// It returns nil when no record has expired
+func ArchiveRecords(organization *Organization) (*RecordArchive, error) {
+	if organization.RecordRetentionDays <= 0 {
+		return nil, nil
+	}
+
+	cutoffTime := time.Now().AddDate(0, 0, -organization.RecordRetentionDays).Format(time.RFC3339)
+	records := getExpiredRecords(organization.Name, cutoffTime)
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	first, last := records[0], records[len(records)-1]
+	archive := &RecordArchive{
+		Owner:         organization.Name,
+		CreatedTime:   util.GetCurrentTime(),
+		Provider:      organization.RecordArchiveProvider,
+		StartRecordId: first.Id,
+		EndRecordId:   last.Id,
+		Count:         len(records),
+		StartTime:     first.CreatedTime,
+		EndTime:       last.CreatedTime,
+		LastHash:      last.Hash,
+	}
+
+	if organization.RecordArchiveProvider != "" {
+		provider := getProvider("admin", organization.RecordArchiveProvider)
+		if provider == nil {
+			return nil, fmt.Errorf("the storage provider: %s doesn't exist", organization.RecordArchiveProvider)
+		}
+		if provider.Category != "Storage" {
+			return nil, fmt.Errorf("the provider: %s is not a storage provider", organization.RecordArchiveProvider)
+		}
+
+		data, err := getRecordArchiveData(records)
+		if err != nil {
+			return nil, err
+		}
+
+		fullFilePath := fmt.Sprintf("records/%s/%d-%d.jsonl.gz", organization.Name, first.Id, last.Id)
+		archive.Url, _, err = uploadFile(provider, fullFilePath, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := adapter.Engine.Insert(archive)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = adapter.Engine.Where("owner = ? and id >= ? and id <= ?", organization.Name, first.Id, last.Id).Delete(&Record{})
+	if err != nil {
+		panic(err)
+	}
+
+	return archive, nil
+}
+
+// InitRecordArchiver applies the record retention of the organizations once a day
+func InitRecordArchiver() {
+	util.SafeGoroutine(func() {
+		ticker := time.NewTicker(recordArchiveInterval)
+		defer ticker.Stop()
+		for {
+			archiveAllRecords()
+			<-ticker.C
+		}
+	})
+}
+
+func archiveAllRecords() {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Error("record archiver panic: %v", r)
+		}
+	}()
+
+	for _, organization := range GetOrganizations("admin") {
+		for {
+			archive, err := ArchiveRecords(organization)
+			if err != nil {
+				logs.Error("failed to archive the records of organization: %s: %s", organization.Name, err.Error())
+				break
+			}
+			if archive == nil || archive.Count < recordArchiveMaxCount {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRecordArchiveData(t *testing.T) {
+	records := []*Record{{Id: 1, Owner: "built-in", Action: "login"}, {Id: 2, Owner: "built-in", Action: "logout"}}
+	data, err := getRecordArchiveData(records)
+	assert.Nil(t, err)
+
+	reader, err := gzip.NewReader(data)
+	assert.Nil(t, err)
+	scanner := bufio.NewScanner(reader)
+	actions := []string{}
+	for scanner.Scan() {
+		record := Record{}
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &record))
+		actions = append(actions, record.Action)
+	}
+	assert.Equal(t, []string{"login", "logout"}, actions)
+}
+
+func TestCheckRecordExportFilter(t *testing.T) {
+	assert.Nil(t, CheckRecordExportFilter("csv", &RecordExportFilter{Fields: []string{"id", "user", "action"}}))
+	assert.Nil(t, CheckRecordExportFilter("jsonl", &RecordExportFilter{}))
+	assert.NotNil(t, CheckRecordExportFilter("xml", &RecordExportFilter{}))
+	assert.NotNil(t, CheckRecordExportFilter("csv", &RecordExportFilter{Fields: []string{"password"}}))
+}
@@ -0,0 +1,429 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	recordChainBatchSize  = 1000
+	recordChainMaxRetries = 100
+)
+
+// RecordChainHead is the last chained record of an organization. It is stored in the database and moved with a
+// compare-and-swap, so that all the Casdoor instances sharing the database extend the same chain
+type RecordChainHead struct {
+	Owner    string `xorm:"varchar(100) notnull pk" json:"owner"`
+	RecordId int    `json:"recordId"`
+	Hash     string `xorm:"varchar(100)" json:"hash"`
+}
+
+// RecordCheckpoint signs the head of the record chain of an organization with the key of a cert, so that
+// the records deleted from the end of the chain are detected too
+type RecordCheckpoint struct {
+	Id          int    `xorm:"int notnull pk autoincr" json:"id"`
+	Owner       string `xorm:"varchar(100) index" json:"owner"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	RecordId  int    `xorm:"index" json:"recordId"`
+	Hash      string `xorm:"varchar(100)" json:"hash"`
+	Cert      string `xorm:"varchar(100)" json:"cert"`
+	Signature string `xorm:"mediumtext" json:"signature"`
+}
+
+// RecordChainResult is the outcome of the verification of the record chain of an organization,
+// BrokenRecordId or BrokenCheckpointId tells the first broken link
+type RecordChainResult struct {
+	Organization       string `json:"organization"`
+	IsValid            bool   `json:"isValid"`
+	RecordCount        int    `json:"recordCount"`
+	UnchainedCount     int    `json:"unchainedCount"`
+	ArchivedCount      int    `json:"archivedCount"`
+	CheckpointCount    int    `json:"checkpointCount"`
+	BrokenRecordId     int    `json:"brokenRecordId"`
+	BrokenCheckpointId int    `json:"brokenCheckpointId"`
+	Error              string `json:"error"`
+}
+
+// recordChainLock only saves the retries of the records inserted by the same process,
+// the chain itself is protected by the compare-and-swap of its head
+var recordChainLock sync.Mutex
+
+// getRecordHash hashes the content of the record together with the hash of the previous record of its organization
+func getRecordHash(record *Record) string {
+	content, _ := json.Marshal([]string{
+		record.PrevHash,
+		record.Owner,
+		record.Name,
+		record.CreatedTime,
+		record.Organization,
+		record.ClientIp,
+		record.User,
+		record.Method,
+		record.RequestUri,
+		record.Action,
+		record.Impersonator,
+		util.BoolToString(record.IsTriggered),
+	})
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+func getLastRecord(owner string) *Record {
+	record := Record{}
+	existed, err := adapter.Engine.Where("owner = ?", owner).Desc("id").Get(&record)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &record
+	}
+	return nil
+}
+
+func getRecordChainHead(owner string) *RecordChainHead {
+	head := RecordChainHead{Owner: owner}
+	existed, err := adapter.Engine.Get(&head)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &head
+	}
+
+	// the chains started before the heads were stored continue from their last record
+	if lastRecord := getLastRecord(owner); lastRecord != nil {
+		head.RecordId = lastRecord.Id
+		head.Hash = lastRecord.Hash
+	}
+	_, err = adapter.Engine.Insert(&head)
+	if err != nil {
+		// another instance has created the head in the meantime
+		head = RecordChainHead{Owner: owner}
+		existed, err = adapter.Engine.Get(&head)
+		if err != nil {
+			panic(err)
+		}
+		if !existed {
+			panic(fmt.Errorf("failed to create the head of the record chain of the organization: %s", owner))
+		}
+	}
+	return &head
+}
+
+// tryInsertChainedRecord saves the record and moves the head to it in a transaction, it returns false
+// when another instance has moved the head since it was read, then nothing is saved
+func tryInsertChainedRecord(record *Record, head *RecordChainHead) (bool, bool) {
+	session := adapter.Engine.NewSession()
+	defer session.Close()
+
+	err := session.Begin()
+	if err != nil {
+		panic(err)
+	}
+
+	affected, err := session.Insert(record)
+	if err != nil {
+		panic(err)
+	}
+
+	// the row of the head stays locked until the commit, so a concurrent update waits and then fails the comparison
+	swapped, err := session.Where("owner = ? and hash = ?", head.Owner, head.Hash).Cols("record_id", "hash").
+		Update(&RecordChainHead{RecordId: record.Id, Hash: record.Hash})
+	if err != nil {
+		panic(err)
+	}
+
+	if swapped == 0 {
+		err = session.Rollback()
+		if err != nil {
+			panic(err)
+		}
+		return false, false
+	}
+
+	err = session.Commit()
+	if err != nil {
+		panic(err)
+	}
+	return affected != 0, true
+}
+
+// insertChainedRecord links the record to the last record of its organization and saves it
+func insertChainedRecord(record *Record) bool {
+	recordChainLock.Lock()
+	defer recordChainLock.Unlock()
+
+	for i := 0; i < recordChainMaxRetries; i++ {
+		head := getRecordChainHead(record.Owner)
+		record.Id = 0
+		record.PrevHash = head.Hash
+		record.Hash = getRecordHash(record)
+
+		affected, ok := tryInsertChainedRecord(record, head)
+		if ok {
+			return affected
+		}
+	}
+
+	panic(fmt.Errorf("failed to link the record to the chain of the organization: %s after %d retries", record.Owner, recordChainMaxRetries))
+}
+
+func getRecordCheckpointContent(checkpoint *RecordCheckpoint) []byte {
+	return []byte(fmt.Sprintf("%s\n%d\n%s\n%s", checkpoint.Owner, checkpoint.RecordId, checkpoint.Hash, checkpoint.CreatedTime))
+}
+
+func GetRecordCheckpoints(owner string) []*RecordCheckpoint {
+	checkpoints := []*RecordCheckpoint{}
+	err := adapter.Engine.Asc("id").Find(&checkpoints, &RecordCheckpoint{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return checkpoints
+}
+
+// AddRecordCheckpoint signs the hash of the last record of the organization with the private key of the cert
+func AddRecordCheckpoint(organization string, certId string) (*RecordCheckpoint, error) {
+	cert := GetCert(certId)
+	if cert == nil {
+		return nil, fmt.Errorf("the cert: %s doesn't exist", certId)
+	}
+
+	lastRecord := getLastRecord(organization)
+	if lastRecord == nil || lastRecord.Hash == "" {
+		return nil, fmt.Errorf("the organization: %s has no chained record", organization)
+	}
+
+	checkpoint := &RecordCheckpoint{
+		Owner:       organization,
+		CreatedTime: util.GetCurrentTime(),
+		RecordId:    lastRecord.Id,
+		Hash:        lastRecord.Hash,
+		Cert:        certId,
+	}
+	err := signRecordCheckpoint(checkpoint, cert.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = adapter.Engine.Insert(checkpoint)
+	if err != nil {
+		panic(err)
+	}
+
+	return checkpoint, nil
+}
+
+func signRecordCheckpoint(checkpoint *RecordCheckpoint, privateKey string) error {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKey))
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(getRecordCheckpointContent(checkpoint))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return err
+	}
+
+	checkpoint.Signature = base64.StdEncoding.EncodeToString(signature)
+	return nil
+}
+
+func verifyRecordCheckpoint(checkpoint *RecordCheckpoint) error {
+	cert := GetCert(checkpoint.Cert)
+	if cert == nil {
+		return fmt.Errorf("the cert: %s of checkpoint %d doesn't exist", checkpoint.Cert, checkpoint.Id)
+	}
+
+	return verifyRecordCheckpointSignature(checkpoint, cert.PublicKey)
+}
+
+func verifyRecordCheckpointSignature(checkpoint *RecordCheckpoint, publicKey string) error {
+	key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(publicKey))
+	if err != nil {
+		return err
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(getRecordCheckpointContent(checkpoint))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	if err != nil {
+		return fmt.Errorf("the signature of checkpoint %d is invalid", checkpoint.Id)
+	}
+	return nil
+}
+
+// VerifyRecordChain walks the records of the organization in order and checks that each record links to the previous
+// one and matches its hash, then that the signed checkpoints match the records. The records saved before the chain
+// was introduced are counted as unchained, the archived records are skipped
+func VerifyRecordChain(organization string) *RecordChainResult {
+	res := &RecordChainResult{Organization: organization}
+
+	checkpoints := GetRecordCheckpoints(organization)
+	res.CheckpointCount = len(checkpoints)
+	checkpointMap := map[int][]*RecordCheckpoint{}
+	for _, checkpoint := range checkpoints {
+		err := verifyRecordCheckpoint(checkpoint)
+		if err != nil {
+			res.BrokenCheckpointId = checkpoint.Id
+			res.Error = err.Error()
+			return res
+		}
+		checkpointMap[checkpoint.RecordId] = append(checkpointMap[checkpoint.RecordId], checkpoint)
+	}
+
+	// the chain of the remaining records starts from the last archived record
+	prevHash := ""
+	lastId := 0
+	archivedId := 0
+	for _, archive := range GetRecordArchives(organization) {
+		res.ArchivedCount += archive.Count
+		prevHash = archive.LastHash
+		archivedId = archive.EndRecordId
+	}
+	isChained := prevHash != ""
+	for {
+		records := []*Record{}
+		err := adapter.Engine.Where("owner = ? and id > ?", organization, lastId).Asc("id").Limit(recordChainBatchSize).Find(&records)
+		if err != nil {
+			panic(err)
+		}
+
+		for _, record := range records {
+			lastId = record.Id
+			if !isChained && record.Hash == "" && record.PrevHash == "" {
+				res.UnchainedCount++
+				continue
+			}
+			isChained = true
+
+			if record.PrevHash != prevHash {
+				res.BrokenRecordId = record.Id
+				res.Error = fmt.Sprintf("record %d doesn't link to the previous record, a record before it has been deleted or changed", record.Id)
+				return res
+			}
+			if getRecordHash(record) != record.Hash {
+				res.BrokenRecordId = record.Id
+				res.Error = fmt.Sprintf("the content of record %d doesn't match its hash", record.Id)
+				return res
+			}
+
+			for _, checkpoint := range checkpointMap[record.Id] {
+				if checkpoint.Hash != record.Hash {
+					res.BrokenCheckpointId = checkpoint.Id
+					res.Error = fmt.Sprintf("record %d doesn't match checkpoint %d", record.Id, checkpoint.Id)
+					return res
+				}
+			}
+			delete(checkpointMap, record.Id)
+
+			prevHash = record.Hash
+			res.RecordCount++
+		}
+
+		if len(records) < recordChainBatchSize {
+			break
+		}
+	}
+
+	for _, checkpoint := range checkpoints {
+		if _, ok := checkpointMap[checkpoint.RecordId]; ok && checkpoint.RecordId > archivedId {
+			res.BrokenCheckpointId = checkpoint.Id
+			res.Error = fmt.Sprintf("record %d of checkpoint %d has been deleted", checkpoint.RecordId, checkpoint.Id)
+			return res
+		}
+	}
+
+	res.IsValid = true
+	return res
+}
+
+// InitRecordCheckpointer signs a checkpoint of each organization with new records at the interval of
+// recordCheckpointInterval hours (24 by default), when the cert is set by recordCheckpointCert
+func InitRecordCheckpointer() {
+	certId := conf.GetConfigString("recordCheckpointCert")
+	if certId == "" {
+		return
+	}
+
+	interval, err := conf.GetConfigInt64("recordCheckpointInterval")
+	if err != nil || interval <= 0 {
+		interval = 24
+	}
+
+	util.SafeGoroutine(func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Hour)
+		defer ticker.Stop()
+		for {
+			addRecordCheckpoints(certId)
+			<-ticker.C
+		}
+	})
+}
+
+func addRecordCheckpoints(certId string) {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Error("record checkpointer panic: %v", r)
+		}
+	}()
+
+	owners := []string{}
+	err := adapter.Engine.Table(&Record{}).Distinct("owner").Find(&owners)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, owner := range owners {
+		lastCheckpoint := RecordCheckpoint{}
+		existed, err := adapter.Engine.Where("owner = ?", owner).Desc("id").Get(&lastCheckpoint)
+		if err != nil {
+			panic(err)
+		}
+
+		lastRecord := getLastRecord(owner)
+		if lastRecord == nil || lastRecord.Hash == "" || (existed && lastCheckpoint.RecordId == lastRecord.Id) {
+			continue
+		}
+
+		_, err = AddRecordCheckpoint(owner, certId)
+		if err != nil {
+			logs.Error("failed to add the record checkpoint of organization: %s: %s", owner, err.Error())
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRecordHash(t *testing.T) {
+	first := &Record{Owner: "built-in", Name: "1", Organization: "built-in", User: "admin", Action: "login"}
+	first.Hash = getRecordHash(first)
+	second := &Record{Owner: "built-in", Name: "2", Organization: "built-in", User: "admin", Action: "logout", PrevHash: first.Hash}
+	second.Hash = getRecordHash(second)
+
+	assert.Equal(t, 64, len(first.Hash))
+	assert.Equal(t, second.Hash, getRecordHash(second))
+
+	first.User = "alice"
+	assert.NotEqual(t, first.Hash, getRecordHash(first))
+
+	second.PrevHash = ""
+	assert.NotEqual(t, second.Hash, getRecordHash(second))
+}
+
+func TestSignRecordCheckpoint(t *testing.T) {
+	publicKey, privateKey := generateRsaKeys(2048, 1, "casdoor", "casdoor")
+	checkpoint := &RecordCheckpoint{Owner: "built-in", RecordId: 42, Hash: "abc", CreatedTime: "2022-01-01T00:00:00Z"}
+
+	err := signRecordCheckpoint(checkpoint, privateKey)
+	assert.Nil(t, err)
+	assert.Nil(t, verifyRecordCheckpointSignature(checkpoint, publicKey))
+
+	checkpoint.RecordId = 41
+	assert.NotNil(t, verifyRecordCheckpointSignature(checkpoint, publicKey))
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	RecordExportFormatCsv   = "csv"
+	RecordExportFormatJsonl = "jsonl"
+)
+
+var recordExportFields = []string{"id", "owner", "name", "createdTime", "organization", "clientIp", "user", "method", "requestUri", "action", "impersonator", "isTriggered", "prevHash", "hash"}
+
+// RecordExportFilter selects the records to export, StartTime is inclusive and EndTime exclusive,
+// Field and Value filter like the list APIs and Fields are the exported fields, all of them if empty
+type RecordExportFilter struct {
+	Organization string
+	StartTime    string
+	EndTime      string
+	Field        string
+	Value        string
+	Fields       []string
+}
+
+func CheckRecordExportFilter(format string, filter *RecordExportFilter) error {
+	if format != RecordExportFormatCsv && format != RecordExportFormatJsonl {
+		return fmt.Errorf("the export format: %s is not supported, use csv or jsonl", format)
+	}
+
+	for _, field := range filter.Fields {
+		if !util.InSlice(recordExportFields, field) {
+			return fmt.Errorf("the record field: %s doesn't exist", field)
+		}
+	}
+	return nil
+}
+
+func getRecordExportBatch(filter *RecordExportFilter, lastId int) []*Record {
+	session := adapter.Engine.Where("id > ?", lastId)
+	if filter.Organization != "" {
+		session = session.And("owner = ?", filter.Organization)
+	}
+	if filter.StartTime != "" {
+		session = session.And("created_time >= ?", filter.StartTime)
+	}
+	if filter.EndTime != "" {
+		session = session.And("created_time < ?", filter.EndTime)
+	}
+	if filter.Field != "" && filter.Value != "" && isQueryFieldAllowed(filter.Field) {
+		session = session.And(fmt.Sprintf("%s like ?", util.SnakeString(filter.Field)), fmt.Sprintf("%%%s%%", filter.Value))
+	}
+
+	records := []*Record{}
+	err := session.Asc("id").Limit(recordChainBatchSize).Find(&records)
+	if err != nil {
+		panic(err)
+	}
+	return records
+}
+
+func getRecordExportValues(record *Record) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           record.Id,
+		"owner":        record.Owner,
+		"name":         record.Name,
+		"createdTime":  record.CreatedTime,
+		"organization": record.Organization,
+		"clientIp":     record.ClientIp,
+		"user":         record.User,
+		"method":       record.Method,
+		"requestUri":   record.RequestUri,
+		"action":       record.Action,
+		"impersonator": record.Impersonator,
+		"isTriggered":  record.IsTriggered,
+		"prevHash":     record.PrevHash,
+		"hash":         record.Hash,
+	}
+}
+
+// ExportRecords writes the records matching the filter in batches, the writer is flushed after each batch
+// when it supports it so that a large export streams to the client
+func ExportRecords(w io.Writer, format string, filter *RecordExportFilter) error {
+	fields := filter.Fields
+	if len(fields) == 0 {
+		fields = recordExportFields
+	}
+
+	var csvWriter *csv.Writer
+	if format == RecordExportFormatCsv {
+		csvWriter = csv.NewWriter(w)
+		err := csvWriter.Write(fields)
+		if err != nil {
+			return err
+		}
+	}
+	encoder := json.NewEncoder(w)
+
+	lastId := 0
+	for {
+		records := getRecordExportBatch(filter, lastId)
+		for _, record := range records {
+			lastId = record.Id
+			values := getRecordExportValues(record)
+
+			var err error
+			if csvWriter != nil {
+				row := []string{}
+				for _, field := range fields {
+					row = append(row, fmt.Sprint(values[field]))
+				}
+				err = csvWriter.Write(row)
+			} else {
+				item := map[string]interface{}{}
+				for _, field := range fields {
+					item[field] = values[field]
+				}
+				err = encoder.Encode(item)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := w.(interface{ Flush() }); ok {
+			flusher.Flush()
+		}
+
+		if len(records) < recordChainBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/util"
+	"xorm.io/core"
+)
+
+const (
+	RecordSinkTypeSyslog = "Syslog"
+	RecordSinkTypeOtlp   = "OTLP"
+)
+
+// RecordSink streams the records to a logging pipeline as they are added, Organization and Actions filter
+// the records, empty for all of them. Protocol, Host and Port are for syslog, Url and Headers for OTLP
+type RecordSink struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Organization string    `xorm:"varchar(100) index" json:"organization"`
+	Type         string    `xorm:"varchar(100)" json:"type"`
+	Protocol     string    `xorm:"varchar(100)" json:"protocol"`
+	Host         string    `xorm:"varchar(100)" json:"host"`
+	Port         int       `json:"port"`
+	Url          string    `xorm:"varchar(500)" json:"url"`
+	Headers      []*Header `xorm:"mediumtext" json:"headers"`
+	Actions      []string  `xorm:"mediumtext" json:"actions"`
+	IsEnabled    bool      `json:"isEnabled"`
+}
+
+func GetRecordSinkCount(owner string, query *Query) int {
+	session := GetSession(&RecordSink{}, owner, -1, -1, query)
+	count, err := session.Count(&RecordSink{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetRecordSinks(owner string) []*RecordSink {
+	sinks := []*RecordSink{}
+	err := adapter.Engine.Desc("created_time").Find(&sinks, &RecordSink{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return sinks
+}
+
+func GetPaginationRecordSinks(owner string, offset, limit int, query *Query) []*RecordSink {
+	sinks := []*RecordSink{}
+	session := GetSession(&RecordSink{}, owner, offset, limit, query)
+	err := session.Find(&sinks)
+	if err != nil {
+		panic(err)
+	}
+
+	return sinks
+}
+
+func getRecordSink(owner string, name string) *RecordSink {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	sink := RecordSink{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&sink)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &sink
+	} else {
+		return nil
+	}
+}
+
+func GetRecordSink(id string) *RecordSink {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getRecordSink(owner, name)
+}
+
+func UpdateRecordSink(id string, sink *RecordSink) bool {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if getRecordSink(owner, name) == nil {
+		return false
+	}
+
+	affected, err := adapter.Engine.ID(core.PK{owner, name}).AllCols().Update(sink)
+	if err != nil {
+		panic(err)
+	}
+
+	if affected != 0 {
+		resetRecordSinkWorkers()
+	}
+
+	return affected != 0
+}
+
+func AddRecordSink(sink *RecordSink) bool {
+	affected, err := adapter.Engine.Insert(sink)
+	if err != nil {
+		panic(err)
+	}
+
+	if affected != 0 {
+		resetRecordSinkWorkers()
+	}
+
+	return affected != 0
+}
+
+func DeleteRecordSink(sink *RecordSink) bool {
+	affected, err := adapter.Engine.ID(core.PK{sink.Owner, sink.Name}).Delete(&RecordSink{})
+	if err != nil {
+		panic(err)
+	}
+
+	if affected != 0 {
+		resetRecordSinkWorkers()
+	}
+
+	return affected != 0
+}
+
+func (sink *RecordSink) GetId() string {
+	return fmt.Sprintf("%s/%s", sink.Owner, sink.Name)
+}
+
+// isRecordMatched tells if the record passes the organization and action filters of the sink
+func (sink *RecordSink) isRecordMatched(record *Record) bool {
+	if sink.Organization != "" && sink.Organization != record.Organization {
+		return false
+	}
+	return len(sink.Actions) == 0 || util.InSlice(sink.Actions, record.Action)
+}
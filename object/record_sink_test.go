@@ -0,0 +1,145 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestSinkRecord() *Record {
+	return &Record{Id: 1, Owner: "built-in", Name: "r1", CreatedTime: "2022-01-01T00:00:00Z", Organization: "built-in", ClientIp: "127.0.0.1",
+		User: "admin", Method: "POST", RequestUri: `/api/login?next="a]"`, Action: "login"}
+}
+
+func TestGetSyslogMessage(t *testing.T) {
+	message := getSyslogMessage(getTestSinkRecord(), "host")
+	assert.True(t, strings.HasPrefix(message, `<110>1 2022-01-01T00:00:00Z host casdoor - login [casdoor@32473 organization="built-in" user="admin" clientIp="127.0.0.1" method="POST" requestUri="/api/login?next=\"a\]\""] {`))
+	assert.Equal(t, "updateuser", getSyslogName("update user", 32))
+	assert.Equal(t, "-", getSyslogName("", 32))
+
+	sink := &RecordSink{Organization: "built-in", Actions: []string{"login"}}
+	assert.True(t, sink.isRecordMatched(getTestSinkRecord()))
+	sink.Actions = []string{"logout"}
+	assert.False(t, sink.isRecordMatched(getTestSinkRecord()))
+	sink = &RecordSink{Organization: "other"}
+	assert.False(t, sink.isRecordMatched(getTestSinkRecord()))
+}
+
+func TestRecordSinkSyslog(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		for {
+			var length int
+			_, err = fmt.Fscanf(reader, "%d ", &length)
+			if err != nil {
+				return
+			}
+			message := make([]byte, length)
+			_, err = io.ReadFull(reader, message)
+			if err != nil {
+				return
+			}
+			messages <- string(message)
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	worker := newRecordSinkWorker(&RecordSink{Name: "tcp", Type: RecordSinkTypeSyslog, Protocol: "tcp", Host: "127.0.0.1", Port: port})
+	defer close(worker.stop)
+	worker.enqueue(getTestSinkRecord())
+	record := getTestSinkRecord()
+	record.Action = "logout"
+	worker.enqueue(record)
+
+	for _, action := range []string{"login", "logout"} {
+		select {
+		case message := <-messages:
+			assert.Contains(t, message, fmt.Sprintf(" casdoor - %s [", action))
+		case <-time.After(5 * time.Second):
+			t.Fatal("no syslog message over TCP")
+		}
+	}
+
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer udpConn.Close()
+
+	port = udpConn.LocalAddr().(*net.UDPAddr).Port
+	udpWorker := newRecordSinkWorker(&RecordSink{Name: "udp", Type: RecordSinkTypeSyslog, Protocol: "udp", Host: "127.0.0.1", Port: port})
+	defer close(udpWorker.stop)
+	udpWorker.enqueue(getTestSinkRecord())
+
+	buf := make([]byte, 4096)
+	_ = udpConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := udpConn.ReadFrom(buf)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(buf[:n]), "<110>1 "))
+}
+
+func TestRecordSinkOtlp(t *testing.T) {
+	requests := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ := ioutil.ReadAll(r.Body)
+		payload := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(body, &payload))
+		requests <- payload
+	}))
+	defer server.Close()
+
+	worker := newRecordSinkWorker(&RecordSink{Name: "otlp", Type: RecordSinkTypeOtlp, Url: server.URL + "/v1/logs", Headers: []*Header{{Name: "Authorization", Value: "Bearer token"}}})
+	defer close(worker.stop)
+	worker.enqueue(getTestSinkRecord())
+
+	select {
+	case payload := <-requests:
+		logRecord := payload["resourceLogs"].([]interface{})[0].(map[string]interface{})["scopeLogs"].([]interface{})[0].(map[string]interface{})["logRecords"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, strconv.FormatInt(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano(), 10), logRecord["timeUnixNano"])
+		assert.Contains(t, logRecord["body"].(map[string]interface{})["stringValue"], `"action":"login"`)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no OTLP request")
+	}
+}
+
+func TestRecordSinkQueueFull(t *testing.T) {
+	worker := &recordSinkWorker{sink: &RecordSink{Owner: "admin", Name: "full"}, queue: make(chan *Record, 1)}
+	worker.enqueue(getTestSinkRecord())
+	worker.enqueue(getTestSinkRecord())
+	assert.Equal(t, int64(1), worker.dropped)
+}
@@ -0,0 +1,360 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	recordSinkQueueSize = 1000
+	recordSinkBatchSize = 100
+	recordSinkTimeout   = 10 * time.Second
+
+	// facility 13 (log audit) and severity 6 (informational)
+	syslogPriority = 13*8 + 6
+	// the structured data ID of the record fields, 32473 is the private enterprise number reserved for examples
+	syslogSdId = "casdoor@32473"
+)
+
+// recordSinkWorker delivers the records of a sink from a bounded queue in its own goroutine,
+// the records are dropped when the queue is full so that a slow sink never blocks AddRecord
+type recordSinkWorker struct {
+	sink     *RecordSink
+	queue    chan *Record
+	stop     chan struct{}
+	dropped  int64
+	hostname string
+	conn     net.Conn
+	client   *http.Client
+}
+
+var (
+	recordSinkLock     sync.Mutex
+	recordSinkWorkers  []*recordSinkWorker
+	isRecordSinkLoaded bool
+)
+
+func getRecordSinkWorkers() []*recordSinkWorker {
+	recordSinkLock.Lock()
+	defer recordSinkLock.Unlock()
+
+	if !isRecordSinkLoaded {
+		for _, sink := range GetRecordSinks("admin") {
+			if sink.IsEnabled {
+				recordSinkWorkers = append(recordSinkWorkers, newRecordSinkWorker(sink))
+			}
+		}
+		isRecordSinkLoaded = true
+	}
+	return recordSinkWorkers
+}
+
+// resetRecordSinkWorkers stops the workers, they are started again from the saved sinks by the next record
+func resetRecordSinkWorkers() {
+	recordSinkLock.Lock()
+	defer recordSinkLock.Unlock()
+
+	for _, worker := range recordSinkWorkers {
+		close(worker.stop)
+	}
+	recordSinkWorkers = nil
+	isRecordSinkLoaded = false
+}
+
+// sendRecordToSinks queues a copy of the record for each sink whose filters match it
+func sendRecordToSinks(record *Record) {
+	for _, worker := range getRecordSinkWorkers() {
+		if worker.sink.isRecordMatched(record) {
+			r := *record
+			r.ExtendedUser = nil
+			worker.enqueue(&r)
+		}
+	}
+}
+
+func newRecordSinkWorker(sink *RecordSink) *recordSinkWorker {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	worker := &recordSinkWorker{
+		sink:     sink,
+		queue:    make(chan *Record, recordSinkQueueSize),
+		stop:     make(chan struct{}),
+		hostname: getSyslogName(hostname, 255),
+		client:   &http.Client{Timeout: recordSinkTimeout},
+	}
+	util.SafeGoroutine(worker.run)
+	return worker
+}
+
+func (w *recordSinkWorker) enqueue(record *Record) {
+	select {
+	case w.queue <- record:
+	default:
+		dropped := atomic.AddInt64(&w.dropped, 1)
+		if dropped == 1 || dropped%1000 == 0 {
+			logs.Warn("record sink: %s is full, %d records have been dropped", w.sink.GetId(), dropped)
+		}
+	}
+}
+
+func (w *recordSinkWorker) run() {
+	defer func() {
+		if w.conn != nil {
+			w.conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case record := <-w.queue:
+			records := []*Record{record}
+			for len(records) < recordSinkBatchSize && len(w.queue) > 0 {
+				records = append(records, <-w.queue)
+			}
+
+			err := w.send(records)
+			if err != nil {
+				logs.Error("record sink: %s failed to send %d records: %s", w.sink.GetId(), len(records), err.Error())
+			}
+		}
+	}
+}
+
+func (w *recordSinkWorker) send(records []*Record) error {
+	switch w.sink.Type {
+	case RecordSinkTypeSyslog:
+		return w.sendSyslog(records)
+	case RecordSinkTypeOtlp:
+		return w.sendOtlp(records)
+	default:
+		return fmt.Errorf("the record sink type: %s is not supported", w.sink.Type)
+	}
+}
+
+func (w *recordSinkWorker) dialSyslog() (net.Conn, error) {
+	port := w.sink.Port
+	if port == 0 {
+		port = 514
+		if w.sink.Protocol == "tls" {
+			port = 6514
+		}
+	}
+
+	address := net.JoinHostPort(w.sink.Host, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: recordSinkTimeout}
+	switch w.sink.Protocol {
+	case "udp":
+		return dialer.Dial("udp", address)
+	case "tls":
+		return tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: w.sink.Host})
+	default:
+		return dialer.Dial("tcp", address)
+	}
+}
+
+// sendSyslog writes a message per record, with octet counting framing over TCP and TLS (RFC 6587),
+// a broken connection is opened again once per batch
+func (w *recordSinkWorker) sendSyslog(records []*Record) error {
+	isRetried := false
+	for i := 0; i < len(records); {
+		if w.conn == nil {
+			conn, err := w.dialSyslog()
+			if err != nil {
+				return err
+			}
+			w.conn = conn
+		}
+
+		message := getSyslogMessage(records[i], w.hostname)
+		if w.sink.Protocol != "udp" {
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+
+		_ = w.conn.SetWriteDeadline(time.Now().Add(recordSinkTimeout))
+		_, err := io.WriteString(w.conn, message)
+		if err != nil {
+			w.conn.Close()
+			w.conn = nil
+			if isRetried {
+				return err
+			}
+			isRetried = true
+			continue
+		}
+		i++
+	}
+	return nil
+}
+
+// getSyslogName keeps the printable ASCII characters of a header field of a syslog message
+func getSyslogName(s string, maxLength int) string {
+	res := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+	if len(res) > maxLength {
+		res = res[:maxLength]
+	}
+	if res == "" {
+		return "-"
+	}
+	return res
+}
+
+func escapeSyslogParam(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(s)
+}
+
+// getSyslogMessage formats the record as an RFC 5424 message, the record fields are in the structured data
+// and the message is the record as JSON
+func getSyslogMessage(record *Record, hostname string) string {
+	timestamp := record.CreatedTime
+	if timestamp == "" {
+		timestamp = "-"
+	}
+
+	params := []string{}
+	for _, param := range [][]string{
+		{"organization", record.Organization},
+		{"user", record.User},
+		{"clientIp", record.ClientIp},
+		{"method", record.Method},
+		{"requestUri", record.RequestUri},
+	} {
+		params = append(params, fmt.Sprintf(`%s="%s"`, param[0], escapeSyslogParam(param[1])))
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s casdoor - %s [%s %s] %s", syslogPriority, timestamp, hostname, getSyslogName(record.Action, 32),
+		syslogSdId, strings.Join(params, " "), util.StructToJson(record))
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string          `json:"timeUnixNano"`
+	SeverityNumber int             `json:"severityNumber"`
+	SeverityText   string          `json:"severityText"`
+	Body           otlpValue       `json:"body"`
+	Attributes     []otlpAttribute `json:"attributes"`
+}
+
+func getOtlpAttributes(pairs ...string) []otlpAttribute {
+	attributes := []otlpAttribute{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		attributes = append(attributes, otlpAttribute{Key: pairs[i], Value: otlpValue{StringValue: pairs[i+1]}})
+	}
+	return attributes
+}
+
+// getOtlpPayload builds an OTLP/HTTP JSON logs request with a log record per record
+func getOtlpPayload(records []*Record) []byte {
+	logRecords := []otlpLogRecord{}
+	for _, record := range records {
+		createdTime, err := time.Parse(time.RFC3339, record.CreatedTime)
+		if err != nil {
+			createdTime = time.Now()
+		}
+
+		logRecords = append(logRecords, otlpLogRecord{
+			TimeUnixNano:   strconv.FormatInt(createdTime.UnixNano(), 10),
+			SeverityNumber: 9,
+			SeverityText:   "INFO",
+			Body:           otlpValue{StringValue: util.StructToJson(record)},
+			Attributes: getOtlpAttributes(
+				"casdoor.record.id", strconv.Itoa(record.Id),
+				"casdoor.organization", record.Organization,
+				"casdoor.user", record.User,
+				"casdoor.action", record.Action,
+				"client.address", record.ClientIp,
+				"http.request.method", record.Method,
+				"url.path", record.RequestUri,
+			),
+		})
+	}
+
+	payload := map[string]interface{}{
+		"resourceLogs": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": getOtlpAttributes("service.name", "casdoor"),
+				},
+				"scopeLogs": []interface{}{
+					map[string]interface{}{
+						"scope":      map[string]interface{}{"name": "casdoor"},
+						"logRecords": logRecords,
+					},
+				},
+			},
+		},
+	}
+
+	data, _ := json.Marshal(payload)
+	return data
+}
+
+func (w *recordSinkWorker) sendOtlp(records []*Record) error {
+	req, err := http.NewRequest("POST", w.sink.Url, bytes.NewReader(getOtlpPayload(records)))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for _, header := range w.sink.Headers {
+		req.Header.Set(header.Name, header.Value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := ioutil.ReadAll(&io.LimitedReader{R: resp.Body, N: webhookMaxResponseSize})
+		return fmt.Errorf("the OTLP endpoint returned status %d: %s", resp.StatusCode, string(data))
+	}
+	return nil
+}
@@ -39,8 +39,8 @@ type Resource struct {
 	Description string `xorm:"varchar(1000)" json:"description"`
 }
 
-func GetResourceCount(owner, user, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetResourceCount(owner, user string, query *Query) int {
+	session := GetSession(&Resource{}, owner, -1, -1, query)
 	count, err := session.Count(&Resource{User: user})
 	if err != nil {
 		panic(err)
@@ -64,14 +64,14 @@ func GetResources(owner string, user string) []*Resource {
 	return resources
 }
 
-func GetPaginationResources(owner, user string, offset, limit int, field, value, sortField, sortOrder string) []*Resource {
+func GetPaginationResources(owner, user string, offset, limit int, query *Query) []*Resource {
 	if owner == "built-in" {
 		owner = ""
 		user = ""
 	}
 
 	resources := []*Resource{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Resource{}, owner, offset, limit, query)
 	err := session.Find(&resources, &Resource{User: user})
 	if err != nil {
 		panic(err)
@@ -30,10 +30,14 @@ type Role struct {
 	Users     []string `xorm:"mediumtext" json:"users"`
 	Roles     []string `xorm:"mediumtext" json:"roles"`
 	IsEnabled bool     `json:"isEnabled"`
+
+	// Ldap and LdapDn are set for the roles synced from an LDAP group, the sync never touches the other roles
+	Ldap   string `xorm:"varchar(100) index" json:"ldap"`
+	LdapDn string `xorm:"varchar(500)" json:"ldapDn"`
 }
 
-func GetRoleCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetRoleCount(owner string, query *Query) int {
+	session := GetSession(&Role{}, owner, -1, -1, query)
 	count, err := session.Count(&Role{})
 	if err != nil {
 		panic(err)
@@ -52,9 +56,9 @@ func GetRoles(owner string) []*Role {
 	return roles
 }
 
-func GetPaginationRoles(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Role {
+func GetPaginationRoles(owner string, offset, limit int, query *Query) []*Role {
 	roles := []*Role{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Role{}, owner, offset, limit, query)
 	err := session.Find(&roles)
 	if err != nil {
 		panic(err)
@@ -88,7 +92,8 @@ func GetRole(id string) *Role {
 
 func UpdateRole(id string, role *Role) bool {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	if getRole(owner, name) == nil {
+	oldRole := getRole(owner, name)
+	if oldRole == nil {
 		return false
 	}
 
@@ -97,9 +102,29 @@ func UpdateRole(id string, role *Role) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		publishRoleMembershipEvent(oldRole, role)
+	}
+
 	return affected != 0
 }
 
+// publishRoleMembershipEvent publishes the users and the sub-roles added to or removed from the role, if any
+func publishRoleMembershipEvent(oldRole *Role, role *Role) {
+	addedUsers, removedUsers := util.DiffSlices(oldRole.Users, role.Users)
+	addedRoles, removedRoles := util.DiffSlices(oldRole.Roles, role.Roles)
+	if len(addedUsers)+len(removedUsers)+len(addedRoles)+len(removedRoles) == 0 {
+		return
+	}
+
+	event := NewEvent(EventRoleMembershipChanged, role.Owner, role.GetId(), oldRole, role)
+	event.Data["addedUsers"] = addedUsers
+	event.Data["removedUsers"] = removedUsers
+	event.Data["addedRoles"] = addedRoles
+	event.Data["removedRoles"] = removedRoles
+	PublishEvent(event)
+}
+
 func AddRole(role *Role) bool {
 	affected, err := adapter.Engine.Insert(role)
 	if err != nil {
@@ -121,3 +146,19 @@ func DeleteRole(role *Role) bool {
 func (role *Role) GetId() string {
 	return fmt.Sprintf("%s/%s", role.Owner, role.Name)
 }
+
+func getRolesByUser(userId string) []*Role {
+	roles := []*Role{}
+	err := adapter.Engine.Where("users like ?", "%"+userId+"%").Find(&roles)
+	if err != nil {
+		panic(err)
+	}
+
+	res := []*Role{}
+	for _, role := range roles {
+		if role.IsEnabled && util.InSlice(role.Users, userId) {
+			res = append(res, role)
+		}
+	}
+	return res
+}
@@ -24,19 +24,18 @@ import (
 	"encoding/pem"
 	"encoding/xml"
 	"fmt"
-	"io"
+	"strings"
 	"time"
 
 	"github.com/RobotsAndPencils/go-saml"
 	"github.com/astaxie/beego"
 	"github.com/beevik/etree"
 	"github.com/golang-jwt/jwt/v4"
-	dsig "github.com/russellhaering/goxmldsig"
 	uuid "github.com/satori/go.uuid"
 )
 
 //returns a saml2 response
-func NewSamlResponse(user *User, host string, publicKey string, destination string, iss string, requestId string, redirectUri []string) (*etree.Element, error) {
+func NewSamlResponse(user *User, host string, publicKey string, destination string, samlSp *SamlSp, requestId string) (*etree.Element, error) {
 	samlResponse := &etree.Element{
 		Space: "samlp",
 		Tag:   "Response",
@@ -51,7 +50,10 @@ func NewSamlResponse(user *User, host string, publicKey string, destination stri
 	samlResponse.CreateAttr("Version", "2.0")
 	samlResponse.CreateAttr("IssueInstant", now)
 	samlResponse.CreateAttr("Destination", destination)
-	samlResponse.CreateAttr("InResponseTo", requestId)
+	if requestId != "" {
+		// an IdP-initiated response is unsolicited
+		samlResponse.CreateAttr("InResponseTo", requestId)
+	}
 	samlResponse.CreateElement("saml:Issuer").SetText(host)
 
 	samlResponse.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", "urn:oasis:names:tc:SAML:2.0:status:Success")
@@ -64,21 +66,22 @@ func NewSamlResponse(user *User, host string, publicKey string, destination stri
 	assertion.CreateAttr("IssueInstant", now)
 	assertion.CreateElement("saml:Issuer").SetText(host)
 	subject := assertion.CreateElement("saml:Subject")
-	subject.CreateElement("saml:NameID").SetText(user.Email)
+	nameId := subject.CreateElement("saml:NameID")
+	nameId.CreateAttr("Format", samlSp.getNameIdFormat())
+	nameId.SetText(samlSp.getNameId(user))
 	subjectConfirmation := subject.CreateElement("saml:SubjectConfirmation")
 	subjectConfirmation.CreateAttr("Method", "urn:oasis:names:tc:SAML:2.0:cm:bearer")
 	subjectConfirmationData := subjectConfirmation.CreateElement("saml:SubjectConfirmationData")
-	subjectConfirmationData.CreateAttr("InResponseTo", requestId)
+	if requestId != "" {
+		subjectConfirmationData.CreateAttr("InResponseTo", requestId)
+	}
 	subjectConfirmationData.CreateAttr("Recipient", destination)
 	subjectConfirmationData.CreateAttr("NotOnOrAfter", expireTime)
 	condition := assertion.CreateElement("saml:Conditions")
 	condition.CreateAttr("NotBefore", now)
 	condition.CreateAttr("NotOnOrAfter", expireTime)
 	audience := condition.CreateElement("saml:AudienceRestriction")
-	audience.CreateElement("saml:Audience").SetText(iss)
-	for _, value := range redirectUri {
-		audience.CreateElement("saml:Audience").SetText(value)
-	}
+	audience.CreateElement("saml:Audience").SetText(samlSp.EntityId)
 	authnStatement := assertion.CreateElement("saml:AuthnStatement")
 	authnStatement.CreateAttr("AuthnInstant", now)
 	authnStatement.CreateAttr("SessionIndex", fmt.Sprintf("_%s", uuid.NewV4()))
@@ -86,18 +89,24 @@ func NewSamlResponse(user *User, host string, publicKey string, destination stri
 	authnStatement.CreateElement("saml:AuthnContext").CreateElement("saml:AuthnContextClassRef").SetText("urn:oasis:names:tc:SAML:2.0:ac:classes:PasswordProtectedTransport")
 
 	attributes := assertion.CreateElement("saml:AttributeStatement")
-	email := attributes.CreateElement("saml:Attribute")
-	email.CreateAttr("Name", "Email")
-	email.CreateAttr("NameFormat", "urn:oasis:names:tc:SAML:2.0:attrname-format:basic")
-	email.CreateElement("saml:AttributeValue").CreateAttr("xsi:type", "xs:string").Element().SetText(user.Email)
-	name := attributes.CreateElement("saml:Attribute")
-	name.CreateAttr("Name", "Name")
-	name.CreateAttr("NameFormat", "urn:oasis:names:tc:SAML:2.0:attrname-format:basic")
-	name.CreateElement("saml:AttributeValue").CreateAttr("xsi:type", "xs:string").Element().SetText(user.Name)
-	displayName := attributes.CreateElement("saml:Attribute")
-	displayName.CreateAttr("Name", "DisplayName")
-	displayName.CreateAttr("NameFormat", "urn:oasis:names:tc:SAML:2.0:attrname-format:basic")
-	displayName.CreateElement("saml:AttributeValue").CreateAttr("xsi:type", "xs:string").Element().SetText(user.DisplayName)
+	for _, item := range samlSp.getAttributes() {
+		values := getSamlAttributeValues(user, item.Value)
+		if item.Value == "" || len(values) == 0 {
+			continue
+		}
+
+		attribute := attributes.CreateElement("saml:Attribute")
+		attribute.CreateAttr("Name", item.Name)
+		if item.NameFormat != "" {
+			attribute.CreateAttr("NameFormat", item.NameFormat)
+		}
+		if item.FriendlyName != "" {
+			attribute.CreateAttr("FriendlyName", item.FriendlyName)
+		}
+		for _, value := range values {
+			attribute.CreateElement("saml:AttributeValue").CreateAttr("xsi:type", "xs:string").Element().SetText(value)
+		}
+	}
 
 	return samlResponse, nil
 
@@ -149,10 +158,12 @@ type KeyDescriptor struct {
 type IdpSSODescriptor struct {
 	XMLName                    xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:metadata IDPSSODescriptor"`
 	ProtocolSupportEnumeration string   `xml:"protocolSupportEnumeration,attr"`
+	WantAuthnRequestsSigned    bool     `xml:"WantAuthnRequestsSigned,attr,omitempty"`
 	SigningKeyDescriptor       KeyDescriptor
-	NameIDFormats              []NameIDFormat      `xml:"NameIDFormat"`
-	SingleSignOnService        SingleSignOnService `xml:"SingleSignOnService"`
-	Attribute                  []Attribute         `xml:"Attribute"`
+	NameIDFormats              []NameIDFormat        `xml:"NameIDFormat"`
+	SingleSignOnService        SingleSignOnService   `xml:"SingleSignOnService"`
+	SingleLogoutServices       []SingleLogoutService `xml:"SingleLogoutService"`
+	Attribute                  []Attribute           `xml:"Attribute"`
 }
 
 type NameIDFormat struct {
@@ -166,6 +177,12 @@ type SingleSignOnService struct {
 	Location string `xml:"Location,attr"`
 }
 
+type SingleLogoutService struct {
+	XMLName  xml.Name
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+}
+
 type Attribute struct {
 	XMLName      xml.Name
 	Name         string `xml:"Name,attr"`
@@ -215,9 +232,14 @@ func GetSamlMeta(application *Application, host string) (*IdpEntityDescriptor, e
 				{Xmlns: "urn:oasis:names:tc:SAML:2.0:assertion", Name: "Name", NameFormat: "urn:oasis:names:tc:SAML:2.0:attrname-format:basic", FriendlyName: "Name"},
 			},
 			SingleSignOnService: SingleSignOnService{
-				Binding:  "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect",
+				Binding:  SamlBindingRedirect,
 				Location: fmt.Sprintf("%s/login/saml/authorize/%s/%s", originFrontend, application.Owner, application.Name),
 			},
+			SingleLogoutServices: []SingleLogoutService{
+				{Binding: SamlBindingRedirect, Location: fmt.Sprintf("%s/api/saml/slo/%s/%s", originBackend, application.Owner, application.Name)},
+				{Binding: SamlBindingPost, Location: fmt.Sprintf("%s/api/saml/slo/%s/%s", originBackend, application.Owner, application.Name)},
+			},
+			WantAuthnRequestsSigned:    application.RequireSamlSignedRequest,
 			ProtocolSupportEnumeration: "urn:oasis:names:tc:SAML:2.0:protocol",
 		},
 	}
@@ -226,28 +248,82 @@ func GetSamlMeta(application *Application, host string) (*IdpEntityDescriptor, e
 }
 
 // GetSamlResponse generates a SAML2.0 response
-// parameter samlRequest is saml request in base64 format
-func GetSamlResponse(application *Application, user *User, samlRequest string, host string) (string, string, error) {
-	// base64 decode
-	defated, err := base64.StdEncoding.DecodeString(samlRequest)
+// parameter samlRequest is saml request in base64 format, samlQuery is the raw query string of the
+// HTTP-Redirect binding as it was received, it is used to verify signed requests
+func GetSamlResponse(application *Application, user *User, samlRequest string, samlQuery string, host string) (string, string, error) {
+	// base64 decode and decompress
+	xmlBytes, err := decodeSamlMessage(samlRequest, SamlBindingRedirect)
 	if err != nil {
 		return "", "", fmt.Errorf("err: %s", err.Error())
 	}
-	// decompress
-	var buffer bytes.Buffer
-	rdr := flate.NewReader(bytes.NewReader(defated))
-	io.Copy(&buffer, rdr)
 	var authnRequest saml.AuthnRequest
-	err = xml.Unmarshal(buffer.Bytes(), &authnRequest)
+	err = xml.Unmarshal(xmlBytes, &authnRequest)
 	if err != nil {
 		return "", "", fmt.Errorf("err: %s", err.Error())
 	}
 
 	// verify samlRequest
-	if valid := CheckRedirectUriValid(application, authnRequest.Issuer.Url); !valid {
-		return "", "", fmt.Errorf("err: invalid issuer url")
+	issuer := strings.TrimSpace(authnRequest.Issuer.Url)
+	samlSp, err := getSamlSpByEntityId(application, issuer)
+	if err != nil {
+		return "", "", fmt.Errorf("err: %s", err.Error())
+	}
+
+	doc := etree.NewDocument()
+	err = doc.ReadFromBytes(xmlBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("err: %s", err.Error())
+	}
+	el, err := verifySamlRequestSignature(application, samlSp, doc.Root(), "SAMLRequest", samlQuery)
+	if err != nil {
+		return "", "", fmt.Errorf("err: %s", err.Error())
+	}
+
+	// only the verified element is trusted from here on
+	authnRequest = saml.AuthnRequest{}
+	err = unmarshalSamlElement(el, &authnRequest)
+	if err != nil {
+		return "", "", fmt.Errorf("err: %s", err.Error())
+	}
+	if strings.TrimSpace(authnRequest.Issuer.Url) != issuer {
+		return "", "", fmt.Errorf("err: the issuer of the signed SAML request doesn't match")
+	}
+
+	// the response is only sent to a registered ACS endpoint of the SP
+	hasIndex := el.SelectAttr("AssertionConsumerServiceIndex") != nil
+	acsEndpoint, err := samlSp.getAcsEndpoint(authnRequest.AssertionConsumerServiceURL, authnRequest.AssertionConsumerServiceIndex, hasIndex)
+	if err != nil {
+		return "", "", fmt.Errorf("err: %s", err.Error())
+	}
+
+	res, err := buildSamlResponse(application, samlSp, user, host, acsEndpoint.Location, authnRequest.ID)
+	if err != nil {
+		return "", "", err
+	}
+	return res, acsEndpoint.Location, nil
+}
+
+// GetSamlIdpInitiatedResponse generates an unsolicited SAML2.0 response for the default ACS endpoint of the registered SP,
+// the first SP of the application is used when entityId is empty
+func GetSamlIdpInitiatedResponse(application *Application, user *User, entityId string, host string) (string, string, error) {
+	samlSp, err := getSamlSpByEntityId(application, entityId)
+	if err != nil {
+		return "", "", fmt.Errorf("err: %s", err.Error())
+	}
+
+	acsEndpoint, err := samlSp.getAcsEndpoint("", 0, false)
+	if err != nil {
+		return "", "", fmt.Errorf("err: %s", err.Error())
 	}
 
+	res, err := buildSamlResponse(application, samlSp, user, host, acsEndpoint.Location, "")
+	if err != nil {
+		return "", "", err
+	}
+	return res, acsEndpoint.Location, nil
+}
+
+func buildSamlResponse(application *Application, samlSp *SamlSp, user *User, host string, destination string, requestId string) (string, error) {
 	// get public key string
 	cert := getCertByApplication(application)
 	block, _ := pem.Decode([]byte(cert.PublicKey))
@@ -256,25 +332,46 @@ func GetSamlResponse(application *Application, user *User, samlRequest string, h
 	_, originBackend := getOriginFromHost(host)
 
 	// build signedResponse
-	samlResponse, _ := NewSamlResponse(user, originBackend, publicKey, authnRequest.AssertionConsumerServiceURL, authnRequest.Issuer.Url, authnRequest.ID, application.RedirectUris)
-	randomKeyStore := &X509Key{
-		PrivateKey:      cert.PrivateKey,
-		X509Certificate: publicKey,
+	samlResponse, _ := NewSamlResponse(user, originBackend, publicKey, destination, samlSp, requestId)
+	ctx, err := getSamlSigningContext(cert)
+	if err != nil {
+		return "", fmt.Errorf("err: %s", err.Error())
 	}
-	ctx := dsig.NewDefaultSigningContext(randomKeyStore)
 	ctx.Hash = crypto.SHA1
+
+	if samlSp.EnableEncryption {
+		// sign the assertion itself so that the SP can still verify it after the decryption
+		assertion := samlResponse.FindElement("./Assertion")
+		assertion.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+		assertionSig, err := ctx.ConstructSignature(assertion, true)
+		if err != nil {
+			return "", fmt.Errorf("err: %s", err.Error())
+		}
+		assertion.InsertChildAt(1, assertionSig)
+
+		encryptedAssertion, err := encryptSamlAssertion(assertion, samlSp.getEncryptionCert())
+		if err != nil {
+			return "", fmt.Errorf("err: failed to encrypt the assertion: %s", err.Error())
+		}
+		samlResponse.RemoveChild(assertion)
+		samlResponse.AddChild(encryptedAssertion)
+	}
+
 	//signedXML, err := ctx.SignEnvelopedLimix(samlResponse)
 	//if err != nil {
-	//	return "", "", fmt.Errorf("err: %s", err.Error())
+	//	return "", fmt.Errorf("err: %s", err.Error())
 	//}
 	sig, err := ctx.ConstructSignature(samlResponse, true)
+	if err != nil {
+		return "", fmt.Errorf("err: %s", err.Error())
+	}
 	samlResponse.InsertChildAt(1, sig)
 
 	doc := etree.NewDocument()
 	doc.SetRoot(samlResponse)
 	xmlBytes, err := doc.WriteToBytes()
 	if err != nil {
-		return "", "", fmt.Errorf("err: %s", err.Error())
+		return "", fmt.Errorf("err: %s", err.Error())
 	}
 
 	// compress
@@ -282,7 +379,7 @@ func GetSamlResponse(application *Application, user *User, samlRequest string, h
 		flated := bytes.NewBuffer(nil)
 		writer, err := flate.NewWriter(flated, flate.DefaultCompression)
 		if err != nil {
-			return "", "", fmt.Errorf("err: %s", err.Error())
+			return "", fmt.Errorf("err: %s", err.Error())
 		}
 		writer.Write(xmlBytes)
 		writer.Close()
@@ -290,7 +387,7 @@ func GetSamlResponse(application *Application, user *User, samlRequest string, h
 	}
 	// base64 encode
 	res := base64.StdEncoding.EncodeToString(xmlBytes)
-	return res, authnRequest.AssertionConsumerServiceURL, nil
+	return res, nil
 }
 
 // NewSamlResponse11 return a saml1.1 response(not 2.0)
@@ -0,0 +1,151 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	uuid "github.com/satori/go.uuid"
+)
+
+type SamlLogoutRequest struct {
+	XMLName      xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:protocol LogoutRequest"`
+	ID           string   `xml:"ID,attr"`
+	Destination  string   `xml:"Destination,attr"`
+	Issuer       string   `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	NameID       string   `xml:"urn:oasis:names:tc:SAML:2.0:assertion NameID"`
+	SessionIndex []string `xml:"urn:oasis:names:tc:SAML:2.0:protocol SessionIndex"`
+}
+
+// NewSamlLogoutResponse returns a successful saml2 LogoutResponse
+func NewSamlLogoutResponse(host string, destination string, requestId string) *etree.Element {
+	logoutResponse := &etree.Element{
+		Space: "samlp",
+		Tag:   "LogoutResponse",
+	}
+	logoutResponse.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	logoutResponse.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	logoutResponse.CreateAttr("ID", fmt.Sprintf("_%s", uuid.NewV4()))
+	logoutResponse.CreateAttr("Version", "2.0")
+	logoutResponse.CreateAttr("IssueInstant", time.Now().UTC().Format(time.RFC3339))
+	logoutResponse.CreateAttr("Destination", destination)
+	logoutResponse.CreateAttr("InResponseTo", requestId)
+	logoutResponse.CreateElement("saml:Issuer").SetText(host)
+	logoutResponse.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", "urn:oasis:names:tc:SAML:2.0:status:Success")
+	return logoutResponse
+}
+
+// HandleSamlLogoutRequest verifies a LogoutRequest sent by a registered SP with the given binding, samlQuery is the raw query
+// string of the HTTP-Redirect binding. It returns the user to sign out (nil if the NameID is unknown), the LogoutResponse and
+// its binding: a redirect URL for HTTP-Redirect or an HTML form for HTTP-POST. Only the Casdoor session of the user is ended,
+// the logout is not propagated to the other SPs the user has signed in to.
+func HandleSamlLogoutRequest(application *Application, samlRequest string, samlQuery string, relayState string, binding string, host string) (*User, string, string, error) {
+	xmlBytes, err := decodeSamlMessage(samlRequest, binding)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+	var logoutRequest SamlLogoutRequest
+	err = xml.Unmarshal(xmlBytes, &logoutRequest)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+
+	// verify samlRequest
+	issuer := strings.TrimSpace(logoutRequest.Issuer)
+	samlSp, err := getSamlSpByEntityId(application, issuer)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+
+	doc := etree.NewDocument()
+	err = doc.ReadFromBytes(xmlBytes)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+	if binding == SamlBindingPost {
+		samlQuery = ""
+	}
+	el, err := verifySamlRequestSignature(application, samlSp, doc.Root(), "SAMLRequest", samlQuery)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+
+	// only the verified element is trusted from here on
+	logoutRequest = SamlLogoutRequest{}
+	err = unmarshalSamlElement(el, &logoutRequest)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+	if strings.TrimSpace(logoutRequest.Issuer) != issuer {
+		return nil, "", "", fmt.Errorf("err: the issuer of the signed SAML request doesn't match")
+	}
+
+	sloEndpoint, destination, err := samlSp.getSloEndpoint(binding)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+
+	var user *User
+	if logoutRequest.NameID != "" {
+		user = samlSp.getUserByNameId(application.Organization, strings.TrimSpace(logoutRequest.NameID))
+	}
+
+	_, originBackend := getOriginFromHost(host)
+	logoutResponse := NewSamlLogoutResponse(originBackend, destination, logoutRequest.ID)
+	cert := getCertByApplication(application)
+
+	if sloEndpoint.Binding == SamlBindingPost {
+		ctx, err := getSamlSigningContext(cert)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("err: %s", err.Error())
+		}
+		ctx.Hash = crypto.SHA1
+		sig, err := ctx.ConstructSignature(logoutResponse, true)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("err: %s", err.Error())
+		}
+		logoutResponse.InsertChildAt(1, sig)
+	}
+
+	doc = etree.NewDocument()
+	doc.SetRoot(logoutResponse)
+	xmlBytes, err = doc.WriteToBytes()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+	message, err := encodeSamlMessage(xmlBytes, sloEndpoint.Binding)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+
+	if sloEndpoint.Binding == SamlBindingPost {
+		return user, GetSamlPostForm(destination, "SAMLResponse", message, relayState), SamlBindingPost, nil
+	}
+
+	query, err := signSamlRedirectQuery(cert, "SAMLResponse", message, relayState)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("err: %s", err.Error())
+	}
+	separator := "?"
+	if strings.Contains(destination, "?") {
+		separator = "&"
+	}
+	return user, destination + separator + query, SamlBindingRedirect, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/russellhaering/gosaml2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptSamlAssertion(t *testing.T) {
+	spCert, spKey := generateRsaKeys(2048, 1, "SP Cert", "SP Organization")
+
+	assertion := &etree.Element{Space: "saml", Tag: "Assertion"}
+	assertion.CreateAttr("ID", "_assertion")
+	assertion.CreateElement("saml:Subject").CreateElement("saml:NameID").SetText("alice@example.com")
+
+	encryptedAssertion, err := encryptSamlAssertion(assertion, spCert)
+	assert.Nil(t, err)
+
+	doc := etree.NewDocument()
+	encryptedAssertion.CreateAttr("xmlns:saml", samlAssertionNsAttr)
+	doc.SetRoot(encryptedAssertion)
+	xmlBytes, err := doc.WriteToBytes()
+	assert.Nil(t, err)
+
+	var ea types.EncryptedAssertion
+	err = xml.Unmarshal(xmlBytes, &ea)
+	assert.Nil(t, err)
+
+	keyPair, err := tls.X509KeyPair([]byte(spCert), []byte(spKey))
+	assert.Nil(t, err)
+	plainText, err := ea.DecryptBytes(&keyPair)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(plainText), "<saml:Assertion"))
+	assert.Contains(t, string(plainText), "alice@example.com")
+
+	_, err = encryptSamlAssertion(assertion, "invalid")
+	assert.NotNil(t, err)
+}
+
+func TestVerifySamlRedirectSignature(t *testing.T) {
+	publicKey, privateKey := generateRsaKeys(2048, 1, "SP Cert", "SP Organization")
+	cert := &Cert{Name: "cert-test", PublicKey: publicKey, PrivateKey: privateKey}
+	application := &Application{Name: "app-test", RequireSamlSignedRequest: true}
+	samlSp := &SamlSp{EntityId: "https://sp.example.com", SigningCert: publicKey}
+	el := etree.NewElement("samlp:AuthnRequest")
+
+	query, err := signSamlRedirectQuery(cert, "SAMLRequest", "request", "state")
+	assert.Nil(t, err)
+
+	_, err = verifySamlRequestSignature(application, samlSp, el, "SAMLRequest", query+"&application=app-test")
+	assert.Nil(t, err)
+
+	_, err = verifySamlRequestSignature(application, samlSp, el, "SAMLRequest", strings.Replace(query, "SAMLRequest=request", "SAMLRequest=tampered", 1))
+	assert.NotNil(t, err)
+
+	_, err = verifySamlRequestSignature(application, samlSp, el, "SAMLRequest", "SAMLRequest=request")
+	assert.NotNil(t, err)
+
+	// the signature is verified over the values as the SP encoded them, e.g. with %20 and lowercase hex
+	ctx, err := getSamlSigningContext(cert)
+	assert.Nil(t, err)
+	signedQuery := fmt.Sprintf("SAMLRequest=a%%2bb&RelayState=a%%20b&SigAlg=%s", url.QueryEscape(ctx.GetSignatureMethodIdentifier()))
+	signature, err := ctx.SignString(signedQuery)
+	assert.Nil(t, err)
+	rawQuery := fmt.Sprintf("%s&Signature=%s", signedQuery, url.QueryEscape(base64.StdEncoding.EncodeToString(signature)))
+	_, err = verifySamlRequestSignature(application, samlSp, el, "SAMLRequest", rawQuery)
+	assert.Nil(t, err)
+}
+
+func TestVerifySamlXmlSignature(t *testing.T) {
+	publicKey, privateKey := generateRsaKeys(2048, 1, "SP Cert", "SP Organization")
+	cert := &Cert{Name: "cert-test", PublicKey: publicKey, PrivateKey: privateKey}
+	application := &Application{Name: "app-test", RequireSamlSignedRequest: true}
+	samlSp := &SamlSp{EntityId: "https://sp.example.com", SigningCert: publicKey}
+
+	logoutRequest := &etree.Element{Space: "samlp", Tag: "LogoutRequest"}
+	logoutRequest.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	logoutRequest.CreateAttr("xmlns:saml", samlAssertionNsAttr)
+	logoutRequest.CreateAttr("ID", "_request")
+	logoutRequest.CreateElement("saml:Issuer").SetText("https://sp.example.com")
+	logoutRequest.CreateElement("saml:NameID").SetText("alice")
+
+	ctx, err := getSamlSigningContext(cert)
+	assert.Nil(t, err)
+	signed, err := ctx.SignEnveloped(logoutRequest)
+	assert.Nil(t, err)
+	doc := etree.NewDocument()
+	doc.SetRoot(signed)
+	xmlBytes, err := doc.WriteToBytes()
+	assert.Nil(t, err)
+	doc = etree.NewDocument()
+	err = doc.ReadFromBytes(xmlBytes)
+	assert.Nil(t, err)
+	signed = doc.Root()
+
+	el, err := verifySamlRequestSignature(application, samlSp, signed, "SAMLRequest", "")
+	assert.Nil(t, err)
+	var request SamlLogoutRequest
+	err = unmarshalSamlElement(el, &request)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", request.NameID)
+
+	// the signed content cannot be modified
+	signed.FindElement("./NameID").SetText("admin")
+	_, err = verifySamlRequestSignature(application, samlSp, signed, "SAMLRequest", "")
+	assert.NotNil(t, err)
+}
+
+func TestImportSamlSpMetadata(t *testing.T) {
+	publicKey, _ := generateRsaKeys(2048, 1, "SP Cert", "SP Organization")
+	block, _ := pem.Decode([]byte(publicKey))
+	certificate := strings.TrimSpace(string(pem.EncodeToMemory(block)))
+	certificate = strings.TrimPrefix(certificate, "-----BEGIN CERTIFICATE-----")
+	certificate = strings.TrimSuffix(certificate, "-----END CERTIFICATE-----")
+
+	metadata := fmt.Sprintf(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" xmlns:ds="http://www.w3.org/2000/09/xmldsig#" entityID="https://sp.example.com">
+  <md:SPSSODescriptor AuthnRequestsSigned="true" WantAssertionsSigned="true" protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing"><ds:KeyInfo><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>
+    <md:KeyDescriptor use="encryption"><ds:KeyInfo><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>
+    <md:SingleLogoutService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://sp.example.com/slo"/>
+    <md:NameIDFormat>urn:oasis:names:tc:SAML:2.0:nameid-format:persistent</md:NameIDFormat>
+    <md:AssertionConsumerService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://sp.example.com/acs/redirect" index="0"/>
+    <md:AssertionConsumerService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://sp.example.com/acs" index="1" isDefault="true"/>
+    <md:AttributeConsumingService index="0">
+      <md:RequestedAttribute Name="urn:oid:0.9.2342.19200300.100.1.3" FriendlyName="mail"/>
+      <md:RequestedAttribute Name="http://schemas.xmlsoap.org/claims/Group"/>
+    </md:AttributeConsumingService>
+  </md:SPSSODescriptor>
+</md:EntityDescriptor>`, certificate, certificate)
+
+	samlSp := &SamlSp{Owner: "admin", Name: "sp-test"}
+	err := ImportSamlSpMetadata(samlSp, []byte(metadata))
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sp.example.com", samlSp.EntityId)
+	assert.True(t, samlSp.AuthnRequestsSigned)
+	assert.True(t, samlSp.EnableEncryption)
+	assert.Equal(t, SamlNameIdFormatPersistent, samlSp.NameIdFormat)
+	assert.Equal(t, 2, len(samlSp.AcsEndpoints))
+	assert.Equal(t, 1, len(samlSp.SloEndpoints))
+	assert.Equal(t, "email", samlSp.Attributes[0].Value)
+	assert.Equal(t, "roles", samlSp.Attributes[1].Value)
+
+	_, err = parseSamlCert(samlSp.SigningCert)
+	assert.Nil(t, err)
+
+	endpoint, err := samlSp.getAcsEndpoint("", 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sp.example.com/acs", endpoint.Location)
+	endpoint, err = samlSp.getAcsEndpoint("", 0, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sp.example.com/acs/redirect", endpoint.Location)
+	_, err = samlSp.getAcsEndpoint("https://evil.example.com/acs", 0, false)
+	assert.NotNil(t, err)
+}
+
+func TestGetLegacySamlSp(t *testing.T) {
+	application := &Application{Owner: "admin", Name: "app-test", RedirectUris: []string{"https://sp.example.com"}}
+
+	samlSp := getLegacySamlSp(application, "https://sp.example.com/metadata")
+	assert.NotNil(t, samlSp)
+	endpoint, err := samlSp.getAcsEndpoint("https://sp.example.com/acs", 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sp.example.com/acs", endpoint.Location)
+	_, err = samlSp.getAcsEndpoint("https://evil.example.com/acs", 0, false)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, getLegacySamlSp(application, "https://other.example.com"))
+	assert.Nil(t, getLegacySamlSp(application, ""))
+}
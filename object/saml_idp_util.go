@@ -0,0 +1,346 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	SamlBindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	SamlBindingPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+
+	xmlencNamespace     = "http://www.w3.org/2001/04/xmlenc#"
+	xmlencElementType   = "http://www.w3.org/2001/04/xmlenc#Element"
+	xmlencAes256Cbc     = "http://www.w3.org/2001/04/xmlenc#aes256-cbc"
+	xmlencRsaOaepMgf1p  = "http://www.w3.org/2001/04/xmlenc#rsa-oaep-mgf1p"
+	xmldsigNamespace    = "http://www.w3.org/2000/09/xmldsig#"
+	xmldsigSha1         = "http://www.w3.org/2000/09/xmldsig#sha1"
+	samlAssertionNsAttr = "urn:oasis:names:tc:SAML:2.0:assertion"
+)
+
+var samlSignatureAlgorithms = map[string]crypto.Hash{
+	dsig.RSASHA1SignatureMethod:   crypto.SHA1,
+	dsig.RSASHA256SignatureMethod: crypto.SHA256,
+	dsig.RSASHA512SignatureMethod: crypto.SHA512,
+}
+
+// parseSamlCert accepts a PEM certificate or the bare base64 content of <ds:X509Certificate>
+func parseSamlCert(certString string) (*x509.Certificate, error) {
+	certString = strings.TrimSpace(certString)
+	if certString == "" {
+		return nil, fmt.Errorf("the SP certificate is empty")
+	}
+
+	var der []byte
+	if block, _ := pem.Decode([]byte(certString)); block != nil {
+		der = block.Bytes
+	} else {
+		var err error
+		der, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(certString), ""))
+		if err != nil {
+			return nil, fmt.Errorf("the SP certificate is neither PEM nor base64: %s", err.Error())
+		}
+	}
+
+	return x509.ParseCertificate(der)
+}
+
+// decodeSamlMessage decodes a SAMLRequest or SAMLResponse parameter, messages of the HTTP-Redirect binding are deflated
+func decodeSamlMessage(message string, binding string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if binding == SamlBindingPost {
+		return decoded, nil
+	}
+
+	inflated, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(decoded)))
+	if err != nil {
+		// some SPs don't compress the message of the HTTP-Redirect binding
+		return decoded, nil
+	}
+	return inflated, nil
+}
+
+func encodeSamlMessage(xmlBytes []byte, binding string) (string, error) {
+	if binding == SamlBindingPost {
+		return base64.StdEncoding.EncodeToString(xmlBytes), nil
+	}
+
+	flated := bytes.NewBuffer(nil)
+	writer, err := flate.NewWriter(flated, flate.DefaultCompression)
+	if err != nil {
+		return "", err
+	}
+	_, err = writer.Write(xmlBytes)
+	if err != nil {
+		return "", err
+	}
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(flated.Bytes()), nil
+}
+
+// getSamlRedirectSignedQuery builds the query string that is signed in the HTTP-Redirect binding,
+// see 3.4.4.1 of https://docs.oasis-open.org/security/saml/v2.0/saml-bindings-2.0-os.pdf
+func getSamlRedirectSignedQuery(messageKey string, message string, relayState string, sigAlg string) string {
+	query := fmt.Sprintf("%s=%s", messageKey, url.QueryEscape(message))
+	if relayState != "" {
+		query += fmt.Sprintf("&RelayState=%s", url.QueryEscape(relayState))
+	}
+	query += fmt.Sprintf("&SigAlg=%s", url.QueryEscape(sigAlg))
+	return query
+}
+
+// getSamlRawQueryParams returns the parameters of the query string as they were received, without decoding them,
+// because the signature of the HTTP-Redirect binding is computed over the original URL-encoded values
+func getSamlRawQueryParams(rawQuery string) map[string]string {
+	params := map[string]string{}
+	for _, param := range strings.Split(rawQuery, "&") {
+		tokens := strings.SplitN(param, "=", 2)
+		if len(tokens) != 2 {
+			continue
+		}
+		if _, ok := params[tokens[0]]; !ok {
+			params[tokens[0]] = tokens[1]
+		}
+	}
+	return params
+}
+
+func verifySamlRedirectSignature(spCert *x509.Certificate, messageKey string, rawQuery string) error {
+	params := getSamlRawQueryParams(rawQuery)
+	sigAlg, err := url.QueryUnescape(params["SigAlg"])
+	if err != nil {
+		return err
+	}
+	hash, ok := samlSignatureAlgorithms[sigAlg]
+	if !ok {
+		return fmt.Errorf("unsupported SigAlg: %s", sigAlg)
+	}
+
+	publicKey, ok := spCert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("the SP certificate doesn't contain a RSA public key")
+	}
+
+	signature, err := url.QueryUnescape(params["Signature"])
+	if err != nil {
+		return err
+	}
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+
+	signedQuery := fmt.Sprintf("%s=%s", messageKey, params[messageKey])
+	if relayState, ok := params["RelayState"]; ok {
+		signedQuery += fmt.Sprintf("&RelayState=%s", relayState)
+	}
+	signedQuery += fmt.Sprintf("&SigAlg=%s", params["SigAlg"])
+
+	h := hash.New()
+	h.Write([]byte(signedQuery))
+	return rsa.VerifyPKCS1v15(publicKey, hash, h.Sum(nil), signatureBytes)
+}
+
+// verifySamlXmlSignature returns the element covered by the signature, it is the only one to trust
+// because the original document may wrap the signed element with unsigned content
+func verifySamlXmlSignature(spCert *x509.Certificate, el *etree.Element) (*etree.Element, error) {
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{
+		Roots: []*x509.Certificate{spCert},
+	})
+	return ctx.Validate(el)
+}
+
+// verifySamlRequestSignature checks the signature of a message sent by the SP, either in the raw query string (HTTP-Redirect)
+// or embedded in the XML (HTTP-POST). A signature is mandatory when the application requires signed requests.
+// It returns the element to read the message from: the validated element when the XML is signed, the original one otherwise.
+func verifySamlRequestSignature(application *Application, samlSp *SamlSp, el *etree.Element, messageKey string, rawQuery string) (*etree.Element, error) {
+	hasQuerySignature := getSamlRawQueryParams(rawQuery)["Signature"] != ""
+	hasXmlSignature := el.FindElement("./Signature") != nil
+	if !hasQuerySignature && !hasXmlSignature {
+		if application.RequireSamlSignedRequest || samlSp.AuthnRequestsSigned {
+			return nil, fmt.Errorf("the application: %s requires signed SAML requests", application.Name)
+		}
+		return el, nil
+	}
+
+	spCert, err := parseSamlCert(samlSp.SigningCert)
+	if err != nil {
+		return nil, fmt.Errorf("the SAML request is signed but the signing certificate of the SP: %s is invalid: %s", samlSp.EntityId, err.Error())
+	}
+
+	if hasQuerySignature {
+		err = verifySamlRedirectSignature(spCert, messageKey, rawQuery)
+	} else {
+		el, err = verifySamlXmlSignature(spCert, el)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature of the SAML request: %s", err.Error())
+	}
+	return el, nil
+}
+
+// unmarshalSamlElement parses the message from an element, usually the one returned by verifySamlRequestSignature()
+func unmarshalSamlElement(el *etree.Element, v interface{}) error {
+	doc := etree.NewDocument()
+	doc.SetRoot(el.Copy())
+	xmlBytes, err := doc.WriteToBytes()
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(xmlBytes, v)
+}
+
+func getSamlSigningContext(cert *Cert) (*dsig.SigningContext, error) {
+	block, _ := pem.Decode([]byte(cert.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("the certificate: %s is invalid", cert.Name)
+	}
+
+	keyStore := &X509Key{
+		PrivateKey:      cert.PrivateKey,
+		X509Certificate: base64.StdEncoding.EncodeToString(block.Bytes),
+	}
+	return dsig.NewDefaultSigningContext(keyStore), nil
+}
+
+// signSamlRedirectQuery returns the query string of a HTTP-Redirect binding message including its signature
+func signSamlRedirectQuery(cert *Cert, messageKey string, message string, relayState string) (string, error) {
+	ctx, err := getSamlSigningContext(cert)
+	if err != nil {
+		return "", err
+	}
+
+	query := getSamlRedirectSignedQuery(messageKey, message, relayState, ctx.GetSignatureMethodIdentifier())
+	signature, err := ctx.SignString(query)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s&Signature=%s", query, url.QueryEscape(base64.StdEncoding.EncodeToString(signature))), nil
+}
+
+// encryptSamlAssertion replaces the assertion with a <saml:EncryptedAssertion> that only the SP can decrypt,
+// the assertion is encrypted with AES-256-CBC and the key is transported with RSA-OAEP using the SP certificate.
+func encryptSamlAssertion(assertion *etree.Element, spCertString string) (*etree.Element, error) {
+	spCert, err := parseSamlCert(spCertString)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := spCert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the SP certificate doesn't contain a RSA public key")
+	}
+
+	// the decrypted assertion must be a standalone XML fragment
+	assertion = assertion.Copy()
+	if assertion.SelectAttr("xmlns:saml") == nil {
+		assertion.CreateAttr("xmlns:saml", samlAssertionNsAttr)
+	}
+	doc := etree.NewDocument()
+	doc.SetRoot(assertion)
+	plainText, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := aes.BlockSize - len(plainText)%aes.BlockSize
+	plainText = append(plainText, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipherText := make([]byte, aes.BlockSize+len(plainText))
+	iv := cipherText[:aes.BlockSize]
+	_, err = rand.Read(iv)
+	if err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(cipherText[aes.BlockSize:], plainText)
+
+	encryptedKey, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedAssertion := &etree.Element{Space: "saml", Tag: "EncryptedAssertion"}
+	encryptedData := encryptedAssertion.CreateElement("xenc:EncryptedData")
+	encryptedData.CreateAttr("xmlns:xenc", xmlencNamespace)
+	encryptedData.CreateAttr("Type", xmlencElementType)
+	encryptedData.CreateElement("xenc:EncryptionMethod").CreateAttr("Algorithm", xmlencAes256Cbc)
+
+	keyInfo := encryptedData.CreateElement("ds:KeyInfo")
+	keyInfo.CreateAttr("xmlns:ds", xmldsigNamespace)
+	encryptedKeyElement := keyInfo.CreateElement("xenc:EncryptedKey")
+	keyEncryptionMethod := encryptedKeyElement.CreateElement("xenc:EncryptionMethod")
+	keyEncryptionMethod.CreateAttr("Algorithm", xmlencRsaOaepMgf1p)
+	keyEncryptionMethod.CreateElement("ds:DigestMethod").CreateAttr("Algorithm", xmldsigSha1)
+	encryptedKeyElement.CreateElement("xenc:CipherData").CreateElement("xenc:CipherValue").SetText(base64.StdEncoding.EncodeToString(encryptedKey))
+
+	encryptedData.CreateElement("xenc:CipherData").CreateElement("xenc:CipherValue").SetText(base64.StdEncoding.EncodeToString(cipherText))
+	return encryptedAssertion, nil
+}
+
+// GetSamlPostForm returns an auto-submitted HTML form that delivers a message with the HTTP-POST binding
+func GetSamlPostForm(destination string, messageKey string, message string, relayState string) string {
+	relayStateInput := ""
+	if relayState != "" {
+		relayStateInput = fmt.Sprintf(`<input type="hidden" name="RelayState" value="%s" />`, html.EscapeString(relayState))
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<body onload="document.forms[0].submit()">
+<form method="post" action="%s">
+<input type="hidden" name="%s" value="%s" />
+%s
+<noscript><input type="submit" value="Continue" /></noscript>
+</form>
+</body>
+</html>`, html.EscapeString(destination), messageKey, html.EscapeString(message), relayStateInput)
+}
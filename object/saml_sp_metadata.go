@@ -0,0 +1,227 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/casdoor/casdoor/proxy"
+)
+
+type samlSpMetadataEndpoint struct {
+	Binding          string `xml:"Binding,attr"`
+	Location         string `xml:"Location,attr"`
+	ResponseLocation string `xml:"ResponseLocation,attr"`
+	Index            int    `xml:"index,attr"`
+	IsDefault        bool   `xml:"isDefault,attr"`
+}
+
+type samlSpMetadataKeyDescriptor struct {
+	Use         string `xml:"use,attr"`
+	Certificate string `xml:"KeyInfo>X509Data>X509Certificate"`
+}
+
+type samlSpMetadataRequestedAttribute struct {
+	Name         string `xml:"Name,attr"`
+	NameFormat   string `xml:"NameFormat,attr"`
+	FriendlyName string `xml:"FriendlyName,attr"`
+}
+
+type samlSpMetadataSsoDescriptor struct {
+	AuthnRequestsSigned        bool                          `xml:"AuthnRequestsSigned,attr"`
+	WantAssertionsSigned       bool                          `xml:"WantAssertionsSigned,attr"`
+	KeyDescriptors             []samlSpMetadataKeyDescriptor `xml:"KeyDescriptor"`
+	SingleLogoutServices       []samlSpMetadataEndpoint      `xml:"SingleLogoutService"`
+	NameIdFormats              []string                      `xml:"NameIDFormat"`
+	AssertionConsumerServices  []samlSpMetadataEndpoint      `xml:"AssertionConsumerService"`
+	AttributeConsumingServices []struct {
+		RequestedAttributes []samlSpMetadataRequestedAttribute `xml:"RequestedAttribute"`
+	} `xml:"AttributeConsumingService"`
+}
+
+type samlSpMetadataEntityDescriptor struct {
+	EntityId        string                       `xml:"entityID,attr"`
+	SpSsoDescriptor *samlSpMetadataSsoDescriptor `xml:"SPSSODescriptor"`
+}
+
+type samlSpMetadataEntitiesDescriptor struct {
+	EntityDescriptors []samlSpMetadataEntityDescriptor `xml:"EntityDescriptor"`
+}
+
+// samlUserFieldAliases maps the common attribute names requested by SPs to the JSON fields of User
+var samlUserFieldAliases = map[string]string{
+	"email":           "email",
+	"mail":            "email",
+	"emailaddress":    "email",
+	"name":            "name",
+	"uid":             "name",
+	"username":        "name",
+	"displayname":     "displayName",
+	"cn":              "displayName",
+	"givenname":       "firstName",
+	"firstname":       "firstName",
+	"sn":              "lastName",
+	"surname":         "lastName",
+	"lastname":        "lastName",
+	"phone":           "phone",
+	"mobile":          "phone",
+	"telephonenumber": "phone",
+	"role":            "roles",
+	"roles":           "roles",
+	"group":           "roles",
+	"groups":          "roles",
+	"memberof":        "roles",
+}
+
+func getSamlUserFieldByAttribute(attribute samlSpMetadataRequestedAttribute) string {
+	names := []string{attribute.FriendlyName, attribute.Name}
+	for _, name := range names {
+		// "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress" or "urn:oid:...:mail"
+		name = name[strings.LastIndexAny(name, "/:")+1:]
+		if field, ok := samlUserFieldAliases[strings.ToLower(name)]; ok {
+			return field
+		}
+	}
+	return ""
+}
+
+func getSamlCertPem(certificate string) (string, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(certificate), ""))
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), nil
+}
+
+func parseSamlSpMetadata(metadata []byte) (*samlSpMetadataEntityDescriptor, error) {
+	var entityDescriptor samlSpMetadataEntityDescriptor
+	err := xml.Unmarshal(metadata, &entityDescriptor)
+	if err == nil && entityDescriptor.SpSsoDescriptor != nil {
+		return &entityDescriptor, nil
+	}
+
+	// the metadata of a federation contains many entities, use the first SP
+	var entitiesDescriptor samlSpMetadataEntitiesDescriptor
+	err = xml.Unmarshal(metadata, &entitiesDescriptor)
+	if err != nil {
+		return nil, err
+	}
+	for _, descriptor := range entitiesDescriptor.EntityDescriptors {
+		if descriptor.SpSsoDescriptor != nil {
+			return &descriptor, nil
+		}
+	}
+	return nil, fmt.Errorf("the metadata doesn't contain a SPSSODescriptor")
+}
+
+// ImportSamlSpMetadata fills the SP with the entity ID, endpoints, certs, NameID format and
+// requested attributes of the SP metadata XML, the attribute mapping edited before is kept
+func ImportSamlSpMetadata(samlSp *SamlSp, metadata []byte) error {
+	entityDescriptor, err := parseSamlSpMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to parse the SP metadata: %s", err.Error())
+	}
+	if entityDescriptor.EntityId == "" {
+		return fmt.Errorf("the entityID of the SP metadata is empty")
+	}
+
+	descriptor := entityDescriptor.SpSsoDescriptor
+	samlSp.EntityId = entityDescriptor.EntityId
+	samlSp.Metadata = string(metadata)
+	samlSp.AuthnRequestsSigned = descriptor.AuthnRequestsSigned
+	samlSp.WantAssertionsSigned = descriptor.WantAssertionsSigned
+
+	samlSp.AcsEndpoints = []*SamlEndpoint{}
+	for _, acs := range descriptor.AssertionConsumerServices {
+		samlSp.AcsEndpoints = append(samlSp.AcsEndpoints, &SamlEndpoint{
+			Binding:   acs.Binding,
+			Location:  acs.Location,
+			Index:     acs.Index,
+			IsDefault: acs.IsDefault,
+		})
+	}
+	if len(samlSp.AcsEndpoints) == 0 {
+		return fmt.Errorf("the SP metadata doesn't contain any AssertionConsumerService")
+	}
+
+	samlSp.SloEndpoints = []*SamlEndpoint{}
+	for _, slo := range descriptor.SingleLogoutServices {
+		samlSp.SloEndpoints = append(samlSp.SloEndpoints, &SamlEndpoint{
+			Binding:          slo.Binding,
+			Location:         slo.Location,
+			ResponseLocation: slo.ResponseLocation,
+		})
+	}
+
+	samlSp.SigningCert = ""
+	samlSp.EncryptionCert = ""
+	for _, keyDescriptor := range descriptor.KeyDescriptors {
+		cert, err := getSamlCertPem(keyDescriptor.Certificate)
+		if err != nil {
+			return fmt.Errorf("failed to parse the %s certificate of the SP metadata: %s", keyDescriptor.Use, err.Error())
+		}
+
+		if (keyDescriptor.Use == "signing" || keyDescriptor.Use == "") && samlSp.SigningCert == "" {
+			samlSp.SigningCert = cert
+		}
+		if keyDescriptor.Use == "encryption" && samlSp.EncryptionCert == "" {
+			samlSp.EncryptionCert = cert
+		}
+	}
+	samlSp.EnableEncryption = samlSp.EncryptionCert != ""
+
+	samlSp.NameIdFormat = ""
+	for _, format := range descriptor.NameIdFormats {
+		format = strings.TrimSpace(format)
+		if format == SamlNameIdFormatEmail || format == SamlNameIdFormatPersistent || format == SamlNameIdFormatUnspecified {
+			samlSp.NameIdFormat = format
+			break
+		}
+	}
+
+	if len(samlSp.Attributes) == 0 {
+		for _, service := range descriptor.AttributeConsumingServices {
+			for _, attribute := range service.RequestedAttributes {
+				samlSp.Attributes = append(samlSp.Attributes, &SamlAttributeItem{
+					Name:         attribute.Name,
+					NameFormat:   attribute.NameFormat,
+					FriendlyName: attribute.FriendlyName,
+					Value:        getSamlUserFieldByAttribute(attribute),
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+// FetchSamlSpMetadata downloads the SP metadata XML from its metadata URL
+func FetchSamlSpMetadata(metadataUrl string) ([]byte, error) {
+	resp, err := proxy.GetHttpClient(metadataUrl).Get(metadataUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to fetch the SP metadata from: %s, status: %s", metadataUrl, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,467 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"xorm.io/core"
+	"xorm.io/xorm/schemas"
+)
+
+const (
+	SamlNameIdFormatEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	SamlNameIdFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+	SamlNameIdFormatPersistent  = "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"
+
+	SamlAttrNameFormatBasic = "urn:oasis:names:tc:SAML:2.0:attrname-format:basic"
+)
+
+type SamlEndpoint struct {
+	Binding          string `json:"binding"`
+	Location         string `json:"location"`
+	ResponseLocation string `json:"responseLocation"`
+	Index            int    `json:"index"`
+	IsDefault        bool   `json:"isDefault"`
+}
+
+type SamlAttributeItem struct {
+	Name         string `json:"name"`
+	NameFormat   string `json:"nameFormat"`
+	FriendlyName string `json:"friendlyName"`
+	Value        string `json:"value"`
+}
+
+// SamlSp is a SAML service provider registered for an application, Casdoor acts as its IdP
+type SamlSp struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+	Application string `xorm:"varchar(100) index" json:"application"`
+	EntityId    string `xorm:"varchar(200)" json:"entityId"`
+	MetadataUrl string `xorm:"varchar(200)" json:"metadataUrl"`
+	Metadata    string `xorm:"mediumtext" json:"metadata"`
+
+	AcsEndpoints   []*SamlEndpoint      `xorm:"mediumtext" json:"acsEndpoints"`
+	SloEndpoints   []*SamlEndpoint      `xorm:"mediumtext" json:"sloEndpoints"`
+	SigningCert    string               `xorm:"mediumtext" json:"signingCert"`
+	EncryptionCert string               `xorm:"mediumtext" json:"encryptionCert"`
+	NameIdFormat   string               `xorm:"varchar(100)" json:"nameIdFormat"`
+	Attributes     []*SamlAttributeItem `xorm:"mediumtext" json:"attributes"`
+
+	AuthnRequestsSigned  bool `json:"authnRequestsSigned"`
+	WantAssertionsSigned bool `json:"wantAssertionsSigned"`
+	EnableEncryption     bool `json:"enableEncryption"`
+
+	// legacyApplication is set for the unregistered SP of getLegacySamlSp()
+	legacyApplication *Application `xorm:"-"`
+}
+
+func GetSamlSpCount(owner string, query *Query) int {
+	session := GetSession(&SamlSp{}, owner, -1, -1, query)
+	count, err := session.Count(&SamlSp{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetSamlSps(owner string) []*SamlSp {
+	samlSps := []*SamlSp{}
+	err := adapter.Engine.Desc("created_time").Find(&samlSps, &SamlSp{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return samlSps
+}
+
+func GetPaginationSamlSps(owner string, offset, limit int, query *Query) []*SamlSp {
+	samlSps := []*SamlSp{}
+	session := GetSession(&SamlSp{}, owner, offset, limit, query)
+	err := session.Find(&samlSps)
+	if err != nil {
+		panic(err)
+	}
+
+	return samlSps
+}
+
+func getSamlSp(owner string, name string) *SamlSp {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	samlSp := SamlSp{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&samlSp)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &samlSp
+	} else {
+		return nil
+	}
+}
+
+func GetSamlSp(id string) *SamlSp {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getSamlSp(owner, name)
+}
+
+func UpdateSamlSp(id string, samlSp *SamlSp) bool {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if getSamlSp(owner, name) == nil {
+		return false
+	}
+
+	affected, err := adapter.Engine.ID(core.PK{owner, name}).AllCols().Update(samlSp)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func AddSamlSp(samlSp *SamlSp) bool {
+	affected, err := adapter.Engine.Insert(samlSp)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func DeleteSamlSp(samlSp *SamlSp) bool {
+	affected, err := adapter.Engine.ID(core.PK{samlSp.Owner, samlSp.Name}).Delete(&SamlSp{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func (samlSp *SamlSp) GetId() string {
+	return fmt.Sprintf("%s/%s", samlSp.Owner, samlSp.Name)
+}
+
+func getSamlSpsByApplication(application *Application) []*SamlSp {
+	samlSps := []*SamlSp{}
+	err := adapter.Engine.Asc("created_time").Find(&samlSps, &SamlSp{Owner: application.Owner, Application: application.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return samlSps
+}
+
+// getLegacySamlSp returns an unregistered SP for the applications that were set up before the SP registry,
+// it is trusted the same way as before: the issuer must match the redirect URIs of the application
+func getLegacySamlSp(application *Application, entityId string) *SamlSp {
+	if entityId == "" || !CheckRedirectUriValid(application, entityId) {
+		return nil
+	}
+
+	samlSp := SamlSp{
+		Owner:       application.Owner,
+		Name:        application.Name,
+		Application: application.Name,
+		EntityId:    entityId,
+
+		legacyApplication: application,
+	}
+	return &samlSp
+}
+
+// getSamlSpByEntityId returns the registered SP of the application, the first SP is used when entityId is empty.
+// An application without any registered SP falls back to the redirect URIs check of getLegacySamlSp().
+func getSamlSpByEntityId(application *Application, entityId string) (*SamlSp, error) {
+	samlSps := getSamlSpsByApplication(application)
+	for _, samlSp := range samlSps {
+		if entityId == "" || samlSp.EntityId == entityId {
+			return samlSp, nil
+		}
+	}
+
+	if len(samlSps) == 0 {
+		if samlSp := getLegacySamlSp(application, entityId); samlSp != nil {
+			return samlSp, nil
+		}
+	}
+
+	if entityId == "" {
+		return nil, fmt.Errorf("the application: %s has no registered SAML SP", application.Name)
+	}
+	return nil, fmt.Errorf("the SAML SP: %s is not registered in the application: %s", entityId, application.Name)
+}
+
+// getAcsEndpoint returns the registered ACS endpoint that matches the AuthnRequest, an unregistered URL is never used.
+// A legacy SP has no registered endpoints, the URL of the AuthnRequest is used when it matches the redirect URIs of the application.
+func (samlSp *SamlSp) getAcsEndpoint(acsUrl string, index int, hasIndex bool) (*SamlEndpoint, error) {
+	if samlSp.legacyApplication != nil && acsUrl != "" {
+		if !CheckRedirectUriValid(samlSp.legacyApplication, acsUrl) {
+			return nil, fmt.Errorf("the ACS URL: %s doesn't match the redirect URIs of the application: %s", acsUrl, samlSp.legacyApplication.Name)
+		}
+		return &SamlEndpoint{Binding: SamlBindingPost, Location: acsUrl, IsDefault: true}, nil
+	}
+
+	if len(samlSp.AcsEndpoints) == 0 {
+		return nil, fmt.Errorf("the SAML SP: %s has no ACS endpoint", samlSp.EntityId)
+	}
+
+	if acsUrl != "" {
+		for _, endpoint := range samlSp.AcsEndpoints {
+			if endpoint.Location == acsUrl {
+				return endpoint, nil
+			}
+		}
+		return nil, fmt.Errorf("the ACS URL: %s is not registered for the SAML SP: %s", acsUrl, samlSp.EntityId)
+	}
+
+	if hasIndex {
+		for _, endpoint := range samlSp.AcsEndpoints {
+			if endpoint.Index == index {
+				return endpoint, nil
+			}
+		}
+		return nil, fmt.Errorf("the ACS index: %d is not registered for the SAML SP: %s", index, samlSp.EntityId)
+	}
+
+	for _, endpoint := range samlSp.AcsEndpoints {
+		if endpoint.IsDefault {
+			return endpoint, nil
+		}
+	}
+	for _, endpoint := range samlSp.AcsEndpoints {
+		if endpoint.Binding == SamlBindingPost {
+			return endpoint, nil
+		}
+	}
+	return samlSp.AcsEndpoints[0], nil
+}
+
+// getSloEndpoint prefers the SLO endpoint of the given binding and returns the location for responses
+func (samlSp *SamlSp) getSloEndpoint(binding string) (*SamlEndpoint, string, error) {
+	if len(samlSp.SloEndpoints) == 0 {
+		return nil, "", fmt.Errorf("the SAML SP: %s has no single logout endpoint", samlSp.EntityId)
+	}
+
+	endpoint := samlSp.SloEndpoints[0]
+	for _, sloEndpoint := range samlSp.SloEndpoints {
+		if sloEndpoint.Binding == binding {
+			endpoint = sloEndpoint
+			break
+		}
+	}
+
+	location := endpoint.ResponseLocation
+	if location == "" {
+		location = endpoint.Location
+	}
+	return endpoint, location, nil
+}
+
+func (samlSp *SamlSp) getEncryptionCert() string {
+	if samlSp.EncryptionCert != "" {
+		return samlSp.EncryptionCert
+	}
+	// a key descriptor without "use" is used for both signing and encryption
+	return samlSp.SigningCert
+}
+
+func (samlSp *SamlSp) getNameIdFormat() string {
+	if samlSp.NameIdFormat == "" {
+		return SamlNameIdFormatEmail
+	}
+	return samlSp.NameIdFormat
+}
+
+func (samlSp *SamlSp) getNameId(user *User) string {
+	switch samlSp.getNameIdFormat() {
+	case SamlNameIdFormatPersistent:
+		return user.Id
+	case SamlNameIdFormatUnspecified:
+		return user.Name
+	default:
+		return user.Email
+	}
+}
+
+func (samlSp *SamlSp) getUserByNameId(organization string, nameId string) *User {
+	switch samlSp.getNameIdFormat() {
+	case SamlNameIdFormatPersistent:
+		return GetUserByField(organization, "id", nameId)
+	case SamlNameIdFormatUnspecified:
+		return GetUserByField(organization, "name", nameId)
+	default:
+		return GetUserByField(organization, "email", nameId)
+	}
+}
+
+func (samlSp *SamlSp) getAttributes() []*SamlAttributeItem {
+	if len(samlSp.Attributes) != 0 {
+		return samlSp.Attributes
+	}
+
+	return []*SamlAttributeItem{
+		{Name: "Email", NameFormat: SamlAttrNameFormatBasic, Value: "email"},
+		{Name: "Name", NameFormat: SamlAttrNameFormatBasic, Value: "name"},
+		{Name: "DisplayName", NameFormat: SamlAttrNameFormatBasic, Value: "displayName"},
+	}
+}
+
+// getSamlAttributeValues returns the values of a user field, the field is the JSON name of the User,
+// "properties.xxx" for a custom property or "roles" for the names of the user's roles
+func getSamlAttributeValues(user *User, field string) []string {
+	if strings.HasPrefix(field, "properties.") {
+		value := user.Properties[strings.TrimPrefix(field, "properties.")]
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	}
+
+	if field == "password" || field == "passwordSalt" {
+		return nil
+	}
+
+	if field == "roles" {
+		values := []string{}
+		for _, role := range getRolesByUser(user.GetId()) {
+			values = append(values, role.Name)
+		}
+		return values
+	}
+
+	userMap := map[string]interface{}{}
+	data, err := json.Marshal(user)
+	if err != nil {
+		panic(err)
+	}
+	err = json.Unmarshal(data, &userMap)
+	if err != nil {
+		panic(err)
+	}
+
+	switch value := userMap[field].(type) {
+	case nil:
+		return nil
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	case []interface{}:
+		values := []string{}
+		for _, v := range value {
+			values = append(values, fmt.Sprintf("%v", v))
+		}
+		return values
+	default:
+		return []string{fmt.Sprintf("%v", value)}
+	}
+}
+
+var legacySamlSpColumns = []string{"saml_sp_entity_id", "saml_sp_cert", "saml_acs_url", "saml_slo_url", "enable_saml_encryption"}
+
+func getLegacySamlSpTable() *schemas.Table {
+	tableName := adapter.Engine.TableName(&Application{})
+	tables, err := adapter.Engine.DBMetas()
+	if err != nil {
+		panic(err)
+	}
+
+	for _, table := range tables {
+		if table.Name == tableName && table.GetColumn(legacySamlSpColumns[0]) != nil {
+			return table
+		}
+	}
+	return nil
+}
+
+// migrateApplicationSamlSps registers the SPs that were configured in the application columns before the SP registry,
+// then drops the columns, so that it only runs once
+func migrateApplicationSamlSps() {
+	table := getLegacySamlSpTable()
+	if table == nil {
+		return
+	}
+
+	rows, err := adapter.Engine.QueryString(fmt.Sprintf("select owner, name, %s from %s", strings.Join(legacySamlSpColumns, ", "), table.Name))
+	if err != nil {
+		panic(err)
+	}
+
+	for _, row := range rows {
+		entityId := strings.TrimSpace(row["saml_sp_entity_id"])
+		if entityId == "" || row["saml_acs_url"] == "" {
+			continue
+		}
+
+		application := &Application{Owner: row["owner"], Name: row["name"]}
+		isRegistered := false
+		for _, samlSp := range getSamlSpsByApplication(application) {
+			if samlSp.EntityId == entityId {
+				isRegistered = true
+			}
+		}
+		if isRegistered {
+			continue
+		}
+
+		name := application.Name
+		if getSamlSp(application.Owner, name) != nil {
+			name = util.GenerateId()
+		}
+
+		samlSp := &SamlSp{
+			Owner:            application.Owner,
+			Name:             name,
+			CreatedTime:      util.GetCurrentTime(),
+			DisplayName:      application.Name,
+			Application:      application.Name,
+			EntityId:         entityId,
+			AcsEndpoints:     []*SamlEndpoint{{Binding: SamlBindingPost, Location: row["saml_acs_url"], IsDefault: true}},
+			SloEndpoints:     []*SamlEndpoint{},
+			SigningCert:      row["saml_sp_cert"],
+			EnableEncryption: row["enable_saml_encryption"] == "1" || row["enable_saml_encryption"] == "true",
+			Attributes:       []*SamlAttributeItem{},
+		}
+		if row["saml_slo_url"] != "" {
+			samlSp.SloEndpoints = append(samlSp.SloEndpoints, &SamlEndpoint{Binding: SamlBindingRedirect, Location: row["saml_slo_url"]})
+		}
+		AddSamlSp(samlSp)
+		logs.Info("registered the SAML SP: %s of the application: %s/%s", entityId, application.Owner, application.Name)
+	}
+
+	for _, column := range legacySamlSpColumns {
+		if table.GetColumn(column) == nil {
+			continue
+		}
+
+		_, err = adapter.Engine.Exec(fmt.Sprintf("alter table %s drop column %s", table.Name, column))
+		if err != nil {
+			logs.Warn("failed to drop the column: %s of the table: %s: %s", column, table.Name, err.Error())
+		}
+	}
+}
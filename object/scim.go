@@ -0,0 +1,287 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/conf"
+)
+
+const (
+	ScimUserSchema                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	ScimGroupSchema                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ScimEnterpriseUserSchema        = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	ScimListResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ScimPatchOpSchema               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ScimErrorSchema                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ScimServiceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ScimResourceTypeSchema          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	ScimSchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+
+	ScimErrorInvalidFilter = "invalidFilter"
+	ScimErrorUniqueness    = "uniqueness"
+	ScimErrorInvalidSyntax = "invalidSyntax"
+	ScimErrorInvalidPath   = "invalidPath"
+	ScimErrorNoTarget      = "noTarget"
+	ScimErrorInvalidValue  = "invalidValue"
+
+	ScimDefaultCount = 100
+	scimMaxCount     = 1000
+)
+
+type ScimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+type ScimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+type ScimListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// ScimServer serves the users and the roles of an organization as SCIM 2.0 (RFC 7643 and RFC 7644) resources,
+// every provisioning client authenticates with the credentials of its own application
+type ScimServer struct {
+	Organization string
+	Application  *Application
+	// BaseUrl is the root of the SCIM endpoints of the organization, like: https://door.casdoor.com/scim/built-in/v2
+	BaseUrl  string
+	ClientIp string
+}
+
+func newScimError(status int, scimType string, detail string) *ScimError {
+	return &ScimError{
+		Schemas:  []string{ScimErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+func (scimErr *ScimError) Error() string {
+	return scimErr.Detail
+}
+
+func (scimErr *ScimError) GetStatus() int {
+	status, err := strconv.Atoi(scimErr.Status)
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+func getScimBaseUrl(host string, organization string) string {
+	_, originBackend := getOriginFromHost(host)
+	origin := conf.GetConfigString("origin")
+	if origin != "" {
+		originBackend = origin
+	}
+	return fmt.Sprintf("%s/scim/%s/v2", originBackend, organization)
+}
+
+// GetScimServer authenticates the client with HTTP Basic (client ID and secret) or with a bearer token of the
+// client credentials grant, the application of the client must belong to the organization and enable the SCIM server
+func GetScimServer(organization string, host string, clientId string, clientSecret string, accessToken string) *ScimServer {
+	var application *Application
+	if clientId != "" {
+		application = GetApplicationByClientId(clientId)
+		if application == nil || subtle.ConstantTimeCompare([]byte(application.ClientSecret), []byte(clientSecret)) != 1 {
+			return nil
+		}
+	} else if accessToken != "" {
+		token := GetTokenByAccessToken(accessToken)
+		if token == nil || token.User != fmt.Sprintf("app/%s", token.Application) {
+			return nil
+		}
+
+		application = getApplication("admin", token.Application)
+		if application == nil {
+			return nil
+		}
+		claims, err := ParseJwtTokenByApplication(accessToken, application)
+		if err != nil || claims.Valid() != nil {
+			return nil
+		}
+	}
+
+	if application == nil || !application.EnableScimServer || application.Organization != organization {
+		return nil
+	}
+	return &ScimServer{
+		Organization: organization,
+		Application:  application,
+		BaseUrl:      getScimBaseUrl(host, organization),
+	}
+}
+
+// addAuditLog records the change of an object made by the SCIM client, the actor is the application of the client
+func (server *ScimServer) addAuditLog(operation string, objectType string, objectId string, before interface{}, after interface{}) {
+	auditLog := &AuditLog{
+		Actor:      fmt.Sprintf("app/%s", server.Application.Name),
+		ClientIp:   server.ClientIp,
+		Operation:  operation,
+		ObjectType: objectType,
+		ObjectId:   objectId,
+	}
+	AddAuditLog(auditLog, before, after)
+}
+
+// getScimVersion returns the weak ETag of a resource, computed from its content
+func getScimVersion(resource interface{}) string {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		panic(err)
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(data))
+	return fmt.Sprintf("W/\"%s\"", hash[:16])
+}
+
+// checkScimVersion implements If-Match: the resource is changed only if the client has its current version
+func checkScimVersion(version string, ifMatch string) *ScimError {
+	if ifMatch == "" || ifMatch == "*" {
+		return nil
+	}
+
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == version {
+			return nil
+		}
+	}
+	return newScimError(http.StatusPreconditionFailed, "", "the resource has been changed, its current version is: "+version)
+}
+
+func getScimTime(t string) string {
+	parsedTime, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return t
+	}
+	return parsedTime.UTC().Format(time.RFC3339)
+}
+
+func getScimPage(resources []interface{}, startIndex int, count int) *ScimListResponse {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	} else if count > scimMaxCount {
+		count = scimMaxCount
+	}
+
+	page := []interface{}{}
+	if startIndex <= len(resources) {
+		end := startIndex - 1 + count
+		if end > len(resources) {
+			end = len(resources)
+		}
+		page = resources[startIndex-1 : end]
+	}
+
+	return &ScimListResponse{
+		Schemas:      []string{ScimListResponseSchema},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}
+}
+
+// toScimObject returns the JSON form of a resource, the filters and the PATCH operations are applied on it
+func toScimObject(resource interface{}) map[string]interface{} {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		panic(err)
+	}
+
+	object := map[string]interface{}{}
+	err = json.Unmarshal(data, &object)
+	if err != nil {
+		panic(err)
+	}
+	return object
+}
+
+func fromScimObject(object map[string]interface{}, resource interface{}) *ScimError {
+	data, err := json.Marshal(object)
+	if err != nil {
+		panic(err)
+	}
+
+	err = json.Unmarshal(data, resource)
+	if err != nil {
+		return newScimError(http.StatusBadRequest, ScimErrorInvalidValue, err.Error())
+	}
+	return nil
+}
+
+// filterScimResources returns the resources matching the filter, all of them if the filter is empty
+func filterScimResources(resources []interface{}, filter scimFilter) []interface{} {
+	if filter == nil {
+		return resources
+	}
+
+	res := []interface{}{}
+	for _, resource := range resources {
+		if filter.match(toScimObject(resource)) {
+			res = append(res, resource)
+		}
+	}
+	return res
+}
+
+func parseScimRequestFilter(filter string) (scimFilter, *ScimError) {
+	if filter == "" {
+		return nil, nil
+	}
+
+	res, err := parseScimFilter(filter)
+	if err != nil {
+		return nil, newScimError(http.StatusBadRequest, ScimErrorInvalidFilter, err.Error())
+	}
+	return res, nil
+}
+
+func parseScimPatchRequest(data []byte) (*ScimPatchRequest, *ScimError) {
+	patchRequest := &ScimPatchRequest{}
+	err := json.Unmarshal(data, patchRequest)
+	if err != nil {
+		return nil, newScimError(http.StatusBadRequest, ScimErrorInvalidSyntax, err.Error())
+	}
+	if len(patchRequest.Operations) == 0 {
+		return nil, newScimError(http.StatusBadRequest, ScimErrorInvalidSyntax, "the PATCH request has no operation")
+	}
+	return patchRequest, nil
+}
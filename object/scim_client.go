@@ -0,0 +1,172 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ScimAuthTypeBearer = "Bearer"
+	ScimAuthTypeBasic  = "Basic"
+)
+
+// scimClient calls the SCIM 2.0 endpoints of a downstream application
+type scimClient struct {
+	baseUrl    string
+	authType   string
+	token      string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// scimClientError is the error of a request answered with a non-2xx status, Status is 0 if no response was received
+type scimClientError struct {
+	Status int
+	Detail string
+}
+
+func (err *scimClientError) Error() string {
+	if err.Status == 0 {
+		return err.Detail
+	}
+	return fmt.Sprintf("SCIM request failed with status %d: %s", err.Status, err.Detail)
+}
+
+// isRetryable tells if the request can succeed later: the network errors, the throttling and the server errors
+func (err *scimClientError) isRetryable() bool {
+	return err.Status == 0 || err.Status == http.StatusRequestTimeout || err.Status == http.StatusTooManyRequests || err.Status >= 500
+}
+
+func newScimClient(application *Application) *scimClient {
+	return &scimClient{
+		baseUrl:    strings.TrimSuffix(application.ScimBaseUrl, "/"),
+		authType:   application.ScimAuthType,
+		token:      application.ScimToken,
+		username:   application.ScimUsername,
+		password:   application.ScimPassword,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (client *scimClient) do(method string, path string, body interface{}, result interface{}) *scimClientError {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			panic(err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, client.baseUrl+path, reader)
+	if err != nil {
+		return &scimClientError{Detail: err.Error()}
+	}
+	req.Header.Set("Accept", "application/scim+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/scim+json")
+	}
+	if client.authType == ScimAuthTypeBasic {
+		req.SetBasicAuth(client.username, client.password)
+	} else if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	}
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return &scimClientError{Detail: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return &scimClientError{Detail: err.Error()}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail := strings.TrimSpace(string(data))
+		scimErr := &ScimError{}
+		if json.Unmarshal(data, scimErr) == nil && scimErr.Detail != "" {
+			detail = scimErr.Detail
+		}
+		return &scimClientError{Status: resp.StatusCode, Detail: detail}
+	}
+
+	if result != nil && len(data) != 0 {
+		err = json.Unmarshal(data, result)
+		if err != nil {
+			return &scimClientError{Status: resp.StatusCode, Detail: err.Error()}
+		}
+	}
+	return nil
+}
+
+// findUser returns the ID of the downstream user with the user name, "" if there is none
+func (client *scimClient) findUser(userName string) (string, *scimClientError) {
+	filter := fmt.Sprintf("userName eq %q", userName)
+	listResponse := &struct {
+		Resources []*ScimUser `json:"Resources"`
+	}{}
+	err := client.do("GET", "/Users?filter="+url.QueryEscape(filter), nil, listResponse)
+	if err != nil {
+		return "", err
+	}
+
+	for _, scimUser := range listResponse.Resources {
+		if strings.EqualFold(scimUser.UserName, userName) {
+			return scimUser.Id, nil
+		}
+	}
+	return "", nil
+}
+
+func (client *scimClient) createUser(user map[string]interface{}) (string, *scimClientError) {
+	scimUser := &ScimUser{}
+	err := client.do("POST", "/Users", user, scimUser)
+	if err != nil {
+		return "", err
+	}
+	if scimUser.Id == "" {
+		return "", &scimClientError{Status: http.StatusCreated, Detail: "the created user has no id"}
+	}
+	return scimUser.Id, nil
+}
+
+func (client *scimClient) replaceUser(id string, user map[string]interface{}) *scimClientError {
+	return client.do("PUT", "/Users/"+url.PathEscape(id), user, nil)
+}
+
+func (client *scimClient) deactivateUser(id string) *scimClientError {
+	patchRequest := map[string]interface{}{
+		"schemas":    []string{ScimPatchOpSchema},
+		"Operations": []map[string]interface{}{{"op": "replace", "path": "active", "value": false}},
+	}
+	return client.do("PATCH", "/Users/"+url.PathEscape(id), patchRequest, nil)
+}
+
+func (client *scimClient) deleteUser(id string) *scimClientError {
+	return client.do("DELETE", "/Users/"+url.PathEscape(id), nil, nil)
+}
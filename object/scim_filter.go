@@ -0,0 +1,406 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// scimFilter is a parsed SCIM filter (RFC 7644 section 3.4.2.2), it is evaluated on the JSON form of a resource
+type scimFilter interface {
+	match(resource map[string]interface{}) bool
+}
+
+type scimLogicalFilter struct {
+	isAnd bool
+	left  scimFilter
+	right scimFilter
+}
+
+type scimNotFilter struct {
+	filter scimFilter
+}
+
+// scimAttributeFilter compares an attribute with a value: userName eq "alice", or checks its presence: title pr
+type scimAttributeFilter struct {
+	path     []string
+	operator string
+	value    interface{}
+}
+
+// scimValuePathFilter matches the resources having a value of a multi-valued attribute that matches the filter:
+// emails[type eq "work" and value co "@example.com"]
+type scimValuePathFilter struct {
+	path   []string
+	filter scimFilter
+}
+
+var scimComparisonOperators = []string{"eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le"}
+
+func (filter *scimLogicalFilter) match(resource map[string]interface{}) bool {
+	if filter.isAnd {
+		return filter.left.match(resource) && filter.right.match(resource)
+	}
+	return filter.left.match(resource) || filter.right.match(resource)
+}
+
+func (filter *scimNotFilter) match(resource map[string]interface{}) bool {
+	return !filter.filter.match(resource)
+}
+
+func (filter *scimValuePathFilter) match(resource map[string]interface{}) bool {
+	for _, value := range getScimValues(resource, filter.path) {
+		if element, ok := value.(map[string]interface{}); ok && filter.filter.match(element) {
+			return true
+		}
+	}
+	return false
+}
+
+func (filter *scimAttributeFilter) match(resource map[string]interface{}) bool {
+	// a complex attribute without a sub-attribute stands for its "value" sub-attribute: emails co "@example.com"
+	values := getScimValues(resource, filter.path)
+	for i, value := range values {
+		if object, ok := value.(map[string]interface{}); ok {
+			_, values[i] = getScimAttribute(object, "value")
+		}
+	}
+	if filter.operator == "pr" {
+		for _, value := range values {
+			if value != nil && value != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	// "ne" matches the resources having no value equal to the given one, including the ones without a value
+	if filter.operator == "ne" {
+		for _, value := range values {
+			if compareScimValue(value, "eq", filter.value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if filter.value == nil && filter.operator == "eq" {
+		return len(values) == 0
+	}
+	for _, value := range values {
+		if compareScimValue(value, filter.operator, filter.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareScimValue compares the strings case-insensitively, the timestamps are compared as strings too
+// because they are all in the same RFC 3339 format
+func compareScimValue(value interface{}, operator string, expected interface{}) bool {
+	switch expected := expected.(type) {
+	case string:
+		actual, ok := value.(string)
+		if !ok {
+			return false
+		}
+
+		actual, expected = strings.ToLower(actual), strings.ToLower(expected)
+		switch operator {
+		case "eq":
+			return actual == expected
+		case "co":
+			return strings.Contains(actual, expected)
+		case "sw":
+			return strings.HasPrefix(actual, expected)
+		case "ew":
+			return strings.HasSuffix(actual, expected)
+		case "gt":
+			return actual > expected
+		case "ge":
+			return actual >= expected
+		case "lt":
+			return actual < expected
+		case "le":
+			return actual <= expected
+		}
+	case float64:
+		actual, ok := value.(float64)
+		if !ok {
+			return false
+		}
+
+		switch operator {
+		case "eq":
+			return actual == expected
+		case "gt":
+			return actual > expected
+		case "ge":
+			return actual >= expected
+		case "lt":
+			return actual < expected
+		case "le":
+			return actual <= expected
+		}
+	case bool:
+		actual, ok := value.(bool)
+		return ok && operator == "eq" && actual == expected
+	}
+	return false
+}
+
+// splitScimPath splits an attribute path like "name.givenName" into its parts. The URN of the core schema is removed
+// and the URN of an extension schema is kept as the first part: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department"
+func splitScimPath(path string) []string {
+	if strings.EqualFold(path, ScimEnterpriseUserSchema) {
+		return []string{ScimEnterpriseUserSchema}
+	}
+
+	parts := []string{}
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		i := strings.LastIndex(path, ":")
+		urn := path[:i]
+		path = path[i+1:]
+		if strings.EqualFold(urn, ScimEnterpriseUserSchema) {
+			parts = append(parts, ScimEnterpriseUserSchema)
+		} else if !strings.EqualFold(urn, ScimUserSchema) && !strings.EqualFold(urn, ScimGroupSchema) {
+			parts = append(parts, urn)
+		}
+	}
+	return append(parts, strings.Split(path, ".")...)
+}
+
+// getScimAttribute returns the value of a key of the resource, the attribute names are case-insensitive
+func getScimAttribute(resource map[string]interface{}, name string) (string, interface{}) {
+	if value, ok := resource[name]; ok {
+		return name, value
+	}
+	for key, value := range resource {
+		if strings.EqualFold(key, name) {
+			return key, value
+		}
+	}
+	return "", nil
+}
+
+// getScimValues returns all the values at the path, the multi-valued attributes are flattened
+func getScimValues(resource map[string]interface{}, path []string) []interface{} {
+	values := []interface{}{resource}
+	for _, name := range path {
+		nextValues := []interface{}{}
+		for _, value := range values {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			_, attribute := getScimAttribute(object, name)
+			if elements, ok := attribute.([]interface{}); ok {
+				nextValues = append(nextValues, elements...)
+			} else if attribute != nil {
+				nextValues = append(nextValues, attribute)
+			}
+		}
+		values = nextValues
+	}
+	return values
+}
+
+type scimFilterParser struct {
+	tokens []string
+	index  int
+}
+
+// tokenizeScimFilter splits the filter into parentheses, brackets, quoted strings and words
+func tokenizeScimFilter(filter string) ([]string, error) {
+	tokens := []string{}
+	for i := 0; i < len(filter); {
+		c := filter[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(filter) && filter[j] != '"'; j++ {
+				if filter[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(filter) {
+				return nil, fmt.Errorf("unterminated string in filter: %s", filter)
+			}
+			tokens = append(tokens, filter[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(filter) && !strings.ContainsRune(" \t()[]\"", rune(filter[j])); j++ {
+			}
+			tokens = append(tokens, filter[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// parseScimFilter parses a filter like: userName eq "alice" and (emails[type eq "work"] or not (title pr))
+func parseScimFilter(filter string) (scimFilter, error) {
+	tokens, err := tokenizeScimFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &scimFilterParser{tokens: tokens}
+	result, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.index != len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected token: %s in filter: %s", parser.tokens[parser.index], filter)
+	}
+	return result, nil
+}
+
+func (parser *scimFilterParser) peek() string {
+	if parser.index >= len(parser.tokens) {
+		return ""
+	}
+	return parser.tokens[parser.index]
+}
+
+func (parser *scimFilterParser) next() string {
+	token := parser.peek()
+	parser.index++
+	return token
+}
+
+func (parser *scimFilterParser) expect(token string) error {
+	if next := parser.next(); next != token {
+		return fmt.Errorf("expected: %s but got: %s in filter", token, next)
+	}
+	return nil
+}
+
+func (parser *scimFilterParser) parseOr() (scimFilter, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.EqualFold(parser.peek(), "or") {
+		parser.next()
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &scimLogicalFilter{isAnd: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *scimFilterParser) parseAnd() (scimFilter, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.EqualFold(parser.peek(), "and") {
+		parser.next()
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &scimLogicalFilter{isAnd: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *scimFilterParser) parseNot() (scimFilter, error) {
+	if strings.EqualFold(parser.peek(), "not") {
+		parser.next()
+		filter, err := parser.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return &scimNotFilter{filter: filter}, nil
+	}
+
+	if parser.peek() == "(" {
+		return parser.parseGroup()
+	}
+	return parser.parseAttribute()
+}
+
+func (parser *scimFilterParser) parseGroup() (scimFilter, error) {
+	err := parser.expect("(")
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	return filter, parser.expect(")")
+}
+
+func (parser *scimFilterParser) parseAttribute() (scimFilter, error) {
+	attribute := parser.next()
+	if attribute == "" || strings.ContainsAny(attribute, "()[]\"") {
+		return nil, fmt.Errorf("expected an attribute but got: %s in filter", attribute)
+	}
+	path := splitScimPath(attribute)
+
+	if parser.peek() == "[" {
+		parser.next()
+		filter, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &scimValuePathFilter{path: path, filter: filter}, parser.expect("]")
+	}
+
+	operator := strings.ToLower(parser.next())
+	if operator == "pr" {
+		return &scimAttributeFilter{path: path, operator: operator}, nil
+	}
+
+	isComparison := false
+	for _, comparisonOperator := range scimComparisonOperators {
+		if operator == comparisonOperator {
+			isComparison = true
+		}
+	}
+	if !isComparison {
+		return nil, fmt.Errorf("unknown operator: %s in filter", operator)
+	}
+
+	token := parser.next()
+	var value interface{}
+	if token == "" || json.Unmarshal([]byte(token), &value) != nil {
+		return nil, fmt.Errorf("invalid value: %s in filter", token)
+	}
+	if _, ok := value.(map[string]interface{}); ok {
+		return nil, fmt.Errorf("invalid value: %s in filter", token)
+	}
+	if _, ok := value.([]interface{}); ok {
+		return nil, fmt.Errorf("invalid value: %s in filter", token)
+	}
+	return &scimAttributeFilter{path: path, operator: operator, value: value}, nil
+}
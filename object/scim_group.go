@@ -0,0 +1,303 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// ScimGroup is a role of the organization, its members are the users and the sub-roles of the role
+type ScimGroup struct {
+	Schemas     []string          `json:"schemas"`
+	Id          string            `json:"id,omitempty"`
+	DisplayName string            `json:"displayName"`
+	Members     []*ScimMultiValue `json:"members,omitempty"`
+	Meta        *ScimMeta         `json:"meta,omitempty"`
+}
+
+func (server *ScimServer) getScimGroup(role *Role, userById map[string]*User) *ScimGroup {
+	scimGroup := &ScimGroup{
+		Schemas:     []string{ScimGroupSchema},
+		Id:          role.Name,
+		DisplayName: role.DisplayName,
+	}
+	if scimGroup.DisplayName == "" {
+		scimGroup.DisplayName = role.Name
+	}
+
+	for _, userId := range role.Users {
+		user, ok := userById[userId]
+		if !ok {
+			continue
+		}
+		scimGroup.Members = append(scimGroup.Members, &ScimMultiValue{
+			Value:   user.Id,
+			Display: user.Name,
+			Type:    "User",
+			Ref:     fmt.Sprintf("%s/Users/%s", server.BaseUrl, user.Id),
+		})
+	}
+	for _, roleId := range role.Roles {
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(roleId)
+		if owner != server.Organization {
+			continue
+		}
+		scimGroup.Members = append(scimGroup.Members, &ScimMultiValue{
+			Value: name,
+			Type:  "Group",
+			Ref:   fmt.Sprintf("%s/Groups/%s", server.BaseUrl, name),
+		})
+	}
+
+	scimGroup.Meta = &ScimMeta{
+		ResourceType: "Group",
+		Created:      getScimTime(role.CreatedTime),
+		LastModified: getScimTime(role.CreatedTime),
+		Location:     fmt.Sprintf("%s/Groups/%s", server.BaseUrl, role.Name),
+	}
+	scimGroup.Meta.Version = getScimVersion(scimGroup)
+	return scimGroup
+}
+
+func (server *ScimServer) getUsersById() map[string]*User {
+	userById := map[string]*User{}
+	for _, user := range GetUsers(server.Organization) {
+		userById[user.GetId()] = user
+	}
+	return userById
+}
+
+func (server *ScimServer) getRolesByName() map[string]*Role {
+	roleByName := map[string]*Role{}
+	for _, role := range GetRoles(server.Organization) {
+		roleByName[role.Name] = role
+	}
+	return roleByName
+}
+
+// getScimGroupMembers returns the users and the sub-roles of the role with the members of the group, a member is
+// a user if its value is the ID of a user, else a role if its value is the name of a role. The members that SCIM
+// can't represent, like the users and the roles of other organizations, are kept. The admins can't be added or removed.
+func (server *ScimServer) getScimGroupMembers(role *Role, scimGroup *ScimGroup, userById map[string]*User, roleByName map[string]*Role) ([]string, []string, *ScimError) {
+	userByScimId := map[string]*User{}
+	for _, user := range userById {
+		if user.Id != "" {
+			userByScimId[user.Id] = user
+		}
+	}
+
+	users := []string{}
+	for _, userId := range role.Users {
+		if _, ok := userById[userId]; !ok {
+			users = append(users, userId)
+		}
+	}
+	roles := []string{}
+	for _, roleId := range role.Roles {
+		if owner, _ := util.GetOwnerAndNameFromIdNoCheck(roleId); owner != server.Organization {
+			roles = append(roles, roleId)
+		}
+	}
+
+	for _, member := range scimGroup.Members {
+		if !strings.EqualFold(member.Type, "Group") {
+			if user, ok := userByScimId[member.Value]; ok {
+				if !util.InSlice(users, user.GetId()) {
+					users = append(users, user.GetId())
+				}
+				continue
+			}
+		}
+
+		memberRole, ok := roleByName[member.Value]
+		if !ok || memberRole.Name == role.Name {
+			return nil, nil, newScimError(http.StatusBadRequest, ScimErrorInvalidValue, fmt.Sprintf("the member: %s is neither a user nor a group", member.Value))
+		}
+		if !util.InSlice(roles, memberRole.GetId()) {
+			roles = append(roles, memberRole.GetId())
+		}
+	}
+
+	addedUsers, removedUsers := util.DiffSlices(role.Users, users)
+	for _, userId := range append(addedUsers, removedUsers...) {
+		if user, ok := userById[userId]; ok && isScimProtectedUser(user) {
+			return nil, nil, newScimError(http.StatusForbidden, "", fmt.Sprintf("the roles of the admin: %s can't be changed by SCIM", userId))
+		}
+	}
+	return users, roles, nil
+}
+
+func (server *ScimServer) setScimGroupMembers(role *Role, scimGroup *ScimGroup, userById map[string]*User) *ScimError {
+	users, roles, scimErr := server.getScimGroupMembers(role, scimGroup, userById, server.getRolesByName())
+	if scimErr != nil {
+		return scimErr
+	}
+
+	role.DisplayName = scimGroup.DisplayName
+	role.Users = users
+	role.Roles = roles
+	return nil
+}
+
+func parseScimGroup(data []byte) (*ScimGroup, *ScimError) {
+	scimGroup := &ScimGroup{}
+	err := json.Unmarshal(data, scimGroup)
+	if err != nil {
+		return nil, newScimError(http.StatusBadRequest, ScimErrorInvalidSyntax, err.Error())
+	}
+	if scimGroup.DisplayName == "" {
+		return nil, newScimError(http.StatusBadRequest, ScimErrorInvalidValue, "displayName is required")
+	}
+	return scimGroup, nil
+}
+
+func (server *ScimServer) getRole(id string) (*Role, *ScimError) {
+	role := getRole(server.Organization, id)
+	if role == nil {
+		return nil, newScimError(http.StatusNotFound, "", fmt.Sprintf("the group: %s doesn't exist", id))
+	}
+	return role, nil
+}
+
+func (server *ScimServer) GetGroups(filter string, startIndex int, count int) (*ScimListResponse, *ScimError) {
+	parsedFilter, scimErr := parseScimRequestFilter(filter)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	userById := server.getUsersById()
+	resources := []interface{}{}
+	for _, role := range GetRoles(server.Organization) {
+		resources = append(resources, server.getScimGroup(role, userById))
+	}
+	return getScimPage(filterScimResources(resources, parsedFilter), startIndex, count), nil
+}
+
+func (server *ScimServer) GetGroup(id string) (*ScimGroup, *ScimError) {
+	role, scimErr := server.getRole(id)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	return server.getScimGroup(role, server.getUsersById()), nil
+}
+
+// AddGroup creates a role named after the display name of the group, like the roles created by the LDAP sync
+func (server *ScimServer) AddGroup(data []byte) (*ScimGroup, *ScimError) {
+	scimGroup, scimErr := parseScimGroup(data)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	if strings.Contains(scimGroup.DisplayName, "/") {
+		return nil, newScimError(http.StatusBadRequest, ScimErrorInvalidValue, "displayName can't contain \"/\"")
+	}
+	if getRole(server.Organization, scimGroup.DisplayName) != nil {
+		return nil, newScimError(http.StatusConflict, ScimErrorUniqueness, fmt.Sprintf("the group: %s already exists", scimGroup.DisplayName))
+	}
+
+	role := &Role{
+		Owner:       server.Organization,
+		Name:        scimGroup.DisplayName,
+		CreatedTime: util.GetCurrentTime(),
+		IsEnabled:   true,
+	}
+	userById := server.getUsersById()
+	scimErr = server.setScimGroupMembers(role, scimGroup, userById)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	if AddRole(role) {
+		server.addAuditLog(AuditOperationAdd, "role", role.GetId(), nil, getRole(role.Owner, role.Name))
+	}
+	return server.getScimGroup(role, userById), nil
+}
+
+func (server *ScimServer) updateGroup(role *Role, scimGroup *ScimGroup, ifMatch string) (*ScimGroup, *ScimError) {
+	userById := server.getUsersById()
+	scimErr := checkScimVersion(server.getScimGroup(role, userById).Meta.Version, ifMatch)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	oldRole := getRole(role.Owner, role.Name)
+	scimErr = server.setScimGroupMembers(role, scimGroup, userById)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	if UpdateRole(role.GetId(), role) {
+		server.addAuditLog(AuditOperationUpdate, "role", role.GetId(), oldRole, getRole(role.Owner, role.Name))
+	}
+	return server.getScimGroup(role, userById), nil
+}
+
+func (server *ScimServer) ReplaceGroup(id string, data []byte, ifMatch string) (*ScimGroup, *ScimError) {
+	role, scimErr := server.getRole(id)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	scimGroup, scimErr := parseScimGroup(data)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	return server.updateGroup(role, scimGroup, ifMatch)
+}
+
+func (server *ScimServer) PatchGroup(id string, data []byte, ifMatch string) (*ScimGroup, *ScimError) {
+	role, scimErr := server.getRole(id)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	patchRequest, scimErr := parseScimPatchRequest(data)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	object := toScimObject(server.getScimGroup(role, server.getUsersById()))
+	scimErr = applyScimPatch(object, patchRequest.Operations)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	scimGroup := &ScimGroup{}
+	scimErr = fromScimObject(object, scimGroup)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	return server.updateGroup(role, scimGroup, ifMatch)
+}
+
+func (server *ScimServer) DeleteGroup(id string, ifMatch string) *ScimError {
+	role, scimErr := server.getRole(id)
+	if scimErr != nil {
+		return scimErr
+	}
+
+	scimErr = checkScimVersion(server.getScimGroup(role, server.getUsersById()).Meta.Version, ifMatch)
+	if scimErr != nil {
+		return scimErr
+	}
+
+	if DeleteRole(role) {
+		server.addAuditLog(AuditOperationDelete, "role", role.GetId(), role, nil)
+	}
+	return nil
+}
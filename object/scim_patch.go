@@ -0,0 +1,250 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+type ScimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type ScimPatchRequest struct {
+	Schemas    []string              `json:"schemas"`
+	Operations []*ScimPatchOperation `json:"Operations"`
+}
+
+// scimPatchPath is a parsed PATCH path: attribute, attribute[filter] or attribute[filter].subAttribute
+type scimPatchPath struct {
+	path         []string
+	filter       scimFilter
+	subAttribute string
+}
+
+func parseScimPatchPath(path string) (*scimPatchPath, error) {
+	start := strings.Index(path, "[")
+	if start == -1 {
+		return &scimPatchPath{path: splitScimPath(path)}, nil
+	}
+
+	end := strings.LastIndex(path, "]")
+	if end < start {
+		return nil, fmt.Errorf("invalid path: %s", path)
+	}
+	filter, err := parseScimFilter(path[start+1 : end])
+	if err != nil {
+		return nil, err
+	}
+
+	patchPath := &scimPatchPath{path: splitScimPath(path[:start]), filter: filter}
+	if rest := path[end+1:]; rest != "" {
+		if !strings.HasPrefix(rest, ".") || strings.Contains(rest[1:], ".") {
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+		patchPath.subAttribute = rest[1:]
+	}
+	return patchPath, nil
+}
+
+// applyScimPatch applies the PATCH operations (RFC 7644 section 3.5.2) on the JSON form of a resource
+func applyScimPatch(resource map[string]interface{}, operations []*ScimPatchOperation) *ScimError {
+	for _, operation := range operations {
+		var value interface{}
+		if len(operation.Value) != 0 {
+			err := json.Unmarshal(operation.Value, &value)
+			if err != nil {
+				return newScimError(http.StatusBadRequest, ScimErrorInvalidSyntax, err.Error())
+			}
+		}
+
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return newScimError(http.StatusBadRequest, ScimErrorInvalidSyntax, fmt.Sprintf("unknown operation: %s", operation.Op))
+		}
+
+		if operation.Path == "" {
+			// without a path, the value holds the attributes to add or replace
+			object, ok := value.(map[string]interface{})
+			if op == "remove" || !ok {
+				return newScimError(http.StatusBadRequest, ScimErrorNoTarget, "the operation requires a path")
+			}
+
+			for key, attributeValue := range object {
+				err := applyScimPatchValue(resource, &scimPatchPath{path: splitScimPath(key)}, op, attributeValue)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		path, err := parseScimPatchPath(operation.Path)
+		if err != nil {
+			return newScimError(http.StatusBadRequest, ScimErrorInvalidPath, err.Error())
+		}
+		scimErr := applyScimPatchValue(resource, path, op, value)
+		if scimErr != nil {
+			return scimErr
+		}
+	}
+	return nil
+}
+
+func applyScimPatchValue(resource map[string]interface{}, path *scimPatchPath, op string, value interface{}) *ScimError {
+	// the parent of the attribute is created by add and replace: "name.givenName" on a user without a name
+	parent := resource
+	for _, name := range path.path[:len(path.path)-1] {
+		key, child := getScimAttribute(parent, name)
+		childObject, ok := child.(map[string]interface{})
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			childObject = map[string]interface{}{}
+			key = name
+			parent[key] = childObject
+		}
+		parent = childObject
+	}
+
+	name := path.path[len(path.path)-1]
+	key, current := getScimAttribute(parent, name)
+	if key == "" {
+		key = name
+	}
+
+	if path.filter != nil {
+		return applyScimPatchFilteredValue(parent, key, current, path, op, value)
+	}
+
+	switch op {
+	case "add":
+		if elements, ok := current.([]interface{}); ok {
+			newElements, isList := value.([]interface{})
+			if !isList {
+				newElements = []interface{}{value}
+			}
+			for _, newElement := range newElements {
+				if !containsScimValue(elements, newElement) {
+					elements = append(elements, newElement)
+				}
+			}
+			parent[key] = elements
+		} else if object, ok := current.(map[string]interface{}); ok {
+			if newObject, ok := value.(map[string]interface{}); ok {
+				for newKey, newValue := range newObject {
+					object[newKey] = newValue
+				}
+			} else {
+				parent[key] = value
+			}
+		} else {
+			parent[key] = value
+		}
+	case "replace":
+		parent[key] = value
+	case "remove":
+		// Azure AD removes the members by giving their values: {"op": "remove", "path": "members", "value": [{"value": "id"}]}
+		elements, isList := current.([]interface{})
+		removedElements, hasValue := value.([]interface{})
+		if isList && hasValue {
+			remainingElements := []interface{}{}
+			for _, element := range elements {
+				if !containsScimValue(removedElements, element) {
+					remainingElements = append(remainingElements, element)
+				}
+			}
+			parent[key] = remainingElements
+		} else {
+			delete(parent, key)
+		}
+	}
+	return nil
+}
+
+// applyScimPatchFilteredValue changes the values of a multi-valued attribute that match the filter of the path:
+// emails[type eq "work"].value
+func applyScimPatchFilteredValue(parent map[string]interface{}, key string, current interface{}, path *scimPatchPath, op string, value interface{}) *ScimError {
+	elements, _ := current.([]interface{})
+	newElements := []interface{}{}
+	isMatched := false
+	for _, element := range elements {
+		object, ok := element.(map[string]interface{})
+		if !ok || !path.filter.match(object) {
+			newElements = append(newElements, element)
+			continue
+		}
+
+		isMatched = true
+		if op == "remove" {
+			if path.subAttribute == "" {
+				continue
+			}
+			subKey, _ := getScimAttribute(object, path.subAttribute)
+			delete(object, subKey)
+		} else if path.subAttribute != "" {
+			subKey, _ := getScimAttribute(object, path.subAttribute)
+			if subKey == "" {
+				subKey = path.subAttribute
+			}
+			object[subKey] = value
+		} else if newObject, ok := value.(map[string]interface{}); ok {
+			for newKey, newValue := range newObject {
+				object[newKey] = newValue
+			}
+		} else {
+			return newScimError(http.StatusBadRequest, ScimErrorInvalidValue, "the value of a filtered path must be an object")
+		}
+		newElements = append(newElements, object)
+	}
+
+	if !isMatched && op != "remove" {
+		// the value is created when the filter is an equality: replace emails[type eq "work"].value on a user without a work email
+		attributeFilter, ok := path.filter.(*scimAttributeFilter)
+		if !ok || attributeFilter.operator != "eq" || len(attributeFilter.path) != 1 || path.subAttribute == "" {
+			return newScimError(http.StatusBadRequest, ScimErrorNoTarget, "no value matches the filter of the path")
+		}
+		newElements = append(newElements, map[string]interface{}{
+			attributeFilter.path[0]: attributeFilter.value,
+			path.subAttribute:       value,
+		})
+	}
+
+	parent[key] = newElements
+	return nil
+}
+
+// containsScimValue checks if the list has the element, the complex values are compared by their "value" sub-attribute
+func containsScimValue(elements []interface{}, element interface{}) bool {
+	object, isObject := element.(map[string]interface{})
+	_, elementValue := getScimAttribute(object, "value")
+	for _, current := range elements {
+		if currentObject, ok := current.(map[string]interface{}); ok && isObject && elementValue != nil {
+			if _, currentValue := getScimAttribute(currentObject, "value"); reflect.DeepEqual(currentValue, elementValue) {
+				return true
+			}
+		} else if reflect.DeepEqual(current, element) {
+			return true
+		}
+	}
+	return false
+}
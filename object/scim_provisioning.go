@@ -0,0 +1,499 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"xorm.io/core"
+)
+
+const (
+	ProvisioningStatePending     = "Pending"
+	ProvisioningStateProvisioned = "Provisioned"
+	ProvisioningStateDeactivated = "Deactivated"
+	ProvisioningStateDeleted     = "Deleted"
+	ProvisioningStateFailed      = "Failed"
+
+	ScimDeletionActionDeactivate = "Deactivate"
+	ScimDeletionActionDelete     = "Delete"
+
+	scimProvisioningMaxAttempts = 10
+	scimProvisioningMaxBackoff  = 24 * time.Hour
+	scimProvisioningInterval    = time.Minute
+)
+
+// ScimAttributeMapping sets a SCIM attribute of the provisioned users, like "name.givenName" or
+// `emails[type eq "home"].value`, with a JSON field of User, like "firstName", or a property: "properties.department"
+type ScimAttributeMapping struct {
+	Attribute string `json:"attribute"`
+	Field     string `json:"field"`
+}
+
+// ProvisioningStatus is the state of a user in the downstream application, the pending ones form the retry queue
+type ProvisioningStatus struct {
+	Application string `xorm:"varchar(100) notnull pk" json:"application"`
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	RemoteId      string `xorm:"varchar(100)" json:"remoteId"`
+	State         string `xorm:"varchar(100)" json:"state"`
+	IsPending     bool   `xorm:"index" json:"isPending"`
+	Attempts      int    `json:"attempts"`
+	NextRetryTime string `xorm:"varchar(100)" json:"nextRetryTime"`
+	LastSyncTime  string `xorm:"varchar(100)" json:"lastSyncTime"`
+	Error         string `xorm:"mediumtext" json:"error"`
+	Hash          string `xorm:"varchar(100)" json:"hash"`
+	// Revision is increased every time the user is queued, so a change made during a push isn't lost
+	Revision int `json:"revision"`
+}
+
+type ScimReconcileResult struct {
+	Users    int `json:"users"`
+	Orphaned int `json:"orphaned"`
+}
+
+var scimProvisioningWakeChan = make(chan struct{}, 1)
+
+func GetProvisioningStatusCount(application string, query *Query) int {
+	session := GetSession(&ProvisioningStatus{}, "", -1, -1, query).And("application=?", application)
+	count, err := session.Count(&ProvisioningStatus{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetProvisioningStatuses(application string) []*ProvisioningStatus {
+	statuses := []*ProvisioningStatus{}
+	err := adapter.Engine.Desc("updated_time").Find(&statuses, &ProvisioningStatus{Application: application})
+	if err != nil {
+		panic(err)
+	}
+
+	return statuses
+}
+
+func GetPaginationProvisioningStatuses(application string, offset, limit int, query *Query) []*ProvisioningStatus {
+	statuses := []*ProvisioningStatus{}
+	session := GetSession(&ProvisioningStatus{}, "", offset, limit, query).And("application=?", application)
+	err := session.Find(&statuses)
+	if err != nil {
+		panic(err)
+	}
+
+	return statuses
+}
+
+func getProvisioningStatus(application string, owner string, name string) *ProvisioningStatus {
+	status := ProvisioningStatus{Application: application, Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&status)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &status
+	}
+	return nil
+}
+
+func (status *ProvisioningStatus) getPk() core.PK {
+	return core.PK{status.Application, status.Owner, status.Name}
+}
+
+// saveProvisioningResult saves the result of a push, the user stays pending if it has been queued again meanwhile
+func saveProvisioningResult(status *ProvisioningStatus, revision int) {
+	status.UpdatedTime = util.GetCurrentTime()
+	affected, err := adapter.Engine.ID(status.getPk()).Where("revision = ?", revision).AllCols().Update(status)
+	if err != nil {
+		panic(err)
+	}
+
+	if affected == 0 {
+		_, err = adapter.Engine.ID(status.getPk()).Cols("remote_id", "state", "error", "last_sync_time", "updated_time").Update(status)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func getScimProvisioningApplications(organization string) []*Application {
+	applications := []*Application{}
+	err := adapter.Engine.Find(&applications, &Application{Organization: organization, EnableScimProvisioning: true})
+	if err != nil {
+		panic(err)
+	}
+
+	return applications
+}
+
+// enqueueProvisioning marks the user as pending for the application, the provisioner pushes its current state,
+// a forced push replaces the downstream user even if it hasn't changed here
+func enqueueProvisioning(application string, owner string, name string, isForced bool) {
+	currentTime := util.GetCurrentTime()
+	status := getProvisioningStatus(application, owner, name)
+	if status == nil {
+		status = &ProvisioningStatus{
+			Application: application,
+			Owner:       owner,
+			Name:        name,
+			CreatedTime: currentTime,
+			UpdatedTime: currentTime,
+			State:       ProvisioningStatePending,
+			IsPending:   true,
+		}
+		_, err := adapter.Engine.Insert(status)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	status.IsPending = true
+	status.Attempts = 0
+	status.NextRetryTime = ""
+	status.UpdatedTime = currentTime
+	columns := []string{"is_pending", "attempts", "next_retry_time", "updated_time"}
+	if isForced {
+		status.Hash = ""
+		columns = append(columns, "hash")
+	}
+	_, err := adapter.Engine.ID(status.getPk()).Incr("revision").Cols(columns...).Update(status)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// provisionUser queues the change of the user for the applications of its organization that provision users by SCIM,
+// it is called when a user is added, updated or deleted
+func provisionUser(owner string, name string) {
+	applications := getScimProvisioningApplications(owner)
+	for _, application := range applications {
+		enqueueProvisioning(application.Name, owner, name, false)
+	}
+
+	if len(applications) != 0 {
+		wakeScimProvisioner()
+	}
+}
+
+// updateUserProvisioning queues an updated user, a renamed user keeps its provisioning states
+func updateUserProvisioning(oldOwner string, oldName string, owner string, name string) {
+	if owner != oldOwner {
+		// the user has moved to another organization: it is removed from the applications of the old one
+		provisionUser(oldOwner, oldName)
+		provisionUser(owner, name)
+		return
+	}
+
+	renameProvisioning(owner, oldName, name)
+	provisionUser(owner, name)
+}
+
+// renameProvisioning keeps the provisioning states of a renamed user, the downstream user is the same
+func renameProvisioning(owner string, oldName string, newName string) {
+	if oldName == newName {
+		return
+	}
+
+	_, err := adapter.Engine.Where("owner = ? and name = ?", owner, oldName).Cols("name").Update(&ProvisioningStatus{Name: newName})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func wakeScimProvisioner() {
+	select {
+	case scimProvisioningWakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// InitScimProvisioner starts the goroutine that pushes the pending users to the downstream applications,
+// it runs when a user changes and every minute for the retries
+func InitScimProvisioner() {
+	util.SafeGoroutine(func() {
+		ticker := time.NewTicker(scimProvisioningInterval)
+		defer ticker.Stop()
+		for {
+			runScimProvisionerOnce()
+
+			select {
+			case <-scimProvisioningWakeChan:
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+func runScimProvisionerOnce() {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Error("SCIM provisioning panic: %v", r)
+		}
+	}()
+
+	statuses := []*ProvisioningStatus{}
+	err := adapter.Engine.Where("is_pending = ?", true).Find(&statuses)
+	if err != nil {
+		panic(err)
+	}
+
+	applications := map[string]*Application{}
+	for _, status := range statuses {
+		if !isProvisioningDue(status, time.Now()) {
+			continue
+		}
+
+		application, ok := applications[status.Application]
+		if !ok {
+			application = getApplication("admin", status.Application)
+			applications[status.Application] = application
+		}
+		if application == nil || !application.EnableScimProvisioning || application.Organization != status.Owner {
+			status.IsPending = false
+			saveProvisioningResult(status, status.Revision)
+			continue
+		}
+
+		processProvisioning(application, status)
+	}
+}
+
+func isProvisioningDue(status *ProvisioningStatus, now time.Time) bool {
+	if status.NextRetryTime == "" {
+		return true
+	}
+
+	nextRetryTime, err := time.Parse(time.RFC3339, status.NextRetryTime)
+	return err != nil || !nextRetryTime.After(now)
+}
+
+// getProvisioningBackoff doubles the delay after each failed attempt: 1 minute, 2 minutes, 4 minutes... up to a day
+func getProvisioningBackoff(attempts int) time.Duration {
+	backoff := scimProvisioningInterval
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= scimProvisioningMaxBackoff {
+			return scimProvisioningMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// processProvisioning pushes the current state of the user: created or replaced if it exists, deactivated or deleted
+// if it has been deleted here
+func processProvisioning(application *Application, status *ProvisioningStatus) {
+	client := newScimClient(application)
+	user := getUser(status.Owner, status.Name)
+	revision := status.Revision
+
+	var err *scimClientError
+	if user == nil {
+		err = removeProvisionedUser(application, client, status)
+	} else {
+		err = pushProvisionedUser(application, client, user, status)
+	}
+
+	if err == nil {
+		status.IsPending = false
+		status.Attempts = 0
+		status.NextRetryTime = ""
+		status.Error = ""
+		status.LastSyncTime = util.GetCurrentTime()
+		saveProvisioningResult(status, revision)
+		return
+	}
+
+	status.Attempts += 1
+	status.State = ProvisioningStateFailed
+	status.Error = err.Error()
+	if err.isRetryable() && status.Attempts < scimProvisioningMaxAttempts {
+		status.NextRetryTime = time.Now().Add(getProvisioningBackoff(status.Attempts)).Format(time.RFC3339)
+	} else {
+		status.IsPending = false
+		status.NextRetryTime = ""
+	}
+	saveProvisioningResult(status, revision)
+	logs.Warning(fmt.Sprintf("SCIM provisioning of %s/%s to %s failed, attempt %d, error %s", status.Owner, status.Name, application.Name, status.Attempts, status.Error))
+}
+
+func pushProvisionedUser(application *Application, client *scimClient, user *User, status *ProvisioningStatus) *scimClientError {
+	scimUser := getScimProvisioningUser(application, user)
+	hash := getScimProvisioningHash(scimUser)
+
+	var err *scimClientError
+	if status.RemoteId == "" {
+		// the user can already exist downstream: provisioned before the status existed or created by hand
+		status.RemoteId, err = client.findUser(user.Name)
+		if err != nil {
+			return err
+		}
+		status.Hash = ""
+	}
+
+	if status.RemoteId != "" && status.Hash != hash {
+		err = client.replaceUser(status.RemoteId, scimUser)
+		if err != nil && err.Status == http.StatusNotFound {
+			status.RemoteId = ""
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if status.RemoteId == "" {
+		status.RemoteId, err = client.createUser(scimUser)
+		if err != nil {
+			return err
+		}
+	}
+
+	status.Hash = hash
+	if user.IsForbidden || user.IsDeleted {
+		status.State = ProvisioningStateDeactivated
+	} else {
+		status.State = ProvisioningStateProvisioned
+	}
+	return nil
+}
+
+func removeProvisionedUser(application *Application, client *scimClient, status *ProvisioningStatus) *scimClientError {
+	if status.RemoteId == "" {
+		status.State = ProvisioningStateDeleted
+		return nil
+	}
+
+	if application.ScimDeletionAction == ScimDeletionActionDelete {
+		err := client.deleteUser(status.RemoteId)
+		if err != nil && err.Status != http.StatusNotFound {
+			return err
+		}
+		status.RemoteId = ""
+		status.State = ProvisioningStateDeleted
+	} else {
+		err := client.deactivateUser(status.RemoteId)
+		if err != nil && err.Status == http.StatusNotFound {
+			status.RemoteId = ""
+			status.State = ProvisioningStateDeleted
+		} else if err != nil {
+			return err
+		} else {
+			status.State = ProvisioningStateDeactivated
+		}
+	}
+	status.Hash = ""
+	return nil
+}
+
+// getScimProvisioningUser returns the SCIM user sent downstream: the attributes served by the SCIM server of Casdoor,
+// with the local ID as externalId, then the attribute mappings of the application
+func getScimProvisioningUser(application *Application, user *User) map[string]interface{} {
+	scimUser := (&ScimServer{}).getScimUser(user, nil)
+	scimUser.Id = ""
+	scimUser.ExternalId = user.Id
+	scimUser.Meta = nil
+
+	object := toScimObject(scimUser)
+	for _, mapping := range application.ScimAttributeMappings {
+		path, err := parseScimPatchPath(mapping.Attribute)
+		if err != nil {
+			continue
+		}
+
+		value := getUserFieldValue(user, mapping.Field)
+		if value == "" {
+			applyScimPatchValue(object, path, "remove", nil)
+		} else {
+			applyScimPatchValue(object, path, "replace", value)
+		}
+	}
+
+	if enterpriseUser, ok := object[ScimEnterpriseUserSchema]; ok && enterpriseUser != nil {
+		if !util.InSlice(scimUser.Schemas, ScimEnterpriseUserSchema) {
+			object["schemas"] = append(scimUser.Schemas, ScimEnterpriseUserSchema)
+		}
+	}
+	return object
+}
+
+func getScimProvisioningHash(scimUser map[string]interface{}) string {
+	data, err := json.Marshal(scimUser)
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// getUserFieldValue returns a string or boolean field of the user by its JSON name, or a property: "properties.department"
+func getUserFieldValue(user *User, field string) string {
+	if strings.HasPrefix(field, "properties.") {
+		return user.Properties[strings.TrimPrefix(field, "properties.")]
+	}
+	if field == "address" {
+		return strings.Join(user.Address, " ")
+	}
+
+	userValue := reflect.ValueOf(user).Elem()
+	for i := 0; i < userValue.NumField(); i++ {
+		structField := userValue.Type().Field(i)
+		if strings.Split(structField.Tag.Get("json"), ",")[0] != field {
+			continue
+		}
+
+		switch structField.Type.Kind() {
+		case reflect.String:
+			return userValue.Field(i).String()
+		case reflect.Bool:
+			return fmt.Sprintf("%t", userValue.Field(i).Bool())
+		}
+		break
+	}
+	return ""
+}
+
+// ReconcileScimProvisioning queues all the users of the organization and the provisioned users that have been
+// deleted, the provisioner then brings the downstream application in line with them
+func ReconcileScimProvisioning(application *Application) *ScimReconcileResult {
+	result := &ScimReconcileResult{}
+	for _, user := range GetUsers(application.Organization) {
+		enqueueProvisioning(application.Name, user.Owner, user.Name, true)
+		result.Users += 1
+	}
+
+	for _, status := range GetProvisioningStatuses(application.Name) {
+		if status.Owner != application.Organization || status.State == ProvisioningStateDeleted {
+			continue
+		}
+		if getUser(status.Owner, status.Name) == nil {
+			enqueueProvisioning(application.Name, status.Owner, status.Name, true)
+			result.Orphaned += 1
+		}
+	}
+
+	wakeScimProvisioner()
+	return result
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "fmt"
+
+type ScimSchemaAttribute struct {
+	Name          string                 `json:"name"`
+	Type          string                 `json:"type"`
+	MultiValued   bool                   `json:"multiValued"`
+	Required      bool                   `json:"required"`
+	CaseExact     bool                   `json:"caseExact"`
+	Mutability    string                 `json:"mutability"`
+	Returned      string                 `json:"returned"`
+	Uniqueness    string                 `json:"uniqueness"`
+	SubAttributes []*ScimSchemaAttribute `json:"subAttributes,omitempty"`
+}
+
+type ScimSchema struct {
+	Schemas     []string               `json:"schemas"`
+	Id          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Attributes  []*ScimSchemaAttribute `json:"attributes"`
+	Meta        *ScimMeta              `json:"meta"`
+}
+
+type ScimSchemaExtension struct {
+	Schema   string `json:"schema"`
+	Required bool   `json:"required"`
+}
+
+type ScimResourceType struct {
+	Schemas          []string               `json:"schemas"`
+	Id               string                 `json:"id"`
+	Name             string                 `json:"name"`
+	Endpoint         string                 `json:"endpoint"`
+	Description      string                 `json:"description"`
+	Schema           string                 `json:"schema"`
+	SchemaExtensions []*ScimSchemaExtension `json:"schemaExtensions,omitempty"`
+	Meta             *ScimMeta              `json:"meta"`
+}
+
+func newScimAttribute(name string, attributeType string, subAttributes ...*ScimSchemaAttribute) *ScimSchemaAttribute {
+	return &ScimSchemaAttribute{
+		Name:          name,
+		Type:          attributeType,
+		Mutability:    "readWrite",
+		Returned:      "default",
+		Uniqueness:    "none",
+		SubAttributes: subAttributes,
+	}
+}
+
+func newScimMultiValuedAttribute(name string, mutability string) *ScimSchemaAttribute {
+	attribute := newScimAttribute(name, "complex",
+		newScimAttribute("value", "string"),
+		newScimAttribute("display", "string"),
+		newScimAttribute("type", "string"),
+		newScimAttribute("primary", "boolean"),
+		newScimAttribute("$ref", "reference"))
+	attribute.MultiValued = true
+	attribute.Mutability = mutability
+	return attribute
+}
+
+func (server *ScimServer) GetServiceProviderConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":          []string{ScimServiceProviderConfigSchema},
+		"documentationUri": "https://casdoor.org",
+		"patch":            map[string]interface{}{"supported": true},
+		"bulk":             map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           map[string]interface{}{"supported": true, "maxResults": scimMaxCount},
+		"changePassword":   map[string]interface{}{"supported": true},
+		"sort":             map[string]interface{}{"supported": false},
+		"etag":             map[string]interface{}{"supported": true},
+		"authenticationSchemes": []map[string]interface{}{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "An access token of the client credentials grant of an application of the organization",
+				"primary":     true,
+			},
+			{
+				"type":        "httpbasic",
+				"name":        "HTTP Basic",
+				"description": "The client ID and the client secret of an application of the organization",
+			},
+		},
+		"meta": &ScimMeta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     fmt.Sprintf("%s/ServiceProviderConfig", server.BaseUrl),
+		},
+	}
+}
+
+func (server *ScimServer) GetSchemas() *ScimListResponse {
+	userName := newScimAttribute("userName", "string")
+	userName.Required = true
+	userName.Uniqueness = "server"
+	password := newScimAttribute("password", "string")
+	password.Mutability = "writeOnly"
+	password.Returned = "never"
+	id := newScimAttribute("id", "string")
+	id.Mutability = "readOnly"
+	id.CaseExact = true
+	displayName := newScimAttribute("displayName", "string")
+	displayName.Required = true
+
+	userAttributes := []*ScimSchemaAttribute{
+		id,
+		newScimAttribute("externalId", "string"),
+		userName,
+		newScimAttribute("name", "complex",
+			newScimAttribute("formatted", "string"),
+			newScimAttribute("familyName", "string"),
+			newScimAttribute("givenName", "string")),
+		newScimAttribute("displayName", "string"),
+		newScimAttribute("title", "string"),
+		newScimAttribute("preferredLanguage", "string"),
+		newScimAttribute("active", "boolean"),
+		password,
+		newScimMultiValuedAttribute("emails", "readWrite"),
+		newScimMultiValuedAttribute("phoneNumbers", "readWrite"),
+		newScimMultiValuedAttribute("groups", "readOnly"),
+	}
+	groupAttributes := []*ScimSchemaAttribute{id, displayName, newScimMultiValuedAttribute("members", "readWrite")}
+	enterpriseUserAttributes := []*ScimSchemaAttribute{}
+	for _, property := range scimEnterpriseUserProperties {
+		enterpriseUserAttributes = append(enterpriseUserAttributes, newScimAttribute(property, "string"))
+	}
+
+	resources := []interface{}{}
+	for _, schema := range []*ScimSchema{
+		{Id: ScimUserSchema, Name: "User", Description: "User Account", Attributes: userAttributes},
+		{Id: ScimGroupSchema, Name: "Group", Description: "Group", Attributes: groupAttributes},
+		{Id: ScimEnterpriseUserSchema, Name: "EnterpriseUser", Description: "Enterprise User", Attributes: enterpriseUserAttributes},
+	} {
+		schema.Schemas = []string{ScimSchemaSchema}
+		schema.Meta = &ScimMeta{
+			ResourceType: "Schema",
+			Location:     fmt.Sprintf("%s/Schemas/%s", server.BaseUrl, schema.Id),
+		}
+		resources = append(resources, schema)
+	}
+	return getScimPage(resources, 1, len(resources))
+}
+
+func (server *ScimServer) GetResourceTypes() *ScimListResponse {
+	resources := []interface{}{
+		&ScimResourceType{
+			Schemas:          []string{ScimResourceTypeSchema},
+			Id:               "User",
+			Name:             "User",
+			Endpoint:         "/Users",
+			Description:      "User Account",
+			Schema:           ScimUserSchema,
+			SchemaExtensions: []*ScimSchemaExtension{{Schema: ScimEnterpriseUserSchema}},
+			Meta:             &ScimMeta{ResourceType: "ResourceType", Location: fmt.Sprintf("%s/ResourceTypes/User", server.BaseUrl)},
+		},
+		&ScimResourceType{
+			Schemas:     []string{ScimResourceTypeSchema},
+			Id:          "Group",
+			Name:        "Group",
+			Endpoint:    "/Groups",
+			Description: "Group",
+			Schema:      ScimGroupSchema,
+			Meta:        &ScimMeta{ResourceType: "ResourceType", Location: fmt.Sprintf("%s/ResourceTypes/Group", server.BaseUrl)},
+		},
+	}
+	return getScimPage(resources, 1, len(resources))
+}
@@ -0,0 +1,259 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestScimUser() map[string]interface{} {
+	active := true
+	return toScimObject(&ScimUser{
+		Schemas:  []string{ScimUserSchema},
+		Id:       "1234",
+		UserName: "alice",
+		Active:   &active,
+		Emails: []*ScimMultiValue{
+			{Value: "alice@example.com", Type: "work", Primary: true},
+			{Value: "alice@home.com", Type: "home"},
+		},
+	})
+}
+
+func TestScimFilter(t *testing.T) {
+	user := newTestScimUser()
+	tests := []struct {
+		filter string
+		match  bool
+	}{
+		{`userName eq "ALICE"`, true},
+		{`userName eq "bob"`, false},
+		{`userName sw "al" and active eq true`, true},
+		{`emails[type eq "work" and value co "example"]`, true},
+		{`emails[type eq "work" and value co "home"]`, false},
+		{`emails.value ew "home.com"`, true},
+		{`emails co "home.com"`, true},
+		{`title pr`, false},
+		{`not (title pr) and (userName eq "bob" or id eq "1234")`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "alice"`, true},
+		{`meta.lastModified gt "2022-01-01T00:00:00Z"`, false},
+	}
+	for _, test := range tests {
+		filter, err := parseScimFilter(test.filter)
+		assert.Nil(t, err, test.filter)
+		assert.Equal(t, test.match, filter.match(user), test.filter)
+	}
+
+	for _, filter := range []string{`userName eq`, `userName foo "alice"`, `(userName eq "alice"`, `emails[type eq "work"`, `userName eq "alice`} {
+		_, err := parseScimFilter(filter)
+		assert.NotNil(t, err, filter)
+	}
+}
+
+func TestScimPatch(t *testing.T) {
+	var operations []*ScimPatchOperation
+	err := json.Unmarshal([]byte(`[
+		{"op": "Replace", "path": "emails[type eq \"work\"].value", "value": "alice@example.org"},
+		{"op": "add", "path": "phoneNumbers[type eq \"mobile\"].value", "value": "+1 555 0100"},
+		{"op": "remove", "path": "emails[type eq \"home\"]"},
+		{"op": "replace", "value": {"active": false, "name.givenName": "Alice"}}
+	]`), &operations)
+	assert.Nil(t, err)
+
+	user := newTestScimUser()
+	assert.Nil(t, applyScimPatch(user, operations))
+
+	scimUser := &ScimUser{}
+	assert.Nil(t, fromScimObject(user, scimUser))
+	assert.False(t, *scimUser.Active)
+	assert.Equal(t, "Alice", scimUser.Name.GivenName)
+	assert.Equal(t, 1, len(scimUser.Emails))
+	assert.Equal(t, "alice@example.org", scimUser.Emails[0].Value)
+	assert.Equal(t, 1, len(scimUser.PhoneNumbers))
+	assert.Equal(t, "mobile", scimUser.PhoneNumbers[0].Type)
+	assert.Equal(t, "+1 555 0100", scimUser.PhoneNumbers[0].Value)
+
+	group := toScimObject(&ScimGroup{
+		DisplayName: "admins",
+		Members:     []*ScimMultiValue{{Value: "1"}, {Value: "2"}},
+	})
+	err = json.Unmarshal([]byte(`[
+		{"op": "add", "path": "members", "value": [{"value": "3"}, {"value": "1"}]},
+		{"op": "remove", "path": "members", "value": [{"value": "2"}]},
+		{"op": "remove", "path": "members[value eq \"1\"]"}
+	]`), &operations)
+	assert.Nil(t, err)
+	assert.Nil(t, applyScimPatch(group, operations))
+
+	scimGroup := &ScimGroup{}
+	assert.Nil(t, fromScimObject(group, scimGroup))
+	assert.Equal(t, 1, len(scimGroup.Members))
+	assert.Equal(t, "3", scimGroup.Members[0].Value)
+
+	err = json.Unmarshal([]byte(`[{"op": "move", "path": "members"}]`), &operations)
+	assert.Nil(t, err)
+	assert.NotNil(t, applyScimPatch(group, operations))
+}
+
+func TestScimPatchGroupKeepsMembers(t *testing.T) {
+	server := &ScimServer{Organization: "org", BaseUrl: "https://casdoor.example.com/scim/org"}
+	userById := map[string]*User{
+		"org/alice": {Owner: "org", Name: "alice", Id: "1"},
+		"org/carol": {Owner: "org", Name: "carol", Id: "3"},
+	}
+	roleByName := map[string]*Role{
+		"devs": {Owner: "org", Name: "devs"},
+	}
+	role := &Role{
+		Owner: "org",
+		Name:  "admins",
+		Users: []string{"other/bob", "org/alice"},
+		Roles: []string{"other/auditors"},
+	}
+
+	var operations []*ScimPatchOperation
+	err := json.Unmarshal([]byte(`[{"op": "add", "path": "members", "value": [{"value": "3"}, {"value": "devs", "type": "Group"}]}]`), &operations)
+	assert.Nil(t, err)
+
+	group := toScimObject(server.getScimGroup(role, userById))
+	assert.Nil(t, applyScimPatch(group, operations))
+	scimGroup := &ScimGroup{}
+	assert.Nil(t, fromScimObject(group, scimGroup))
+
+	// the user and the role of the other organization are not members of the SCIM group, but they are kept
+	users, roles, scimErr := server.getScimGroupMembers(role, scimGroup, userById, roleByName)
+	assert.Nil(t, scimErr)
+	assert.Equal(t, []string{"other/bob", "org/alice", "org/carol"}, users)
+	assert.Equal(t, []string{"other/auditors", "org/devs"}, roles)
+
+	scimGroup.Members = append(scimGroup.Members, &ScimMultiValue{Value: "unknown"})
+	_, _, scimErr = server.getScimGroupMembers(role, scimGroup, userById, roleByName)
+	assert.NotNil(t, scimErr)
+
+	// the admins can be neither added to nor removed from a role
+	userById["org/carol"].IsAdmin = true
+	scimGroup.Members = scimGroup.Members[:len(scimGroup.Members)-1]
+	_, _, scimErr = server.getScimGroupMembers(role, scimGroup, userById, roleByName)
+	assert.Equal(t, http.StatusForbidden, scimErr.GetStatus())
+
+	userById["org/alice"].IsGlobalAdmin = true
+	scimGroup.Members = []*ScimMultiValue{}
+	_, _, scimErr = server.getScimGroupMembers(role, scimGroup, userById, roleByName)
+	assert.Equal(t, http.StatusForbidden, scimErr.GetStatus())
+}
+
+func TestScimProvisioning(t *testing.T) {
+	requests := []string{}
+	remoteUsers := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests = append(requests, r.Method)
+		data, _ := ioutil.ReadAll(r.Body)
+		body := map[string]interface{}{}
+		_ = json.Unmarshal(data, &body)
+
+		id := strings.TrimPrefix(r.URL.Path, "/scim/v2/Users/")
+		switch r.Method {
+		case "GET":
+			_, _ = w.Write([]byte(`{"Resources": []}`))
+		case "POST":
+			body["id"] = "remote-1"
+			remoteUsers["remote-1"] = body
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(body)
+		case "PUT", "PATCH":
+			if _, ok := remoteUsers[id]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.Method == "PUT" {
+				remoteUsers[id] = body
+			} else {
+				remoteUsers[id]["active"] = false
+			}
+		}
+	}))
+	defer server.Close()
+
+	application := &Application{
+		ScimBaseUrl: server.URL + "/scim/v2/",
+		ScimToken:   "secret",
+		ScimAttributeMappings: []*ScimAttributeMapping{
+			{Attribute: "title", Field: "properties.jobTitle"},
+			{Attribute: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", Field: "affiliation"},
+		},
+	}
+	user := &User{Owner: "example", Name: "alice", Id: "1234", Email: "alice@example.com", Affiliation: "R&D",
+		Properties: map[string]string{"jobTitle": "Engineer"}}
+	status := &ProvisioningStatus{}
+	client := newScimClient(application)
+
+	assert.Nil(t, pushProvisionedUser(application, client, user, status))
+	assert.Equal(t, []string{"GET", "POST"}, requests)
+	assert.Equal(t, "remote-1", status.RemoteId)
+	assert.Equal(t, ProvisioningStateProvisioned, status.State)
+	remoteUser := remoteUsers["remote-1"]
+	assert.Equal(t, "alice", remoteUser["userName"])
+	assert.Equal(t, "1234", remoteUser["externalId"])
+	assert.Equal(t, "Engineer", remoteUser["title"])
+	assert.Equal(t, map[string]interface{}{"department": "R&D"}, remoteUser[ScimEnterpriseUserSchema])
+
+	// an unchanged user isn't sent again
+	assert.Nil(t, pushProvisionedUser(application, client, user, status))
+	assert.Equal(t, 2, len(requests))
+
+	user.IsForbidden = true
+	assert.Nil(t, pushProvisionedUser(application, client, user, status))
+	assert.Equal(t, "PUT", requests[2])
+	assert.Equal(t, false, remoteUsers["remote-1"]["active"])
+	assert.Equal(t, ProvisioningStateDeactivated, status.State)
+
+	assert.Nil(t, removeProvisionedUser(application, client, status))
+	assert.Equal(t, "PATCH", requests[3])
+	assert.Equal(t, ProvisioningStateDeactivated, status.State)
+
+	// the downstream user has been deleted by hand
+	delete(remoteUsers, "remote-1")
+	assert.Nil(t, removeProvisionedUser(application, client, status))
+	assert.Equal(t, ProvisioningStateDeleted, status.State)
+	assert.Equal(t, "", status.RemoteId)
+
+	application.ScimToken = "wrong"
+	err := pushProvisionedUser(application, newScimClient(application), user, status)
+	assert.NotNil(t, err)
+	assert.False(t, err.isRetryable())
+}
+
+func TestProvisioningBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, getProvisioningBackoff(1))
+	assert.Equal(t, 8*time.Minute, getProvisioningBackoff(4))
+	assert.Equal(t, 24*time.Hour, getProvisioningBackoff(20))
+
+	now := time.Now()
+	assert.True(t, isProvisioningDue(&ProvisioningStatus{}, now))
+	assert.False(t, isProvisioningDue(&ProvisioningStatus{NextRetryTime: now.Add(time.Minute).Format(time.RFC3339)}, now))
+	assert.True(t, isProvisioningDue(&ProvisioningStatus{NextRetryTime: now.Add(-time.Minute).Format(time.RFC3339)}, now))
+}
@@ -0,0 +1,405 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/astaxie/beego"
+	"github.com/casdoor/casdoor/util"
+	"xorm.io/core"
+)
+
+// the SCIM attributes without a User field are kept in the properties of the user
+const scimExternalIdProperty = "scimExternalId"
+
+var scimEnterpriseUserProperties = []string{"employeeNumber", "costCenter", "organization", "division", "department"}
+
+type ScimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+}
+
+type ScimMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type ScimUser struct {
+	Schemas           []string          `json:"schemas"`
+	Id                string            `json:"id,omitempty"`
+	ExternalId        string            `json:"externalId,omitempty"`
+	UserName          string            `json:"userName"`
+	Name              *ScimName         `json:"name,omitempty"`
+	DisplayName       string            `json:"displayName,omitempty"`
+	Title             string            `json:"title,omitempty"`
+	PreferredLanguage string            `json:"preferredLanguage,omitempty"`
+	Active            *bool             `json:"active,omitempty"`
+	Password          string            `json:"password,omitempty"`
+	Emails            []*ScimMultiValue `json:"emails,omitempty"`
+	PhoneNumbers      []*ScimMultiValue `json:"phoneNumbers,omitempty"`
+	Groups            []*ScimMultiValue `json:"groups,omitempty"`
+	EnterpriseUser    map[string]string `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Meta              *ScimMeta         `json:"meta,omitempty"`
+}
+
+func getPrimaryScimValue(values []*ScimMultiValue) string {
+	for _, value := range values {
+		if value.Primary {
+			return value.Value
+		}
+	}
+	if len(values) != 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+// migrateUserIds assigns an ID to the users added before AddUsers() did, the SCIM server addresses the users by their ID
+func migrateUserIds() {
+	users := []*User{}
+	err := adapter.Engine.Where("id = ? or id is null", "").Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, user := range users {
+		user.Id = util.GenerateId()
+		_, err = adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("id").Update(user)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// isScimProtectedUser tells if the user is an admin, whose password and roles can't be changed by a SCIM client
+func isScimProtectedUser(user *User) bool {
+	return user.IsAdmin || user.IsGlobalAdmin
+}
+
+func (server *ScimServer) getScimUser(user *User, roles []*Role) *ScimUser {
+	active := !user.IsForbidden && !user.IsDeleted
+	scimUser := &ScimUser{
+		Schemas:           []string{ScimUserSchema},
+		Id:                user.Id,
+		ExternalId:        user.Properties[scimExternalIdProperty],
+		UserName:          user.Name,
+		DisplayName:       user.DisplayName,
+		Title:             user.Title,
+		PreferredLanguage: user.Language,
+		Active:            &active,
+	}
+	if user.FirstName != "" || user.LastName != "" {
+		scimUser.Name = &ScimName{
+			Formatted:  strings.TrimSpace(fmt.Sprintf("%s %s", user.FirstName, user.LastName)),
+			FamilyName: user.LastName,
+			GivenName:  user.FirstName,
+		}
+	}
+	if user.Email != "" {
+		scimUser.Emails = []*ScimMultiValue{{Value: user.Email, Type: "work", Primary: true}}
+	}
+	if user.Phone != "" {
+		scimUser.PhoneNumbers = []*ScimMultiValue{{Value: user.Phone, Type: "work", Primary: true}}
+	}
+
+	enterpriseUser := map[string]string{}
+	for _, property := range scimEnterpriseUserProperties {
+		if value := user.Properties[property]; value != "" {
+			enterpriseUser[property] = value
+		}
+	}
+	if len(enterpriseUser) != 0 {
+		scimUser.Schemas = append(scimUser.Schemas, ScimEnterpriseUserSchema)
+		scimUser.EnterpriseUser = enterpriseUser
+	}
+
+	for _, role := range roles {
+		if util.InSlice(role.Users, user.GetId()) {
+			scimUser.Groups = append(scimUser.Groups, &ScimMultiValue{
+				Value:   role.Name,
+				Display: role.DisplayName,
+				Type:    "direct",
+				Ref:     fmt.Sprintf("%s/Groups/%s", server.BaseUrl, role.Name),
+			})
+		}
+	}
+
+	lastModified := user.UpdatedTime
+	if lastModified == "" {
+		lastModified = user.CreatedTime
+	}
+	scimUser.Meta = &ScimMeta{
+		ResourceType: "User",
+		Created:      getScimTime(user.CreatedTime),
+		LastModified: getScimTime(lastModified),
+		Location:     fmt.Sprintf("%s/Users/%s", server.BaseUrl, user.Id),
+	}
+	scimUser.Meta.Version = getScimVersion(scimUser)
+	return scimUser
+}
+
+// setScimUserFields replaces the fields of the user with the SCIM attributes, the missing attributes are cleared
+func setScimUserFields(user *User, scimUser *ScimUser) {
+	user.Name = scimUser.UserName
+	user.DisplayName = scimUser.DisplayName
+	user.FirstName = ""
+	user.LastName = ""
+	if scimUser.Name != nil {
+		user.FirstName = scimUser.Name.GivenName
+		user.LastName = scimUser.Name.FamilyName
+		if user.DisplayName == "" {
+			user.DisplayName = scimUser.Name.Formatted
+		}
+	}
+	user.Email = getPrimaryScimValue(scimUser.Emails)
+	user.Phone = getPrimaryScimValue(scimUser.PhoneNumbers)
+	user.Title = scimUser.Title
+	user.Language = scimUser.PreferredLanguage
+	user.IsForbidden = scimUser.Active != nil && !*scimUser.Active
+
+	if user.Properties == nil {
+		user.Properties = map[string]string{}
+	}
+	properties := map[string]string{scimExternalIdProperty: scimUser.ExternalId}
+	for _, property := range scimEnterpriseUserProperties {
+		properties[property] = scimUser.EnterpriseUser[property]
+	}
+	for key, value := range properties {
+		if value == "" {
+			delete(user.Properties, key)
+		} else {
+			user.Properties[key] = value
+		}
+	}
+}
+
+func parseScimUser(data []byte) (*ScimUser, *ScimError) {
+	object := map[string]interface{}{}
+	err := json.Unmarshal(data, &object)
+	if err != nil {
+		return nil, newScimError(http.StatusBadRequest, ScimErrorInvalidSyntax, err.Error())
+	}
+	return getScimUserFromObject(object)
+}
+
+// getScimUserFromObject converts the JSON form back to a user, Azure AD sends the booleans as strings: "active": "False"
+func getScimUserFromObject(object map[string]interface{}) (*ScimUser, *ScimError) {
+	if key, value := getScimAttribute(object, "active"); key != "" {
+		if active, ok := value.(string); ok {
+			object[key] = strings.EqualFold(active, "true")
+		}
+	}
+
+	scimUser := &ScimUser{}
+	scimErr := fromScimObject(object, scimUser)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	if scimUser.UserName == "" {
+		return nil, newScimError(http.StatusBadRequest, ScimErrorInvalidValue, "userName is required")
+	}
+	return scimUser, nil
+}
+
+func (server *ScimServer) getUser(id string) (*User, *ScimError) {
+	user := getUserById(server.Organization, id)
+	if user == nil {
+		return nil, newScimError(http.StatusNotFound, "", fmt.Sprintf("the user: %s doesn't exist", id))
+	}
+	return user, nil
+}
+
+func (server *ScimServer) GetUsers(filter string, startIndex int, count int) (*ScimListResponse, *ScimError) {
+	parsedFilter, scimErr := parseScimRequestFilter(filter)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	// the clients look up a user by its name before creating it, this doesn't need to read all the users
+	var users []*User
+	if attributeFilter, ok := parsedFilter.(*scimAttributeFilter); ok && attributeFilter.operator == "eq" &&
+		len(attributeFilter.path) == 1 && strings.EqualFold(attributeFilter.path[0], "userName") {
+		if user := getUser(server.Organization, fmt.Sprintf("%v", attributeFilter.value)); user != nil {
+			users = append(users, user)
+		}
+	} else {
+		users = GetUsers(server.Organization)
+	}
+
+	roles := GetRoles(server.Organization)
+	resources := []interface{}{}
+	for _, user := range users {
+		resources = append(resources, server.getScimUser(user, roles))
+	}
+	return getScimPage(filterScimResources(resources, parsedFilter), startIndex, count), nil
+}
+
+func (server *ScimServer) GetUser(id string) (*ScimUser, *ScimError) {
+	user, scimErr := server.getUser(id)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	return server.getScimUser(user, GetRoles(server.Organization)), nil
+}
+
+func (server *ScimServer) AddUser(data []byte) (*ScimUser, *ScimError) {
+	scimUser, scimErr := parseScimUser(data)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	if getUser(server.Organization, scimUser.UserName) != nil {
+		return nil, newScimError(http.StatusConflict, ScimErrorUniqueness, fmt.Sprintf("the user: %s already exists", scimUser.UserName))
+	}
+
+	organization := getOrganization("admin", server.Organization)
+	if organization == nil {
+		return nil, newScimError(http.StatusNotFound, "", fmt.Sprintf("the organization: %s doesn't exist", server.Organization))
+	}
+
+	user := &User{
+		Owner:             server.Organization,
+		CreatedTime:       util.GetCurrentTime(),
+		Type:              "normal-user",
+		Password:          scimUser.Password,
+		Avatar:            organization.DefaultAvatar,
+		Score:             beego.AppConfig.DefaultInt("initScore", 2000),
+		SignupApplication: server.Application.Name,
+		Properties:        map[string]string{},
+	}
+	setScimUserFields(user, scimUser)
+	if !AddUser(user) {
+		return nil, newScimError(http.StatusBadRequest, ScimErrorInvalidValue, fmt.Sprintf("failed to add the user: %s", scimUser.UserName))
+	}
+	server.addAuditLog(AuditOperationAdd, "user", user.GetId(), nil, getUser(user.Owner, user.Name))
+	return server.getScimUser(user, GetRoles(server.Organization)), nil
+}
+
+// updateUser saves the user changed by a PUT or a PATCH, the roles follow the user if it is renamed
+func (server *ScimServer) updateUser(user *User, scimUser *ScimUser, ifMatch string) (*ScimUser, *ScimError) {
+	roles := GetRoles(server.Organization)
+	scimErr := checkScimVersion(server.getScimUser(user, roles).Meta.Version, ifMatch)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	oldId := user.GetId()
+	if scimUser.UserName != user.Name && getUser(server.Organization, scimUser.UserName) != nil {
+		return nil, newScimError(http.StatusConflict, ScimErrorUniqueness, fmt.Sprintf("the user: %s already exists", scimUser.UserName))
+	}
+
+	if scimUser.Password != "" && isScimProtectedUser(user) {
+		return nil, newScimError(http.StatusForbidden, "", fmt.Sprintf("the password of the admin: %s can't be changed by SCIM", user.GetId()))
+	}
+
+	oldUser := getUser(user.Owner, user.Name)
+	setScimUserFields(user, scimUser)
+	if scimUser.Password != "" {
+		user.Password = scimUser.Password
+		user.UpdateUserPassword(getOrganization("admin", server.Organization))
+	}
+	user.UpdatedTime = util.GetCurrentTime()
+	if UpdateUserForAllFields(oldId, user) {
+		server.addAuditLog(AuditOperationUpdate, "user", oldId, oldUser, getUser(user.Owner, user.Name))
+	}
+
+	if user.GetId() != oldId {
+		for _, role := range roles {
+			for i, roleUser := range role.Users {
+				if roleUser == oldId {
+					role.Users[i] = user.GetId()
+					UpdateRole(role.GetId(), role)
+					break
+				}
+			}
+		}
+	}
+	return server.getScimUser(user, roles), nil
+}
+
+func (server *ScimServer) ReplaceUser(id string, data []byte, ifMatch string) (*ScimUser, *ScimError) {
+	user, scimErr := server.getUser(id)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	scimUser, scimErr := parseScimUser(data)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	return server.updateUser(user, scimUser, ifMatch)
+}
+
+func (server *ScimServer) PatchUser(id string, data []byte, ifMatch string) (*ScimUser, *ScimError) {
+	user, scimErr := server.getUser(id)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	patchRequest, scimErr := parseScimPatchRequest(data)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	object := toScimObject(server.getScimUser(user, GetRoles(server.Organization)))
+	scimErr = applyScimPatch(object, patchRequest.Operations)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+
+	scimUser, scimErr := getScimUserFromObject(object)
+	if scimErr != nil {
+		return nil, scimErr
+	}
+	return server.updateUser(user, scimUser, ifMatch)
+}
+
+func (server *ScimServer) DeleteUser(id string, ifMatch string) *ScimError {
+	user, scimErr := server.getUser(id)
+	if scimErr != nil {
+		return scimErr
+	}
+
+	roles := GetRoles(server.Organization)
+	scimErr = checkScimVersion(server.getScimUser(user, roles).Meta.Version, ifMatch)
+	if scimErr != nil {
+		return scimErr
+	}
+
+	if DeleteUser(user) {
+		server.addAuditLog(AuditOperationDelete, "user", user.GetId(), user, nil)
+	}
+	for _, role := range roles {
+		if !util.InSlice(role.Users, user.GetId()) {
+			continue
+		}
+
+		roleUsers := []string{}
+		for _, roleUser := range role.Users {
+			if roleUser != user.GetId() {
+				roleUsers = append(roleUsers, roleUser)
+			}
+		}
+		role.Users = roleUsers
+		UpdateRole(role.GetId(), role)
+	}
+	return nil
+}
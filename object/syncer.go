@@ -52,11 +52,23 @@ type Syncer struct {
 	SyncInterval     int            `json:"syncInterval"`
 	IsEnabled        bool           `json:"isEnabled"`
 
+	SyncDirection      string `xorm:"varchar(100)" json:"syncDirection"`
+	ConflictPolicy     string `xorm:"varchar(100)" json:"conflictPolicy"`
+	PropagateDeletion  bool   `json:"propagateDeletion"`
+	MaxDeletionPercent int    `json:"maxDeletionPercent"`
+
+	Provider     string `xorm:"varchar(100)" json:"provider"`
+	FilePath     string `xorm:"varchar(500)" json:"filePath"`
+	Url          string `xorm:"varchar(500)" json:"url"`
+	ResultPath   string `xorm:"varchar(100)" json:"resultPath"`
+	NextPagePath string `xorm:"varchar(100)" json:"nextPagePath"`
+	PageParam    string `xorm:"varchar(100)" json:"pageParam"`
+
 	Adapter *Adapter `xorm:"-" json:"-"`
 }
 
-func GetSyncerCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetSyncerCount(owner string, query *Query) int {
+	session := GetSession(&Syncer{}, owner, -1, -1, query)
 	count, err := session.Count(&Syncer{})
 	if err != nil {
 		panic(err)
@@ -75,9 +87,9 @@ func GetSyncers(owner string) []*Syncer {
 	return syncers
 }
 
-func GetPaginationSyncers(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Syncer {
+func GetPaginationSyncers(owner string, offset, limit int, query *Query) []*Syncer {
 	syncers := []*Syncer{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Syncer{}, owner, offset, limit, query)
 	err := session.Find(&syncers)
 	if err != nil {
 		panic(err)
@@ -155,7 +167,7 @@ func updateSyncerErrorText(syncer *Syncer, line string) bool {
 		return false
 	}
 
-	s.ErrorText = s.ErrorText + line
+	s.ErrorText = trimSyncerErrorText(s.ErrorText + line)
 
 	affected, err := adapter.Engine.ID(core.PK{s.Owner, s.Name}).Cols("error_text").Update(s)
 	if err != nil {
@@ -186,6 +198,7 @@ func DeleteSyncer(syncer *Syncer) bool {
 
 	if affected == 1 {
 		deleteSyncerJob(syncer)
+		deleteSyncerStates(syncer)
 	}
 
 	return affected != 0
@@ -211,7 +224,13 @@ func (syncer *Syncer) getTable() string {
 	}
 }
 
-func RunSyncer(syncer *Syncer) {
+func RunSyncer(syncer *Syncer) *SyncerRun {
+	syncer.initAdapter()
+	return syncer.runSyncer()
+}
+
+// DryRunSyncer returns the operations the syncer would apply without applying them
+func DryRunSyncer(syncer *Syncer) (*SyncerPlan, error) {
 	syncer.initAdapter()
-	syncer.syncUsers()
+	return syncer.getSyncerPlan()
 }
@@ -30,6 +30,11 @@ func (syncer *Syncer) getAffiliations() []*Affiliation {
 }
 
 func (syncer *Syncer) getAffiliationMap() ([]*Affiliation, map[int]string) {
+	if !syncer.isDatabaseSource() {
+		// the file and HTTP sources have no affiliation table
+		return []*Affiliation{}, nil
+	}
+
 	affiliations := syncer.getAffiliations()
 
 	m := map[int]string{}
@@ -0,0 +1,406 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"xorm.io/core"
+)
+
+const (
+	SyncerDirectionPull          = "Pull"
+	SyncerDirectionPush          = "Push"
+	SyncerDirectionBidirectional = "Bidirectional"
+
+	SyncerConflictPolicySourceWins  = "SourceWins"
+	SyncerConflictPolicyCasdoorWins = "CasdoorWins"
+	SyncerConflictPolicyNewestWins  = "NewestWins"
+	SyncerConflictPolicyManual      = "Manual"
+
+	SyncerDecisionTypeConflict = "Conflict"
+	SyncerDecisionTypeDeletion = "Deletion"
+
+	SyncerActionPull               = "Pull"
+	SyncerActionPush               = "Push"
+	SyncerActionPending            = "Pending"
+	SyncerActionDeleteUser         = "DeleteUser"
+	SyncerActionDeleteOriginalUser = "DeleteOriginalUser"
+)
+
+// SyncerDifference is a column whose value differs between Casdoor and the source
+type SyncerDifference struct {
+	Column       string `json:"column"`
+	CasdoorValue string `json:"casdoorValue"`
+	SourceValue  string `json:"sourceValue"`
+}
+
+// SyncerDecision records a conflict (the user has changed on both sides since the last sync) or a propagated deletion,
+// with the action taken. The conflicts of the "Manual" policy stay pending until an admin resolves them
+type SyncerDecision struct {
+	Id          int    `xorm:"int notnull pk autoincr" json:"id"`
+	Owner       string `xorm:"varchar(100) index" json:"owner"`
+	Syncer      string `xorm:"varchar(100) index" json:"syncer"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	UserId      string              `xorm:"varchar(100) index" json:"userId"`
+	UserName    string              `xorm:"varchar(100)" json:"userName"`
+	Type        string              `xorm:"varchar(100)" json:"type"`
+	Policy      string              `xorm:"varchar(100)" json:"policy"`
+	Action      string              `xorm:"varchar(100)" json:"action"`
+	IsResolved  bool                `json:"isResolved"`
+	Resolver    string              `xorm:"varchar(100)" json:"resolver"`
+	Error       string              `xorm:"mediumtext" json:"error"`
+	Differences []*SyncerDifference `xorm:"mediumtext" json:"differences"`
+}
+
+// SyncedUser is a user that existed on both sides after a sync, a synced user missing on one side has been deleted there
+type SyncedUser struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Syncer      string `xorm:"varchar(100) notnull pk" json:"syncer"`
+	Id          string `xorm:"varchar(100) notnull pk" json:"id"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+}
+
+func GetSyncerDecisionCount(syncer *Syncer, query *Query) int {
+	session := GetSession(&SyncerDecision{}, syncer.Owner, -1, -1, query).And("syncer=?", syncer.Name)
+	count, err := session.Count(&SyncerDecision{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetSyncerDecisions(syncer *Syncer) []*SyncerDecision {
+	decisions := []*SyncerDecision{}
+	err := adapter.Engine.Desc("id").Find(&decisions, &SyncerDecision{Owner: syncer.Owner, Syncer: syncer.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return decisions
+}
+
+func GetPaginationSyncerDecisions(syncer *Syncer, offset, limit int, query *Query) []*SyncerDecision {
+	query.setDefaultSort("id", "descend")
+
+	decisions := []*SyncerDecision{}
+	session := GetSession(&SyncerDecision{}, syncer.Owner, offset, limit, query).And("syncer=?", syncer.Name)
+	err := session.Find(&decisions)
+	if err != nil {
+		panic(err)
+	}
+
+	return decisions
+}
+
+func getSyncerDecision(id int) *SyncerDecision {
+	decision := SyncerDecision{Id: id}
+	existed, err := adapter.Engine.Get(&decision)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &decision
+	}
+	return nil
+}
+
+func getPendingSyncerDecision(syncer *Syncer, userId string) *SyncerDecision {
+	decision := SyncerDecision{}
+	existed, err := adapter.Engine.Where("owner = ? and syncer = ? and user_id = ? and action = ? and is_resolved = ?",
+		syncer.Owner, syncer.Name, userId, SyncerActionPending, false).Get(&decision)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &decision
+	}
+	return nil
+}
+
+// addSyncerDecision saves the decision, a conflict still pending for the user is refreshed instead of duplicated
+func (syncer *Syncer) addSyncerDecision(decision *SyncerDecision) {
+	currentTime := util.GetCurrentTime()
+	decision.Owner = syncer.Owner
+	decision.Syncer = syncer.Name
+	decision.UpdatedTime = currentTime
+
+	if pendingDecision := getPendingSyncerDecision(syncer, decision.UserId); pendingDecision != nil {
+		decision.Id = pendingDecision.Id
+		decision.CreatedTime = pendingDecision.CreatedTime
+		_, err := adapter.Engine.ID(decision.Id).AllCols().Update(decision)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	decision.CreatedTime = currentTime
+	_, err := adapter.Engine.Insert(decision)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// getSyncerDifferences compares the mapped columns of both sides, the password columns are masked
+func (syncer *Syncer) getSyncerDifferences(user *User, oUser *OriginalUser) []*SyncerDifference {
+	m := syncer.getMapFromOriginalUser(syncer.createOriginalUserFromUser(user))
+	oM := syncer.getMapFromOriginalUser(oUser)
+
+	differences := []*SyncerDifference{}
+	for _, tableColumn := range syncer.TableColumns {
+		value, oValue := m[tableColumn.Name], oM[tableColumn.Name]
+		if value == oValue {
+			continue
+		}
+
+		if tableColumn.CasdoorName == "Password" || tableColumn.CasdoorName == "PasswordSalt" {
+			value, oValue = "***", "***"
+		}
+		differences = append(differences, &SyncerDifference{Column: tableColumn.Name, CasdoorValue: value, SourceValue: oValue})
+	}
+	return differences
+}
+
+func (syncer *Syncer) getSyncedUserIds() map[string]bool {
+	syncedUsers := []*SyncedUser{}
+	err := adapter.Engine.Find(&syncedUsers, &SyncedUser{Owner: syncer.Owner, Syncer: syncer.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	ids := map[string]bool{}
+	for _, syncedUser := range syncedUsers {
+		ids[syncedUser.Id] = true
+	}
+	return ids
+}
+
+// updateSyncedUserIds replaces the synced users saved by the previous run with the ones of this run
+func (syncer *Syncer) updateSyncedUserIds(oldIds map[string]bool, newIds map[string]bool) {
+	for id := range oldIds {
+		if newIds[id] {
+			continue
+		}
+
+		_, err := adapter.Engine.ID(core.PK{syncer.Owner, syncer.Name, id}).Delete(&SyncedUser{})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	syncedUsers := []*SyncedUser{}
+	currentTime := util.GetCurrentTime()
+	for id := range newIds {
+		if !oldIds[id] && id != "" {
+			syncedUsers = append(syncedUsers, &SyncedUser{Owner: syncer.Owner, Syncer: syncer.Name, Id: id, CreatedTime: currentTime})
+		}
+	}
+	if len(syncedUsers) != 0 {
+		_, err := adapter.Engine.Insert(syncedUsers)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func deleteSyncerStates(syncer *Syncer) {
+	_, err := adapter.Engine.Delete(&SyncedUser{Owner: syncer.Owner, Syncer: syncer.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = adapter.Engine.Delete(&SyncerDecision{Owner: syncer.Owner, Syncer: syncer.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	deleteSyncerRuns(syncer)
+}
+
+// getSyncDirection returns the direction of the syncer, the read-only sources can only be pulled from
+func (syncer *Syncer) getSyncDirection() string {
+	if !syncer.isDatabaseSource() {
+		return SyncerDirectionPull
+	}
+	if syncer.SyncDirection == "" {
+		return SyncerDirectionBidirectional
+	}
+	return syncer.SyncDirection
+}
+
+// getConflictPolicy returns the policy of the conflicts, a one-way syncer always keeps the side it syncs from
+func (syncer *Syncer) getConflictPolicy() string {
+	switch syncer.getSyncDirection() {
+	case SyncerDirectionPull:
+		return SyncerConflictPolicySourceWins
+	case SyncerDirectionPush:
+		return SyncerConflictPolicyCasdoorWins
+	}
+
+	if syncer.ConflictPolicy == "" {
+		return SyncerConflictPolicySourceWins
+	}
+	return syncer.ConflictPolicy
+}
+
+func parseSyncerTime(t string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+		parsedTime, err := time.Parse(layout, t)
+		if err == nil {
+			return parsedTime, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// resolveSyncerConflict returns the action of the conflict policy, the newest change wins only if both sides have
+// an updated time, else the conflict is left to an admin
+func (syncer *Syncer) resolveSyncerConflict(user *User, oUser *OriginalUser) string {
+	switch syncer.getConflictPolicy() {
+	case SyncerConflictPolicyCasdoorWins:
+		return SyncerActionPush
+	case SyncerConflictPolicyNewestWins:
+		updatedTime, ok := parseSyncerTime(user.UpdatedTime)
+		oUpdatedTime, oOk := parseSyncerTime(oUser.UpdatedTime)
+		if !ok || !oOk {
+			return SyncerActionPending
+		}
+		if updatedTime.After(oUpdatedTime) {
+			return SyncerActionPush
+		}
+		return SyncerActionPull
+	case SyncerConflictPolicyManual:
+		return SyncerActionPending
+	default:
+		return SyncerActionPull
+	}
+}
+
+// getSyncAction decides how a user present on both sides is synced by comparing the hash of the source (oHash)
+// with the hashes of the user: Hash is its current state, PreHash its state after the last sync.
+// It returns "" if there is nothing to do, isConflict tells if both sides have changed since the last sync
+func (syncer *Syncer) getSyncAction(user *User, oUser *OriginalUser, oHash string) (string, bool) {
+	action := ""
+	isConflict := false
+	if user.Hash == user.PreHash {
+		if user.Hash != oHash {
+			action = SyncerActionPull
+		}
+	} else if user.PreHash == oHash {
+		action = SyncerActionPush
+	} else if user.Hash != oHash {
+		isConflict = true
+		action = syncer.resolveSyncerConflict(user, oUser)
+	}
+
+	switch syncer.getSyncDirection() {
+	case SyncerDirectionPull:
+		if action == SyncerActionPush {
+			action = ""
+		}
+	case SyncerDirectionPush:
+		if action == SyncerActionPull {
+			action = ""
+		}
+	}
+	return action, isConflict
+}
+
+func (syncer *Syncer) pullUser(oUser *OriginalUser, oHash string, affiliationMap map[int]string) error {
+	updatedUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
+	updatedUser.Hash = oHash
+	updatedUser.PreHash = oHash
+	_, err := syncer.updateUserForOriginalFields(updatedUser)
+	fmt.Printf("Update from oUser to user: %v\n", updatedUser)
+	return err
+}
+
+func (syncer *Syncer) pushUser(user *User) error {
+	updatedOUser := syncer.createOriginalUserFromUser(user)
+	_, err := syncer.updateUser(updatedOUser)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Update from user to oUser: %v\n", updatedOUser)
+
+	// update preHash
+	user.PreHash = user.Hash
+	SetUserField(user, "pre_hash", user.PreHash)
+	return nil
+}
+
+// ResolveSyncerDecision resolves a pending conflict by pulling the user from the source or pushing it to the source
+func ResolveSyncerDecision(id int, action string, resolver string) error {
+	decision := getSyncerDecision(id)
+	if decision == nil {
+		return fmt.Errorf("the syncer decision: %d doesn't exist", id)
+	}
+	if decision.IsResolved || decision.Action != SyncerActionPending {
+		return fmt.Errorf("the syncer decision: %d has been resolved", id)
+	}
+	if action != SyncerActionPull && action != SyncerActionPush {
+		return fmt.Errorf("unknown action: %s", action)
+	}
+
+	syncer := getSyncer(decision.Owner, decision.Syncer)
+	if syncer == nil {
+		return fmt.Errorf("the syncer: %s doesn't exist", decision.Syncer)
+	}
+	if action == SyncerActionPush && syncer.getSyncDirection() == SyncerDirectionPull {
+		return fmt.Errorf("the syncer: %s can't write to its source", syncer.Name)
+	}
+	syncer.initAdapter()
+
+	user := getUserById(syncer.Organization, decision.UserId)
+	_, oUserMap, err := syncer.getOriginalUserMap()
+	if err != nil {
+		return err
+	}
+	oUser, ok := oUserMap[decision.UserId]
+	if user == nil || !ok {
+		return fmt.Errorf("the user: %s doesn't exist on both sides anymore", decision.UserId)
+	}
+
+	if action == SyncerActionPull {
+		var affiliationMap map[int]string
+		if syncer.AffiliationTable != "" {
+			_, affiliationMap = syncer.getAffiliationMap()
+		}
+		err = syncer.pullUser(oUser, syncer.calculateHash(oUser), affiliationMap)
+	} else {
+		err = syncer.pushUser(user)
+	}
+	if err != nil {
+		return err
+	}
+
+	decision.Action = action
+	decision.IsResolved = true
+	decision.Resolver = resolver
+	decision.UpdatedTime = util.GetCurrentTime()
+	_, err = adapter.Engine.ID(decision.Id).Cols("action", "is_resolved", "resolver", "updated_time").Update(decision)
+	if err != nil {
+		panic(err)
+	}
+	return nil
+}
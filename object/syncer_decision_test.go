@@ -0,0 +1,77 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSyncAction(t *testing.T) {
+	unchanged := &User{Hash: "a", PreHash: "a", UpdatedTime: "2022-06-01T10:00:00+08:00"}
+	changed := &User{Hash: "b", PreHash: "a", UpdatedTime: "2022-06-01T10:00:00+08:00"}
+	oUser := &OriginalUser{UpdatedTime: "2022-06-01 11:00:00"}
+
+	tests := []struct {
+		direction  string
+		policy     string
+		user       *User
+		oHash      string
+		action     string
+		isConflict bool
+	}{
+		{"", "", unchanged, "a", "", false},
+		{"", "", unchanged, "c", SyncerActionPull, false},
+		{"", "", changed, "a", SyncerActionPush, false},
+		{"", "", changed, "b", "", false},
+		{"", "", changed, "c", SyncerActionPull, true},
+		{SyncerDirectionBidirectional, SyncerConflictPolicyCasdoorWins, changed, "c", SyncerActionPush, true},
+		{SyncerDirectionBidirectional, SyncerConflictPolicyNewestWins, changed, "c", SyncerActionPull, true},
+		{SyncerDirectionBidirectional, SyncerConflictPolicyManual, changed, "c", SyncerActionPending, true},
+		{SyncerDirectionPull, SyncerConflictPolicyCasdoorWins, changed, "c", SyncerActionPull, true},
+		{SyncerDirectionPull, "", changed, "a", "", false},
+		{SyncerDirectionPush, SyncerConflictPolicySourceWins, changed, "c", SyncerActionPush, true},
+		{SyncerDirectionPush, "", unchanged, "c", "", false},
+	}
+	for i, test := range tests {
+		syncer := &Syncer{SyncDirection: test.direction, ConflictPolicy: test.policy}
+		action, isConflict := syncer.getSyncAction(test.user, oUser, test.oHash)
+		assert.Equal(t, test.action, action, i)
+		assert.Equal(t, test.isConflict, isConflict, i)
+	}
+
+	// the newest change wins only if both sides have an updated time
+	syncer := &Syncer{ConflictPolicy: SyncerConflictPolicyNewestWins}
+	assert.Equal(t, SyncerActionPush, syncer.resolveSyncerConflict(&User{UpdatedTime: "2022-06-01T12:00:00+00:00"}, oUser))
+	assert.Equal(t, SyncerActionPending, syncer.resolveSyncerConflict(&User{}, oUser))
+}
+
+func TestGetSyncerDifferences(t *testing.T) {
+	syncer := &Syncer{TableColumns: []*TableColumn{
+		{Name: "id", CasdoorName: "Id"},
+		{Name: "nickname", CasdoorName: "DisplayName"},
+		{Name: "mail", CasdoorName: "Email"},
+		{Name: "pwd", CasdoorName: "Password"},
+	}}
+	user := &User{Id: "1", DisplayName: "Alice", Email: "alice@example.com", Password: "123"}
+	oUser := &OriginalUser{Id: "1", DisplayName: "Alice L.", Email: "alice@example.com", Password: "456"}
+
+	differences := syncer.getSyncerDifferences(user, oUser)
+	assert.Equal(t, []*SyncerDifference{
+		{Column: "nickname", CasdoorValue: "Alice", SourceValue: "Alice L."},
+		{Column: "pwd", CasdoorValue: "***", SourceValue: "***"},
+	}, differences)
+}
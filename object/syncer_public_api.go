@@ -38,7 +38,7 @@ func getEnabledSyncerForOrganization(organization string) *Syncer {
 
 func AddUserToOriginalDatabase(user *User) {
 	syncer := getEnabledSyncerForOrganization(user.Owner)
-	if syncer == nil {
+	if syncer == nil || syncer.getSyncDirection() == SyncerDirectionPull {
 		return
 	}
 
@@ -49,7 +49,7 @@ func AddUserToOriginalDatabase(user *User) {
 
 func UpdateUserToOriginalDatabase(user *User) {
 	syncer := getEnabledSyncerForOrganization(user.Owner)
-	if syncer == nil {
+	if syncer == nil || syncer.getSyncDirection() == SyncerDirectionPull {
 		return
 	}
 
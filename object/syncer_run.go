@@ -0,0 +1,121 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	syncerRunMaxCount       = 100
+	syncerRunMaxErrors      = 100
+	syncerErrorTextMaxLines = 100
+)
+
+// SyncerRun is the history entry of a sync run, Counts holds the number of the applied operations per type
+type SyncerRun struct {
+	Id        int    `xorm:"int notnull pk autoincr" json:"id"`
+	Owner     string `xorm:"varchar(100) index" json:"owner"`
+	Syncer    string `xorm:"varchar(100) index" json:"syncer"`
+	StartTime string `xorm:"varchar(100)" json:"startTime"`
+	EndTime   string `xorm:"varchar(100)" json:"endTime"`
+
+	Counts      map[string]int `xorm:"mediumtext" json:"counts"`
+	FailedCount int            `json:"failedCount"`
+	Errors      []string       `xorm:"mediumtext" json:"errors"`
+}
+
+func GetSyncerRunCount(syncer *Syncer, query *Query) int {
+	session := GetSession(&SyncerRun{}, syncer.Owner, -1, -1, query).And("syncer=?", syncer.Name)
+	count, err := session.Count(&SyncerRun{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetSyncerRuns(syncer *Syncer) []*SyncerRun {
+	runs := []*SyncerRun{}
+	err := adapter.Engine.Desc("id").Find(&runs, &SyncerRun{Owner: syncer.Owner, Syncer: syncer.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return runs
+}
+
+func GetPaginationSyncerRuns(syncer *Syncer, offset, limit int, query *Query) []*SyncerRun {
+	query.setDefaultSort("id", "descend")
+
+	runs := []*SyncerRun{}
+	session := GetSession(&SyncerRun{}, syncer.Owner, offset, limit, query).And("syncer=?", syncer.Name)
+	err := session.Find(&runs)
+	if err != nil {
+		panic(err)
+	}
+
+	return runs
+}
+
+// addSyncerRun saves the run and drops the oldest runs of the syncer beyond syncerRunMaxCount
+func addSyncerRun(run *SyncerRun) {
+	_, err := adapter.Engine.Insert(run)
+	if err != nil {
+		panic(err)
+	}
+
+	runs := []*SyncerRun{}
+	err = adapter.Engine.Cols("id").Where("owner = ? and syncer = ?", run.Owner, run.Syncer).
+		Desc("id").Limit(1, syncerRunMaxCount).Find(&runs)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(runs) != 0 {
+		_, err = adapter.Engine.Where("owner = ? and syncer = ? and id <= ?", run.Owner, run.Syncer, runs[0].Id).Delete(&SyncerRun{})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func deleteSyncerRuns(syncer *Syncer) {
+	_, err := adapter.Engine.Delete(&SyncerRun{Owner: syncer.Owner, Syncer: syncer.Name})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// addError records a failed operation, only the first syncerRunMaxErrors messages are kept
+func (run *SyncerRun) addError(format string, a ...interface{}) {
+	run.FailedCount++
+	if len(run.Errors) < syncerRunMaxErrors {
+		run.Errors = append(run.Errors, fmt.Sprintf(format, a...))
+	}
+}
+
+// trimSyncerErrorText keeps the last syncerErrorTextMaxLines lines of the error text
+func trimSyncerErrorText(text string) string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= syncerErrorTextMaxLines {
+		return text
+	}
+	return strings.Join(lines[len(lines)-syncerErrorTextMaxLines:], "")
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimSyncerErrorText(t *testing.T) {
+	assert.Equal(t, "", trimSyncerErrorText(""))
+	assert.Equal(t, "a\nb\n", trimSyncerErrorText("a\nb\n"))
+
+	text := ""
+	for i := 0; i < syncerErrorTextMaxLines+5; i++ {
+		text += fmt.Sprintf("line %d\n", i)
+	}
+	trimmed := trimSyncerErrorText(text)
+	assert.Equal(t, syncerErrorTextMaxLines, strings.Count(trimmed, "\n"))
+	assert.True(t, strings.HasPrefix(trimmed, "line 5\n"))
+	assert.True(t, strings.HasSuffix(text, trimmed))
+}
+
+func TestSyncerRunAddError(t *testing.T) {
+	run := &SyncerRun{}
+	for i := 0; i < syncerRunMaxErrors+3; i++ {
+		run.addError("Push %d: %s", i, "failed")
+	}
+	assert.Equal(t, syncerRunMaxErrors+3, run.FailedCount)
+	assert.Equal(t, syncerRunMaxErrors, len(run.Errors))
+	assert.Equal(t, "Push 0: failed", run.Errors[0])
+}
+
+func TestCheckSyncerPlanDeletions(t *testing.T) {
+	syncedIds := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		syncedIds[fmt.Sprintf("%d", i)] = true
+	}
+	getPlan := func(deletionCount int) *SyncerPlan {
+		return &SyncerPlan{Counts: map[string]int{SyncerActionDeleteUser: deletionCount}, syncedIds: syncedIds}
+	}
+
+	syncer := &Syncer{PropagateDeletion: true}
+	assert.Nil(t, syncer.checkSyncerPlanDeletions(getPlan(0), 100, 0))
+	assert.Nil(t, syncer.checkSyncerPlanDeletions(getPlan(10), 100, 90))
+	assert.NotNil(t, syncer.checkSyncerPlanDeletions(getPlan(11), 100, 89))
+
+	// an empty source never deletes anything, whatever the threshold
+	syncer.MaxDeletionPercent = 100
+	assert.NotNil(t, syncer.checkSyncerPlanDeletions(getPlan(100), 100, 0))
+	assert.Nil(t, syncer.checkSyncerPlanDeletions(getPlan(99), 100, 1))
+}
@@ -0,0 +1,308 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/storage"
+)
+
+const (
+	SyncerTypeCsv   = "CSV"
+	SyncerTypeJsonl = "JSONL"
+	SyncerTypeHttp  = "HTTP"
+
+	syncerHttpMaxPages = 10000
+)
+
+// isDatabaseSource tells if the syncer reads and writes a database table, the file and HTTP sources are read-only
+func (syncer *Syncer) isDatabaseSource() bool {
+	switch syncer.Type {
+	case SyncerTypeCsv, SyncerTypeJsonl, SyncerTypeHttp:
+		return false
+	default:
+		return true
+	}
+}
+
+// getSourceResults reads the records of a file or HTTP source, keyed like the table columns of a database source
+func (syncer *Syncer) getSourceResults() ([]map[string]string, error) {
+	switch syncer.Type {
+	case SyncerTypeCsv, SyncerTypeJsonl:
+		data, err := syncer.getSourceFile()
+		if err != nil {
+			return nil, err
+		}
+
+		if syncer.Type == SyncerTypeCsv {
+			return parseSyncerCsv(data)
+		}
+		return parseSyncerJsonl(data)
+	case SyncerTypeHttp:
+		return syncer.getHttpResults()
+	default:
+		return nil, fmt.Errorf("the syncer type: %s is not a file or HTTP source", syncer.Type)
+	}
+}
+
+func (syncer *Syncer) getSourceFile() ([]byte, error) {
+	provider := getProvider("admin", syncer.Provider)
+	if provider == nil {
+		return nil, fmt.Errorf("the storage provider: %s doesn't exist", syncer.Provider)
+	}
+	if provider.Category != "Storage" {
+		return nil, fmt.Errorf("the provider: %s is not a storage provider", syncer.Provider)
+	}
+
+	endpoint := getProviderEndpoint(provider)
+	storageProvider := storage.GetStorageProvider(provider.Type, provider.ClientId, provider.ClientSecret, provider.RegionId, provider.Bucket, endpoint)
+	if storageProvider == nil {
+		return nil, fmt.Errorf("the provider type: %s is not supported", provider.Type)
+	}
+
+	_, objectKey := getUploadFileUrl(provider, syncer.FilePath, false)
+	reader, err := storageProvider.GetStream(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
+
+// parseSyncerCsv reads the records of a CSV file whose first row holds the column names
+func parseSyncerCsv(data []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	results := []map[string]string{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result := map[string]string{}
+		for i, name := range header {
+			if i < len(record) {
+				result[name] = record[i]
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// parseSyncerJsonl reads the records of a file with a JSON object per line
+func parseSyncerJsonl(data []byte) ([]map[string]string, error) {
+	results := []map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for i := 1; scanner.Scan(); i++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var item interface{}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		err := decoder.Decode(&item)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i, err.Error())
+		}
+
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("line %d: not a JSON object", i)
+		}
+		results = append(results, flattenSyncerJson(object))
+	}
+	return results, scanner.Err()
+}
+
+// flattenSyncerJson turns a JSON object into a record, the nested objects are keyed with dotted names like
+// "profile.email", the booleans become "1" or "0" and the arrays stay JSON
+func flattenSyncerJson(object map[string]interface{}) map[string]string {
+	result := map[string]string{}
+	var flatten func(prefix string, value interface{})
+	flatten = func(prefix string, value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				if prefix != "" {
+					key = prefix + "." + key
+				}
+				flatten(key, child)
+			}
+		case nil:
+			result[prefix] = ""
+		case string:
+			result[prefix] = v
+		case bool:
+			if v {
+				result[prefix] = "1"
+			} else {
+				result[prefix] = "0"
+			}
+		case json.Number:
+			result[prefix] = v.String()
+		default:
+			data, _ := json.Marshal(v)
+			result[prefix] = string(data)
+		}
+	}
+	flatten("", object)
+	return result
+}
+
+// getSyncerJsonValue returns the value at the dotted path of a JSON document, "" is the document itself
+func getSyncerJsonValue(document interface{}, path string) interface{} {
+	if path == "" {
+		return document
+	}
+
+	value := document
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// getHttpResults reads all the pages of the HTTP source. The users of a page are the array at ResultPath. The next page
+// is the URL at NextPagePath if set, else the page number in the PageParam query parameter until a page is empty,
+// else there is a single page
+func (syncer *Syncer) getHttpResults() ([]map[string]string, error) {
+	httpClient := &http.Client{Timeout: 60 * time.Second}
+	pageUrl := syncer.Url
+	results := []map[string]string{}
+	for page := 1; page <= syncerHttpMaxPages; page++ {
+		requestUrl := pageUrl
+		if syncer.NextPagePath == "" && syncer.PageParam != "" {
+			u, err := url.Parse(syncer.Url)
+			if err != nil {
+				return nil, err
+			}
+			query := u.Query()
+			query.Set(syncer.PageParam, strconv.Itoa(page))
+			u.RawQuery = query.Encode()
+			requestUrl = u.String()
+		}
+
+		document, err := syncer.getHttpDocument(httpClient, requestUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		items, ok := getSyncerJsonValue(document, syncer.ResultPath).([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("no user array at \"%s\" in the response of %s", syncer.ResultPath, requestUrl)
+		}
+		for _, item := range items {
+			object, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("a user in the response of %s is not a JSON object", requestUrl)
+			}
+			results = append(results, flattenSyncerJson(object))
+		}
+
+		if syncer.NextPagePath != "" {
+			nextUrl, _ := getSyncerJsonValue(document, syncer.NextPagePath).(string)
+			if nextUrl == "" {
+				return results, nil
+			}
+
+			base, err := url.Parse(requestUrl)
+			if err != nil {
+				return nil, err
+			}
+			next, err := base.Parse(nextUrl)
+			if err != nil {
+				return nil, err
+			}
+			pageUrl = next.String()
+		} else if syncer.PageParam == "" || len(items) == 0 {
+			return results, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the HTTP source has more than %d pages", syncerHttpMaxPages)
+}
+
+func (syncer *Syncer) getHttpDocument(httpClient *http.Client, requestUrl string) (interface{}, error) {
+	req, err := http.NewRequest("GET", requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if syncer.User != "" {
+		req.SetBasicAuth(syncer.User, syncer.Password)
+	} else if syncer.Password != "" {
+		req.Header.Set("Authorization", "Bearer "+syncer.Password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the HTTP source returned status %d for %s", resp.StatusCode, requestUrl)
+	}
+
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+	return document, nil
+}
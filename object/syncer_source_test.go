@@ -0,0 +1,109 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSyncerFiles(t *testing.T) {
+	syncer := &Syncer{Type: SyncerTypeCsv, TableColumns: []*TableColumn{
+		{Name: "id", CasdoorName: "Id"},
+		{Name: "login", CasdoorName: "Name"},
+		{Name: "profile.email", CasdoorName: "Email"},
+		{Name: "locked", CasdoorName: "IsForbidden"},
+	}}
+
+	results, err := parseSyncerCsv([]byte("\xef\xbb\xbfid, login,profile.email\n1,alice,alice@example.com\n2,\"bob, jr\"\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{"id": "1", "login": "alice", "profile.email": "alice@example.com"},
+		{"id": "2", "login": "bob, jr"},
+	}, results)
+
+	results, err = parseSyncerJsonl([]byte("{\"id\": 1, \"login\": \"alice\", \"profile\": {\"email\": \"alice@example.com\"}, \"locked\": true}\n\n{\"id\": \"2\", \"login\": null}\n"))
+	assert.Nil(t, err)
+	users := syncer.getOriginalUsersFromMap(results)
+	assert.Equal(t, 2, len(users))
+	assert.Equal(t, "1", users[0].Id)
+	assert.Equal(t, "alice", users[0].Name)
+	assert.Equal(t, "alice@example.com", users[0].Email)
+	assert.True(t, users[0].IsForbidden)
+	assert.Equal(t, "2", users[1].Id)
+	assert.Equal(t, "", users[1].Name)
+
+	_, err = parseSyncerJsonl([]byte("{\"id\": 1}\n[1]\n"))
+	assert.EqualError(t, err, "line 2: not a JSON object")
+}
+
+func TestGetHttpResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/cursor":
+			if r.URL.Query().Get("after") == "" {
+				fmt.Fprint(w, `{"data": {"users": [{"id": 1}, {"id": 2}]}, "links": {"next": "/cursor?after=2"}}`)
+			} else {
+				fmt.Fprint(w, `{"data": {"users": [{"id": 3}]}, "links": {"next": null}}`)
+			}
+		case "/pages":
+			switch r.URL.Query().Get("page") {
+			case "1":
+				fmt.Fprint(w, `[{"id": 1}, {"id": 2}]`)
+			case "2":
+				fmt.Fprint(w, `[{"id": 3}]`)
+			default:
+				fmt.Fprint(w, `[]`)
+			}
+		}
+	}))
+	defer server.Close()
+
+	getIds := func(results []map[string]string) []string {
+		ids := []string{}
+		for _, result := range results {
+			ids = append(ids, result["id"])
+		}
+		return ids
+	}
+
+	syncer := &Syncer{Type: SyncerTypeHttp, Url: server.URL + "/cursor", Password: "token", ResultPath: "data.users", NextPagePath: "links.next"}
+	results, err := syncer.getSourceResults()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, getIds(results))
+
+	syncer = &Syncer{Type: SyncerTypeHttp, Url: server.URL + "/pages?sort=id", Password: "token", PageParam: "page"}
+	results, err = syncer.getSourceResults()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, getIds(results))
+
+	syncer.ResultPath = "users"
+	_, err = syncer.getSourceResults()
+	assert.NotNil(t, err)
+
+	syncer.Password = "wrong"
+	_, err = syncer.getSourceResults()
+	assert.NotNil(t, err)
+	assert.Equal(t, SyncerDirectionPull, syncer.getSyncDirection())
+}
@@ -16,82 +16,286 @@ package object
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/casdoor/casdoor/util"
 )
 
-func (syncer *Syncer) syncUsers() {
-	fmt.Printf("Running syncUsers()..\n")
+const (
+	SyncerOperationAddUser         = "AddUser"
+	SyncerOperationAddOriginalUser = "AddOriginalUser"
+	SyncerOperationUpdatePreHash   = "UpdatePreHash"
+
+	defaultSyncerMaxDeletionPercent = 10
+)
+
+// SyncerOperation is a change planned by a sync run, Type is one of the SyncerOperation* or SyncerAction* constants
+type SyncerOperation struct {
+	Type        string              `json:"type"`
+	UserId      string              `json:"userId"`
+	UserName    string              `json:"userName"`
+	IsConflict  bool                `json:"isConflict"`
+	Differences []*SyncerDifference `json:"differences"`
+
+	user  *User
+	oUser *OriginalUser
+	oHash string
+}
+
+// SyncerPlan is the list of the operations a sync run would apply, Counts holds their number per type
+type SyncerPlan struct {
+	Operations []*SyncerOperation `json:"operations"`
+	Counts     map[string]int     `json:"counts"`
+
+	affiliationMap map[int]string
+	syncedIds      map[string]bool
+	newSyncedIds   map[string]bool
+}
 
+func (plan *SyncerPlan) addOperation(operation *SyncerOperation) {
+	plan.Operations = append(plan.Operations, operation)
+	plan.Counts[operation.Type]++
+}
+
+// getSyncerPlan compares both sides and returns the operations to sync them, nothing is written
+func (syncer *Syncer) getSyncerPlan() (*SyncerPlan, error) {
 	users, userMap := syncer.getUserMap()
 	oUsers, oUserMap, err := syncer.getOriginalUserMap()
 	if err != nil {
-		fmt.Printf(err.Error())
-
-		timestamp := time.Now().Format("2006-01-02 15:04:05")
-		line := fmt.Sprintf("[%s] %s\n", timestamp, err.Error())
-		updateSyncerErrorText(syncer, line)
-		return
+		return nil, err
 	}
 
 	fmt.Printf("Users: %d, oUsers: %d\n", len(users), len(oUsers))
 
-	var affiliationMap map[int]string
+	plan := &SyncerPlan{
+		Operations:   []*SyncerOperation{},
+		Counts:       map[string]int{},
+		syncedIds:    syncer.getSyncedUserIds(),
+		newSyncedIds: map[string]bool{},
+	}
 	if syncer.AffiliationTable != "" {
-		_, affiliationMap = syncer.getAffiliationMap()
+		_, plan.affiliationMap = syncer.getAffiliationMap()
 	}
+	direction := syncer.getSyncDirection()
 
-	newUsers := []*User{}
 	for _, oUser := range oUsers {
 		id := oUser.Id
-		if _, ok := userMap[id]; !ok {
-			newUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
-			fmt.Printf("New user: %v\n", newUser)
-			newUsers = append(newUsers, newUser)
-		} else {
-			user := userMap[id]
-			oHash := syncer.calculateHash(oUser)
-
-			if user.Hash == user.PreHash {
-				if user.Hash != oHash {
-					updatedUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
-					updatedUser.Hash = oHash
-					updatedUser.PreHash = oHash
-					syncer.updateUserForOriginalFields(updatedUser)
-					fmt.Printf("Update from oUser to user: %v\n", updatedUser)
-				}
-			} else {
-				if user.PreHash == oHash {
-					updatedOUser := syncer.createOriginalUserFromUser(user)
-					syncer.updateUser(updatedOUser)
-					fmt.Printf("Update from user to oUser: %v\n", updatedOUser)
-
-					// update preHash
-					user.PreHash = user.Hash
-					SetUserField(user, "pre_hash", user.PreHash)
-				} else {
-					if user.Hash == oHash {
-						// update preHash
-						user.PreHash = user.Hash
-						SetUserField(user, "pre_hash", user.PreHash)
-					} else {
-						updatedUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
-						updatedUser.Hash = oHash
-						updatedUser.PreHash = oHash
-						syncer.updateUserForOriginalFields(updatedUser)
-						fmt.Printf("Update from oUser to user (2nd condition): %v\n", updatedUser)
-					}
-				}
+		user, ok := userMap[id]
+		if !ok {
+			if plan.syncedIds[id] && syncer.PropagateDeletion && direction != SyncerDirectionPull {
+				// the user has been deleted in Casdoor since the last sync
+				plan.addOperation(&SyncerOperation{Type: SyncerActionDeleteOriginalUser, UserId: id, UserName: oUser.Name, oUser: oUser})
+				continue
+			}
+			if direction == SyncerDirectionPush {
+				continue
+			}
+
+			newUser := syncer.createUserFromOriginalUser(oUser, plan.affiliationMap)
+			plan.addOperation(&SyncerOperation{Type: SyncerOperationAddUser, UserId: id, UserName: newUser.Name, user: newUser})
+			plan.newSyncedIds[id] = true
+			continue
+		}
+
+		plan.newSyncedIds[id] = true
+		oHash := syncer.calculateHash(oUser)
+		action, isConflict := syncer.getSyncAction(user, oUser, oHash)
+		if action == "" && !isConflict {
+			if user.Hash != user.PreHash && user.Hash == oHash {
+				plan.addOperation(&SyncerOperation{Type: SyncerOperationUpdatePreHash, UserId: id, UserName: user.Name, user: user})
 			}
+			continue
 		}
+
+		plan.addOperation(&SyncerOperation{
+			Type:        action,
+			UserId:      id,
+			UserName:    user.Name,
+			IsConflict:  isConflict,
+			Differences: syncer.getSyncerDifferences(user, oUser),
+			user:        user,
+			oUser:       oUser,
+			oHash:       oHash,
+		})
 	}
-	AddUsersInBatch(newUsers)
 
 	for _, user := range users {
 		id := user.Id
-		if _, ok := oUserMap[id]; !ok {
-			newOUser := syncer.createOriginalUserFromUser(user)
-			syncer.addUser(newOUser)
+		if _, ok := oUserMap[id]; ok {
+			continue
+		}
+
+		if plan.syncedIds[id] && syncer.PropagateDeletion && direction != SyncerDirectionPush {
+			// the user has been deleted in the source since the last sync
+			plan.addOperation(&SyncerOperation{Type: SyncerActionDeleteUser, UserId: id, UserName: user.Name, user: user})
+			continue
+		}
+		if direction == SyncerDirectionPull {
+			continue
+		}
+
+		plan.addOperation(&SyncerOperation{Type: SyncerOperationAddOriginalUser, UserId: id, UserName: user.Name, user: user})
+		plan.newSyncedIds[id] = true
+	}
+
+	err = syncer.checkSyncerPlanDeletions(plan, len(users), len(oUsers))
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (syncer *Syncer) getMaxDeletionPercent() int {
+	if syncer.MaxDeletionPercent <= 0 {
+		return defaultSyncerMaxDeletionPercent
+	}
+	return syncer.MaxDeletionPercent
+}
+
+// checkSyncerPlanDeletions aborts the run when a side returns no users at all or when the propagated deletions
+// exceed MaxDeletionPercent of the synced users, which usually means an empty or truncated source, not real deletions
+func (syncer *Syncer) checkSyncerPlanDeletions(plan *SyncerPlan, userCount int, oUserCount int) error {
+	deletionCount := plan.Counts[SyncerActionDeleteUser] + plan.Counts[SyncerActionDeleteOriginalUser]
+	if deletionCount == 0 {
+		return nil
+	}
+
+	if plan.Counts[SyncerActionDeleteUser] != 0 && oUserCount == 0 {
+		return fmt.Errorf("the source returned no users, the deletion of %d synced users is aborted", plan.Counts[SyncerActionDeleteUser])
+	}
+	if plan.Counts[SyncerActionDeleteOriginalUser] != 0 && userCount == 0 {
+		return fmt.Errorf("Casdoor returned no users, the deletion of %d synced users in the source is aborted", plan.Counts[SyncerActionDeleteOriginalUser])
+	}
+
+	maxDeletionPercent := syncer.getMaxDeletionPercent()
+	if deletionCount*100 > maxDeletionPercent*len(plan.syncedIds) {
+		return fmt.Errorf("the sync would delete %d of %d synced users, more than %d%%, the run is aborted, raise the max deletion percent of the syncer if the deletions are expected",
+			deletionCount, len(plan.syncedIds), maxDeletionPercent)
+	}
+	return nil
+}
+
+// applySyncerPlan applies the operations of the plan and records the outcome in the run
+func (syncer *Syncer) applySyncerPlan(plan *SyncerPlan, run *SyncerRun) {
+	newUsers := []*User{}
+	for _, operation := range plan.Operations {
+		var err error
+		switch operation.Type {
+		case SyncerOperationAddUser:
+			fmt.Printf("New user: %v\n", operation.user)
+			newUsers = append(newUsers, operation.user)
+			continue
+		case SyncerOperationAddOriginalUser:
+			newOUser := syncer.createOriginalUserFromUser(operation.user)
+			_, err = syncer.addUser(newOUser)
+			if err != nil {
+				delete(plan.newSyncedIds, operation.UserId)
+			}
 			fmt.Printf("New oUser: %v\n", newOUser)
+		case SyncerOperationUpdatePreHash:
+			operation.user.PreHash = operation.user.Hash
+			SetUserField(operation.user, "pre_hash", operation.user.PreHash)
+		case SyncerActionPull:
+			err = syncer.pullUser(operation.oUser, operation.oHash, plan.affiliationMap)
+		case SyncerActionPush:
+			err = syncer.pushUser(operation.user)
+		case SyncerActionDeleteOriginalUser:
+			_, err = syncer.deleteUser(operation.oUser)
+			if err != nil {
+				plan.newSyncedIds[operation.UserId] = true
+			}
+			syncer.addDeletionDecision(operation.oUser, SyncerActionDeleteOriginalUser, err)
+		case SyncerActionDeleteUser:
+			if !DeleteUser(operation.user) {
+				err = fmt.Errorf("failed to delete the user: %s", operation.user.GetId())
+				plan.newSyncedIds[operation.UserId] = true
+			}
+			syncer.addDeletionDecision(operation.user, SyncerActionDeleteUser, err)
 		}
+
+		if operation.IsConflict {
+			decision := &SyncerDecision{
+				UserId:      operation.UserId,
+				UserName:    operation.UserName,
+				Type:        SyncerDecisionTypeConflict,
+				Policy:      syncer.getConflictPolicy(),
+				Action:      operation.Type,
+				IsResolved:  operation.Type != SyncerActionPending,
+				Differences: operation.Differences,
+			}
+			if err != nil {
+				decision.Error = err.Error()
+			}
+			syncer.addSyncerDecision(decision)
+		}
+
+		if err != nil {
+			run.addError("%s %s: %s", operation.Type, operation.UserId, err.Error())
+		} else {
+			run.Counts[operation.Type]++
+		}
+	}
+
+	if len(newUsers) != 0 {
+		AddUsersInBatch(newUsers)
+		run.Counts[SyncerOperationAddUser] += len(newUsers)
+	}
+
+	syncer.updateSyncedUserIds(plan.syncedIds, plan.newSyncedIds)
+}
+
+// runSyncer syncs the users and saves the run in the history of the syncer, the errors are also appended to ErrorText
+func (syncer *Syncer) runSyncer() *SyncerRun {
+	fmt.Printf("Running syncUsers()..\n")
+
+	run := &SyncerRun{
+		Owner:     syncer.Owner,
+		Syncer:    syncer.Name,
+		StartTime: util.GetCurrentTime(),
+		Counts:    map[string]int{},
+		Errors:    []string{},
+	}
+
+	plan, err := syncer.getSyncerPlan()
+	if err != nil {
+		run.addError("%s", err.Error())
+	} else {
+		syncer.applySyncerPlan(plan, run)
+	}
+
+	if run.FailedCount > len(run.Errors) {
+		run.Errors = append(run.Errors, fmt.Sprintf("... and %d more errors", run.FailedCount-len(run.Errors)))
+	}
+	run.EndTime = util.GetCurrentTime()
+	addSyncerRun(run)
+
+	if len(run.Errors) != 0 {
+		timestamp := time.Now().Format("2006-01-02 15:04:05")
+		lines := ""
+		for _, message := range run.Errors {
+			lines += fmt.Sprintf("[%s] %s\n", timestamp, strings.TrimSuffix(message, "\n"))
+		}
+		updateSyncerErrorText(syncer, lines)
+	}
+
+	return run
+}
+
+func (syncer *Syncer) syncUsers() {
+	syncer.runSyncer()
+}
+
+func (syncer *Syncer) addDeletionDecision(user *User, action string, err error) {
+	decision := &SyncerDecision{
+		UserId:     user.Id,
+		UserName:   user.Name,
+		Type:       SyncerDecisionTypeDeletion,
+		Action:     action,
+		IsResolved: true,
+	}
+	if err != nil {
+		decision.Error = err.Error()
 	}
+	syncer.addSyncerDecision(decision)
 }
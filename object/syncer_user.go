@@ -31,6 +31,15 @@ type Credential struct {
 }
 
 func (syncer *Syncer) getOriginalUsers() ([]*OriginalUser, error) {
+	if !syncer.isDatabaseSource() {
+		results, err := syncer.getSourceResults()
+		if err != nil {
+			return nil, err
+		}
+
+		return syncer.getOriginalUsersFromMap(results), nil
+	}
+
 	sql := fmt.Sprintf("select * from %s", syncer.getTable())
 	results, err := syncer.Adapter.Engine.QueryString(sql)
 	if err != nil {
@@ -112,6 +121,23 @@ func (syncer *Syncer) updateUser(user *OriginalUser) (bool, error) {
 	return affected != 0, nil
 }
 
+func (syncer *Syncer) deleteUser(user *OriginalUser) (bool, error) {
+	m := syncer.getMapFromOriginalUser(user)
+
+	sql := fmt.Sprintf("delete from %s where %s = ?", syncer.getTable(), syncer.TablePrimaryKey)
+	res, err := syncer.Adapter.Engine.Exec(sql, m[syncer.TablePrimaryKey])
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
 func (syncer *Syncer) updateUserForOriginalFields(user *User) (bool, error) {
 	owner, name := util.GetOwnerAndNameFromId(user.GetId())
 	oldUser := getUserById(owner, name)
@@ -130,6 +156,10 @@ func (syncer *Syncer) updateUserForOriginalFields(user *User) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		provisionUser(oldUser.Owner, oldUser.Name)
+	}
+
 	return affected != 0, nil
 }
 
@@ -147,7 +177,7 @@ func (syncer *Syncer) calculateHash(user *OriginalUser) string {
 }
 
 func (syncer *Syncer) initAdapter() {
-	if syncer.Adapter == nil {
+	if syncer.Adapter == nil && syncer.isDatabaseSource() {
 		var dataSourceName string
 		if syncer.DatabaseType == "mssql" {
 			dataSourceName = fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s", syncer.User, syncer.Password, syncer.Host, syncer.Port, syncer.Database)
@@ -60,6 +60,12 @@ type Token struct {
 	CodeChallenge string `xorm:"varchar(100)" json:"codeChallenge"`
 	CodeIsUsed    bool   `json:"codeIsUsed"`
 	CodeExpireIn  int64  `json:"codeExpireIn"`
+
+	AuthTime int64    `json:"authTime"`
+	Acr      string   `xorm:"varchar(100)" json:"acr"`
+	Amr      []string `xorm:"varchar(100)" json:"amr"`
+
+	Impersonator string `xorm:"varchar(100)" json:"impersonator"`
 }
 
 type TokenWrapper struct {
@@ -91,8 +97,8 @@ type IntrospectionResponse struct {
 	Jti       string   `json:"jti,omitempty"`
 }
 
-func GetTokenCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetTokenCount(owner string, query *Query) int {
+	session := GetSession(&Token{}, owner, -1, -1, query)
 	count, err := session.Count(&Token{})
 	if err != nil {
 		panic(err)
@@ -111,9 +117,9 @@ func GetTokens(owner string) []*Token {
 	return tokens
 }
 
-func GetPaginationTokens(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Token {
+func GetPaginationTokens(owner string, offset, limit int, query *Query) []*Token {
 	tokens := []*Token{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Token{}, owner, offset, limit, query)
 	err := session.Find(&tokens)
 	if err != nil {
 		panic(err)
@@ -188,15 +194,24 @@ func AddToken(token *Token) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		PublishEvent(NewEvent(EventTokenIssued, token.Organization, token.GetId(), nil, getEventToken(token)))
+	}
+
 	return affected != 0
 }
 
 func DeleteToken(token *Token) bool {
+	oldToken := getToken(token.Owner, token.Name)
 	affected, err := adapter.Engine.ID(core.PK{token.Owner, token.Name}).Delete(&Token{})
 	if err != nil {
 		panic(err)
 	}
 
+	if affected != 0 && oldToken != nil {
+		PublishEvent(NewEvent(EventTokenRevoked, oldToken.Organization, oldToken.GetId(), getEventToken(oldToken), nil))
+	}
+
 	return affected != 0
 }
 
@@ -216,9 +231,17 @@ func DeleteTokenByAceessToken(accessToken string) (bool, *Application) {
 		panic(err)
 	}
 
+	if affected != 0 {
+		PublishEvent(NewEvent(EventTokenRevoked, token.Organization, token.GetId(), getEventToken(&token), nil))
+	}
+
 	return affected != 0, application
 }
 
+func (token *Token) GetId() string {
+	return fmt.Sprintf("%s/%s", token.Owner, token.Name)
+}
+
 func GetTokenByAccessToken(accessToken string) *Token {
 	//Check if the accessToken is in the database
 	token := Token{AccessToken: accessToken}
@@ -264,7 +287,7 @@ func CheckOAuthLogin(clientId string, responseType string, redirectUri string, s
 	return "", application
 }
 
-func GetOAuthCode(userId string, clientId string, responseType string, redirectUri string, scope string, state string, nonce string, challenge string, host string) *Code {
+func GetOAuthCode(userId string, clientId string, responseType string, redirectUri string, scope string, state string, nonce string, challenge string, host string, acrValues string, maxAge int, authContext *AuthContext) *Code {
 	user := GetUser(userId)
 	if user == nil {
 		return &Code{
@@ -287,7 +310,18 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 		}
 	}
 
-	accessToken, refreshToken, err := generateJwtToken(application, user, nonce, scope, host)
+	acr, msg := CheckAuthContext(application, authContext, acrValues, maxAge)
+	if msg != "" {
+		return &Code{
+			Message: msg,
+			Code:    "",
+		}
+	}
+	if authContext != nil {
+		authContext.Acr = acr
+	}
+
+	accessToken, refreshToken, err := generateJwtToken(application, user, nonce, scope, host, authContext)
 	if err != nil {
 		panic(err)
 	}
@@ -313,6 +347,7 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 		CodeIsUsed:    false,
 		CodeExpireIn:  time.Now().Add(time.Minute * 5).Unix(),
 	}
+	token.setAuthContext(authContext)
 	AddToken(token)
 
 	return &Code{
@@ -420,7 +455,9 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 			ErrorDescription: "the user is forbidden to sign in, please contact the administrator",
 		}
 	}
-	newAccessToken, newRefreshToken, err := generateJwtToken(application, user, "", scope, host)
+	// the refreshed tokens keep describing the original authentication
+	authContext := token.getAuthContext()
+	newAccessToken, newRefreshToken, err := generateJwtToken(application, user, "", scope, host, authContext)
 	if err != nil {
 		return &TokenError{
 			Error:            ENDPOINT_ERROR,
@@ -442,6 +479,7 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 		Scope:        scope,
 		TokenType:    "Bearer",
 	}
+	newToken.setAuthContext(authContext)
 	AddToken(newToken)
 	DeleteToken(&token)
 
@@ -566,7 +604,9 @@ func GetPasswordToken(application *Application, username string, password string
 			ErrorDescription: "the user is forbidden to sign in, please contact the administrator",
 		}
 	}
-	accessToken, refreshToken, err := generateJwtToken(application, user, "", scope, host)
+	authContext := NewAuthContext(AuthMethodPassword)
+	authContext.Acr, _ = CheckAuthContext(application, authContext, "", -1)
+	accessToken, refreshToken, err := generateJwtToken(application, user, "", scope, host, authContext)
 	if err != nil {
 		return nil, &TokenError{
 			Error:            ENDPOINT_ERROR,
@@ -588,6 +628,7 @@ func GetPasswordToken(application *Application, username string, password string
 		TokenType:    "Bearer",
 		CodeIsUsed:   true,
 	}
+	token.setAuthContext(authContext)
 	AddToken(token)
 	return token, nil
 }
@@ -605,7 +646,7 @@ func GetClientCredentialsToken(application *Application, clientSecret string, sc
 		Id:    application.GetId(),
 		Name:  fmt.Sprintf("app/%s", application.Name),
 	}
-	accessToken, _, err := generateJwtToken(application, nullUser, "", scope, host)
+	accessToken, _, err := generateJwtToken(application, nullUser, "", scope, host, nil)
 	if err != nil {
 		return nil, &TokenError{
 			Error:            ENDPOINT_ERROR,
@@ -631,8 +672,8 @@ func GetClientCredentialsToken(application *Application, clientSecret string, sc
 }
 
 // Implicit flow
-func GetTokenByUser(application *Application, user *User, scope string, host string) (*Token, error) {
-	accessToken, refreshToken, err := generateJwtToken(application, user, "", scope, host)
+func GetTokenByUser(application *Application, user *User, scope string, host string, authContext *AuthContext) (*Token, error) {
+	accessToken, refreshToken, err := generateJwtToken(application, user, "", scope, host, authContext)
 	if err != nil {
 		return nil, err
 	}
@@ -651,6 +692,7 @@ func GetTokenByUser(application *Application, user *User, scope string, host str
 		TokenType:    "Bearer",
 		CodeIsUsed:   true,
 	}
+	token.setAuthContext(authContext)
 	AddToken(token)
 	return token, nil
 }
@@ -714,7 +756,7 @@ func GetWechatMiniProgramToken(application *Application, code string, host strin
 		AddUser(user)
 	}
 
-	accessToken, refreshToken, err := generateJwtToken(application, user, "", "", host)
+	accessToken, refreshToken, err := generateJwtToken(application, user, "", "", host, NewAuthContext(AuthMethodFederated))
 	if err != nil {
 		return nil, &TokenError{
 			Error:            ENDPOINT_ERROR,
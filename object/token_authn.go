@@ -0,0 +1,211 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// authentication method references, see: https://datatracker.ietf.org/doc/html/rfc8176
+const (
+	AuthMethodPassword  = "pwd"
+	AuthMethodOtp       = "otp"
+	AuthMethodFederated = "fed"
+	AuthMethodMfa       = "mfa"
+)
+
+// factors that can be required by an ACR level
+const (
+	AcrFactorPassword         = "Password"
+	AcrFactorVerificationCode = "Verification code"
+	AcrFactorThirdParty       = "Third-party"
+	AcrFactorMfa              = "MFA"
+)
+
+// AcrItem maps an ACR level name (the value of "acr_values" and the "acr" claim) to the factors the user must have passed.
+type AcrItem struct {
+	Name    string   `json:"name"`
+	Factors []string `json:"factors"`
+}
+
+// AuthContext describes how and when the user authenticated in the current session.
+type AuthContext struct {
+	AuthTime int64    `json:"authTime"`
+	Methods  []string `json:"methods"`
+	Acr      string   `json:"acr"`
+
+	// the admin who is impersonating the user, empty for a normal session
+	Impersonator string `json:"impersonator"`
+}
+
+func NewAuthContext(methods ...string) *AuthContext {
+	return &AuthContext{
+		AuthTime: time.Now().Unix(),
+		Methods:  methods,
+	}
+}
+
+func (authContext *AuthContext) HasMethod(method string) bool {
+	if authContext == nil {
+		return false
+	}
+
+	for _, m := range authContext.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge adds the methods of a previous authentication of the same user into the current one (step-up).
+func (authContext *AuthContext) Merge(previous *AuthContext) {
+	if previous == nil {
+		return
+	}
+
+	for _, method := range previous.Methods {
+		if !authContext.HasMethod(method) {
+			authContext.Methods = append(authContext.Methods, method)
+		}
+	}
+}
+
+// GetAmr returns the "amr" claim, "mfa" is added when at least two different methods have been used.
+func (authContext *AuthContext) GetAmr() []string {
+	if authContext == nil || len(authContext.Methods) == 0 {
+		return nil
+	}
+
+	amr := append([]string{}, authContext.Methods...)
+	if len(authContext.Methods) >= 2 && !authContext.HasMethod(AuthMethodMfa) {
+		amr = append(amr, AuthMethodMfa)
+	}
+	return amr
+}
+
+func (authContext *AuthContext) hasFactor(factor string) bool {
+	switch factor {
+	case AcrFactorPassword:
+		return authContext.HasMethod(AuthMethodPassword)
+	case AcrFactorVerificationCode:
+		return authContext.HasMethod(AuthMethodOtp)
+	case AcrFactorThirdParty:
+		return authContext.HasMethod(AuthMethodFederated)
+	case AcrFactorMfa:
+		return len(authContext.Methods) >= 2 || authContext.HasMethod(AuthMethodMfa)
+	default:
+		return false
+	}
+}
+
+func (authContext *AuthContext) satisfies(acrItem *AcrItem) bool {
+	for _, factor := range acrItem.Factors {
+		if !authContext.hasFactor(factor) {
+			return false
+		}
+	}
+	return true
+}
+
+func (application *Application) GetAcrItem(name string) *AcrItem {
+	for _, acrItem := range application.AcrItems {
+		if acrItem.Name == name {
+			return acrItem
+		}
+	}
+	return nil
+}
+
+// CheckAuthContext verifies the authentication of the session against "max_age" and "acr_values",
+// it returns the ACR level that will be emitted in the tokens, or an error message when re-authentication is required.
+// maxAge < 0 means that "max_age" is not requested.
+func CheckAuthContext(application *Application, authContext *AuthContext, acrValues string, maxAge int) (string, string) {
+	if authContext == nil {
+		if acrValues != "" || maxAge >= 0 {
+			return "", "the authentication time and methods are unknown, please sign in again"
+		}
+		return "", ""
+	}
+
+	if maxAge >= 0 && time.Now().Unix()-authContext.AuthTime > int64(maxAge) {
+		return "", fmt.Sprintf("the last authentication is older than max_age: %d seconds, please sign in again", maxAge)
+	}
+
+	if acrValues == "" {
+		// no ACR is requested, report the strongest configured level the user has reached
+		acr := ""
+		for _, acrItem := range application.AcrItems {
+			if authContext.satisfies(acrItem) {
+				acr = acrItem.Name
+			}
+		}
+		return acr, ""
+	}
+
+	// "acr_values" is a space-separated list in order of preference
+	for _, acrValue := range strings.Fields(acrValues) {
+		acrItem := application.GetAcrItem(acrValue)
+		if acrItem != nil && authContext.satisfies(acrItem) {
+			return acrItem.Name, ""
+		}
+	}
+
+	requiredFactors := []string{}
+	for _, acrValue := range strings.Fields(acrValues) {
+		acrItem := application.GetAcrItem(acrValue)
+		if acrItem == nil {
+			continue
+		}
+
+		for _, factor := range acrItem.Factors {
+			if !authContext.hasFactor(factor) {
+				requiredFactors = append(requiredFactors, factor)
+			}
+		}
+		break
+	}
+	if len(requiredFactors) == 0 {
+		return "", fmt.Sprintf("the requested acr_values: \"%s\" are not supported by the application: %s", acrValues, application.Name)
+	}
+
+	return "", fmt.Sprintf("the requested acr_values: \"%s\" require signing in again with: %s", acrValues, strings.Join(requiredFactors, ", "))
+}
+
+func (token *Token) setAuthContext(authContext *AuthContext) {
+	if authContext == nil {
+		return
+	}
+
+	token.AuthTime = authContext.AuthTime
+	token.Acr = authContext.Acr
+	token.Amr = authContext.GetAmr()
+	token.Impersonator = authContext.Impersonator
+}
+
+func (token *Token) getAuthContext() *AuthContext {
+	if token.AuthTime == 0 {
+		return nil
+	}
+
+	return &AuthContext{
+		AuthTime:     token.AuthTime,
+		Methods:      token.Amr,
+		Acr:          token.Acr,
+		Impersonator: token.Impersonator,
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAuthContext(t *testing.T) {
+	application := &Application{
+		Name: "app-test",
+		AcrItems: []*AcrItem{
+			{Name: "silver", Factors: []string{AcrFactorPassword}},
+			{Name: "gold", Factors: []string{AcrFactorMfa}},
+		},
+	}
+
+	password := NewAuthContext(AuthMethodPassword)
+	acr, msg := CheckAuthContext(application, password, "", -1)
+	assert.Equal(t, "silver", acr)
+	assert.Equal(t, "", msg)
+
+	_, msg = CheckAuthContext(application, password, "gold", -1)
+	assert.NotEqual(t, "", msg)
+
+	acr, msg = CheckAuthContext(application, password, "gold silver", -1)
+	assert.Equal(t, "silver", acr)
+	assert.Equal(t, "", msg)
+
+	steppedUp := NewAuthContext(AuthMethodOtp)
+	steppedUp.Merge(password)
+	assert.Equal(t, []string{AuthMethodOtp, AuthMethodPassword, AuthMethodMfa}, steppedUp.GetAmr())
+	acr, msg = CheckAuthContext(application, steppedUp, "gold", -1)
+	assert.Equal(t, "gold", acr)
+	assert.Equal(t, "", msg)
+
+	_, msg = CheckAuthContext(application, password, "platinum", -1)
+	assert.NotEqual(t, "", msg)
+
+	old := NewAuthContext(AuthMethodPassword)
+	old.AuthTime = time.Now().Unix() - 600
+	_, msg = CheckAuthContext(application, old, "", 300)
+	assert.NotEqual(t, "", msg)
+	_, msg = CheckAuthContext(application, old, "", 900)
+	assert.Equal(t, "", msg)
+
+	_, msg = CheckAuthContext(application, nil, "", 300)
+	assert.NotEqual(t, "", msg)
+}
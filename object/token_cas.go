@@ -21,8 +21,7 @@ import (
 	"encoding/pem"
 	"encoding/xml"
 	"fmt"
-	"math/rand"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/beevik/etree"
@@ -86,12 +85,6 @@ type CasAnyAttribute struct {
 	Value   string `xml:",chardata"`
 }
 
-type CasAuthenticationSuccessWrapper struct {
-	AuthenticationSuccess *CasAuthenticationSuccess // the token we issued
-	Service               string                    //to which service this token is issued
-	UserId                string
-}
-
 type CasProxySuccess struct {
 	XMLName     xml.Name `xml:"cas:proxySuccess" json:"-"`
 	ProxyTicket string   `xml:"cas:proxyTicket"`
@@ -116,19 +109,38 @@ type Saml11AssertionArtifact struct {
 	InnerXML string   `xml:",innerxml"`
 }
 
-//st is short for service ticket
-var stToServiceResponse sync.Map
+func storeCasTicket(ticketType string, token *CasAuthenticationSuccess, service, userId string) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
 
-//pgt is short for proxy granting ticket
-var pgtToServiceResponse sync.Map
+	ttl := casTicketTtl
+	if ticketType == CasTicketTypeProxyGranting {
+		ttl = casPgtTtl
+	}
+
+	ticket := &CasTicket{
+		Name:        fmt.Sprintf("%s-%s", ticketType, util.GenerateId()),
+		CreatedTime: util.GetCurrentTime(),
+		Type:        ticketType,
+		Service:     service,
+		UserId:      userId,
+		Response:    string(data),
+		ExpireTime:  time.Now().Add(ttl).Unix(),
+	}
+	err = getCasTicketStore().addTicket(ticket)
+	if err != nil {
+		return "", err
+	}
+	return ticket.Name, nil
+}
 
 func StoreCasTokenForPgt(token *CasAuthenticationSuccess, service, userId string) string {
-	pgt := fmt.Sprintf("PGT-%s", util.GenerateId())
-	pgtToServiceResponse.Store(pgt, &CasAuthenticationSuccessWrapper{
-		AuthenticationSuccess: token,
-		Service:               service,
-		UserId:                userId,
-	})
+	pgt, err := storeCasTicket(CasTicketTypeProxyGranting, token, service, userId)
+	if err != nil {
+		panic(err)
+	}
 	return pgt
 }
 
@@ -143,11 +155,20 @@ func GenerateId() {
 @ret4: userIf of user who requested to issue this token
 */
 func GetCasTokenByPgt(pgt string) (bool, *CasAuthenticationSuccess, string, string) {
-	if responseWrapperType, ok := pgtToServiceResponse.LoadAndDelete(pgt); ok {
-		responseWrapperTypeCast := responseWrapperType.(*CasAuthenticationSuccessWrapper)
-		return true, responseWrapperTypeCast.AuthenticationSuccess, responseWrapperTypeCast.Service, responseWrapperTypeCast.UserId
+	// a proxy-granting ticket can be used to issue many proxy tickets until it expires
+	ticket, err := getCasTicketStore().getTicket(pgt)
+	if err != nil {
+		panic(err)
 	}
-	return false, nil, "", ""
+	if ticket == nil || ticket.Type != CasTicketTypeProxyGranting {
+		return false, nil, "", ""
+	}
+
+	response, err := ticket.getResponse()
+	if err != nil {
+		panic(err)
+	}
+	return true, response, ticket.Service, ticket.UserId
 }
 
 /**
@@ -157,20 +178,31 @@ func GetCasTokenByPgt(pgt string) (bool, *CasAuthenticationSuccess, string, stri
 @ret4: userIf of user who requested to issue this token
 */
 func GetCasTokenByTicket(ticket string) (bool, *CasAuthenticationSuccess, string, string) {
-	if responseWrapperType, ok := stToServiceResponse.LoadAndDelete(ticket); ok {
-		responseWrapperTypeCast := responseWrapperType.(*CasAuthenticationSuccessWrapper)
-		return true, responseWrapperTypeCast.AuthenticationSuccess, responseWrapperTypeCast.Service, responseWrapperTypeCast.UserId
+	// service tickets and proxy tickets can only be validated once
+	if strings.HasPrefix(ticket, CasTicketTypeProxyGranting+"-") {
+		return false, nil, "", ""
+	}
+
+	casTicket, err := getCasTicketStore().consumeTicket(ticket)
+	if err != nil {
+		panic(err)
 	}
-	return false, nil, "", ""
+	if casTicket == nil {
+		return false, nil, "", ""
+	}
+
+	response, err := casTicket.getResponse()
+	if err != nil {
+		panic(err)
+	}
+	return true, response, casTicket.Service, casTicket.UserId
 }
 
 func StoreCasTokenForProxyTicket(token *CasAuthenticationSuccess, targetService, userId string) string {
-	proxyTicket := fmt.Sprintf("PT-%s", util.GenerateId())
-	stToServiceResponse.Store(proxyTicket, &CasAuthenticationSuccessWrapper{
-		AuthenticationSuccess: token,
-		Service:               targetService,
-		UserId:                userId,
-	})
+	proxyTicket, err := storeCasTicket(CasTicketTypeProxy, token, targetService, userId)
+	if err != nil {
+		panic(err)
+	}
 	return proxyTicket
 }
 
@@ -195,13 +227,7 @@ func GenerateCasToken(userId string, service string) (string, error) {
 				})
 			}
 		}
-		st := fmt.Sprintf("ST-%d", rand.Int())
-		stToServiceResponse.Store(st, &CasAuthenticationSuccessWrapper{
-			AuthenticationSuccess: &authenticationSuccess,
-			Service:               service,
-			UserId:                userId,
-		})
-		return st, nil
+		return storeCasTicket(CasTicketTypeService, &authenticationSuccess, service, userId)
 	} else {
 		return "", fmt.Errorf("invalid user Id")
 	}
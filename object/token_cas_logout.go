@@ -0,0 +1,80 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/beevik/etree"
+	"github.com/casdoor/casdoor/proxy"
+	uuid "github.com/satori/go.uuid"
+)
+
+// getCasLogoutRequest returns the SAML LogoutRequest that the CAS protocol posts to a service
+// when the SSO session of its service ticket ends
+func getCasLogoutRequest(ticket string) (string, error) {
+	logoutRequest := &etree.Element{
+		Space: "samlp",
+		Tag:   "LogoutRequest",
+	}
+	logoutRequest.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	logoutRequest.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	logoutRequest.CreateAttr("ID", fmt.Sprintf("LR-%s", uuid.NewV4()))
+	logoutRequest.CreateAttr("Version", "2.0")
+	logoutRequest.CreateAttr("IssueInstant", time.Now().UTC().Format(time.RFC3339))
+	logoutRequest.CreateElement("saml:NameID").SetText("@NOT_USED@")
+	logoutRequest.CreateElement("samlp:SessionIndex").SetText(ticket)
+
+	doc := etree.NewDocument()
+	doc.SetRoot(logoutRequest)
+	return doc.WriteToString()
+}
+
+func sendCasLogoutRequest(ticket *CasTicket) error {
+	logoutRequest, err := getCasLogoutRequest(ticket.Name)
+	if err != nil {
+		return err
+	}
+
+	resp, err := proxy.GetHttpClient(ticket.Service).PostForm(ticket.Service, url.Values{"logoutRequest": {logoutRequest}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("the service: %s returned status: %s", ticket.Service, resp.Status)
+	}
+	return nil
+}
+
+// SendCasLogoutRequests notifies every service that validated a ticket of the user that the SSO session has ended,
+// the failures are only logged because the services are not required to support the single logout
+func SendCasLogoutRequests(userId string) {
+	tickets, err := getCasTicketStore().popSessionTickets(userId)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, ticket := range tickets {
+		err = sendCasLogoutRequest(ticket)
+		if err != nil {
+			logs.Error("failed to send the CAS logout request of ticket: %s, error: %s", ticket.Name, err.Error())
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	CasTicketTypeService       = "ST"
+	CasTicketTypeProxy         = "PT"
+	CasTicketTypeProxyGranting = "PGT"
+
+	// service and proxy tickets must be validated soon after they are issued
+	casTicketTtl = 5 * time.Minute
+	casPgtTtl    = 2 * time.Hour
+	// validated tickets are kept to send the single logout callbacks to their services
+	casSessionTtl = 24 * time.Hour
+)
+
+// CasTicket is a CAS ticket shared by all the Casdoor instances, it's stored in the database or in Redis when redisEndpoint is set
+type CasTicket struct {
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Type       string `xorm:"varchar(100)" json:"type"`
+	Service    string `xorm:"varchar(1000)" json:"service"`
+	UserId     string `xorm:"varchar(100) index" json:"userId"`
+	Response   string `xorm:"mediumtext" json:"response"`
+	IsUsed     bool   `json:"isUsed"`
+	ExpireTime int64  `xorm:"index" json:"expireTime"`
+}
+
+type casTicketStore interface {
+	addTicket(ticket *CasTicket) error
+	getTicket(name string) (*CasTicket, error)
+	// consumeTicket returns the ticket only for the first caller, it keeps the ticket for the single logout
+	consumeTicket(name string) (*CasTicket, error)
+	// popSessionTickets returns and forgets the validated tickets of the user
+	popSessionTickets(userId string) ([]*CasTicket, error)
+}
+
+var casStore casTicketStore
+var casStoreOnce sync.Once
+
+func getCasTicketStore() casTicketStore {
+	casStoreOnce.Do(func() {
+		redisEndpoint := conf.GetConfigString("redisEndpoint")
+		if redisEndpoint == "" {
+			casStore = &casTicketDbStore{}
+		} else {
+			casStore = newCasTicketRedisStore(redisEndpoint)
+		}
+	})
+	return casStore
+}
+
+func (ticket *CasTicket) isExpired() bool {
+	return time.Now().Unix() >= ticket.ExpireTime
+}
+
+func (ticket *CasTicket) getResponse() (*CasAuthenticationSuccess, error) {
+	response := CasAuthenticationSuccess{}
+	err := json.Unmarshal([]byte(ticket.Response), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+type casTicketDbStore struct{}
+
+func (s *casTicketDbStore) addTicket(ticket *CasTicket) error {
+	// expired tickets are removed lazily when new tickets are issued
+	_, err := adapter.Engine.Where("expire_time < ?", time.Now().Unix()).Delete(&CasTicket{})
+	if err != nil {
+		return err
+	}
+
+	_, err = adapter.Engine.Insert(ticket)
+	return err
+}
+
+func (s *casTicketDbStore) getTicket(name string) (*CasTicket, error) {
+	ticket := CasTicket{Name: name}
+	existed, err := adapter.Engine.Get(&ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	if !existed || ticket.isExpired() {
+		return nil, nil
+	}
+	return &ticket, nil
+}
+
+func (s *casTicketDbStore) consumeTicket(name string) (*CasTicket, error) {
+	ticket, err := s.getTicket(name)
+	if err != nil || ticket == nil || ticket.IsUsed {
+		return nil, err
+	}
+
+	// the conditional update makes sure that only one instance can use the ticket
+	ticket.IsUsed = true
+	ticket.ExpireTime = time.Now().Add(casSessionTtl).Unix()
+	affected, err := adapter.Engine.Where("name = ? and is_used = ?", name, false).Cols("is_used", "expire_time").Update(ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, nil
+	}
+	return ticket, nil
+}
+
+func (s *casTicketDbStore) popSessionTickets(userId string) ([]*CasTicket, error) {
+	tickets := []*CasTicket{}
+	err := adapter.Engine.Where("user_id = ? and is_used = ? and type != ?", userId, true, CasTicketTypeProxyGranting).Find(&tickets)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = adapter.Engine.Where("user_id = ?", userId).Delete(&CasTicket{})
+	if err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+type casTicketRedisStore struct {
+	pool *redis.Pool
+}
+
+// newCasTicketRedisStore accepts the same redisEndpoint as the Redis session provider: "host:port,poolSize,password,dbNum"
+func newCasTicketRedisStore(redisEndpoint string) *casTicketRedisStore {
+	params := strings.Split(redisEndpoint, ",")
+	address := params[0]
+	password := ""
+	if len(params) > 2 {
+		password = params[2]
+	}
+	dbNum := 0
+	if len(params) > 3 {
+		dbNum, _ = strconv.Atoi(params[3])
+	}
+
+	return &casTicketRedisStore{
+		pool: &redis.Pool{
+			MaxIdle:     3,
+			IdleTimeout: 180 * time.Second,
+			Dial: func() (redis.Conn, error) {
+				options := []redis.DialOption{redis.DialDatabase(dbNum)}
+				if password != "" {
+					options = append(options, redis.DialPassword(password))
+				}
+				return redis.Dial("tcp", address, options...)
+			},
+		},
+	}
+}
+
+func getCasTicketKey(name string) string {
+	return "casdoor_cas_ticket_" + name
+}
+
+func getCasSessionKey(userId string) string {
+	return "casdoor_cas_session_" + userId
+}
+
+func (s *casTicketRedisStore) addTicket(ticket *CasTicket) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		return err
+	}
+
+	ttl := ticket.ExpireTime - time.Now().Unix()
+	_, err = conn.Do("SET", getCasTicketKey(ticket.Name), data, "EX", ttl)
+	return err
+}
+
+func (s *casTicketRedisStore) getTicket(name string) (*CasTicket, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	data, err := redis.Bytes(conn.Do("GET", getCasTicketKey(name)))
+	if err == redis.ErrNil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	ticket := CasTicket{}
+	err = json.Unmarshal(data, &ticket)
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func (s *casTicketRedisStore) consumeTicket(name string) (*CasTicket, error) {
+	ticket, err := s.getTicket(name)
+	if err != nil || ticket == nil {
+		return nil, err
+	}
+
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	// only the instance that really deletes the key can use the ticket
+	deleted, err := redis.Int(conn.Do("DEL", getCasTicketKey(name)))
+	if err != nil || deleted == 0 {
+		return nil, err
+	}
+
+	ticket.IsUsed = true
+	ticket.ExpireTime = time.Now().Add(casSessionTtl).Unix()
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionKey := getCasSessionKey(ticket.UserId)
+	_, err = conn.Do("RPUSH", sessionKey, data)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Do("EXPIRE", sessionKey, int64(casSessionTtl.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	return ticket, nil
+}
+
+func (s *casTicketRedisStore) popSessionTickets(userId string) ([]*CasTicket, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	// LRANGE and DEL run in a transaction, so that a ticket validated in between is not lost
+	sessionKey := getCasSessionKey(userId)
+	err := conn.Send("MULTI")
+	if err != nil {
+		return nil, err
+	}
+	err = conn.Send("LRANGE", sessionKey, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	err = conn.Send("DEL", sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return nil, err
+	}
+	values, err := redis.ByteSlices(replies[0], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tickets := []*CasTicket{}
+	for _, value := range values {
+		ticket := CasTicket{}
+		err = json.Unmarshal(value, &ticket)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, &ticket)
+	}
+	return tickets, nil
+}
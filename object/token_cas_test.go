@@ -0,0 +1,42 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCasLogoutRequest(t *testing.T) {
+	logoutRequest, err := getCasLogoutRequest("ST-123")
+	assert.Nil(t, err)
+
+	var request SamlLogoutRequest
+	err = xml.Unmarshal([]byte(logoutRequest), &request)
+	assert.Nil(t, err)
+	assert.Equal(t, "@NOT_USED@", request.NameID)
+	assert.Equal(t, []string{"ST-123"}, request.SessionIndex)
+}
+
+func TestCasTicketExpiration(t *testing.T) {
+	ticket := &CasTicket{Name: "ST-123", ExpireTime: time.Now().Add(casTicketTtl).Unix()}
+	assert.False(t, ticket.isExpired())
+
+	ticket.ExpireTime = time.Now().Add(-time.Second).Unix()
+	assert.True(t, ticket.isExpired())
+}
@@ -24,9 +24,13 @@ import (
 
 type Claims struct {
 	*User
-	Nonce string `json:"nonce,omitempty"`
-	Tag   string `json:"tag,omitempty"`
-	Scope string `json:"scope,omitempty"`
+	Nonce    string      `json:"nonce,omitempty"`
+	Tag      string      `json:"tag,omitempty"`
+	Scope    string      `json:"scope,omitempty"`
+	Acr      string      `json:"acr,omitempty"`
+	Amr      []string    `json:"amr,omitempty"`
+	AuthTime int64       `json:"auth_time,omitempty"`
+	Act      *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -37,8 +41,12 @@ type UserShort struct {
 
 type ClaimsShort struct {
 	*UserShort
-	Nonce string `json:"nonce,omitempty"`
-	Scope string `json:"scope,omitempty"`
+	Nonce    string      `json:"nonce,omitempty"`
+	Scope    string      `json:"scope,omitempty"`
+	Acr      string      `json:"acr,omitempty"`
+	Amr      []string    `json:"amr,omitempty"`
+	AuthTime int64       `json:"auth_time,omitempty"`
+	Act      *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -55,12 +63,16 @@ func getShortClaims(claims Claims) ClaimsShort {
 		UserShort:        getShortUser(claims.User),
 		Nonce:            claims.Nonce,
 		Scope:            claims.Scope,
+		Acr:              claims.Acr,
+		Amr:              claims.Amr,
+		AuthTime:         claims.AuthTime,
+		Act:              claims.Act,
 		RegisteredClaims: claims.RegisteredClaims,
 	}
 	return res
 }
 
-func generateJwtToken(application *Application, user *User, nonce string, scope string, host string) (string, string, error) {
+func generateJwtToken(application *Application, user *User, nonce string, scope string, host string, authContext *AuthContext) (string, string, error) {
 	nowTime := time.Now()
 	expireTime := nowTime.Add(time.Duration(application.ExpireInHours) * time.Hour)
 	refreshExpireTime := nowTime.Add(time.Duration(application.RefreshExpireInHours) * time.Hour)
@@ -89,6 +101,13 @@ func generateJwtToken(application *Application, user *User, nonce string, scope
 		},
 	}
 
+	if authContext != nil {
+		claims.Acr = authContext.Acr
+		claims.Amr = authContext.GetAmr()
+		claims.AuthTime = authContext.AuthTime
+		claims.Act = getActorClaim(authContext)
+	}
+
 	var token *jwt.Token
 	var refreshToken *jwt.Token
 
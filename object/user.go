@@ -115,8 +115,8 @@ type Userinfo struct {
 	Phone       string `json:"phone,omitempty"`
 }
 
-func GetGlobalUserCount(field, value string) int {
-	session := GetSession("", -1, -1, field, value, "", "")
+func GetGlobalUserCount(query *Query) int {
+	session := GetSession(&User{}, "", -1, -1, query)
 	count, err := session.Count(&User{})
 	if err != nil {
 		panic(err)
@@ -135,9 +135,9 @@ func GetGlobalUsers() []*User {
 	return users
 }
 
-func GetPaginationGlobalUsers(offset, limit int, field, value, sortField, sortOrder string) []*User {
+func GetPaginationGlobalUsers(offset, limit int, query *Query) []*User {
 	users := []*User{}
-	session := GetSession("", offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&User{}, "", offset, limit, query)
 	err := session.Find(&users)
 	if err != nil {
 		panic(err)
@@ -146,8 +146,8 @@ func GetPaginationGlobalUsers(offset, limit int, field, value, sortField, sortOr
 	return users
 }
 
-func GetUserCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetUserCount(owner string, query *Query) int {
+	session := GetSession(&User{}, owner, -1, -1, query)
 	count, err := session.Count(&User{})
 	if err != nil {
 		panic(err)
@@ -185,9 +185,9 @@ func GetSortedUsers(owner string, sorter string, limit int) []*User {
 	return users
 }
 
-func GetPaginationUsers(owner string, offset, limit int, field, value, sortField, sortOrder string) []*User {
+func GetPaginationUsers(owner string, offset, limit int, query *Query) []*User {
 	users := []*User{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&User{}, owner, offset, limit, query)
 	err := session.Find(&users)
 	if err != nil {
 		panic(err)
@@ -339,6 +339,18 @@ func UpdateUser(id string, user *User, columns []string, isGlobalAdmin bool) boo
 		panic(err)
 	}
 
+	if affected != 0 {
+		newOwner, newName := owner, name
+		if util.InSlice(columns, "owner") {
+			newOwner = user.Owner
+		}
+		if util.InSlice(columns, "name") {
+			newName = user.Name
+		}
+		updateUserProvisioning(owner, name, newOwner, newName)
+		publishUserEvent(EventUserUpdated, oldUser, getUser(newOwner, newName))
+	}
+
 	return affected != 0
 }
 
@@ -360,6 +372,11 @@ func UpdateUserForAllFields(id string, user *User) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		updateUserProvisioning(owner, name, user.Owner, user.Name)
+		publishUserEvent(EventUserUpdated, oldUser, getUser(user.Owner, user.Name))
+	}
+
 	return affected != 0
 }
 
@@ -380,13 +397,18 @@ func AddUser(user *User) bool {
 
 	user.PermanentAvatar = getPermanentAvatarUrl(user.Owner, user.Name, user.Avatar)
 
-	user.Ranking = GetUserCount(user.Owner, "", "") + 1
+	user.Ranking = GetUserCount(user.Owner, nil) + 1
 
 	affected, err := adapter.Engine.Insert(user)
 	if err != nil {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionUser(user.Owner, user.Name)
+		publishUserEvent(EventUserCreated, nil, user)
+	}
+
 	return affected != 0
 }
 
@@ -400,6 +422,10 @@ func AddUsers(users []*User) bool {
 		// this function is only used for syncer or batch upload, so no need to encrypt the password
 		//user.UpdateUserPassword(organization)
 
+		if user.Id == "" {
+			user.Id = util.GenerateId()
+		}
+
 		user.UpdateUserHash()
 		user.PreHash = user.Hash
 
@@ -413,6 +439,13 @@ func AddUsers(users []*User) bool {
 		}
 	}
 
+	if affected != 0 {
+		for _, user := range users {
+			provisionUser(user.Owner, user.Name)
+			publishUserEvent(EventUserCreated, nil, user)
+		}
+	}
+
 	return affected != 0
 }
 
@@ -443,11 +476,17 @@ func AddUsersInBatch(users []*User) bool {
 }
 
 func DeleteUser(user *User) bool {
+	oldUser := getUser(user.Owner, user.Name)
 	affected, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Delete(&User{})
 	if err != nil {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionUser(user.Owner, user.Name)
+		publishUserEvent(EventUserDeleted, oldUser, nil)
+	}
+
 	return affected != 0
 }
 
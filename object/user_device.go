@@ -0,0 +1,266 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/url"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	"xorm.io/core"
+)
+
+// UserDevice is a browser a user has signed in from, Owner is the organization of the user.
+// The fingerprint is the hash of the device cookie and the user agent, SessionId is the last
+// web session of the device and ReportToken is the secret of the "this wasn't me" link.
+type UserDevice struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User           string `xorm:"varchar(100) index" json:"user"`
+	Application    string `xorm:"varchar(100)" json:"application"`
+	Fingerprint    string `xorm:"varchar(100) index" json:"fingerprint"`
+	UserAgent      string `xorm:"varchar(500)" json:"userAgent"`
+	ClientIp       string `xorm:"varchar(100)" json:"clientIp"`
+	LastSigninTime string `xorm:"varchar(100)" json:"lastSigninTime"`
+	LastSigninIp   string `xorm:"varchar(100)" json:"lastSigninIp"`
+	SessionId      string `xorm:"varchar(100)" json:"-"`
+	ReportToken    string `xorm:"varchar(100) index" json:"-"`
+}
+
+// the "this wasn't me" link expires a week after the notification
+const userDeviceReportTokenTtl = 7 * 24 * time.Hour
+
+// UserDeviceSignin is what a sign-in tells about the device, DeviceId is the value of the device cookie
+type UserDeviceSignin struct {
+	DeviceId  string
+	UserAgent string
+	ClientIp  string
+	SessionId string
+}
+
+func getUserDeviceFingerprint(deviceId string, userAgent string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s", deviceId, userAgent)))
+	return hex.EncodeToString(hash[:])
+}
+
+func GetUserDevices(owner string, user string) []*UserDevice {
+	devices := []*UserDevice{}
+	err := adapter.Engine.Desc("last_signin_time").Find(&devices, &UserDevice{Owner: owner, User: user})
+	if err != nil {
+		panic(err)
+	}
+
+	return devices
+}
+
+func getUserDeviceByFingerprint(owner string, user string, fingerprint string) *UserDevice {
+	device := UserDevice{Owner: owner, User: user, Fingerprint: fingerprint}
+	existed, err := adapter.Engine.Get(&device)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &device
+	}
+	return nil
+}
+
+func getUserDeviceByReportToken(reportToken string) *UserDevice {
+	if reportToken == "" {
+		return nil
+	}
+
+	device := UserDevice{ReportToken: reportToken}
+	existed, err := adapter.Engine.Get(&device)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &device
+	}
+	return nil
+}
+
+func AddUserDevice(device *UserDevice) bool {
+	affected, err := adapter.Engine.Insert(device)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func DeleteUserDevice(device *UserDevice) bool {
+	affected, err := adapter.Engine.ID(core.PK{device.Owner, device.Name}).Delete(&UserDevice{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+// getUserDeviceOrigin returns the origin of the "this wasn't me" link, it never comes from the request,
+// whose Host header is controlled by the client
+func getUserDeviceOrigin(application *Application) string {
+	origin := conf.GetConfigString("origin")
+	if origin != "" {
+		return origin
+	}
+
+	homepageUrl, err := url.Parse(application.HomepageUrl)
+	if err != nil || homepageUrl.Scheme == "" || homepageUrl.Host == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s://%s", homepageUrl.Scheme, homepageUrl.Host)
+}
+
+func isUserDeviceReportTokenExpired(device *UserDevice) bool {
+	createdTime, err := time.Parse(time.RFC3339, device.CreatedTime)
+	if err != nil {
+		return true
+	}
+	return time.Since(createdTime) > userDeviceReportTokenTtl
+}
+
+func getUserDeviceEmailContent(user *User, device *UserDevice, origin string) string {
+	link := fmt.Sprintf("%s/report-device?token=%s", origin, device.ReportToken)
+	return fmt.Sprintf(`<p>Hi %s,</p>
+<p>Your account was just used to sign in from a new device:</p>
+<ul>
+<li>Time: %s</li>
+<li>IP address: %s</li>
+<li>Browser: %s</li>
+</ul>
+<p>If this was you, you can ignore this email. If it wasn't you, <a href="%s">secure your account</a> within %d days: all of your sessions will be signed out and you will have to reset your password.</p>`,
+		html.EscapeString(user.GetId()), html.EscapeString(device.CreatedTime), html.EscapeString(device.ClientIp), html.EscapeString(device.UserAgent), html.EscapeString(link), int(userDeviceReportTokenTtl.Hours()/24))
+}
+
+// CheckUserDevice remembers the device of a successful sign-in, and when the organization enables
+// the device notification, emails the user about a device it hasn't seen before through the Email
+// provider of the application. The first device of a user is remembered silently.
+func CheckUserDevice(user *User, application *Application, signin *UserDeviceSignin) {
+	organization := GetOrganizationByUser(user)
+	if organization == nil || !organization.EnableDeviceNotification || signin.DeviceId == "" {
+		return
+	}
+
+	fingerprint := getUserDeviceFingerprint(signin.DeviceId, signin.UserAgent)
+	device := getUserDeviceByFingerprint(user.Owner, user.Name, fingerprint)
+	if device != nil {
+		device.LastSigninTime = util.GetCurrentTime()
+		device.LastSigninIp = signin.ClientIp
+		device.SessionId = signin.SessionId
+		_, err := adapter.Engine.ID(core.PK{device.Owner, device.Name}).Cols("last_signin_time", "last_signin_ip", "session_id").Update(device)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	isFirstDevice := len(GetUserDevices(user.Owner, user.Name)) == 0
+	device = &UserDevice{
+		Owner:          user.Owner,
+		Name:           util.GenerateId(),
+		CreatedTime:    util.GetCurrentTime(),
+		User:           user.Name,
+		Application:    application.Name,
+		Fingerprint:    fingerprint,
+		UserAgent:      signin.UserAgent,
+		ClientIp:       signin.ClientIp,
+		LastSigninTime: util.GetCurrentTime(),
+		LastSigninIp:   signin.ClientIp,
+		SessionId:      signin.SessionId,
+		ReportToken:    util.GenerateId(),
+	}
+	AddUserDevice(device)
+
+	if isFirstDevice || user.Email == "" {
+		return
+	}
+
+	provider := application.GetEmailProvider()
+	if provider == nil {
+		logs.Warn("the application: %s has no Email provider to notify the user: %s of a new device", application.Name, user.GetId())
+		return
+	}
+
+	origin := getUserDeviceOrigin(application)
+	if origin == "" {
+		logs.Warn("neither the origin nor the homepage URL of the application: %s is set to notify the user: %s of a new device", application.Name, user.GetId())
+		return
+	}
+
+	content := getUserDeviceEmailContent(user, device, origin)
+	err := SendEmail(provider, "New sign-in to your account", content, user.Email, organization.DisplayName)
+	if err != nil {
+		logs.Error("failed to notify the user: %s of a new device: %s", user.GetId(), err.Error())
+	}
+}
+
+// ReportUserDevice handles the "this wasn't me" link: the device is forgotten, the tokens of the
+// user are revoked and the password is replaced by a random one, so that the user has to set a
+// new one through the forget password page. It returns the device and the web sessions to destroy.
+func ReportUserDevice(reportToken string) (*UserDevice, []string, error) {
+	device := getUserDeviceByReportToken(reportToken)
+	if device == nil {
+		return nil, nil, fmt.Errorf("the link is invalid or has already been used")
+	}
+	if isUserDeviceReportTokenExpired(device) {
+		return nil, nil, fmt.Errorf("the link has expired")
+	}
+
+	user := getUser(device.Owner, device.User)
+	if user == nil {
+		return nil, nil, fmt.Errorf("the user: %s/%s doesn't exist", device.Owner, device.User)
+	}
+
+	sessionIds := []string{}
+	for _, userDevice := range GetUserDevices(user.Owner, user.Name) {
+		if userDevice.SessionId == "" {
+			continue
+		}
+
+		sessionIds = append(sessionIds, userDevice.SessionId)
+		userDevice.SessionId = ""
+		_, err := adapter.Engine.ID(core.PK{userDevice.Owner, userDevice.Name}).Cols("session_id").Update(userDevice)
+		if err != nil {
+			panic(err)
+		}
+	}
+	DeleteUserDevice(device)
+
+	tokens := []*Token{}
+	err := adapter.Engine.Find(&tokens, &Token{Organization: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+	for _, token := range tokens {
+		DeleteToken(token)
+	}
+
+	user.Password = util.GenerateId()
+	SetUserField(user, "password", user.Password)
+	return device, sessionIds, nil
+}
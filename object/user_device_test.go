@@ -0,0 +1,43 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserDeviceNotification(t *testing.T) {
+	fingerprint := getUserDeviceFingerprint("device-1", "Mozilla/5.0 (X11; Linux x86_64)")
+	assert.Equal(t, fingerprint, getUserDeviceFingerprint("device-1", "Mozilla/5.0 (X11; Linux x86_64)"))
+	assert.NotEqual(t, fingerprint, getUserDeviceFingerprint("device-1", "Mozilla/5.0 (Windows NT 10.0)"))
+	assert.NotEqual(t, fingerprint, getUserDeviceFingerprint("device-2", "Mozilla/5.0 (X11; Linux x86_64)"))
+
+	user := &User{Owner: "built-in", Name: "alice"}
+	device := &UserDevice{CreatedTime: "2022-03-01T10:00:00+08:00", ClientIp: "10.0.0.1", UserAgent: "<script>alert(1)</script>", ReportToken: "report-token"}
+	content := getUserDeviceEmailContent(user, device, "https://door.casdoor.com")
+	assert.Contains(t, content, `href="https://door.casdoor.com/report-device?token=report-token"`)
+	assert.Contains(t, content, "built-in/alice")
+	assert.Contains(t, content, "10.0.0.1")
+	assert.NotContains(t, content, "<script>")
+}
+
+func TestUserDeviceReportToken(t *testing.T) {
+	assert.True(t, isUserDeviceReportTokenExpired(&UserDevice{CreatedTime: "2022-03-01T10:00:00+08:00"}))
+	assert.True(t, isUserDeviceReportTokenExpired(&UserDevice{CreatedTime: ""}))
+	assert.False(t, isUserDeviceReportTokenExpired(&UserDevice{CreatedTime: util.GetCurrentTime()}))
+}
@@ -47,7 +47,8 @@ func parseLineItemBool(line *[]string, i int) bool {
 	return parseLineItemInt(line, i) != 0
 }
 
-func UploadUsers(owner string, fileId string) bool {
+// UploadUsers adds the users of the uploaded file that don't exist yet and returns them
+func UploadUsers(owner string, fileId string) ([]*User, bool) {
 	table := xlsx.ReadXlsxFile(fileId)
 
 	oldUserMap := getUserMap(owner)
@@ -112,7 +113,7 @@ func UploadUsers(owner string, fileId string) bool {
 	}
 
 	if len(newUsers) == 0 {
-		return false
+		return nil, false
 	}
-	return AddUsersInBatch(newUsers)
+	return newUsers, AddUsersInBatch(newUsers)
 }
@@ -80,6 +80,7 @@ func SetUserField(user *User, field string, value string) bool {
 		value = user.Password
 	}
 
+	oldUser := getUser(user.Owner, user.Name)
 	affected, err := adapter.Engine.Table(user).ID(core.PK{user.Owner, user.Name}).Update(map[string]interface{}{field: value})
 	if err != nil {
 		panic(err)
@@ -92,6 +93,11 @@ func SetUserField(user *User, field string, value string) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionUser(user.Owner, user.Name)
+		publishUserEvent(EventUserUpdated, oldUser, user)
+	}
+
 	return affected != 0
 }
 
@@ -38,12 +38,15 @@ type Webhook struct {
 	ContentType    string    `xorm:"varchar(100)" json:"contentType"`
 	Headers        []*Header `xorm:"mediumtext" json:"headers"`
 	Events         []string  `xorm:"varchar(100)" json:"events"`
+	Secret         string    `xorm:"varchar(100)" json:"secret"`
+	Filter         string    `xorm:"varchar(1000)" json:"filter"`
+	Template       string    `xorm:"mediumtext" json:"template"`
 	IsUserExtended bool      `json:"isUserExtended"`
 	IsEnabled      bool      `json:"isEnabled"`
 }
 
-func GetWebhookCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetWebhookCount(owner string, query *Query) int {
+	session := GetSession(&Webhook{}, owner, -1, -1, query)
 	count, err := session.Count(&Webhook{})
 	if err != nil {
 		panic(err)
@@ -62,9 +65,9 @@ func GetWebhooks(owner string) []*Webhook {
 	return webhooks
 }
 
-func GetPaginationWebhooks(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Webhook {
+func GetPaginationWebhooks(owner string, offset, limit int, query *Query) []*Webhook {
 	webhooks := []*Webhook{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := GetSession(&Webhook{}, owner, offset, limit, query)
 	err := session.Find(&webhooks)
 	if err != nil {
 		panic(err)
@@ -106,12 +109,43 @@ func GetWebhook(id string) *Webhook {
 	return getWebhook(owner, name)
 }
 
+func GetMaskedWebhook(webhook *Webhook, userId string) *Webhook {
+	if isUserIdGlobalAdmin(userId) {
+		return webhook
+	}
+
+	if webhook == nil {
+		return nil
+	}
+
+	if webhook.Secret != "" {
+		webhook.Secret = "***"
+	}
+	return webhook
+}
+
+func GetMaskedWebhooks(webhooks []*Webhook, userId string) []*Webhook {
+	if isUserIdGlobalAdmin(userId) {
+		return webhooks
+	}
+
+	for _, webhook := range webhooks {
+		webhook = GetMaskedWebhook(webhook, userId)
+	}
+	return webhooks
+}
+
 func UpdateWebhook(id string, webhook *Webhook) bool {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	if getWebhook(owner, name) == nil {
+	oldWebhook := getWebhook(owner, name)
+	if oldWebhook == nil {
 		return false
 	}
 
+	if webhook.Secret == "***" {
+		webhook.Secret = oldWebhook.Secret
+	}
+
 	affected, err := adapter.Engine.ID(core.PK{owner, name}).AllCols().Update(webhook)
 	if err != nil {
 		panic(err)
@@ -135,6 +169,10 @@ func DeleteWebhook(webhook *Webhook) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		deleteWebhookEvents(webhook)
+	}
+
 	return affected != 0
 }
 
@@ -0,0 +1,332 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	WebhookEventStatePending   = "Pending"
+	WebhookEventStateDelivered = "Delivered"
+	WebhookEventStateFailed    = "Failed"
+
+	webhookMaxAttempts     = 12
+	webhookMaxBackoff      = 6 * time.Hour
+	webhookRetryInterval   = time.Minute
+	webhookMaxResponseSize = 1000
+	webhookBatchSize       = 100
+)
+
+// WebhookEvent is a record queued for delivery to a webhook, the payload is saved when the event is queued so that
+// a retry or a redelivery sends the same body. The pending events form the delivery queue and survive a restart
+type WebhookEvent struct {
+	Id           int    `xorm:"int notnull pk autoincr" json:"id"`
+	Owner        string `xorm:"varchar(100) index" json:"owner"`
+	Webhook      string `xorm:"varchar(100) index" json:"webhook"`
+	CreatedTime  string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime  string `xorm:"varchar(100)" json:"updatedTime"`
+	Organization string `xorm:"varchar(100)" json:"organization"`
+
+	RecordId       int    `xorm:"index" json:"recordId"`
+	Action         string `xorm:"varchar(1000)" json:"action"`
+	Payload        string `xorm:"mediumtext" json:"payload"`
+	State          string `xorm:"varchar(100)" json:"state"`
+	IsPending      bool   `xorm:"index" json:"isPending"`
+	Attempts       int    `json:"attempts"`
+	NextRetryTime  string `xorm:"varchar(100)" json:"nextRetryTime"`
+	LastStatusCode int    `json:"lastStatusCode"`
+	LastError      string `xorm:"mediumtext" json:"lastError"`
+}
+
+// WebhookDelivery is the log entry of an attempt to deliver an event
+type WebhookDelivery struct {
+	Id          int    `xorm:"int notnull pk autoincr" json:"id"`
+	Owner       string `xorm:"varchar(100) index" json:"owner"`
+	Webhook     string `xorm:"varchar(100) index" json:"webhook"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Event        int    `xorm:"index" json:"event"`
+	Attempt      int    `json:"attempt"`
+	Url          string `xorm:"varchar(100)" json:"url"`
+	StatusCode   int    `json:"statusCode"`
+	Latency      int    `json:"latency"`
+	Response     string `xorm:"mediumtext" json:"response"`
+	Error        string `xorm:"mediumtext" json:"error"`
+	IsSuccessful bool   `json:"isSuccessful"`
+}
+
+var webhookWakeChan = make(chan struct{}, 1)
+
+func GetWebhookEventCount(webhook *Webhook, query *Query) int {
+	session := GetSession(&WebhookEvent{}, webhook.Owner, -1, -1, query).And("webhook=?", webhook.Name)
+	count, err := session.Count(&WebhookEvent{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetWebhookEvents(webhook *Webhook) []*WebhookEvent {
+	events := []*WebhookEvent{}
+	err := adapter.Engine.Desc("id").Omit("payload").Find(&events, &WebhookEvent{Owner: webhook.Owner, Webhook: webhook.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return events
+}
+
+func GetPaginationWebhookEvents(webhook *Webhook, offset, limit int, query *Query) []*WebhookEvent {
+	query.setDefaultSort("id", "descend")
+
+	events := []*WebhookEvent{}
+	session := GetSession(&WebhookEvent{}, webhook.Owner, offset, limit, query).And("webhook=?", webhook.Name)
+	err := session.Omit("payload").Find(&events)
+	if err != nil {
+		panic(err)
+	}
+
+	return events
+}
+
+func GetWebhookEvent(id int) *WebhookEvent {
+	event := WebhookEvent{Id: id}
+	existed, err := adapter.Engine.Get(&event)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &event
+	}
+	return nil
+}
+
+func GetWebhookDeliveries(event *WebhookEvent) []*WebhookDelivery {
+	deliveries := []*WebhookDelivery{}
+	err := adapter.Engine.Desc("id").Find(&deliveries, &WebhookDelivery{Event: event.Id})
+	if err != nil {
+		panic(err)
+	}
+
+	return deliveries
+}
+
+func updateWebhookEvent(event *WebhookEvent) {
+	event.UpdatedTime = util.GetCurrentTime()
+	_, err := adapter.Engine.ID(event.Id).AllCols().Update(event)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func deleteWebhookEvents(webhook *Webhook) {
+	_, err := adapter.Engine.Delete(&WebhookEvent{Owner: webhook.Owner, Webhook: webhook.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = adapter.Engine.Delete(&WebhookDelivery{Owner: webhook.Owner, Webhook: webhook.Name})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// getSubscribedWebhooks returns the enabled webhooks of the organization subscribed to the event type,
+// or to the action of a record for the webhooks set up before the typed events
+func getSubscribedWebhooks(organization string, eventType string) []*Webhook {
+	res := []*Webhook{}
+	for _, webhook := range getWebhooksByOrganization(organization) {
+		if webhook.IsEnabled && util.InSlice(webhook.Events, eventType) {
+			res = append(res, webhook)
+		}
+	}
+	return res
+}
+
+// enqueueWebhookEvents queues the record for each webhook, the dispatcher delivers it in the background
+func enqueueWebhookEvents(record *Record, webhooks []*Webhook) {
+	if len(webhooks) == 0 {
+		return
+	}
+
+	events := []*WebhookEvent{}
+	for _, webhook := range webhooks {
+		record.ExtendedUser = nil
+		if webhook.IsUserExtended {
+			record.ExtendedUser = getUser(record.Organization, record.User)
+		}
+
+		event := newWebhookEvent(webhook, record.Organization, record.Action, util.StructToJson(record))
+		if event != nil {
+			event.RecordId = record.Id
+			events = append(events, event)
+		}
+	}
+	record.ExtendedUser = nil
+	if len(events) == 0 {
+		return
+	}
+
+	_, err := adapter.Engine.Insert(events)
+	if err != nil {
+		panic(err)
+	}
+
+	wakeWebhookDispatcher()
+}
+
+// RedeliverWebhookEvent queues the event again with a fresh attempt count, even if it has been delivered
+func RedeliverWebhookEvent(event *WebhookEvent) {
+	event.State = WebhookEventStatePending
+	event.IsPending = true
+	event.Attempts = 0
+	event.NextRetryTime = ""
+	updateWebhookEvent(event)
+	wakeWebhookDispatcher()
+}
+
+func wakeWebhookDispatcher() {
+	select {
+	case webhookWakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// InitWebhookDispatcher starts the goroutine that delivers the pending webhook events,
+// it runs when an event is queued and every minute for the retries
+func InitWebhookDispatcher() {
+	util.SafeGoroutine(func() {
+		ticker := time.NewTicker(webhookRetryInterval)
+		defer ticker.Stop()
+		for {
+			runWebhookDispatcherOnce()
+
+			select {
+			case <-webhookWakeChan:
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+func runWebhookDispatcherOnce() {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Error("webhook dispatcher panic: %v", r)
+		}
+	}()
+
+	for {
+		events := []*WebhookEvent{}
+		err := adapter.Engine.Where("is_pending = ? and (next_retry_time = ? or next_retry_time <= ?)", true, "", time.Now().UTC().Format(time.RFC3339)).
+			Asc("id").Limit(webhookBatchSize).Find(&events)
+		if err != nil {
+			panic(err)
+		}
+
+		webhooks := map[string]*Webhook{}
+		for _, event := range events {
+			webhookId := fmt.Sprintf("%s/%s", event.Owner, event.Webhook)
+			webhook, ok := webhooks[webhookId]
+			if !ok {
+				webhook = getWebhook(event.Owner, event.Webhook)
+				webhooks[webhookId] = webhook
+			}
+			if webhook == nil {
+				event.State = WebhookEventStateFailed
+				event.IsPending = false
+				event.LastError = fmt.Sprintf("the webhook: %s/%s doesn't exist", event.Owner, event.Webhook)
+				updateWebhookEvent(event)
+				continue
+			}
+
+			deliverWebhookEvent(webhook, event)
+		}
+
+		if len(events) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// getWebhookBackoff doubles the delay after each failed attempt: 1 minute, 2 minutes, 4 minutes... up to 6 hours
+func getWebhookBackoff(attempts int) time.Duration {
+	backoff := webhookRetryInterval
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// deliverWebhookEvent makes an attempt to deliver the event and logs it, a non-2xx response or a network error is
+// retried with backoff until webhookMaxAttempts, a request that can't be built fails at once
+func deliverWebhookEvent(webhook *Webhook, event *WebhookEvent) {
+	startTime := time.Now()
+	statusCode, response, err := sendWebhook(webhook, event)
+	latency := time.Since(startTime)
+
+	if len(response) > webhookMaxResponseSize {
+		response = response[:webhookMaxResponseSize]
+	}
+
+	event.Attempts++
+	delivery := &WebhookDelivery{
+		Owner:        event.Owner,
+		Webhook:      event.Webhook,
+		CreatedTime:  util.GetCurrentTime(),
+		Event:        event.Id,
+		Attempt:      event.Attempts,
+		Url:          webhook.Url,
+		StatusCode:   statusCode,
+		Latency:      int(latency.Milliseconds()),
+		Response:     response,
+		IsSuccessful: err == nil && statusCode >= 200 && statusCode < 300,
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	} else if !delivery.IsSuccessful {
+		delivery.Error = fmt.Sprintf("the webhook returned status %d", statusCode)
+	}
+
+	_, dbErr := adapter.Engine.Insert(delivery)
+	if dbErr != nil {
+		panic(dbErr)
+	}
+
+	event.LastStatusCode = statusCode
+	event.LastError = delivery.Error
+	if delivery.IsSuccessful {
+		event.State = WebhookEventStateDelivered
+		event.IsPending = false
+		event.NextRetryTime = ""
+	} else if _, ok := err.(*webhookRequestError); ok || event.Attempts >= webhookMaxAttempts {
+		event.State = WebhookEventStateFailed
+		event.IsPending = false
+		event.NextRetryTime = ""
+	} else {
+		event.NextRetryTime = time.Now().Add(getWebhookBackoff(event.Attempts)).UTC().Format(time.RFC3339)
+	}
+	updateWebhookEvent(event)
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"contains": func(list interface{}, v interface{}) bool {
+		items, _ := list.([]interface{})
+		for _, item := range items {
+			if fmt.Sprint(item) == fmt.Sprint(v) {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+// WebhookTestResult is the outcome of rendering a sample event for a webhook without delivering it
+type WebhookTestResult struct {
+	Event     string `json:"event"`
+	IsMatched bool   `json:"isMatched"`
+	Payload   string `json:"payload"`
+	Error     string `json:"error"`
+}
+
+// getWebhookTemplateData decodes the JSON payload of an event, the template and the filter see the fields
+// by their JSON names like {{.after.email}}
+func getWebhookTemplateData(payload string) (interface{}, error) {
+	var data interface{}
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.UseNumber()
+	err := decoder.Decode(&data)
+	return data, err
+}
+
+func executeWebhookTemplate(name string, text string, payload string) (string, error) {
+	t, err := template.New(name).Funcs(webhookTemplateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := getWebhookTemplateData(payload)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderWebhookTemplate returns the body sent for the payload, the payload itself when the webhook has no template
+func renderWebhookTemplate(text string, payload string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return payload, nil
+	}
+	return executeWebhookTemplate("template", text, payload)
+}
+
+// matchWebhookFilter tells if the webhook wants the event. The filter is a template rendering "true" or "false",
+// like {{eq .after.tag "staff"}}, an empty filter accepts all the events
+func matchWebhookFilter(filter string, payload string) (bool, error) {
+	if strings.TrimSpace(filter) == "" {
+		return true, nil
+	}
+
+	res, err := executeWebhookTemplate("filter", filter, payload)
+	if err != nil {
+		return false, err
+	}
+
+	matched, err := strconv.ParseBool(strings.TrimSpace(res))
+	if err != nil {
+		return false, fmt.Errorf("the filter must render true or false, got: %q", res)
+	}
+	return matched, nil
+}
+
+// newWebhookEvent queues the payload for the webhook if its filter accepts it, a filter error is saved as a failed
+// event so that it shows in the delivery log
+func newWebhookEvent(webhook *Webhook, organization string, action string, payload string) *WebhookEvent {
+	matched, err := matchWebhookFilter(webhook.Filter, payload)
+	if err == nil && !matched {
+		return nil
+	}
+
+	currentTime := util.GetCurrentTime()
+	event := &WebhookEvent{
+		Owner:        webhook.Owner,
+		Webhook:      webhook.Name,
+		CreatedTime:  currentTime,
+		UpdatedTime:  currentTime,
+		Organization: organization,
+		Action:       action,
+		Payload:      payload,
+		State:        WebhookEventStatePending,
+		IsPending:    true,
+	}
+	if err != nil {
+		event.State = WebhookEventStateFailed
+		event.IsPending = false
+		event.LastError = fmt.Sprintf("filter: %s", err.Error())
+	}
+	return event
+}
+
+// getWebhookTestPayload builds the payload of a sample event of the type for the webhook, about the given user
+func getWebhookTestPayload(webhook *Webhook, eventType string, user *User) string {
+	if getEventObjectType(eventType) == "" {
+		record := &Record{
+			Id:           1,
+			Owner:        webhook.Organization,
+			Name:         "test",
+			CreatedTime:  util.GetCurrentTime(),
+			Organization: webhook.Organization,
+			ClientIp:     "127.0.0.1",
+			User:         user.Name,
+			Method:       "POST",
+			RequestUri:   "/api/" + eventType,
+			Action:       eventType,
+			IsTriggered:  true,
+		}
+		if webhook.IsUserExtended {
+			record.ExtendedUser = user
+		}
+		return util.StructToJson(record)
+	}
+
+	var before, after interface{}
+	if getEventObjectType(eventType) == "user" {
+		before, after = getEventUser(user), getEventUser(user)
+		switch eventType {
+		case EventUserCreated:
+			before = nil
+		case EventUserDeleted:
+			after = nil
+		}
+	}
+
+	event := NewEvent(eventType, webhook.Organization, user.GetId(), before, after)
+	event.Actor = user.GetId()
+	event.ClientIp = "127.0.0.1"
+	return util.StructToJson(event)
+}
+
+// TestWebhook renders a sample event with the filter and the template of the webhook, nothing is delivered
+func TestWebhook(webhook *Webhook, eventType string, user *User) *WebhookTestResult {
+	if user == nil {
+		user = &User{Owner: webhook.Organization, Name: "admin"}
+	}
+
+	res := &WebhookTestResult{Event: getWebhookTestPayload(webhook, eventType, user)}
+	matched, err := matchWebhookFilter(webhook.Filter, res.Event)
+	if err != nil {
+		res.Error = fmt.Sprintf("filter: %s", err.Error())
+		return res
+	}
+	res.IsMatched = matched
+
+	res.Payload, err = renderWebhookTemplate(webhook.Template, res.Event)
+	if err != nil {
+		res.Error = fmt.Sprintf("template: %s", err.Error())
+	}
+	return res
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendWebhook(t *testing.T) {
+	secret := "whsec"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "7", r.Header.Get(WebhookEventIdHeader))
+		assert.Equal(t, "value", r.Header.Get("X-Custom"))
+
+		// the receiver side of the signature check
+		var timestamp int64
+		var signature string
+		_, err := fmt.Sscanf(strings.Replace(r.Header.Get(WebhookSignatureHeader), ",", " ", 1), "t=%d v1=%s", &timestamp, &signature)
+		assert.Nil(t, err)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(fmt.Sprintf("%d.%s", timestamp, body)))
+		if signature != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, strings.Repeat("a", webhookMaxResponseSize*2))
+	}))
+	defer server.Close()
+
+	webhook := &Webhook{Url: server.URL, Method: "POST", ContentType: "application/json", Secret: secret, Headers: []*Header{{Name: "X-Custom", Value: "value"}}}
+	event := &WebhookEvent{Id: 7, Payload: `{"action":"login"}`}
+	statusCode, response, err := sendWebhook(webhook, event)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, statusCode)
+	assert.Equal(t, webhookMaxResponseSize, len(response))
+
+	webhook.Secret = "wrong"
+	statusCode, _, err = sendWebhook(webhook, event)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, statusCode)
+
+	// a template that can't be rendered is not worth retrying
+	webhook.Template = "{{"
+	_, _, err = sendWebhook(webhook, event)
+	assert.IsType(t, &webhookRequestError{}, err)
+}
+
+func TestGetWebhookBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, getWebhookBackoff(1))
+	assert.Equal(t, 4*time.Minute, getWebhookBackoff(3))
+	assert.Equal(t, webhookMaxBackoff, getWebhookBackoff(webhookMaxAttempts))
+}
+
+func TestGetWebhookSignature(t *testing.T) {
+	assert.Equal(t, "t=1600000000,v1=88a91a38b2ad4950e2252df03d4c89e741e1efc7489495183ea408507958e659", getWebhookSignature("whsec", 1600000000, "{}"))
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	payload := `{"type":"user.updated","after":{"name":"alice","tag":"staff","score":2000,"groups":["a","b"]},"changedFields":["tag"]}`
+
+	body, err := renderWebhookTemplate(`{"text": "{{.after.name}} ({{.after.score}}) changed {{json .changedFields}}"}`, payload)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"text": "alice (2000) changed ["tag"]"}`, body)
+
+	body, err = renderWebhookTemplate(" ", payload)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, body)
+
+	_, err = renderWebhookTemplate("{{.after.name", payload)
+	assert.NotNil(t, err)
+
+	for filter, expected := range map[string]bool{
+		"":                               true,
+		`{{eq .after.tag "staff"}}`:      true,
+		`{{eq .after.tag "student"}}`:    false,
+		`{{contains .after.groups "b"}}`: true,
+		`{{and (eq .type "user.updated") (contains .changedFields "email")}}`: false,
+	} {
+		matched, err := matchWebhookFilter(filter, payload)
+		assert.Nil(t, err, filter)
+		assert.Equal(t, expected, matched, filter)
+	}
+
+	_, err = matchWebhookFilter(`{{.after.name}}`, payload)
+	assert.NotNil(t, err)
+
+	webhook := &Webhook{Owner: "admin", Name: "w", Filter: `{{eq .after.tag "student"}}`}
+	assert.Nil(t, newWebhookEvent(webhook, "built-in", "user.updated", payload))
+	webhook.Filter = `{{.after.name}}`
+	event := newWebhookEvent(webhook, "built-in", "user.updated", payload)
+	assert.Equal(t, WebhookEventStateFailed, event.State)
+	assert.False(t, event.IsPending)
+
+	webhook = &Webhook{Organization: "built-in", Filter: `{{eq .type "user.created"}}`, Template: `{{.after.name}}`}
+	res := TestWebhook(webhook, EventUserCreated, &User{Owner: "built-in", Name: "alice", Password: "123"})
+	assert.Equal(t, "", res.Error)
+	assert.True(t, res.IsMatched)
+	assert.Equal(t, "alice", res.Payload)
+	assert.NotContains(t, res.Event, `"123"`)
+}
@@ -15,20 +15,54 @@
 package object
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+)
 
-	"github.com/casdoor/casdoor/util"
+const (
+	WebhookSignatureHeader = "Casdoor-Signature"
+	WebhookEventIdHeader   = "Casdoor-Event-Id"
 )
 
-func sendWebhook(webhook *Webhook, record *Record) error {
-	client := &http.Client{}
+var webhookHttpClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookRequestError is returned when the request can't be built, e.g. the template fails to render,
+// retrying would fail the same way
+type webhookRequestError struct {
+	err error
+}
+
+func (e *webhookRequestError) Error() string {
+	return e.err.Error()
+}
+
+// getWebhookSignature signs the timestamp and the body with the secret of the webhook, the receiver recomputes
+// HMAC-SHA256(secret, "<timestamp>.<body>") and rejects the stale timestamps to prevent replays
+func getWebhookSignature(secret string, timestamp int64, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "." + payload))
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
 
-	body := strings.NewReader(util.StructToJson(record))
+// sendWebhook posts the payload of the event rendered with the template of the webhook and returns the status code and the body of the response
+func sendWebhook(webhook *Webhook, event *WebhookEvent) (int, string, error) {
+	payload, err := renderWebhookTemplate(webhook.Template, event.Payload)
+	if err != nil {
+		return 0, "", &webhookRequestError{err: err}
+	}
+	body := strings.NewReader(payload)
 
 	req, err := http.NewRequest(webhook.Method, webhook.Url, body)
 	if err != nil {
-		return err
+		return 0, "", &webhookRequestError{err: err}
 	}
 
 	req.Header.Set("Content-Type", webhook.ContentType)
@@ -37,6 +71,20 @@ func sendWebhook(webhook *Webhook, record *Record) error {
 		req.Header.Set(header.Name, header.Value)
 	}
 
-	_, err = client.Do(req)
-	return err
+	req.Header.Set(WebhookEventIdHeader, strconv.Itoa(event.Id))
+	if webhook.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, getWebhookSignature(webhook.Secret, time.Now().Unix(), payload))
+	}
+
+	resp, err := webhookHttpClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(&io.LimitedReader{R: resp.Body, N: webhookMaxResponseSize})
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+	return resp.StatusCode, string(data), nil
 }
@@ -109,6 +109,14 @@ func getUrlPath(urlPath string) string {
 		return "/api/login/oauth"
 	}
 
+	if strings.HasPrefix(urlPath, "/api/saml/slo/") {
+		return "/api/saml/slo"
+	}
+
+	if strings.HasPrefix(urlPath, "/scim/") {
+		return "/scim"
+	}
+
 	return urlPath
 }
 
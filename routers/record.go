@@ -58,6 +58,11 @@ func RecordMessage(ctx *context.Context) {
 		return
 	}
 
+	// the impersonation APIs write their own records with the real admin
+	if ctx.Request.URL.Path == "/api/start-impersonation" || ctx.Request.URL.Path == "/api/stop-impersonation" {
+		return
+	}
+
 	record := object.NewRecord(ctx)
 
 	userId := getUser(ctx)
@@ -55,6 +55,10 @@ func initAPI() {
 	beego.Router("/api/get-saml-login", &controllers.ApiController{}, "GET:GetSamlLogin")
 	beego.Router("/api/acs", &controllers.ApiController{}, "POST:HandleSamlLogin")
 	beego.Router("/api/saml/metadata", &controllers.ApiController{}, "GET:GetSamlMeta")
+	beego.Router("/api/saml/slo/:owner/:application", &controllers.ApiController{}, "GET:HandleSamlLogout;POST:HandleSamlLogout")
+	beego.Router("/api/saml/idp-initiated", &controllers.ApiController{}, "GET:GetSamlIdpInitiatedLogin")
+	beego.Router("/api/start-impersonation", &controllers.ApiController{}, "POST:StartImpersonation")
+	beego.Router("/api/stop-impersonation", &controllers.ApiController{}, "POST:StopImpersonation")
 
 	beego.Router("/api/get-organizations", &controllers.ApiController{}, "GET:GetOrganizations")
 	beego.Router("/api/get-organization", &controllers.ApiController{}, "GET:GetOrganization")
@@ -96,6 +100,8 @@ func initAPI() {
 	beego.Router("/api/send-verification-code", &controllers.ApiController{}, "POST:SendVerificationCode")
 	beego.Router("/api/verify-captcha", &controllers.ApiController{}, "POST:VerifyCaptcha")
 	beego.Router("/api/reset-email-or-phone", &controllers.ApiController{}, "POST:ResetEmailOrPhone")
+	beego.Router("/api/get-user-devices", &controllers.ApiController{}, "GET:GetUserDevices")
+	beego.Router("/api/report-device", &controllers.ApiController{}, "POST:ReportUserDevice")
 	beego.Router("/api/get-captcha", &controllers.ApiController{}, "GET:GetCaptcha")
 
 	beego.Router("/api/get-ldap-user", &controllers.ApiController{}, "POST:GetLdapUser")
@@ -119,6 +125,8 @@ func initAPI() {
 	beego.Router("/api/update-application", &controllers.ApiController{}, "POST:UpdateApplication")
 	beego.Router("/api/add-application", &controllers.ApiController{}, "POST:AddApplication")
 	beego.Router("/api/delete-application", &controllers.ApiController{}, "POST:DeleteApplication")
+	beego.Router("/api/get-provisioning-statuses", &controllers.ApiController{}, "GET:GetProvisioningStatuses")
+	beego.Router("/api/reconcile-scim-provisioning", &controllers.ApiController{}, "POST:ReconcileScimProvisioning")
 
 	beego.Router("/api/get-resources", &controllers.ApiController{}, "GET:GetResources")
 	beego.Router("/api/get-resource", &controllers.ApiController{}, "GET:GetResource")
@@ -140,12 +148,31 @@ func initAPI() {
 
 	beego.Router("/api/get-records", &controllers.ApiController{}, "GET:GetRecords")
 	beego.Router("/api/get-records-filter", &controllers.ApiController{}, "POST:GetRecordsByFilter")
+	beego.Router("/api/verify-records", &controllers.ApiController{}, "GET:VerifyRecords")
+	beego.Router("/api/add-record-checkpoint", &controllers.ApiController{}, "POST:AddRecordCheckpoint")
+	beego.Router("/api/export-records", &controllers.ApiController{}, "GET:ExportRecords")
+	beego.Router("/api/get-record-archives", &controllers.ApiController{}, "GET:GetRecordArchives")
+	beego.Router("/api/archive-records", &controllers.ApiController{}, "POST:ArchiveRecords")
+	beego.Router("/api/get-audit-logs", &controllers.ApiController{}, "GET:GetAuditLogs")
+	beego.Router("/api/get-login-attempts", &controllers.ApiController{}, "GET:GetLoginAttempts")
+	beego.Router("/api/get-login-stats", &controllers.ApiController{}, "GET:GetLoginStats")
 
 	beego.Router("/api/get-webhooks", &controllers.ApiController{}, "GET:GetWebhooks")
 	beego.Router("/api/get-webhook", &controllers.ApiController{}, "GET:GetWebhook")
 	beego.Router("/api/update-webhook", &controllers.ApiController{}, "POST:UpdateWebhook")
 	beego.Router("/api/add-webhook", &controllers.ApiController{}, "POST:AddWebhook")
 	beego.Router("/api/delete-webhook", &controllers.ApiController{}, "POST:DeleteWebhook")
+	beego.Router("/api/get-webhook-events", &controllers.ApiController{}, "GET:GetWebhookEvents")
+	beego.Router("/api/get-webhook-deliveries", &controllers.ApiController{}, "GET:GetWebhookDeliveries")
+	beego.Router("/api/redeliver-webhook-event", &controllers.ApiController{}, "POST:RedeliverWebhookEvent")
+	beego.Router("/api/test-webhook", &controllers.ApiController{}, "POST:TestWebhook")
+	beego.Router("/api/get-event-types", &controllers.ApiController{}, "GET:GetEventTypes")
+
+	beego.Router("/api/get-record-sinks", &controllers.ApiController{}, "GET:GetRecordSinks")
+	beego.Router("/api/get-record-sink", &controllers.ApiController{}, "GET:GetRecordSink")
+	beego.Router("/api/update-record-sink", &controllers.ApiController{}, "POST:UpdateRecordSink")
+	beego.Router("/api/add-record-sink", &controllers.ApiController{}, "POST:AddRecordSink")
+	beego.Router("/api/delete-record-sink", &controllers.ApiController{}, "POST:DeleteRecordSink")
 
 	beego.Router("/api/get-syncers", &controllers.ApiController{}, "GET:GetSyncers")
 	beego.Router("/api/get-syncer", &controllers.ApiController{}, "GET:GetSyncer")
@@ -153,6 +180,10 @@ func initAPI() {
 	beego.Router("/api/add-syncer", &controllers.ApiController{}, "POST:AddSyncer")
 	beego.Router("/api/delete-syncer", &controllers.ApiController{}, "POST:DeleteSyncer")
 	beego.Router("/api/run-syncer", &controllers.ApiController{}, "GET:RunSyncer")
+	beego.Router("/api/dry-run-syncer", &controllers.ApiController{}, "GET:DryRunSyncer")
+	beego.Router("/api/get-syncer-runs", &controllers.ApiController{}, "GET:GetSyncerRuns")
+	beego.Router("/api/get-syncer-decisions", &controllers.ApiController{}, "GET:GetSyncerDecisions")
+	beego.Router("/api/resolve-syncer-decision", &controllers.ApiController{}, "POST:ResolveSyncerDecision")
 
 	beego.Router("/api/get-certs", &controllers.ApiController{}, "GET:GetCerts")
 	beego.Router("/api/get-cert", &controllers.ApiController{}, "GET:GetCert")
@@ -160,6 +191,13 @@ func initAPI() {
 	beego.Router("/api/add-cert", &controllers.ApiController{}, "POST:AddCert")
 	beego.Router("/api/delete-cert", &controllers.ApiController{}, "POST:DeleteCert")
 
+	beego.Router("/api/get-saml-sps", &controllers.ApiController{}, "GET:GetSamlSps")
+	beego.Router("/api/get-saml-sp", &controllers.ApiController{}, "GET:GetSamlSp")
+	beego.Router("/api/update-saml-sp", &controllers.ApiController{}, "POST:UpdateSamlSp")
+	beego.Router("/api/add-saml-sp", &controllers.ApiController{}, "POST:AddSamlSp")
+	beego.Router("/api/delete-saml-sp", &controllers.ApiController{}, "POST:DeleteSamlSp")
+	beego.Router("/api/import-saml-sp-metadata", &controllers.ApiController{}, "POST:ImportSamlSpMetadata")
+
 	beego.Router("/api/get-products", &controllers.ApiController{}, "GET:GetProducts")
 	beego.Router("/api/get-product", &controllers.ApiController{}, "GET:GetProduct")
 	beego.Router("/api/update-product", &controllers.ApiController{}, "POST:UpdateProduct")
@@ -190,4 +228,12 @@ func initAPI() {
 	beego.Router("/cas/:organization/:application/p3/serviceValidate", &controllers.RootController{}, "GET:CasP3ServiceAndProxyValidate")
 	beego.Router("/cas/:organization/:application/p3/proxyValidate", &controllers.RootController{}, "GET:CasP3ServiceAndProxyValidate")
 	beego.Router("/cas/:organization/:application/samlValidate", &controllers.RootController{}, "POST:SamlValidate")
+
+	beego.Router("/scim/:organization/v2/Users", &controllers.RootController{}, "GET:GetScimUsers;POST:AddScimUser")
+	beego.Router("/scim/:organization/v2/Users/:id", &controllers.RootController{}, "GET:GetScimUser;PUT:UpdateScimUser;PATCH:UpdateScimUser;DELETE:DeleteScimUser")
+	beego.Router("/scim/:organization/v2/Groups", &controllers.RootController{}, "GET:GetScimGroups;POST:AddScimGroup")
+	beego.Router("/scim/:organization/v2/Groups/:id", &controllers.RootController{}, "GET:GetScimGroup;PUT:UpdateScimGroup;PATCH:UpdateScimGroup;DELETE:DeleteScimGroup")
+	beego.Router("/scim/:organization/v2/ServiceProviderConfig", &controllers.RootController{}, "GET:GetScimServiceProviderConfig")
+	beego.Router("/scim/:organization/v2/Schemas", &controllers.RootController{}, "GET:GetScimSchemas")
+	beego.Router("/scim/:organization/v2/ResourceTypes", &controllers.RootController{}, "GET:GetScimResourceTypes")
 }
@@ -235,3 +235,30 @@ func maskString(str string) string {
 		return fmt.Sprintf("%c%s%c", str[0], strings.Repeat("*", len(str)-2), str[len(str)-1])
 	}
 }
+
+func InSlice(slice []string, elem string) bool {
+	for _, val := range slice {
+		if val == elem {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffSlices returns the elements of newSlice missing in oldSlice and the elements of oldSlice missing in newSlice
+func DiffSlices(oldSlice []string, newSlice []string) ([]string, []string) {
+	added := []string{}
+	for _, elem := range newSlice {
+		if !InSlice(oldSlice, elem) {
+			added = append(added, elem)
+		}
+	}
+
+	removed := []string{}
+	for _, elem := range oldSlice {
+		if !InSlice(newSlice, elem) {
+			removed = append(removed, elem)
+		}
+	}
+	return added, removed
+}
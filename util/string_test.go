@@ -246,3 +246,12 @@ func TestSnakeString(t *testing.T) {
 	}
 }
 
+func TestDiffSlices(t *testing.T) {
+	added, removed := DiffSlices([]string{"a", "b", "c"}, []string{"b", "d"})
+	assert.Equal(t, []string{"d"}, added)
+	assert.Equal(t, []string{"a", "c"}, removed)
+
+	added, removed = DiffSlices(nil, nil)
+	assert.Equal(t, []string{}, added)
+	assert.Equal(t, []string{}, removed)
+}
@@ -37,12 +37,17 @@ import LdapSyncPage from "./LdapSyncPage";
 import TokenListPage from "./TokenListPage";
 import TokenEditPage from "./TokenEditPage";
 import RecordListPage from "./RecordListPage";
+import AuditLogListPage from "./AuditLogListPage";
 import WebhookListPage from "./WebhookListPage";
 import WebhookEditPage from "./WebhookEditPage";
+import RecordSinkListPage from "./RecordSinkListPage";
+import RecordSinkEditPage from "./RecordSinkEditPage";
 import SyncerListPage from "./SyncerListPage";
 import SyncerEditPage from "./SyncerEditPage";
 import CertListPage from "./CertListPage";
 import CertEditPage from "./CertEditPage";
+import SamlSpListPage from "./SamlSpListPage";
+import SamlSpEditPage from "./SamlSpEditPage";
 import ProductListPage from "./ProductListPage";
 import ProductEditPage from "./ProductEditPage";
 import ProductBuyPage from "./ProductBuyPage";
@@ -69,6 +74,7 @@ import PromptPage from "./auth/PromptPage";
 import OdicDiscoveryPage from "./auth/OidcDiscoveryPage";
 import SamlCallback from './auth/SamlCallback';
 import CasLogout from "./auth/CasLogout";
+import ReportDevicePage from "./auth/ReportDevicePage";
 import ModelListPage from "./ModelListPage";
 import ModelEditPage from "./ModelEditPage";
 
@@ -132,12 +138,18 @@ class App extends Component {
       this.setState({ selectedMenuKey: '/tokens' });
     } else if (uri.includes('/records')) {
       this.setState({ selectedMenuKey: '/records' });
+    } else if (uri.includes('/audit-logs')) {
+      this.setState({ selectedMenuKey: '/audit-logs' });
     } else if (uri.includes('/webhooks')) {
       this.setState({ selectedMenuKey: '/webhooks' });
+    } else if (uri.includes('/record-sinks')) {
+      this.setState({ selectedMenuKey: '/record-sinks' });
     } else if (uri.includes('/syncers')) {
       this.setState({ selectedMenuKey: '/syncers' });
     } else if (uri.includes('/certs')) {
       this.setState({ selectedMenuKey: '/certs' });
+    } else if (uri.includes('/saml-sps')) {
+      this.setState({ selectedMenuKey: '/saml-sps' });
     } else if (uri.includes('/products')) {
       this.setState({ selectedMenuKey: '/products' });
     } else if (uri.includes('/payments')) {
@@ -431,6 +443,13 @@ class App extends Component {
           </Link>
         </Menu.Item>
       );
+      res.push(
+        <Menu.Item key="/audit-logs">
+          <Link to="/audit-logs">
+            {i18next.t("general:Audit Logs")}
+          </Link>
+        </Menu.Item>
+      );
       res.push(
         <Menu.Item key="/webhooks">
           <Link to="/webhooks">
@@ -438,6 +457,13 @@ class App extends Component {
           </Link>
         </Menu.Item>
       );
+      res.push(
+        <Menu.Item key="/record-sinks">
+          <Link to="/record-sinks">
+            {i18next.t("general:Record Sinks")}
+          </Link>
+        </Menu.Item>
+      );
       res.push(
         <Menu.Item key="/syncers">
           <Link to="/syncers">
@@ -452,6 +478,13 @@ class App extends Component {
           </Link>
         </Menu.Item>
       );
+      res.push(
+        <Menu.Item key="/saml-sps">
+          <Link to="/saml-sps">
+            {i18next.t("general:SAML SPs")}
+          </Link>
+        </Menu.Item>
+      );
 
       if (Conf.EnableExtraPages) {
         res.push(
@@ -539,10 +572,14 @@ class App extends Component {
           <Route exact path="/tokens/:tokenName" render={(props) => this.renderLoginIfNotLoggedIn(<TokenEditPage account={this.state.account} {...props} />)}/>
           <Route exact path="/webhooks" render={(props) => this.renderLoginIfNotLoggedIn(<WebhookListPage account={this.state.account} {...props} />)}/>
           <Route exact path="/webhooks/:webhookName" render={(props) => this.renderLoginIfNotLoggedIn(<WebhookEditPage account={this.state.account} {...props} />)}/>
+          <Route exact path="/record-sinks" render={(props) => this.renderLoginIfNotLoggedIn(<RecordSinkListPage account={this.state.account} {...props} />)}/>
+          <Route exact path="/record-sinks/:recordSinkName" render={(props) => this.renderLoginIfNotLoggedIn(<RecordSinkEditPage account={this.state.account} {...props} />)}/>
           <Route exact path="/syncers" render={(props) => this.renderLoginIfNotLoggedIn(<SyncerListPage account={this.state.account} {...props} />)}/>
           <Route exact path="/syncers/:syncerName" render={(props) => this.renderLoginIfNotLoggedIn(<SyncerEditPage account={this.state.account} {...props} />)}/>
           <Route exact path="/certs" render={(props) => this.renderLoginIfNotLoggedIn(<CertListPage account={this.state.account} {...props} />)}/>
           <Route exact path="/certs/:certName" render={(props) => this.renderLoginIfNotLoggedIn(<CertEditPage account={this.state.account} {...props} />)}/>
+          <Route exact path="/saml-sps" render={(props) => this.renderLoginIfNotLoggedIn(<SamlSpListPage account={this.state.account} {...props} />)}/>
+          <Route exact path="/saml-sps/:samlSpName" render={(props) => this.renderLoginIfNotLoggedIn(<SamlSpEditPage account={this.state.account} {...props} />)}/>
           <Route exact path="/products" render={(props) => this.renderLoginIfNotLoggedIn(<ProductListPage account={this.state.account} {...props} />)}/>
           <Route exact path="/products/:productName" render={(props) => this.renderLoginIfNotLoggedIn(<ProductEditPage account={this.state.account} {...props} />)}/>
           <Route exact path="/products/:productName/buy" render={(props) => this.renderLoginIfNotLoggedIn(<ProductBuyPage account={this.state.account} {...props} />)}/>
@@ -550,6 +587,7 @@ class App extends Component {
           <Route exact path="/payments/:paymentName" render={(props) => this.renderLoginIfNotLoggedIn(<PaymentEditPage account={this.state.account} {...props} />)}/>
           <Route exact path="/payments/:paymentName/result" render={(props) => this.renderLoginIfNotLoggedIn(<PaymentResultPage account={this.state.account} {...props} />)}/>
           <Route exact path="/records" render={(props) => this.renderLoginIfNotLoggedIn(<RecordListPage account={this.state.account} {...props} />)}/>
+          <Route exact path="/audit-logs" render={(props) => this.renderLoginIfNotLoggedIn(<AuditLogListPage account={this.state.account} {...props} />)}/>
           <Route exact path="/.well-known/openid-configuration" render={(props) => <OdicDiscoveryPage />}/>
           <Route path="" render={() => <Result status="404" title="404 NOT FOUND" subTitle={i18next.t("general:Sorry, the page you visited does not exist.")}
                                                extra={<a href="/"><Button type="primary">{i18next.t("general:Back Home")}</Button></a>} />} />
@@ -657,6 +695,7 @@ class App extends Component {
       window.location.pathname.startsWith("/callback") ||
       window.location.pathname.startsWith("/prompt") ||
       window.location.pathname.startsWith("/forget") ||
+      window.location.pathname.startsWith("/report-device") ||
       window.location.pathname.startsWith("/cas");
   }
 
@@ -677,6 +716,7 @@ class App extends Component {
             <Route exact path="/callback/saml" component={SamlCallback}/>
             <Route exact path="/forget" render={(props) => this.renderHomeIfLoggedIn(<SelfForgetPage {...props} />)}/>
             <Route exact path="/forget/:applicationName" render={(props) => this.renderHomeIfLoggedIn(<ForgetPage {...props} />)}/>
+            <Route exact path="/report-device" render={(props) => <ReportDevicePage {...props} />}/>
             <Route exact path="/prompt" render={(props) => this.renderLoginIfNotLoggedIn(<PromptPage account={this.state.account} {...props} />)}/>
             <Route exact path="/prompt/:applicationName" render={(props) => this.renderLoginIfNotLoggedIn(<PromptPage account={this.state.account} onUpdateAccount={(account) => {this.onUpdateAccount(account)}} {...props} />)}/>
             <Route path="" render={() => <Result status="404" title="404 NOT FOUND" subTitle={i18next.t("general:Sorry, the page you visited does not exist.")}
@@ -13,7 +13,7 @@
 // limitations under the License.
 
 import React from "react";
-import {Button, Card, Col, Input, Popover, Row, Select, Switch, Upload} from 'antd';
+import {Button, Card, Col, Input, Popover, Row, Select, Switch, Table, Upload} from 'antd';
 import {CopyOutlined, LinkOutlined, UploadOutlined} from "@ant-design/icons";
 import * as ApplicationBackend from "./backend/ApplicationBackend";
 import * as CertBackend from "./backend/CertBackend";
@@ -27,6 +27,7 @@ import i18next from "i18next";
 import UrlTable from "./UrlTable";
 import ProviderTable from "./ProviderTable";
 import SignupTable from "./SignupTable";
+import LdapAttributeMappingTable from "./LdapAttributeMappingTable";
 import PromptPage from "./auth/PromptPage";
 import copy from "copy-to-clipboard";
 
@@ -51,6 +52,7 @@ class ApplicationEditPage extends React.Component {
       uploading: false,
       mode: props.location.mode !== undefined ? props.location.mode : "edit",
       samlMetadata: null,
+      provisioningStatuses: [],
     };
   }
 
@@ -60,6 +62,7 @@ class ApplicationEditPage extends React.Component {
     this.getCerts();
     this.getProviders();
     this.getSamlMetadata();
+    this.getProvisioningStatuses();
   }
 
   getApplication() {
@@ -110,6 +113,30 @@ class ApplicationEditPage extends React.Component {
       });
   }
 
+  getProvisioningStatuses() {
+    ApplicationBackend.getProvisioningStatuses("admin", this.state.applicationName)
+      .then((res) => {
+        this.setState({
+          provisioningStatuses: (res.msg === undefined) ? res : [],
+        });
+      });
+  }
+
+  reconcileScimProvisioning() {
+    ApplicationBackend.reconcileScimProvisioning("admin", this.state.applicationName)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", `${res.data.users} ${i18next.t("application:users queued")}, ${res.data.orphaned} ${i18next.t("application:deleted users queued")}`);
+          this.getProvisioningStatuses();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `Failed to connect to server: ${error}`);
+      });
+  }
+
   parseApplicationField(key, value) {
     if (["expireInHours", "refreshExpireInHours"].includes(key)) {
       value = Setting.myParseInt(value);
@@ -484,6 +511,16 @@ class ApplicationEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:Require signed SAML requests"), i18next.t("application:Require signed SAML requests - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.requireSamlSignedRequest} onChange={checked => {
+              this.updateApplicationField('requireSamlSignedRequest', checked);
+            }} />
+          </Col>
+        </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("application:SAML metadata"), i18next.t("application:SAML metadata - Tooltip"))} :
@@ -518,6 +555,29 @@ class ApplicationEditPage extends React.Component {
             />
           </Col>
         </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:SCIM server"), i18next.t("application:SCIM server - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.enableScimServer} onChange={checked => {
+              this.updateApplicationField('enableScimServer', checked);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:SCIM provisioning"), i18next.t("application:SCIM provisioning - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.enableScimProvisioning} onChange={checked => {
+              this.updateApplicationField('enableScimProvisioning', checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.application.enableScimProvisioning ? null : this.renderScimProvisioning()
+        }
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("general:Preview"), i18next.t("general:Preview - Tooltip"))} :
@@ -554,6 +614,155 @@ class ApplicationEditPage extends React.Component {
     )
   }
 
+  renderScimProvisioning() {
+    const columns = [
+      {
+        title: i18next.t("general:User"),
+        dataIndex: 'name',
+        key: 'name',
+        render: (text, record, index) => `${record.owner}/${text}`
+      },
+      {
+        title: i18next.t("application:Remote ID"),
+        dataIndex: 'remoteId',
+        key: 'remoteId',
+      },
+      {
+        title: i18next.t("application:State"),
+        dataIndex: 'state',
+        key: 'state',
+        width: '120px',
+      },
+      {
+        title: i18next.t("application:Attempts"),
+        dataIndex: 'attempts',
+        key: 'attempts',
+        width: '100px',
+      },
+      {
+        title: i18next.t("application:Last sync time"),
+        dataIndex: 'lastSyncTime',
+        key: 'lastSyncTime',
+        width: '180px',
+        render: (text, record, index) => Setting.getFormattedDate(text)
+      },
+      {
+        title: i18next.t("application:Error"),
+        dataIndex: 'error',
+        key: 'error',
+      },
+    ];
+
+    return (
+      <React.Fragment>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:SCIM base URL"), i18next.t("application:SCIM base URL - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input prefix={<LinkOutlined/>} placeholder="https://example.com/scim/v2" value={this.state.application.scimBaseUrl} onChange={e => {
+              this.updateApplicationField('scimBaseUrl', e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:SCIM auth type"), i18next.t("application:SCIM auth type - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: '100%'}} value={this.state.application.scimAuthType === "" ? "Bearer" : this.state.application.scimAuthType} onChange={(value => {this.updateApplicationField('scimAuthType', value);})}>
+              {
+                [
+                  {id: "Bearer", name: "Bearer token"},
+                  {id: "Basic", name: "HTTP Basic"},
+                ].map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        {
+          this.state.application.scimAuthType === "Basic" ? (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("signup:Username"), i18next.t("signup:Username - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.application.scimUsername} onChange={e => {
+                    this.updateApplicationField('scimUsername', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("general:Password"), i18next.t("general:Password - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input.Password value={this.state.application.scimPassword} onChange={e => {
+                    this.updateApplicationField('scimPassword', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          ) : (
+            <Row style={{marginTop: '20px'}} >
+              <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("application:SCIM token"), i18next.t("application:SCIM token - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <Input.Password value={this.state.application.scimToken} onChange={e => {
+                  this.updateApplicationField('scimToken', e.target.value);
+                }} />
+              </Col>
+            </Row>
+          )
+        }
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:SCIM deletion action"), i18next.t("application:SCIM deletion action - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: '100%'}} value={this.state.application.scimDeletionAction === "" ? "Deactivate" : this.state.application.scimDeletionAction} onChange={(value => {this.updateApplicationField('scimDeletionAction', value);})}>
+              {
+                [
+                  {id: "Deactivate", name: "Deactivate"},
+                  {id: "Delete", name: "Delete"},
+                ].map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:SCIM attribute mappings"), i18next.t("application:SCIM attribute mappings - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <LdapAttributeMappingTable
+              title={i18next.t("application:SCIM attribute mappings")}
+              table={this.state.application.scimAttributeMappings}
+              onUpdateTable={(value) => { this.updateApplicationField('scimAttributeMappings', value)}}
+            />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:Provisioning status"), i18next.t("application:Provisioning status - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Table rowKey={(record) => `${record.owner}/${record.name}`} columns={columns} dataSource={this.state.provisioningStatuses} size="middle" bordered pagination={{pageSize: 10}}
+                   title={() => (
+                     <div>
+                       <Button type="primary" size="small" onClick={() => this.reconcileScimProvisioning()}>{i18next.t("application:Full reconcile")}</Button>
+                       <Button style={{marginLeft: "10px"}} size="small" onClick={() => this.getProvisioningStatuses()}>{i18next.t("general:Refresh")}</Button>
+                     </div>
+                   )}
+            />
+          </Col>
+        </Row>
+      </React.Fragment>
+    )
+  }
+
   renderSignupSigninPreview() {
     let signUpUrl = `/signup/${this.state.application.name}`;
     let signInUrl = `/login/oauth/authorize?client_id=${this.state.application.clientId}&response_type=code&redirect_uri=${this.state.application.redirectUris[0]}&scope=read&state=casdoor`;
@@ -0,0 +1,189 @@
+// Copyright 2021 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import React from "react";
+import {Link} from "react-router-dom";
+import {Table} from 'antd';
+import * as Setting from "./Setting";
+import * as AuditLogBackend from "./backend/AuditLogBackend";
+import i18next from "i18next";
+import BaseListPage from "./BaseListPage";
+
+class AuditLogListPage extends BaseListPage {
+  UNSAFE_componentWillMount() {
+    this.state.pagination.pageSize = 20;
+    const { pagination } = this.state;
+    this.fetch({ pagination });
+  }
+
+  renderDiffs(auditLog) {
+    const columns = [
+      {
+        title: i18next.t("auditLog:Field"),
+        dataIndex: 'field',
+        key: 'field',
+        width: '200px',
+      },
+      {
+        title: i18next.t("auditLog:Old value"),
+        dataIndex: 'old',
+        key: 'old',
+        render: (text, record, index) => JSON.stringify(text),
+      },
+      {
+        title: i18next.t("auditLog:New value"),
+        dataIndex: 'new',
+        key: 'new',
+        render: (text, record, index) => JSON.stringify(text),
+      },
+    ];
+
+    return (
+      <Table rowKey="field" columns={columns} dataSource={auditLog.diffs} size="small" pagination={false} />
+    );
+  }
+
+  renderTable(auditLogs) {
+    const columns = [
+      {
+        title: i18next.t("general:ID"),
+        dataIndex: 'id',
+        key: 'id',
+        width: '90px',
+        sorter: true,
+      },
+      {
+        title: i18next.t("general:Timestamp"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '180px',
+        sorter: true,
+        render: (text, record, index) => {
+          return Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("auditLog:Actor"),
+        dataIndex: 'actor',
+        key: 'actor',
+        width: '160px',
+        sorter: true,
+        ...this.getColumnSearchProps('actor'),
+        render: (text, record, index) => {
+          return (
+            <Link to={`/users/${text}`}>
+              {text}
+            </Link>
+          )
+        }
+      },
+      {
+        title: i18next.t("general:Client IP"),
+        dataIndex: 'clientIp',
+        key: 'clientIp',
+        width: '150px',
+        sorter: true,
+        ...this.getColumnSearchProps('clientIp'),
+      },
+      {
+        title: i18next.t("auditLog:Operation"),
+        dataIndex: 'operation',
+        key: 'operation',
+        width: '110px',
+        sorter: true,
+        filterMultiple: false,
+        filters: [
+          {text: 'add', value: 'add'},
+          {text: 'update', value: 'update'},
+          {text: 'delete', value: 'delete'},
+        ],
+      },
+      {
+        title: i18next.t("auditLog:Object type"),
+        dataIndex: 'objectType',
+        key: 'objectType',
+        width: '130px',
+        sorter: true,
+        ...this.getColumnSearchProps('objectType'),
+      },
+      {
+        title: i18next.t("auditLog:Object ID"),
+        dataIndex: 'objectId',
+        key: 'objectId',
+        sorter: true,
+        ...this.getColumnSearchProps('objectId'),
+      },
+      {
+        title: i18next.t("auditLog:Changed fields"),
+        dataIndex: 'diffs',
+        key: 'diffs',
+        render: (text, record, index) => {
+          return text === null ? "" : text.map(diff => diff.field).join(", ");
+        }
+      },
+    ];
+
+    const paginationProps = {
+      total: this.state.pagination.total,
+      pageSize: this.state.pagination.pageSize,
+      showQuickJumper: true,
+      showSizeChanger: true,
+      showTotal: () => i18next.t("general:{total} in total").replace("{total}", this.state.pagination.total),
+    };
+
+    return (
+      <div>
+        <Table scroll={{x: 'max-content'}} columns={columns} dataSource={auditLogs} rowKey="id" size="middle" bordered pagination={paginationProps}
+               expandable={{
+                 expandedRowRender: record => this.renderDiffs(record),
+               }}
+               title={() => (
+                 <div>
+                   {i18next.t("general:Audit Logs")}&nbsp;&nbsp;&nbsp;&nbsp;
+                 </div>
+               )}
+               loading={this.state.loading}
+               onChange={this.handleTableChange}
+        />
+      </div>
+    );
+  }
+
+  fetch = (params = {}) => {
+    let field = params.searchedColumn, value = params.searchText;
+    let sortField = params.sortField, sortOrder = params.sortOrder;
+    if (params.operation !== undefined && params.operation !== null) {
+      field = "operation";
+      value = params.operation;
+    }
+    this.setState({ loading: true });
+    AuditLogBackend.getAuditLogs("", params.pagination.current, params.pagination.pageSize, field, value, sortField, sortOrder)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            loading: false,
+            data: res.data,
+            pagination: {
+              ...params.pagination,
+              total: res.data2,
+            },
+            searchText: params.searchText,
+            searchedColumn: params.searchedColumn,
+          });
+        }
+      });
+  };
+}
+
+export default AuditLogListPage;
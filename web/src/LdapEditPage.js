@@ -17,8 +17,10 @@ import {Button, Card, Col, Input, InputNumber, Row, Select} from "antd";
 import {EyeInvisibleOutlined, EyeTwoTone} from "@ant-design/icons";
 import * as LddpBackend from "./backend/LdapBackend";
 import * as OrganizationBackend from "./backend/OrganizationBackend";
+import * as CertBackend from "./backend/CertBackend";
 import * as Setting from "./Setting";
 import i18next from "i18next";
+import LdapAttributeMappingTable from "./LdapAttributeMappingTable";
 
 const {Option} = Select;
 
@@ -29,12 +31,14 @@ class LdapEditPage extends React.Component {
       ldapId: props.match.params.ldapId,
       ldap: null,
       organizations: [],
+      certs: [],
     };
   }
 
   UNSAFE_componentWillMount() {
     this.getLdap();
     this.getOrganizations();
+    this.getCerts();
   }
 
   getLdap() {
@@ -59,6 +63,15 @@ class LdapEditPage extends React.Component {
       });
   }
 
+  getCerts() {
+    CertBackend.getCerts("admin")
+      .then((res) => {
+        this.setState({
+          certs: (res.msg === undefined) ? res : [],
+        });
+      });
+  }
+
   updateLdapField(key, value) {
     this.setState((prevState) => {
       prevState.ldap[key] = value;
@@ -140,6 +153,23 @@ class LdapEditPage extends React.Component {
             }}/>
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:TLS Mode"), i18next.t("ldap:TLS Mode - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} style={{width: "100%"}} value={this.state.ldap.tlsMode === "" ? "None" : this.state.ldap.tlsMode} onChange={(value => {
+              this.updateLdapField("tlsMode", value);
+            })}>
+              <Option key="None" value="None">{i18next.t("ldap:None")}</Option>
+              <Option key="LDAPS" value="LDAPS">LDAPS</Option>
+              <Option key="StartTLS" value="StartTLS">StartTLS</Option>
+            </Select>
+          </Col>
+        </Row>
+        {
+          (this.state.ldap.tlsMode === "" || this.state.ldap.tlsMode === "None") ? null : this.renderTls()
+        }
         <Row style={{marginTop: "20px"}}>
           <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
             {Setting.getLabel(i18next.t("ldap:Base DN"), i18next.t("ldap:Base DN - Tooltip"))} :
@@ -150,6 +180,51 @@ class LdapEditPage extends React.Component {
             }}/>
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Filter"), i18next.t("ldap:Filter - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Input value={this.state.ldap.filter} placeholder="(objectClass=posixAccount)" onChange={e => {
+              this.updateLdapField("filter", e.target.value);
+            }}/>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Search Scope"), i18next.t("ldap:Search Scope - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} style={{width: "100%"}} value={this.state.ldap.searchScope === "" ? "Subtree" : this.state.ldap.searchScope} onChange={(value => {
+              this.updateLdapField("searchScope", value);
+            })}>
+              <Option key="Subtree" value="Subtree">{i18next.t("ldap:Subtree")}</Option>
+              <Option key="OneLevel" value="OneLevel">{i18next.t("ldap:One level")}</Option>
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Page Size"), i18next.t("ldap:Page Size - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <InputNumber min={0} value={this.state.ldap.pageSize} onChange={value => {
+              this.updateLdapField("pageSize", value);
+            }}/>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Attribute Mappings"), i18next.t("ldap:Attribute Mappings - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <LdapAttributeMappingTable
+              title={i18next.t("ldap:Attribute Mappings")}
+              table={this.state.ldap.attributeMappings === null ? [] : this.state.ldap.attributeMappings}
+              onUpdateTable={(value) => { this.updateLdapField("attributeMappings", value)}}
+            />
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}}>
           <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
             {Setting.getLabel(i18next.t("ldap:Admin"), i18next.t("ldap:Admin - Tooltip"))} :
@@ -185,10 +260,105 @@ class LdapEditPage extends React.Component {
             {this.renderAutoSyncWarn()}
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Deletion Policy"), i18next.t("ldap:Deletion Policy - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} style={{width: "100%"}} value={this.state.ldap.deletionPolicy === "" ? "Keep" : this.state.ldap.deletionPolicy} onChange={(value => {
+              this.updateLdapField("deletionPolicy", value);
+            })}>
+              <Option key="Keep" value="Keep">{i18next.t("ldap:Keep")}</Option>
+              <Option key="Disable" value="Disable">{i18next.t("ldap:Disable")}</Option>
+              <Option key="Delete" value="Delete">{i18next.t("ldap:Delete")}</Option>
+            </Select>
+          </Col>
+        </Row>
+        {this.renderSyncSummary()}
       </Card>
     )
   }
 
+  renderTls() {
+    return (
+      <React.Fragment>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:CA Cert"), i18next.t("ldap:CA Cert - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} allowClear style={{width: "100%"}} value={this.state.ldap.caCert === "" ? undefined : this.state.ldap.caCert} onChange={(value => {
+              this.updateLdapField("caCert", value === undefined ? "" : value);
+            })}>
+              {
+                this.state.certs.map((cert, index) => <Option key={index} value={cert.name}>{cert.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Client Cert"), i18next.t("ldap:Client Cert - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} allowClear style={{width: "100%"}} value={this.state.ldap.clientCert === "" ? undefined : this.state.ldap.clientCert} onChange={(value => {
+              this.updateLdapField("clientCert", value === undefined ? "" : value);
+            })}>
+              {
+                this.state.certs.map((cert, index) => <Option key={index} value={cert.name}>{cert.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:TLS Server Name"), i18next.t("ldap:TLS Server Name - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Input value={this.state.ldap.tlsServerName} placeholder={this.state.ldap.host} onChange={e => {
+              this.updateLdapField("tlsServerName", e.target.value);
+            }}/>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:TLS Verification"), i18next.t("ldap:TLS Verification - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} style={{width: "100%"}} value={this.state.ldap.tlsVerification === "" ? "Full" : this.state.ldap.tlsVerification} onChange={(value => {
+              this.updateLdapField("tlsVerification", value);
+            })}>
+              <Option key="Full" value="Full">{i18next.t("ldap:Certificate and hostname")}</Option>
+              <Option key="CaOnly" value="CaOnly">{i18next.t("ldap:Certificate only")}</Option>
+              <Option key="None" value="None">{i18next.t("ldap:None (insecure)")}</Option>
+            </Select>
+          </Col>
+        </Row>
+      </React.Fragment>
+    );
+  }
+
+  renderSyncSummary() {
+    const summaries = this.state.ldap.syncSummaries;
+    if (!summaries || summaries.length === 0) {
+      return null;
+    }
+
+    const summary = summaries[0];
+    return (
+      <Row style={{marginTop: "20px"}}>
+        <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+          {Setting.getLabel(i18next.t("ldap:Last Sync Summary"), i18next.t("ldap:Last Sync Summary - Tooltip"))} :
+        </Col>
+        <Col span={21} style={{lineHeight: "32px"}}>
+          {`${Setting.getFormattedDate(summary.startTime)} (${summary.isIncremental ? i18next.t("ldap:Incremental") : i18next.t("ldap:Full")}): `}
+          {`${summary.changed} changed, ${summary.added} added, ${summary.updated} updated, ${summary.disabled} disabled, ${summary.deleted} deleted, ${summary.failed} failed`}
+          {summary.error === "" ? null : <span style={{color: "red"}}>{` ${summary.error}`}</span>}
+        </Col>
+      </Row>
+    );
+  }
+
   submitLdapEdit() {
     LddpBackend.updateLdap(this.state.ldap)
       .then((res) => {
@@ -14,18 +14,49 @@
 
 import React from "react";
 import {Link} from "react-router-dom";
-import {Switch, Table} from 'antd';
+import {Button, Select, Switch, Table} from 'antd';
 import * as Setting from "./Setting";
 import * as RecordBackend from "./backend/RecordBackend";
+import * as OrganizationBackend from "./backend/OrganizationBackend";
 import i18next from "i18next";
 import moment from "moment";
 import BaseListPage from "./BaseListPage";
 
+const { Option } = Select;
+
 class RecordListPage extends BaseListPage {
   UNSAFE_componentWillMount() {
     this.state.pagination.pageSize = 20;
+    this.state.organizations = [];
+    this.state.verifiedOrganization = "built-in";
     const { pagination } = this.state;
     this.fetch({ pagination });
+    this.getOrganizations();
+  }
+
+  getOrganizations() {
+    OrganizationBackend.getOrganizations("admin")
+      .then((res) => {
+        this.setState({
+          organizations: (res.msg === undefined) ? res : [],
+        });
+      });
+  }
+
+  verifyRecords() {
+    RecordBackend.verifyRecords(this.state.verifiedOrganization)
+      .then((res) => {
+        if (res.status !== "ok") {
+          Setting.showMessage("error", res.msg);
+        } else if (res.data.isValid) {
+          Setting.showMessage("success", `${i18next.t("record:The record chain is valid")} (${res.data.recordCount})`);
+        } else {
+          Setting.showMessage("error", `${i18next.t("record:The record chain is broken")}: ${res.data.error}`);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `Failed to connect to server: ${error}`);
+      });
   }
 
   newRecord() {
@@ -188,6 +219,18 @@ class RecordListPage extends BaseListPage {
                title={() => (
                  <div>
                    {i18next.t("general:Records")}&nbsp;&nbsp;&nbsp;&nbsp;
+                   <Select virtual={false} size="small" style={{width: '200px'}} value={this.state.verifiedOrganization} onChange={value => {
+                     this.setState({
+                       verifiedOrganization: value,
+                     });
+                   }} >
+                     {
+                       this.state.organizations.map((organization, index) => <Option key={index} value={organization.name}>{organization.name}</Option>)
+                     }
+                   </Select>
+                   <Button style={{marginLeft: '10px'}} size="small" onClick={() => this.verifyRecords()}>{i18next.t("record:Verify chain")}</Button>
+                   <Button style={{marginLeft: '10px'}} size="small" href={RecordBackend.getExportRecordsUrl(this.state.verifiedOrganization, "csv")}>{i18next.t("record:Export CSV")}</Button>
+                   <Button style={{marginLeft: '10px'}} size="small" href={RecordBackend.getExportRecordsUrl(this.state.verifiedOrganization, "jsonl")}>{i18next.t("record:Export JSONL")}</Button>
                  </div>
                )}
                loading={this.state.loading}
@@ -0,0 +1,271 @@
+// Copyright 2021 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import React from "react";
+import {Button, Card, Col, Input, InputNumber, Row, Select, Switch} from 'antd';
+import {LinkOutlined} from "@ant-design/icons";
+import * as RecordSinkBackend from "./backend/RecordSinkBackend";
+import * as OrganizationBackend from "./backend/OrganizationBackend";
+import * as Setting from "./Setting";
+import i18next from "i18next";
+import WebhookHeaderTable from "./WebhookHeaderTable";
+
+const { Option } = Select;
+
+class RecordSinkEditPage extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      recordSinkName: props.match.params.recordSinkName,
+      recordSink: null,
+      organizations: [],
+      mode: props.location.mode !== undefined ? props.location.mode : "edit",
+    };
+  }
+
+  UNSAFE_componentWillMount() {
+    this.getRecordSink();
+    this.getOrganizations();
+  }
+
+  getRecordSink() {
+    RecordSinkBackend.getRecordSink("admin", this.state.recordSinkName)
+      .then((recordSink) => {
+        this.setState({
+          recordSink: recordSink,
+        });
+      });
+  }
+
+  getOrganizations() {
+    OrganizationBackend.getOrganizations("admin")
+      .then((res) => {
+        this.setState({
+          organizations: (res.msg === undefined) ? res : [],
+        });
+      });
+  }
+
+  parseRecordSinkField(key, value) {
+    if (["port"].includes(key)) {
+      value = Setting.myParseInt(value);
+    }
+    return value;
+  }
+
+  updateRecordSinkField(key, value) {
+    value = this.parseRecordSinkField(key, value);
+
+    let recordSink = this.state.recordSink;
+    recordSink[key] = value;
+    this.setState({
+      recordSink: recordSink,
+    });
+  }
+
+  renderRecordSink() {
+    return (
+      <Card size="small" title={
+        <div>
+          {this.state.mode === "add" ? i18next.t("recordSink:New Record Sink") : i18next.t("recordSink:Edit Record Sink")}&nbsp;&nbsp;&nbsp;&nbsp;
+          <Button onClick={() => this.submitRecordSinkEdit(false)}>{i18next.t("general:Save")}</Button>
+          <Button style={{marginLeft: '20px'}} type="primary" onClick={() => this.submitRecordSinkEdit(true)}>{i18next.t("general:Save & Exit")}</Button>
+          {this.state.mode === "add" ? <Button style={{marginLeft: '20px'}} onClick={() => this.deleteRecordSink()}>{i18next.t("general:Cancel")}</Button> : null}
+        </div>
+      } style={(Setting.isMobile())? {margin: '5px'}:{}} type="inner">
+        <Row style={{marginTop: '10px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Name"), i18next.t("general:Name - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.recordSink.name} onChange={e => {
+              this.updateRecordSinkField('name', e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Organization"), i18next.t("recordSink:Organization - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: '100%'}} allowClear value={this.state.recordSink.organization} onChange={(value => {this.updateRecordSinkField('organization', value === undefined ? "" : value);})}>
+              {
+                this.state.organizations.map((organization, index) => <Option key={index} value={organization.name}>{organization.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("provider:Type"), i18next.t("provider:Type - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: '100%'}} value={this.state.recordSink.type} onChange={(value => {this.updateRecordSinkField('type', value);})}>
+              {
+                [
+                  {id: 'Syslog', name: 'Syslog (RFC 5424)'},
+                  {id: 'OTLP', name: 'OpenTelemetry logs (OTLP/HTTP)'},
+                ].map((type, index) => <Option key={index} value={type.id}>{type.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        {
+          this.state.recordSink.type === "OTLP" ? (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("webhook:URL"), i18next.t("recordSink:URL - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input prefix={<LinkOutlined/>} value={this.state.recordSink.url} placeholder="http://localhost:4318/v1/logs" onChange={e => {
+                    this.updateRecordSinkField('url', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("webhook:Headers"), i18next.t("webhook:Headers - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <WebhookHeaderTable
+                    title={i18next.t("webhook:Headers")}
+                    table={this.state.recordSink.headers}
+                    onUpdateTable={(value) => { this.updateRecordSinkField('headers', value)}}
+                  />
+                </Col>
+              </Row>
+            </React.Fragment>
+          ) : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("recordSink:Protocol"), i18next.t("recordSink:Protocol - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Select virtual={false} style={{width: '100%'}} value={this.state.recordSink.protocol} onChange={(value => {this.updateRecordSinkField('protocol', value);})}>
+                    {
+                      [
+                        {id: 'tcp', name: 'TCP'},
+                        {id: 'udp', name: 'UDP'},
+                        {id: 'tls', name: 'TLS'},
+                      ].map((protocol, index) => <Option key={index} value={protocol.id}>{protocol.name}</Option>)
+                    }
+                  </Select>
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Host"), i18next.t("provider:Host - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input prefix={<LinkOutlined/>} value={this.state.recordSink.host} onChange={e => {
+                    this.updateRecordSinkField('host', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Port"), i18next.t("provider:Port - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <InputNumber value={this.state.recordSink.port} onChange={value => {
+                    this.updateRecordSinkField('port', value);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("recordSink:Actions"), i18next.t("recordSink:Actions - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="tags" style={{width: '100%'}} value={this.state.recordSink.actions} onChange={value => {
+              this.updateRecordSinkField('actions', value);
+            }} >
+              {
+                ["signup", "login", "logout", "update-user"].map((option, index) => <Option key={option} value={option}>{option}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("general:Is enabled"), i18next.t("general:Is enabled - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.recordSink.isEnabled} onChange={checked => {
+              this.updateRecordSinkField('isEnabled', checked);
+            }} />
+          </Col>
+        </Row>
+      </Card>
+    )
+  }
+
+  submitRecordSinkEdit(willExist) {
+    let recordSink = Setting.deepCopy(this.state.recordSink);
+    RecordSinkBackend.updateRecordSink(this.state.recordSink.owner, this.state.recordSinkName, recordSink)
+      .then((res) => {
+        if (res.msg === "") {
+          Setting.showMessage("success", `Successfully saved`);
+          this.setState({
+            recordSinkName: this.state.recordSink.name,
+          });
+
+          if (willExist) {
+            this.props.history.push(`/record-sinks`);
+          } else {
+            this.props.history.push(`/record-sinks/${this.state.recordSink.name}`);
+          }
+        } else {
+          Setting.showMessage("error", res.msg);
+          this.updateRecordSinkField('name', this.state.recordSinkName);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `Failed to connect to server: ${error}`);
+      });
+  }
+
+  deleteRecordSink() {
+    RecordSinkBackend.deleteRecordSink(this.state.recordSink)
+      .then(() => {
+        this.props.history.push(`/record-sinks`);
+      })
+      .catch(error => {
+        Setting.showMessage("error", `Record sink failed to delete: ${error}`);
+      });
+  }
+
+  render() {
+    return (
+      <div>
+        {
+          this.state.recordSink !== null ? this.renderRecordSink() : null
+        }
+        <div style={{marginTop: '20px', marginLeft: '40px'}}>
+          <Button size="large" onClick={() => this.submitRecordSinkEdit(false)}>{i18next.t("general:Save")}</Button>
+          <Button style={{marginLeft: '20px'}} type="primary" size="large" onClick={() => this.submitRecordSinkEdit(true)}>{i18next.t("general:Save & Exit")}</Button>
+          {this.state.mode === "add" ? <Button style={{marginLeft: '20px'}} size="large" onClick={() => this.deleteRecordSink()}>{i18next.t("general:Cancel")}</Button> : null}
+        </div>
+      </div>
+    );
+  }
+}
+
+export default RecordSinkEditPage;
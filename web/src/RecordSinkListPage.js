@@ -0,0 +1,227 @@
+// Copyright 2021 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import React from "react";
+import {Link} from "react-router-dom";
+import {Button, Popconfirm, Switch, Table} from 'antd';
+import moment from "moment";
+import * as Setting from "./Setting";
+import * as RecordSinkBackend from "./backend/RecordSinkBackend";
+import i18next from "i18next";
+import BaseListPage from "./BaseListPage";
+
+class RecordSinkListPage extends BaseListPage {
+  newRecordSink() {
+    const randomName = Setting.getRandomName();
+    return {
+      owner: "admin",
+      name: `record_sink_${randomName}`,
+      createdTime: moment().format(),
+      organization: "",
+      type: "Syslog",
+      protocol: "tcp",
+      host: "127.0.0.1",
+      port: 514,
+      url: "",
+      headers: [],
+      actions: [],
+      isEnabled: false,
+    }
+  }
+
+  addRecordSink() {
+    const newRecordSink = this.newRecordSink();
+    RecordSinkBackend.addRecordSink(newRecordSink)
+      .then((res) => {
+          this.props.history.push({pathname: `/record-sinks/${newRecordSink.name}`, mode: "add"});
+        }
+      )
+      .catch(error => {
+        Setting.showMessage("error", `Record sink failed to add: ${error}`);
+      });
+  }
+
+  deleteRecordSink(i) {
+    RecordSinkBackend.deleteRecordSink(this.state.data[i])
+      .then((res) => {
+          Setting.showMessage("success", `Record sink deleted successfully`);
+          this.setState({
+            data: Setting.deleteRow(this.state.data, i),
+            pagination: {total: this.state.pagination.total - 1},
+          });
+        }
+      )
+      .catch(error => {
+        Setting.showMessage("error", `Record sink failed to delete: ${error}`);
+      });
+  }
+
+  renderTable(recordSinks) {
+    const columns = [
+      {
+        title: i18next.t("general:Name"),
+        dataIndex: 'name',
+        key: 'name',
+        width: '150px',
+        fixed: 'left',
+        sorter: true,
+        ...this.getColumnSearchProps('name'),
+        render: (text, record, index) => {
+          return (
+            <Link to={`/record-sinks/${text}`}>
+              {text}
+            </Link>
+          )
+        }
+      },
+      {
+        title: i18next.t("general:Organization"),
+        dataIndex: 'organization',
+        key: 'organization',
+        width: '110px',
+        sorter: true,
+        ...this.getColumnSearchProps('organization'),
+        render: (text, record, index) => {
+          return (
+            <Link to={`/organizations/${text}`}>
+              {text}
+            </Link>
+          )
+        }
+      },
+      {
+        title: i18next.t("general:Created time"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '180px',
+        sorter: true,
+        render: (text, record, index) => {
+          return Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("provider:Type"),
+        dataIndex: 'type',
+        key: 'type',
+        width: '110px',
+        sorter: true,
+        filterMultiple: false,
+        filters: [
+          {text: 'Syslog', value: 'Syslog'},
+          {text: 'OTLP', value: 'OTLP'},
+        ],
+      },
+      {
+        title: i18next.t("recordSink:Destination"),
+        dataIndex: 'host',
+        key: 'host',
+        width: '300px',
+        render: (text, record, index) => {
+          return record.type === "OTLP" ? record.url : `${record.protocol}://${record.host}:${record.port}`;
+        }
+      },
+      {
+        title: i18next.t("recordSink:Actions"),
+        dataIndex: 'actions',
+        key: 'actions',
+        sorter: true,
+        ...this.getColumnSearchProps('actions'),
+        render: (text, record, index) => {
+          return Setting.getTags(text);
+        }
+      },
+      {
+        title: i18next.t("general:Is enabled"),
+        dataIndex: 'isEnabled',
+        key: 'isEnabled',
+        width: '120px',
+        sorter: true,
+        render: (text, record, index) => {
+          return (
+            <Switch disabled checkedChildren="ON" unCheckedChildren="OFF" checked={text} />
+          )
+        }
+      },
+      {
+        title: i18next.t("general:Action"),
+        dataIndex: '',
+        key: 'op',
+        width: '170px',
+        fixed: (Setting.isMobile()) ? "false" : "right",
+        render: (text, record, index) => {
+          return (
+            <div>
+              <Button style={{marginTop: '10px', marginBottom: '10px', marginRight: '10px'}} type="primary" onClick={() => this.props.history.push(`/record-sinks/${record.name}`)}>{i18next.t("general:Edit")}</Button>
+              <Popconfirm
+                title={`Sure to delete record sink: ${record.name} ?`}
+                onConfirm={() => this.deleteRecordSink(index)}
+              >
+                <Button style={{marginBottom: '10px'}} type="danger">{i18next.t("general:Delete")}</Button>
+              </Popconfirm>
+            </div>
+          )
+        }
+      },
+    ];
+
+    const paginationProps = {
+      total: this.state.pagination.total,
+      showQuickJumper: true,
+      showSizeChanger: true,
+      showTotal: () => i18next.t("general:{total} in total").replace("{total}", this.state.pagination.total),
+    };
+
+    return (
+      <div>
+        <Table scroll={{x: 'max-content'}} columns={columns} dataSource={recordSinks} rowKey="name" size="middle" bordered pagination={paginationProps}
+               title={() => (
+                 <div>
+                   {i18next.t("general:Record Sinks")}&nbsp;&nbsp;&nbsp;&nbsp;
+                   <Button type="primary" size="small" onClick={this.addRecordSink.bind(this)}>{i18next.t("general:Add")}</Button>
+                 </div>
+               )}
+               loading={this.state.loading}
+               onChange={this.handleTableChange}
+        />
+      </div>
+    );
+  }
+
+  fetch = (params = {}) => {
+    let field = params.searchedColumn, value = params.searchText;
+    let sortField = params.sortField, sortOrder = params.sortOrder;
+    if (params.type !== undefined && params.type !== null) {
+      field = "type";
+      value = params.type;
+    }
+    this.setState({ loading: true });
+    RecordSinkBackend.getRecordSinks("admin", params.pagination.current, params.pagination.pageSize, field, value, sortField, sortOrder)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            loading: false,
+            data: res.data,
+            pagination: {
+              ...params.pagination,
+              total: res.data2,
+            },
+            searchText: params.searchText,
+            searchedColumn: params.searchedColumn,
+          });
+        }
+      });
+  };
+}
+
+export default RecordSinkListPage;
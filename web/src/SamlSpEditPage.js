@@ -0,0 +1,395 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import React from "react";
+import {Button, Card, Col, Input, Row, Select, Switch, Table, Upload} from 'antd';
+import {UploadOutlined} from "@ant-design/icons";
+import * as SamlSpBackend from "./backend/SamlSpBackend";
+import * as ApplicationBackend from "./backend/ApplicationBackend";
+import * as Setting from "./Setting";
+import i18next from "i18next";
+
+const { Option } = Select;
+const { TextArea } = Input;
+
+class SamlSpEditPage extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      samlSpName: props.match.params.samlSpName,
+      samlSp: null,
+      applications: [],
+      importing: false,
+      mode: props.location.mode !== undefined ? props.location.mode : "edit",
+    };
+  }
+
+  UNSAFE_componentWillMount() {
+    this.getSamlSp();
+    this.getApplications();
+  }
+
+  getSamlSp() {
+    SamlSpBackend.getSamlSp("admin", this.state.samlSpName)
+      .then((samlSp) => {
+        this.setState({
+          samlSp: samlSp,
+        });
+      });
+  }
+
+  getApplications() {
+    ApplicationBackend.getApplications("admin")
+      .then((applications) => {
+        this.setState({
+          applications: applications,
+        });
+      });
+  }
+
+  updateSamlSpField(key, value) {
+    let samlSp = this.state.samlSp;
+    samlSp[key] = value;
+    this.setState({
+      samlSp: samlSp,
+    });
+  }
+
+  updateAttributeField(index, key, value) {
+    let attributes = this.state.samlSp.attributes;
+    attributes[index][key] = value;
+    this.updateSamlSpField('attributes', attributes);
+  }
+
+  importMetadata(file) {
+    this.setState({importing: true});
+    SamlSpBackend.importSamlSpMetadata(this.state.samlSp.owner, this.state.samlSpName, file === undefined ? this.state.samlSp.metadataUrl : "", file)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("samlSp:Metadata imported successfully"));
+          this.setState({
+            samlSp: res.data,
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      }).finally(() => {
+        this.setState({importing: false});
+      });
+  }
+
+  renderEndpoints(endpoints) {
+    const columns = [
+      {
+        title: i18next.t("samlSp:Binding"),
+        dataIndex: 'binding',
+        key: 'binding',
+      },
+      {
+        title: i18next.t("samlSp:Location"),
+        dataIndex: 'location',
+        key: 'location',
+      },
+      {
+        title: i18next.t("samlSp:Index"),
+        dataIndex: 'index',
+        key: 'index',
+        width: '80px',
+      },
+      {
+        title: i18next.t("samlSp:Is default"),
+        dataIndex: 'isDefault',
+        key: 'isDefault',
+        width: '100px',
+        render: (text, record, index) => {
+          return <Switch disabled checked={text} />;
+        }
+      },
+    ];
+
+    return (
+      <Table rowKey="location" columns={columns} dataSource={endpoints === null ? [] : endpoints} size="middle" bordered pagination={false} />
+    );
+  }
+
+  renderAttributes() {
+    const columns = [
+      {
+        title: i18next.t("general:Name"),
+        dataIndex: 'name',
+        key: 'name',
+        render: (text, record, index) => {
+          return (
+            <Input value={text} onChange={e => {
+              this.updateAttributeField(index, 'name', e.target.value);
+            }} />
+          )
+        }
+      },
+      {
+        title: i18next.t("samlSp:Friendly name"),
+        dataIndex: 'friendlyName',
+        key: 'friendlyName',
+        width: '200px',
+      },
+      {
+        title: i18next.t("samlSp:User field"),
+        dataIndex: 'value',
+        key: 'value',
+        width: '250px',
+        render: (text, record, index) => {
+          return (
+            <Input value={text} onChange={e => {
+              this.updateAttributeField(index, 'value', e.target.value);
+            }} />
+          )
+        }
+      },
+      {
+        title: i18next.t("general:Action"),
+        key: 'op',
+        width: '100px',
+        render: (text, record, index) => {
+          return (
+            <Button type="danger" onClick={() => {
+              this.updateSamlSpField('attributes', Setting.deleteRow(this.state.samlSp.attributes, index));
+            }}>{i18next.t("general:Delete")}</Button>
+          )
+        }
+      },
+    ];
+
+    return (
+      <Table rowKey={(record, index) => index} columns={columns} dataSource={this.state.samlSp.attributes === null ? [] : this.state.samlSp.attributes} size="middle" bordered pagination={false}
+             title={() => (
+               <div>
+                 <Button size="small" onClick={() => {
+                   const attributes = this.state.samlSp.attributes === null ? [] : this.state.samlSp.attributes;
+                   this.updateSamlSpField('attributes', Setting.addRow(attributes, {name: "", nameFormat: "urn:oasis:names:tc:SAML:2.0:attrname-format:basic", friendlyName: "", value: ""}));
+                 }}>{i18next.t("general:Add")}</Button>
+               </div>
+             )}
+      />
+    );
+  }
+
+  renderSamlSp() {
+    return (
+      <Card size="small" title={
+        <div>
+          {this.state.mode === "add" ? i18next.t("samlSp:New SAML SP") : i18next.t("samlSp:Edit SAML SP")}&nbsp;&nbsp;&nbsp;&nbsp;
+          <Button onClick={() => this.submitSamlSpEdit(false)}>{i18next.t("general:Save")}</Button>
+          <Button style={{marginLeft: '20px'}} type="primary" onClick={() => this.submitSamlSpEdit(true)}>{i18next.t("general:Save & Exit")}</Button>
+          {this.state.mode === "add" ? <Button style={{marginLeft: '20px'}} onClick={() => this.deleteSamlSp()}>{i18next.t("general:Cancel")}</Button> : null}
+        </div>
+      } style={(Setting.isMobile())? {margin: '5px'}:{}} type="inner">
+        <Row style={{marginTop: '10px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Name"), i18next.t("general:Name - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.samlSp.name} onChange={e => {
+              this.updateSamlSpField('name', e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Display name"), i18next.t("general:Display name - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.samlSp.displayName} onChange={e => {
+              this.updateSamlSpField('displayName', e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Application"), i18next.t("general:Application - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: '100%'}} value={this.state.samlSp.application} onChange={(value => {
+              this.updateSamlSpField('application', value);
+            })}>
+              {
+                this.state.applications.map((application, index) => <Option key={index} value={application.name}>{application.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:Metadata URL"), i18next.t("samlSp:Metadata URL - Tooltip"))} :
+          </Col>
+          <Col span={16} >
+            <Input value={this.state.samlSp.metadataUrl} onChange={e => {
+              this.updateSamlSpField('metadataUrl', e.target.value);
+            }} />
+          </Col>
+          <Col span={6} >
+            <Button style={{marginLeft: '10px'}} type="primary" loading={this.state.importing} onClick={() => this.importMetadata()}>{i18next.t("samlSp:Import from URL")}</Button>
+            <Upload maxCount={1} accept=".xml" showUploadList={false}
+                    beforeUpload={file => {return false}} onChange={info => {this.importMetadata(info.file)}}>
+              <Button style={{marginLeft: '10px'}} icon={<UploadOutlined />} loading={this.state.importing}>{i18next.t("general:Click to Upload")}</Button>
+            </Upload>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:Entity ID"), i18next.t("samlSp:Entity ID - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.samlSp.entityId} onChange={e => {
+              this.updateSamlSpField('entityId', e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:ACS endpoints"), i18next.t("samlSp:ACS endpoints - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            {this.renderEndpoints(this.state.samlSp.acsEndpoints)}
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:SLO endpoints"), i18next.t("samlSp:SLO endpoints - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            {this.renderEndpoints(this.state.samlSp.sloEndpoints)}
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:NameID format"), i18next.t("samlSp:NameID format - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: '100%'}} value={this.state.samlSp.nameIdFormat} onChange={(value => {
+              this.updateSamlSpField('nameIdFormat', value);
+            })}>
+              {
+                [
+                  {id: 'urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress', name: 'Email address'},
+                  {id: 'urn:oasis:names:tc:SAML:2.0:nameid-format:persistent', name: 'Persistent (user ID)'},
+                  {id: 'urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified', name: 'Unspecified (username)'},
+                ].map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:Attributes"), i18next.t("samlSp:Attributes - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            {this.renderAttributes()}
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:Authn requests signed"), i18next.t("samlSp:Authn requests signed - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.samlSp.authnRequestsSigned} onChange={checked => {
+              this.updateSamlSpField('authnRequestsSigned', checked);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:Enable encryption"), i18next.t("samlSp:Enable encryption - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.samlSp.enableEncryption} onChange={checked => {
+              this.updateSamlSpField('enableEncryption', checked);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:Signing cert"), i18next.t("samlSp:Signing cert - Tooltip"))} :
+          </Col>
+          <Col span={9} >
+            <TextArea autoSize={{minRows: 10, maxRows: 10}} value={this.state.samlSp.signingCert} onChange={e => {
+              this.updateSamlSpField('signingCert', e.target.value);
+            }} />
+          </Col>
+          <Col span={1} />
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("samlSp:Encryption cert"), i18next.t("samlSp:Encryption cert - Tooltip"))} :
+          </Col>
+          <Col span={9} >
+            <TextArea autoSize={{minRows: 10, maxRows: 10}} value={this.state.samlSp.encryptionCert} onChange={e => {
+              this.updateSamlSpField('encryptionCert', e.target.value);
+            }} />
+          </Col>
+        </Row>
+      </Card>
+    )
+  }
+
+  submitSamlSpEdit(willExist) {
+    let samlSp = Setting.deepCopy(this.state.samlSp);
+    SamlSpBackend.updateSamlSp(this.state.samlSp.owner, this.state.samlSpName, samlSp)
+      .then((res) => {
+        if (res.msg === "") {
+          Setting.showMessage("success", `Successfully saved`);
+          this.setState({
+            samlSpName: this.state.samlSp.name,
+          });
+
+          if (willExist) {
+            this.props.history.push(`/saml-sps`);
+          } else {
+            this.props.history.push(`/saml-sps/${this.state.samlSp.name}`);
+          }
+        } else {
+          Setting.showMessage("error", res.msg);
+          this.updateSamlSpField('name', this.state.samlSpName);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `Failed to connect to server: ${error}`);
+      });
+  }
+
+  deleteSamlSp() {
+    SamlSpBackend.deleteSamlSp(this.state.samlSp)
+      .then(() => {
+        this.props.history.push(`/saml-sps`);
+      })
+      .catch(error => {
+        Setting.showMessage("error", `SAML SP failed to delete: ${error}`);
+      });
+  }
+
+  render() {
+    return (
+      <div>
+        {
+          this.state.samlSp !== null ? this.renderSamlSp() : null
+        }
+        <div style={{marginTop: '20px', marginLeft: '40px'}}>
+          <Button size="large" onClick={() => this.submitSamlSpEdit(false)}>{i18next.t("general:Save")}</Button>
+          <Button style={{marginLeft: '20px'}} type="primary" size="large" onClick={() => this.submitSamlSpEdit(true)}>{i18next.t("general:Save & Exit")}</Button>
+          {this.state.mode === "add" ? <Button style={{marginLeft: '20px'}} size="large" onClick={() => this.deleteSamlSp()}>{i18next.t("general:Cancel")}</Button> : null}
+        </div>
+      </div>
+    );
+  }
+}
+
+export default SamlSpEditPage;
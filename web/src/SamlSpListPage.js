@@ -0,0 +1,199 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import React from "react";
+import {Link} from "react-router-dom";
+import {Button, Popconfirm, Table} from 'antd';
+import moment from "moment";
+import * as Setting from "./Setting";
+import * as SamlSpBackend from "./backend/SamlSpBackend";
+import i18next from "i18next";
+import BaseListPage from "./BaseListPage";
+
+class SamlSpListPage extends BaseListPage {
+  newSamlSp() {
+    const randomName = Setting.getRandomName();
+    return {
+      owner: "admin",
+      name: `saml_sp_${randomName}`,
+      createdTime: moment().format(),
+      displayName: `New SAML SP - ${randomName}`,
+      application: "app-built-in",
+      entityId: "",
+      metadataUrl: "",
+      metadata: "",
+      acsEndpoints: [],
+      sloEndpoints: [],
+      signingCert: "",
+      encryptionCert: "",
+      nameIdFormat: "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress",
+      attributes: [],
+      authnRequestsSigned: false,
+      wantAssertionsSigned: false,
+      enableEncryption: false,
+    }
+  }
+
+  addSamlSp() {
+    const newSamlSp = this.newSamlSp();
+    SamlSpBackend.addSamlSp(newSamlSp)
+      .then((res) => {
+          this.props.history.push({pathname: `/saml-sps/${newSamlSp.name}`, mode: "add"});
+        }
+      )
+      .catch(error => {
+        Setting.showMessage("error", `SAML SP failed to add: ${error}`);
+      });
+  }
+
+  deleteSamlSp(i) {
+    SamlSpBackend.deleteSamlSp(this.state.data[i])
+      .then((res) => {
+          Setting.showMessage("success", `SAML SP deleted successfully`);
+          this.setState({
+            data: Setting.deleteRow(this.state.data, i),
+            pagination: {total: this.state.pagination.total - 1},
+          });
+        }
+      )
+      .catch(error => {
+        Setting.showMessage("error", `SAML SP failed to delete: ${error}`);
+      });
+  }
+
+  renderTable(samlSps) {
+    const columns = [
+      {
+        title: i18next.t("general:Name"),
+        dataIndex: 'name',
+        key: 'name',
+        width: '150px',
+        fixed: 'left',
+        sorter: true,
+        ...this.getColumnSearchProps('name'),
+        render: (text, record, index) => {
+          return (
+            <Link to={`/saml-sps/${text}`}>
+              {text}
+            </Link>
+          )
+        }
+      },
+      {
+        title: i18next.t("general:Created time"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '180px',
+        sorter: true,
+        render: (text, record, index) => {
+          return Setting.getFormattedDate(text);
+        }
+      },
+      {
+        title: i18next.t("general:Display name"),
+        dataIndex: 'displayName',
+        key: 'displayName',
+        sorter: true,
+        ...this.getColumnSearchProps('displayName'),
+      },
+      {
+        title: i18next.t("general:Application"),
+        dataIndex: 'application',
+        key: 'application',
+        width: '150px',
+        sorter: true,
+        ...this.getColumnSearchProps('application'),
+        render: (text, record, index) => {
+          return (
+            <Link to={`/applications/${text}`}>
+              {text}
+            </Link>
+          )
+        }
+      },
+      {
+        title: i18next.t("samlSp:Entity ID"),
+        dataIndex: 'entityId',
+        key: 'entityId',
+        sorter: true,
+        ...this.getColumnSearchProps('entityId'),
+      },
+      {
+        title: i18next.t("general:Action"),
+        dataIndex: '',
+        key: 'op',
+        width: '170px',
+        fixed: (Setting.isMobile()) ? "false" : "right",
+        render: (text, record, index) => {
+          return (
+            <div>
+              <Button style={{marginTop: '10px', marginBottom: '10px', marginRight: '10px'}} type="primary" onClick={() => this.props.history.push(`/saml-sps/${record.name}`)}>{i18next.t("general:Edit")}</Button>
+              <Popconfirm
+                title={`Sure to delete SAML SP: ${record.name} ?`}
+                onConfirm={() => this.deleteSamlSp(index)}
+              >
+                <Button style={{marginBottom: '10px'}} type="danger">{i18next.t("general:Delete")}</Button>
+              </Popconfirm>
+            </div>
+          )
+        }
+      },
+    ];
+
+    const paginationProps = {
+      total: this.state.pagination.total,
+      showQuickJumper: true,
+      showSizeChanger: true,
+      showTotal: () => i18next.t("general:{total} in total").replace("{total}", this.state.pagination.total),
+    };
+
+    return (
+      <div>
+        <Table scroll={{x: 'max-content'}} columns={columns} dataSource={samlSps} rowKey="name" size="middle" bordered pagination={paginationProps}
+               title={() => (
+                 <div>
+                   {i18next.t("general:SAML SPs")}&nbsp;&nbsp;&nbsp;&nbsp;
+                   <Button type="primary" size="small" onClick={this.addSamlSp.bind(this)}>{i18next.t("general:Add")}</Button>
+                 </div>
+               )}
+               loading={this.state.loading}
+               onChange={this.handleTableChange}
+        />
+      </div>
+    );
+  }
+
+  fetch = (params = {}) => {
+    let field = params.searchedColumn, value = params.searchText;
+    let sortField = params.sortField, sortOrder = params.sortOrder;
+    this.setState({ loading: true });
+    SamlSpBackend.getSamlSps("admin", params.pagination.current, params.pagination.pageSize, field, value, sortField, sortOrder)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            loading: false,
+            data: res.data,
+            pagination: {
+              ...params.pagination,
+              total: res.data2,
+            },
+            searchText: params.searchText,
+            searchedColumn: params.searchedColumn,
+          });
+        }
+      });
+  };
+}
+
+export default SamlSpListPage;
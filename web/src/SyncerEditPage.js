@@ -13,10 +13,11 @@
 // limitations under the License.
 
 import React from "react";
-import {Button, Card, Col, Input, InputNumber, Row, Select, Switch} from 'antd';
+import {Button, Card, Col, Input, InputNumber, Row, Select, Switch, Table} from 'antd';
 import {LinkOutlined} from "@ant-design/icons";
 import * as SyncerBackend from "./backend/SyncerBackend";
 import * as OrganizationBackend from "./backend/OrganizationBackend";
+import * as ProviderBackend from "./backend/ProviderBackend";
 import * as Setting from "./Setting";
 import i18next from "i18next";
 import SyncerTableColumnTable from "./SyncerTableColumnTable";
@@ -36,6 +37,11 @@ class SyncerEditPage extends React.Component {
       syncerName: props.match.params.syncerName,
       syncer: null,
       organizations: [],
+      decisions: [],
+      providers: [],
+      runs: [],
+      plan: null,
+      planLoading: false,
       mode: props.location.mode !== undefined ? props.location.mode : "edit",
     };
   }
@@ -43,6 +49,9 @@ class SyncerEditPage extends React.Component {
   UNSAFE_componentWillMount() {
     this.getSyncer();
     this.getOrganizations();
+    this.getProviders();
+    this.getSyncerDecisions();
+    this.getSyncerRuns();
   }
 
   getSyncer() {
@@ -63,6 +72,67 @@ class SyncerEditPage extends React.Component {
       });
   }
 
+  getProviders() {
+    ProviderBackend.getProviders("admin")
+      .then((res) => {
+        this.setState({
+          providers: (res.msg === undefined) ? res : [],
+        });
+      });
+  }
+
+  getSyncerDecisions() {
+    SyncerBackend.getSyncerDecisions("admin", this.state.syncerName)
+      .then((res) => {
+        this.setState({
+          decisions: (res.msg === undefined) ? res : [],
+        });
+      });
+  }
+
+  getSyncerRuns() {
+    SyncerBackend.getSyncerRuns("admin", this.state.syncerName)
+      .then((res) => {
+        this.setState({
+          runs: (res.msg === undefined) ? res : [],
+        });
+      });
+  }
+
+  dryRunSyncer() {
+    this.setState({planLoading: true});
+    SyncerBackend.dryRunSyncer("admin", this.state.syncerName)
+      .then((res) => {
+        this.setState({planLoading: false});
+        if (res.status === "ok") {
+          this.setState({
+            plan: res.data,
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      })
+      .catch(error => {
+        this.setState({planLoading: false});
+        Setting.showMessage("error", `Failed to connect to server: ${error}`);
+      });
+  }
+
+  resolveSyncerDecision(decision, action) {
+    SyncerBackend.resolveSyncerDecision(decision.id, action)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", `Successfully resolved`);
+          this.getSyncerDecisions();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `Failed to connect to server: ${error}`);
+      });
+  }
+
   parseSyncerField(key, value) {
     if (["port"].includes(key)) {
       value = Setting.myParseInt(value);
@@ -80,6 +150,10 @@ class SyncerEditPage extends React.Component {
     });
   }
 
+  isDatabaseSource() {
+    return !["CSV", "JSONL", "HTTP"].includes(this.state.syncer.type);
+  }
+
   renderSyncer() {
     return (
       <Card size="small" title={
@@ -127,173 +201,521 @@ class SyncerEditPage extends React.Component {
               });
             })}>
               {
-                ['Database', 'LDAP', 'Keycloak']
+                ['Database', 'LDAP', 'Keycloak', 'CSV', 'JSONL', 'HTTP']
                   .map((item, index) => <Option key={index} value={item}>{item}</Option>)
               }
             </Select>
           </Col>
         </Row>
+        {
+          !this.isDatabaseSource() ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Host"), i18next.t("provider:Host - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.host} onChange={e => {
+                    this.updateSyncerField('host', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Port"), i18next.t("provider:Port - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <InputNumber value={this.state.syncer.port} onChange={value => {
+                    this.updateSyncerField('port', value);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
+        {
+          (!this.isDatabaseSource() && this.state.syncer.type !== "HTTP") ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("general:User"), i18next.t("general:User - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.user} onChange={e => {
+                    this.updateSyncerField('user', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("general:Password"), i18next.t("general:Password - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.password} onChange={e => {
+                    this.updateSyncerField('password', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
+        {
+          !this.isDatabaseSource() ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Database type"), i18next.t("syncer:Database type - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Select virtual={false} style={{width: '100%'}} value={this.state.syncer.databaseType} onChange={(value => {this.updateSyncerField('databaseType', value);})}>
+                    {
+                      [
+                        {id: 'mysql', name: 'MySQL'},
+                        {id: 'postgres', name: 'PostgreSQL'},
+                        {id: 'mssql', name: 'SQL Server'},
+                        {id: 'oracle', name: 'Oracle'},
+                        {id: 'sqlite3', name: 'Sqlite 3'},
+                      ].map((databaseType, index) => <Option key={index} value={databaseType.id}>{databaseType.name}</Option>)
+                    }
+                  </Select>
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Database"), i18next.t("syncer:Database - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.database} onChange={e => {
+                    this.updateSyncerField('database', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Table"), i18next.t("syncer:Table - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.table}
+                         disabled={this.state.syncer.type === "Keycloak"} onChange={e => {
+                    this.updateSyncerField('table', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Table primary key"), i18next.t("syncer:Table primary key - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.tablePrimaryKey} onChange={e => {
+                    this.updateSyncerField('tablePrimaryKey', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
+        {
+          !["CSV", "JSONL"].includes(this.state.syncer.type) ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Storage provider"), i18next.t("syncer:Storage provider - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Select virtual={false} style={{width: '100%'}} value={this.state.syncer.provider} onChange={(value => {this.updateSyncerField('provider', value);})}>
+                    {
+                      this.state.providers.filter(provider => provider.category === "Storage").map((provider, index) => <Option key={index} value={provider.name}>{provider.name}</Option>)
+                    }
+                  </Select>
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:File path"), i18next.t("syncer:File path - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.filePath} onChange={e => {
+                    this.updateSyncerField('filePath', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
+        {
+          this.state.syncer.type !== "HTTP" ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:URL"), i18next.t("syncer:URL - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input prefix={<LinkOutlined/>} value={this.state.syncer.url} onChange={e => {
+                    this.updateSyncerField('url', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Result path"), i18next.t("syncer:Result path - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.resultPath} onChange={e => {
+                    this.updateSyncerField('resultPath', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Next page path"), i18next.t("syncer:Next page path - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.nextPagePath} onChange={e => {
+                    this.updateSyncerField('nextPagePath', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Page parameter"), i18next.t("syncer:Page parameter - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.pageParam} onChange={e => {
+                    this.updateSyncerField('pageParam', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("provider:Host"), i18next.t("provider:Host - Tooltip"))} :
-          </Col>
-          <Col span={22} >
-            <Input value={this.state.syncer.host} onChange={e => {
-              this.updateSyncerField('host', e.target.value);
-            }} />
-          </Col>
-        </Row>
-        <Row style={{marginTop: '20px'}} >
-          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("provider:Port"), i18next.t("provider:Port - Tooltip"))} :
+            {Setting.getLabel(i18next.t("syncer:Table columns"), i18next.t("syncer:Table columns - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <InputNumber value={this.state.syncer.port} onChange={value => {
-              this.updateSyncerField('port', value);
-            }} />
+            <SyncerTableColumnTable
+              title={i18next.t("syncer:Table columns")}
+              table={this.state.syncer.tableColumns}
+              onUpdateTable={(value) => { this.updateSyncerField('tableColumns', value)}}
+            />
           </Col>
         </Row>
+        {
+          !this.isDatabaseSource() ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Affiliation table"), i18next.t("syncer:Affiliation table - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.syncer.affiliationTable} onChange={e => {
+                    this.updateSyncerField('affiliationTable', e.target.value);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("general:User"), i18next.t("general:User - Tooltip"))} :
+            {Setting.getLabel(i18next.t("syncer:Avatar base URL"), i18next.t("syncer:Avatar base URL - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <Input value={this.state.syncer.user} onChange={e => {
-              this.updateSyncerField('user', e.target.value);
+            <Input prefix={<LinkOutlined/>} value={this.state.syncer.avatarBaseUrl} onChange={e => {
+              this.updateSyncerField('avatarBaseUrl', e.target.value);
             }} />
           </Col>
         </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("general:Password"), i18next.t("general:Password - Tooltip"))} :
+            {Setting.getLabel(i18next.t("syncer:Sync interval"), i18next.t("syncer:Sync interval - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <Input value={this.state.syncer.password} onChange={e => {
-              this.updateSyncerField('password', e.target.value);
+            <InputNumber value={this.state.syncer.syncInterval} onChange={value => {
+              this.updateSyncerField('syncInterval', value);
             }} />
           </Col>
         </Row>
-        <Row style={{marginTop: '20px'}} >
-          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("syncer:Database type"), i18next.t("syncer:Database type - Tooltip"))} :
-          </Col>
-          <Col span={22} >
-            <Select virtual={false} style={{width: '100%'}} value={this.state.syncer.databaseType} onChange={(value => {this.updateSyncerField('databaseType', value);})}>
+        {
+          !this.isDatabaseSource() ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: '20px'}} >
+                <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("syncer:Sync direction"), i18next.t("syncer:Sync direction - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Select virtual={false} style={{width: '100%'}} value={this.state.syncer.syncDirection === "" ? "Bidirectional" : this.state.syncer.syncDirection} onChange={(value => {this.updateSyncerField('syncDirection', value);})}>
+                    {
+                      [
+                        {id: "Pull", name: "Pull only"},
+                        {id: "Push", name: "Push only"},
+                        {id: "Bidirectional", name: "Bidirectional"},
+                      ].map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+                    }
+                  </Select>
+                </Col>
+              </Row>
               {
-                [
-                  {id: 'mysql', name: 'MySQL'},
-                  {id: 'postgres', name: 'PostgreSQL'},
-                  {id: 'mssql', name: 'SQL Server'},
-                  {id: 'oracle', name: 'Oracle'},
-                  {id: 'sqlite3', name: 'Sqlite 3'},
-                ].map((databaseType, index) => <Option key={index} value={databaseType.id}>{databaseType.name}</Option>)
+                (this.state.syncer.syncDirection !== "" && this.state.syncer.syncDirection !== "Bidirectional") ? null : (
+                  <Row style={{marginTop: '20px'}} >
+                    <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+                      {Setting.getLabel(i18next.t("syncer:Conflict policy"), i18next.t("syncer:Conflict policy - Tooltip"))} :
+                    </Col>
+                    <Col span={22} >
+                      <Select virtual={false} style={{width: '100%'}} value={this.state.syncer.conflictPolicy === "" ? "SourceWins" : this.state.syncer.conflictPolicy} onChange={(value => {this.updateSyncerField('conflictPolicy', value);})}>
+                        {
+                          [
+                            {id: "SourceWins", name: "Source wins"},
+                            {id: "CasdoorWins", name: "Casdoor wins"},
+                            {id: "NewestWins", name: "Newest updated time wins"},
+                            {id: "Manual", name: "Flag for manual review"},
+                          ].map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
+                        }
+                      </Select>
+                    </Col>
+                  </Row>
+                )
               }
-            </Select>
-          </Col>
-        </Row>
-        <Row style={{marginTop: '20px'}} >
-          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("syncer:Database"), i18next.t("syncer:Database - Tooltip"))} :
-          </Col>
-          <Col span={22} >
-            <Input value={this.state.syncer.database} onChange={e => {
-              this.updateSyncerField('database', e.target.value);
-            }} />
-          </Col>
-        </Row>
+            </React.Fragment>
+          )
+        }
         <Row style={{marginTop: '20px'}} >
-          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("syncer:Table"), i18next.t("syncer:Table - Tooltip"))} :
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("syncer:Propagate deletion"), i18next.t("syncer:Propagate deletion - Tooltip"))} :
           </Col>
-          <Col span={22} >
-            <Input value={this.state.syncer.table}
-                   disabled={this.state.syncer.type === "Keycloak"} onChange={e => {
-              this.updateSyncerField('table', e.target.value);
+          <Col span={1} >
+            <Switch checked={this.state.syncer.propagateDeletion} onChange={checked => {
+              this.updateSyncerField('propagateDeletion', checked);
             }} />
           </Col>
         </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("syncer:Table primary key"), i18next.t("syncer:Table primary key - Tooltip"))} :
+            {Setting.getLabel(i18next.t("syncer:Max deletion percent"), i18next.t("syncer:Max deletion percent - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <Input value={this.state.syncer.tablePrimaryKey} onChange={e => {
-              this.updateSyncerField('tablePrimaryKey', e.target.value);
+            <InputNumber min={0} max={100} value={this.state.syncer.maxDeletionPercent} onChange={value => {
+              this.updateSyncerField('maxDeletionPercent', value);
             }} />
           </Col>
         </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("syncer:Table columns"), i18next.t("syncer:Table columns - Tooltip"))} :
+            {Setting.getLabel(i18next.t("syncer:Error text"), i18next.t("syncer:Error text - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <SyncerTableColumnTable
-              title={i18next.t("syncer:Table columns")}
-              table={this.state.syncer.tableColumns}
-              onUpdateTable={(value) => { this.updateSyncerField('tableColumns', value)}}
-            />
+            <div style={{width: "100%", height: "300px"}} >
+              <CodeMirror
+                value={this.state.syncer.errorText}
+                options={{mode: 'javascript', theme: "material-darker"}}
+                onBeforeChange={(editor, data, value) => {
+                  this.updateSyncerField("errorText", value);
+                }}
+              />
+            </div>
           </Col>
         </Row>
         <Row style={{marginTop: '20px'}} >
-          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("syncer:Affiliation table"), i18next.t("syncer:Affiliation table - Tooltip"))} :
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("general:Is enabled"), i18next.t("general:Is enabled - Tooltip"))} :
           </Col>
-          <Col span={22} >
-            <Input value={this.state.syncer.affiliationTable} onChange={e => {
-              this.updateSyncerField('affiliationTable', e.target.value);
+          <Col span={1} >
+            <Switch checked={this.state.syncer.isEnabled} onChange={checked => {
+              this.updateSyncerField('isEnabled', checked);
             }} />
           </Col>
         </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("syncer:Avatar base URL"), i18next.t("syncer:Avatar base URL - Tooltip"))} :
+            {Setting.getLabel(i18next.t("syncer:Decisions"), i18next.t("syncer:Decisions - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <Input prefix={<LinkOutlined/>} value={this.state.syncer.avatarBaseUrl} onChange={e => {
-              this.updateSyncerField('avatarBaseUrl', e.target.value);
-            }} />
+            {
+              this.renderDecisions()
+            }
           </Col>
         </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("syncer:Sync interval"), i18next.t("syncer:Sync interval - Tooltip"))} :
+            {Setting.getLabel(i18next.t("syncer:Dry run"), i18next.t("syncer:Dry run - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <InputNumber value={this.state.syncer.syncInterval} onChange={value => {
-              this.updateSyncerField('syncInterval', value);
-            }} />
+            <Button loading={this.state.planLoading} onClick={() => this.dryRunSyncer()}>{i18next.t("syncer:Dry run")}</Button>
+            {
+              this.renderPlan()
+            }
           </Col>
         </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
-            {Setting.getLabel(i18next.t("syncer:Error text"), i18next.t("syncer:Error text - Tooltip"))} :
+            {Setting.getLabel(i18next.t("syncer:Run history"), i18next.t("syncer:Run history - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <div style={{width: "100%", height: "300px"}} >
-              <CodeMirror
-                value={this.state.syncer.errorText}
-                options={{mode: 'javascript', theme: "material-darker"}}
-                onBeforeChange={(editor, data, value) => {
-                  this.updateSyncerField("errorText", value);
-                }}
-              />
-            </div>
-          </Col>
-        </Row>
-        <Row style={{marginTop: '20px'}} >
-          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
-            {Setting.getLabel(i18next.t("general:Is enabled"), i18next.t("general:Is enabled - Tooltip"))} :
-          </Col>
-          <Col span={1} >
-            <Switch checked={this.state.syncer.isEnabled} onChange={checked => {
-              this.updateSyncerField('isEnabled', checked);
-            }} />
+            {
+              this.renderRuns()
+            }
           </Col>
         </Row>
       </Card>
     )
   }
 
+  renderDecisions() {
+    const columns = [
+      {
+        title: i18next.t("general:Created time"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '160px',
+        render: (text, record, index) => Setting.getFormattedDate(text)
+      },
+      {
+        title: i18next.t("general:User"),
+        dataIndex: 'userName',
+        key: 'userName',
+        width: '120px',
+      },
+      {
+        title: i18next.t("provider:Type"),
+        dataIndex: 'type',
+        key: 'type',
+        width: '100px',
+      },
+      {
+        title: i18next.t("syncer:Conflict policy"),
+        dataIndex: 'policy',
+        key: 'policy',
+        width: '120px',
+      },
+      {
+        title: i18next.t("syncer:Differences"),
+        dataIndex: 'differences',
+        key: 'differences',
+        render: (text, record, index) => {
+          if (record.differences === null || record.differences === undefined) {
+            return null;
+          }
+          return record.differences.map((difference, i) => (
+            <div key={i}>{`${difference.column}: "${difference.casdoorValue}" (Casdoor) / "${difference.sourceValue}" (${i18next.t("syncer:Source")})`}</div>
+          ));
+        }
+      },
+      {
+        title: i18next.t("general:Action"),
+        dataIndex: 'action',
+        key: 'action',
+        width: '200px',
+        render: (text, record, index) => {
+          if (record.isResolved) {
+            return record.error === "" ? text : `${text}: ${record.error}`;
+          }
+
+          return (
+            <div>
+              <Button size="small" style={{marginRight: "5px"}} onClick={() => this.resolveSyncerDecision(record, "Pull")}>{i18next.t("syncer:Keep source")}</Button>
+              <Button size="small" onClick={() => this.resolveSyncerDecision(record, "Push")}>{i18next.t("syncer:Keep Casdoor")}</Button>
+            </div>
+          )
+        }
+      },
+    ];
+
+    return (
+      <Table rowKey="id" columns={columns} dataSource={this.state.decisions} size="middle" bordered pagination={{pageSize: 10}} />
+    );
+  }
+
+  renderCounts(counts) {
+    if (counts === null || counts === undefined) {
+      return null;
+    }
+    return Object.keys(counts).map(key => `${key}: ${counts[key]}`).join(", ");
+  }
+
+  renderPlan() {
+    if (this.state.plan === null) {
+      return null;
+    }
+
+    const columns = [
+      {
+        title: i18next.t("general:User"),
+        dataIndex: 'userName',
+        key: 'userName',
+        width: '120px',
+      },
+      {
+        title: i18next.t("general:Action"),
+        dataIndex: 'type',
+        key: 'type',
+        width: '160px',
+        render: (text, record, index) => record.isConflict ? `${text} (${i18next.t("syncer:Conflict")})` : text
+      },
+      {
+        title: i18next.t("syncer:Differences"),
+        dataIndex: 'differences',
+        key: 'differences',
+        render: (text, record, index) => {
+          if (record.differences === null || record.differences === undefined) {
+            return null;
+          }
+          return record.differences.map((difference, i) => (
+            <div key={i}>{`${difference.column}: "${difference.casdoorValue}" (Casdoor) / "${difference.sourceValue}" (${i18next.t("syncer:Source")})`}</div>
+          ));
+        }
+      },
+    ];
+
+    return (
+      <div style={{marginTop: "10px"}}>
+        <div style={{marginBottom: "10px"}}>{this.renderCounts(this.state.plan.counts)}</div>
+        <Table rowKey={(record, index) => `${record.type}/${record.userId}/${index}`} columns={columns} dataSource={this.state.plan.operations} size="middle" bordered pagination={{pageSize: 10}} />
+      </div>
+    );
+  }
+
+  renderRuns() {
+    const columns = [
+      {
+        title: i18next.t("syncer:Start time"),
+        dataIndex: 'startTime',
+        key: 'startTime',
+        width: '160px',
+        render: (text, record, index) => Setting.getFormattedDate(text)
+      },
+      {
+        title: i18next.t("syncer:End time"),
+        dataIndex: 'endTime',
+        key: 'endTime',
+        width: '160px',
+        render: (text, record, index) => Setting.getFormattedDate(text)
+      },
+      {
+        title: i18next.t("syncer:Counts"),
+        dataIndex: 'counts',
+        key: 'counts',
+        render: (text, record, index) => this.renderCounts(record.counts)
+      },
+      {
+        title: i18next.t("syncer:Errors"),
+        dataIndex: 'errors',
+        key: 'errors',
+        render: (text, record, index) => {
+          if (record.errors === null || record.errors === undefined) {
+            return null;
+          }
+          return record.errors.map((error, i) => (
+            <div key={i}>{error}</div>
+          ));
+        }
+      },
+    ];
+
+    return (
+      <Table rowKey="id" columns={columns} dataSource={this.state.runs} size="middle" bordered pagination={{pageSize: 10}} />
+    );
+  }
+
   submitSyncerEdit(willExist) {
     let syncer = Setting.deepCopy(this.state.syncer);
     SyncerBackend.updateSyncer(this.state.syncer.owner, this.state.syncerName, syncer)
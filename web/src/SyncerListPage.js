@@ -77,7 +77,13 @@ class SyncerListPage extends BaseListPage {
     SyncerBackend.runSyncer("admin", this.state.data[i].name)
       .then((res) => {
           this.setState({loading: false});
-          Setting.showMessage("success", `Syncer sync users successfully`);
+          if (res.status !== "ok") {
+            Setting.showMessage("error", res.msg);
+          } else if (res.data.failedCount > 0) {
+            Setting.showMessage("error", `Syncer finished with ${res.data.failedCount} errors: ${res.data.errors[0]}`);
+          } else {
+            Setting.showMessage("success", `Syncer sync users successfully`);
+          }
         }
       )
       .catch(error => {
@@ -13,7 +13,7 @@
 // limitations under the License.
 
 import React from "react";
-import {Button, Card, Col, Input, Row, Select, Switch} from 'antd';
+import {Button, Card, Col, Input, Row, Select, Switch, Table} from 'antd';
 import {LinkOutlined} from "@ant-design/icons";
 import * as WebhookBackend from "./backend/WebhookBackend";
 import * as OrganizationBackend from "./backend/OrganizationBackend";
@@ -96,6 +96,11 @@ class WebhookEditPage extends React.Component {
       webhookName: props.match.params.webhookName,
       webhook: null,
       organizations: [],
+      events: [],
+      eventTypes: [],
+      deliveries: {},
+      testEvent: "",
+      testResult: null,
       mode: props.location.mode !== undefined ? props.location.mode : "edit",
     };
   }
@@ -103,6 +108,8 @@ class WebhookEditPage extends React.Component {
   UNSAFE_componentWillMount() {
     this.getWebhook();
     this.getOrganizations();
+    this.getWebhookEvents();
+    this.getEventTypes();
   }
 
   getWebhook() {
@@ -123,6 +130,83 @@ class WebhookEditPage extends React.Component {
       });
   }
 
+  getWebhookEvents() {
+    WebhookBackend.getWebhookEvents("admin", this.state.webhookName)
+      .then((res) => {
+        this.setState({
+          events: (res.msg === undefined) ? res : [],
+        });
+      });
+  }
+
+  getEventTypes() {
+    WebhookBackend.getEventTypes()
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            eventTypes: res.data,
+          });
+        }
+      });
+  }
+
+  getWebhookDeliveries(event) {
+    WebhookBackend.getWebhookDeliveries(event.id)
+      .then((res) => {
+        if (res.status === "ok") {
+          let deliveries = this.state.deliveries;
+          deliveries[event.id] = res.data;
+          this.setState({
+            deliveries: deliveries,
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  redeliverWebhookEvent(event) {
+    WebhookBackend.redeliverWebhookEvent(event.id)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", `Successfully queued for redelivery`);
+          this.getWebhookEvents();
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `Failed to connect to server: ${error}`);
+      });
+  }
+
+  testWebhook() {
+    WebhookBackend.testWebhook(this.state.webhook, this.state.testEvent)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            testResult: res.data,
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `Failed to connect to server: ${error}`);
+      });
+  }
+
+  getTestResultText() {
+    const res = this.state.testResult;
+    if (res.error !== "") {
+      return res.error;
+    }
+    if (!res.isMatched) {
+      return `${i18next.t("webhook:The filter rejects the event")}\n\n${res.event}`;
+    }
+    return res.payload;
+  }
+
   parseWebhookField(key, value) {
     if (["port"].includes(key)) {
       value = Setting.myParseInt(value);
@@ -145,7 +229,7 @@ class WebhookEditPage extends React.Component {
     if (this.state.webhook.isUserExtended) {
       preview["extendedUser"] = userTemplate;
     }
-    const previewText = JSON.stringify(preview, null, 2);
+    const previewText = this.state.testResult !== null ? this.getTestResultText() : JSON.stringify(preview, null, 2);
 
     return (
       <Card size="small" title={
@@ -232,6 +316,16 @@ class WebhookEditPage extends React.Component {
             />
           </Col>
         </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Secret"), i18next.t("webhook:Secret - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input.Password value={this.state.webhook.secret} onChange={e => {
+              this.updateWebhookField('secret', e.target.value);
+            }} />
+          </Col>
+        </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("webhook:Events"), i18next.t("webhook:Events - Tooltip"))} :
@@ -242,6 +336,13 @@ class WebhookEditPage extends React.Component {
                     onChange={value => {
                       this.updateWebhookField('events', value);
                     }} >
+              {
+                this.state.eventTypes.map((eventType, index) => {
+                  return (
+                    <Option key={eventType.name} value={eventType.name} title={eventType.description}>{eventType.name}</Option>
+                  )
+                })
+              }
               {
                 (
                   ["signup", "login", "logout", "update-user"].map((option, index) => {
@@ -254,6 +355,32 @@ class WebhookEditPage extends React.Component {
             </Select>
           </Col>
         </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Filter"), i18next.t("webhook:Filter - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.webhook.filter} placeholder={'{{eq .after.tag "staff"}}'} onChange={e => {
+              this.updateWebhookField('filter', e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Payload template"), i18next.t("webhook:Payload template - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <div style={{width: "900px", height: "200px"}} >
+              <CodeMirror
+                value={this.state.webhook.template}
+                options={{mode: 'javascript', theme: "material-darker"}}
+                onBeforeChange={(editor, data, value) => {
+                  this.updateWebhookField('template', value);
+                }}
+              />
+            </div>
+          </Col>
+        </Row>
         <Row style={{marginTop: '20px'}} >
           <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 19 : 2}>
             {Setting.getLabel(i18next.t("webhook:Is user extended"), i18next.t("webhook:Is user extended - Tooltip"))} :
@@ -269,6 +396,18 @@ class WebhookEditPage extends React.Component {
             {Setting.getLabel(i18next.t("general:Preview"), i18next.t("general:Preview - Tooltip"))} :
           </Col>
           <Col span={22} >
+            <div style={{marginBottom: '10px'}} >
+              <Select virtual={false} style={{width: '300px'}} value={this.state.testEvent} onChange={value => {
+                this.setState({
+                  testEvent: value,
+                });
+              }} >
+                {
+                  (this.state.webhook.events !== null ? this.state.webhook.events : []).map((event, index) => <Option key={event} value={event}>{event}</Option>)
+                }
+              </Select>
+              <Button style={{marginLeft: '20px'}} onClick={() => this.testWebhook()}>{i18next.t("webhook:Send test event")}</Button>
+            </div>
             <div style={{width: "900px", height: "300px"}} >
               <CodeMirror
                 value={previewText}
@@ -288,10 +427,120 @@ class WebhookEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: '20px'}} >
+          <Col style={{marginTop: '5px'}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Delivery log"), i18next.t("webhook:Delivery log - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            {
+              this.renderEvents()
+            }
+          </Col>
+        </Row>
       </Card>
     )
   }
 
+  renderDeliveries(event) {
+    const columns = [
+      {
+        title: i18next.t("general:Created time"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '160px',
+        render: (text, record, index) => Setting.getFormattedDate(text)
+      },
+      {
+        title: i18next.t("webhook:Attempt"),
+        dataIndex: 'attempt',
+        key: 'attempt',
+        width: '80px',
+      },
+      {
+        title: i18next.t("webhook:Status code"),
+        dataIndex: 'statusCode',
+        key: 'statusCode',
+        width: '100px',
+      },
+      {
+        title: i18next.t("webhook:Latency"),
+        dataIndex: 'latency',
+        key: 'latency',
+        width: '100px',
+        render: (text, record, index) => `${text} ms`
+      },
+      {
+        title: i18next.t("webhook:Response"),
+        dataIndex: 'response',
+        key: 'response',
+        render: (text, record, index) => record.error === "" ? text : `${record.error} ${text}`
+      },
+    ];
+
+    return (
+      <Table rowKey="id" columns={columns} dataSource={this.state.deliveries[event.id]} size="small" pagination={false} />
+    );
+  }
+
+  renderEvents() {
+    const columns = [
+      {
+        title: i18next.t("general:Created time"),
+        dataIndex: 'createdTime',
+        key: 'createdTime',
+        width: '160px',
+        render: (text, record, index) => Setting.getFormattedDate(text)
+      },
+      {
+        title: i18next.t("general:Action"),
+        dataIndex: 'action',
+        key: 'action',
+        width: '120px',
+      },
+      {
+        title: i18next.t("webhook:State"),
+        dataIndex: 'state',
+        key: 'state',
+        width: '100px',
+      },
+      {
+        title: i18next.t("webhook:Attempts"),
+        dataIndex: 'attempts',
+        key: 'attempts',
+        width: '80px',
+      },
+      {
+        title: i18next.t("webhook:Last error"),
+        dataIndex: 'lastError',
+        key: 'lastError',
+      },
+      {
+        title: i18next.t("general:Action"),
+        dataIndex: '',
+        key: 'op',
+        width: '120px',
+        render: (text, record, index) => {
+          return (
+            <Button size="small" onClick={() => this.redeliverWebhookEvent(record)}>{i18next.t("webhook:Redeliver")}</Button>
+          )
+        }
+      },
+    ];
+
+    return (
+      <Table rowKey="id" columns={columns} dataSource={this.state.events} size="middle" bordered pagination={{pageSize: 10}}
+             expandable={{
+               expandedRowRender: record => this.renderDeliveries(record),
+               onExpand: (expanded, record) => {
+                 if (expanded) {
+                   this.getWebhookDeliveries(record);
+                 }
+               },
+             }}
+      />
+    );
+  }
+
   submitWebhookEdit(willExist) {
     let webhook = Setting.deepCopy(this.state.webhook);
     WebhookBackend.updateWebhook(this.state.webhook.owner, this.state.webhookName, webhook)
@@ -30,13 +30,16 @@ class AuthCallback extends React.Component {
     };
   }
 
+  getInnerQuery() {
+    const params = new URLSearchParams(this.props.location.search);
+    const state = params.get("state");
+    return Util.stateToGetQueryParams(state);
+  }
+
   getInnerParams() {
     // For example, for Casbin-OA, realRedirectUri = "http://localhost:9000/login"
     // realRedirectUrl = "http://localhost:9000"
-    const params = new URLSearchParams(this.props.location.search);
-    const state = params.get("state");
-    const queryString = Util.stateToGetQueryParams(state);
-    return new URLSearchParams(queryString);
+    return new URLSearchParams(this.getInnerQuery());
   }
 
   getResponseType() {
@@ -97,6 +100,7 @@ class AuthCallback extends React.Component {
     const providerName = innerParams.get("provider");
     const method = innerParams.get("method");
     const samlRequest = innerParams.get("SAMLRequest");
+    const relayState = innerParams.get("RelayState");
 
     let redirectUri = `${window.location.origin}/callback`;
 
@@ -106,6 +110,8 @@ class AuthCallback extends React.Component {
       provider: providerName,
       code: code,
       samlRequest: samlRequest,
+      relayState: relayState,
+      samlQuery: this.getInnerQuery().replace(/^\?/, ""),
       // state: innerParams.get("state"),
       state: applicationName,
       redirectUri: redirectUri,
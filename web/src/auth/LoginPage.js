@@ -182,6 +182,9 @@ class LoginPage extends React.Component {
 
       if (oAuthParams !== null) {
         values["samlRequest"] = oAuthParams.samlRequest;
+        values["relayState"] = oAuthParams.relayState;
+        // the signature of the HTTP-Redirect binding is verified over the raw query string
+        values["samlQuery"] = window.location.search.substring(1);
       }
 
       if (values["samlRequest"] != null && values["samlRequest"] !== "") {
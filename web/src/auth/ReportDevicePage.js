@@ -0,0 +1,86 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import React from "react";
+import {Button, Result} from "antd";
+import {withRouter} from "react-router-dom";
+import * as UserBackend from "../backend/UserBackend";
+import * as Setting from "../Setting";
+import i18next from "i18next";
+
+class ReportDevicePage extends React.Component {
+  constructor(props) {
+    super(props);
+    const params = new URLSearchParams(props.location.search);
+    this.state = {
+      classes: props,
+      token: params.get("token"),
+      loading: false,
+      applicationName: null,
+    };
+  }
+
+  reportDevice() {
+    this.setState({
+      loading: true,
+    });
+
+    UserBackend.reportDevice(this.state.token)
+      .then((res) => {
+        if (res.status === 'ok') {
+          this.setState({
+            loading: false,
+            applicationName: res.data,
+          });
+        } else {
+          this.setState({
+            loading: false,
+          });
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
+  render() {
+    if (this.state.applicationName !== null) {
+      return (
+        <Result
+          status="success"
+          title={i18next.t("device:Your account has been secured")}
+          subTitle={i18next.t("device:All of your sessions have been signed out, please reset your password to sign in again")}
+          extra={[
+            <Button type="primary" key="forget" onClick={() => Setting.goToLinkSoft(this, `/forget/${this.state.applicationName}`)}>
+              {i18next.t("device:Reset password")}
+            </Button>
+          ]}
+        />
+      );
+    }
+
+    return (
+      <Result
+        status="warning"
+        title={i18next.t("device:Wasn't you?")}
+        subTitle={i18next.t("device:Securing your account will sign out all of your sessions and you will have to reset your password")}
+        extra={[
+          <Button type="primary" danger key="report" loading={this.state.loading} onClick={() => this.reportDevice()}>
+            {i18next.t("device:Secure my account")}
+          </Button>
+        ]}
+      />
+    );
+  }
+}
+
+export default withRouter(ReportDevicePage);
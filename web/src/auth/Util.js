@@ -103,6 +103,9 @@ export function getOAuthGetParameters(params) {
   const codeChallenge = getRefinedValue(queries.get("code_challenge"));
   const samlRequest = getRefinedValue(queries.get("SAMLRequest"));
   const relayState = getRefinedValue(queries.get("RelayState"));
+  const acrValues = getRefinedValue(queries.get("acr_values"));
+  const maxAge = getRefinedValue(queries.get("max_age"));
+  const prompt = getRefinedValue(queries.get("prompt"));
 
   if ((clientId === undefined || clientId === null || clientId === "") && (samlRequest === "" || samlRequest === undefined)) {
     // login
@@ -120,6 +123,9 @@ export function getOAuthGetParameters(params) {
       codeChallenge: codeChallenge,
       samlRequest: samlRequest,
       relayState: relayState,
+      acrValues: acrValues,
+      maxAge: maxAge,
+      prompt: prompt,
     };
   }
 }
@@ -76,3 +76,17 @@ export function getSamlMetadata(owner, name) {
     credentials: "include"
   }).then(res => res.text());
 }
+
+export function getProvisioningStatuses(owner, name, page = "", pageSize = "", field = "", value = "", sortField = "", sortOrder = "") {
+  return fetch(`${Setting.ServerUrl}/api/get-provisioning-statuses?id=${owner}/${encodeURIComponent(name)}&p=${page}&pageSize=${pageSize}&field=${field}&value=${value}&sortField=${sortField}&sortOrder=${sortOrder}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function reconcileScimProvisioning(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/reconcile-scim-provisioning?id=${owner}/${encodeURIComponent(name)}`, {
+    method: 'POST',
+    credentials: 'include',
+  }).then(res => res.json());
+}
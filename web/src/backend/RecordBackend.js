@@ -20,3 +20,14 @@ export function getRecords(page, pageSize, field = "", value = "", sortField = "
     credentials: "include"
   }).then(res => res.json());
 }
+
+export function verifyRecords(organization) {
+  return fetch(`${Setting.ServerUrl}/api/verify-records?organization=${organization}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function getExportRecordsUrl(organization, format) {
+  return `${Setting.ServerUrl}/api/export-records?organization=${organization}&format=${format}`;
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as Setting from "../Setting";
+
+export function getSamlSps(owner, page = "", pageSize = "", field = "", value = "", sortField = "", sortOrder = "") {
+  return fetch(`${Setting.ServerUrl}/api/get-saml-sps?owner=${owner}&p=${page}&pageSize=${pageSize}&field=${field}&value=${value}&sortField=${sortField}&sortOrder=${sortOrder}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function getSamlSp(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/get-saml-sp?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function updateSamlSp(owner, name, samlSp) {
+  let newSamlSp = Setting.deepCopy(samlSp);
+  return fetch(`${Setting.ServerUrl}/api/update-saml-sp?id=${owner}/${encodeURIComponent(name)}`, {
+    method: 'POST',
+    credentials: 'include',
+    body: JSON.stringify(newSamlSp),
+  }).then(res => res.json());
+}
+
+export function addSamlSp(samlSp) {
+  let newSamlSp = Setting.deepCopy(samlSp);
+  return fetch(`${Setting.ServerUrl}/api/add-saml-sp`, {
+    method: 'POST',
+    credentials: 'include',
+    body: JSON.stringify(newSamlSp),
+  }).then(res => res.json());
+}
+
+export function deleteSamlSp(samlSp) {
+  let newSamlSp = Setting.deepCopy(samlSp);
+  return fetch(`${Setting.ServerUrl}/api/delete-saml-sp`, {
+    method: 'POST',
+    credentials: 'include',
+    body: JSON.stringify(newSamlSp),
+  }).then(res => res.json());
+}
+
+export function importSamlSpMetadata(owner, name, url, file) {
+  let formData = new FormData();
+  if (file !== undefined && file !== null) {
+    formData.append("file", file);
+  }
+  return fetch(`${Setting.ServerUrl}/api/import-saml-sp-metadata?id=${owner}/${encodeURIComponent(name)}&url=${encodeURIComponent(url)}`, {
+    method: 'POST',
+    credentials: 'include',
+    body: formData,
+  }).then(res => res.json());
+}
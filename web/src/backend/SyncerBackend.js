@@ -61,3 +61,31 @@ export function runSyncer(owner, name) {
     credentials: 'include',
   }).then(res => res.json());
 }
+
+export function getSyncerDecisions(owner, name, page = "", pageSize = "", field = "", value = "", sortField = "", sortOrder = "") {
+  return fetch(`${Setting.ServerUrl}/api/get-syncer-decisions?id=${owner}/${encodeURIComponent(name)}&p=${page}&pageSize=${pageSize}&field=${field}&value=${value}&sortField=${sortField}&sortOrder=${sortOrder}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function resolveSyncerDecision(id, action) {
+  return fetch(`${Setting.ServerUrl}/api/resolve-syncer-decision?id=${id}&action=${action}`, {
+    method: 'POST',
+    credentials: 'include',
+  }).then(res => res.json());
+}
+
+export function dryRunSyncer(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/dry-run-syncer?id=${owner}/${encodeURIComponent(name)}`, {
+    method: 'GET',
+    credentials: 'include',
+  }).then(res => res.json());
+}
+
+export function getSyncerRuns(owner, name, page = "", pageSize = "", field = "", value = "", sortField = "", sortOrder = "") {
+  return fetch(`${Setting.ServerUrl}/api/get-syncer-runs?id=${owner}/${encodeURIComponent(name)}&p=${page}&pageSize=${pageSize}&field=${field}&value=${value}&sortField=${sortField}&sortOrder=${sortOrder}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
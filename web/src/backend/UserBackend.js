@@ -88,6 +88,16 @@ export function setPassword(userOwner, userName, oldPassword, newPassword) {
   }).then(res => res.json());
 }
 
+export function reportDevice(token) {
+  let formData = new FormData();
+  formData.append("token", token);
+  return fetch(`${Setting.ServerUrl}/api/report-device`, {
+    method: "POST",
+    credentials: "include",
+    body: formData
+  }).then(res => res.json());
+}
+
 export function sendCode(checkType, checkId, checkKey, dest, type, applicationId, checkUser) {
   let formData = new FormData();
   formData.append("checkType", checkType);
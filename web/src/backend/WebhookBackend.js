@@ -54,3 +54,40 @@ export function deleteWebhook(webhook) {
     body: JSON.stringify(newWebhook),
   }).then(res => res.json());
 }
+
+export function getWebhookEvents(owner, name, page = "", pageSize = "", field = "", value = "", sortField = "", sortOrder = "") {
+  return fetch(`${Setting.ServerUrl}/api/get-webhook-events?id=${owner}/${encodeURIComponent(name)}&p=${page}&pageSize=${pageSize}&field=${field}&value=${value}&sortField=${sortField}&sortOrder=${sortOrder}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function getWebhookDeliveries(id) {
+  return fetch(`${Setting.ServerUrl}/api/get-webhook-deliveries?id=${id}`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function redeliverWebhookEvent(id) {
+  return fetch(`${Setting.ServerUrl}/api/redeliver-webhook-event?id=${id}`, {
+    method: 'POST',
+    credentials: 'include',
+  }).then(res => res.json());
+}
+
+export function getEventTypes() {
+  return fetch(`${Setting.ServerUrl}/api/get-event-types`, {
+    method: "GET",
+    credentials: "include"
+  }).then(res => res.json());
+}
+
+export function testWebhook(webhook, event) {
+  let newWebhook = Setting.deepCopy(webhook);
+  return fetch(`${Setting.ServerUrl}/api/test-webhook?event=${encodeURIComponent(event)}`, {
+    method: 'POST',
+    credentials: 'include',
+    body: JSON.stringify(newWebhook),
+  }).then(res => res.json());
+}